@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// ErrInvalidRootAnchor is returned when a root anchor is malformed, or does
+// not describe the root it is meant to anchor
+type ErrInvalidRootAnchor struct {
+	Reason string
+}
+
+func (err ErrInvalidRootAnchor) Error() string {
+	return fmt.Sprintf("invalid root anchor: %s", err.Reason)
+}
+
+// MinimalRootAnchor updates the repository, and then returns a data.RootAnchor
+// for its current root, serialized as JSON.  The anchor holds only the root
+// role's keys and threshold, so it is much smaller than the root itself, and
+// can be embedded in an application for use with UpdateWithAnchor.
+func (r *repository) MinimalRootAnchor() ([]byte, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	root := r.tufRepo.Root
+	rootRole, err := root.BuildBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data.RootAnchor{
+		GUN:       r.gun,
+		Version:   root.Signed.Version,
+		Threshold: rootRole.Threshold,
+		Keys:      rootRole.ListKeys(),
+	})
+}
+
+// UpdateWithAnchor updates the repository, trusting the root anchored by an
+// anchor from MinimalRootAnchor in place of any cached root, and of the root
+// pinning of the trust pinning configuration: its certificates, CAs and
+// DisableTOFU are not used for this update, even should the anchored root have
+// expired.  The rest of the configuration, such as pinned snapshot and
+// timestamp keys and root witnesses, still applies.  The anchored version of
+// the root is downloaded, and must be signed by a threshold of the anchor's
+// keys, and have exactly those keys and that threshold for its root role.  It
+// is then cached as the trusted root, so the update proceeds as though it had
+// been cached all along, verifying any rotations to newer roots.
+func (r *repository) UpdateWithAnchor(anchor []byte) error {
+	parsed := data.RootAnchor{}
+	if err := json.Unmarshal(anchor, &parsed); err != nil {
+		return ErrInvalidRootAnchor{Reason: "could not parse the anchor"}
+	}
+	if parsed.GUN != r.gun {
+		return ErrInvalidRootAnchor{Reason: fmt.Sprintf("anchor is for %s, not %s", parsed.GUN, r.gun)}
+	}
+	if parsed.Version < 1 || parsed.Threshold < 1 || len(parsed.Keys) < parsed.Threshold {
+		return ErrInvalidRootAnchor{Reason: "anchor does not hold enough keys to meet its threshold"}
+	}
+
+	versioned := fmt.Sprintf("%d.%s", parsed.Version, data.CanonicalRootRole)
+	raw, err := r.getRemoteStore().GetSized(versioned, notary.MaxDownloadSize)
+	if err != nil {
+		return err
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return err
+	}
+	anchorRole := data.NewBaseRole(data.CanonicalRootRole, parsed.Threshold, parsed.Keys...)
+	if err := signed.VerifySignatures(s, anchorRole); err != nil {
+		return err
+	}
+	root, err := data.RootFromSigned(s)
+	if err != nil {
+		return err
+	}
+	rootRole, err := root.BuildBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return err
+	}
+	if root.Signed.Version != parsed.Version || rootRole.Threshold != parsed.Threshold ||
+		!sameStrings(rootRole.ListKeyIDs(), anchorRole.ListKeyIDs()) {
+		return ErrInvalidRootAnchor{Reason: fmt.Sprintf("anchor does not describe the root role of %s", versioned)}
+	}
+
+	if err := r.cache.Set(data.CanonicalRootRole.String(), raw); err != nil {
+		return err
+	}
+	logrus.Debugf("anchored trust in %s to %s", r.gun, versioned)
+
+	options := r.tufLoadOptions(false)
+	options.TrustPinning.CA = nil
+	options.TrustPinning.Certs = nil
+	options.TrustPinning.DisableTOFU = false
+	repo, invalid, err := LoadTUFRepo(options)
+	if err != nil {
+		return err
+	}
+	r.tufRepo = repo
+	r.invalid = invalid
+	return nil
+}
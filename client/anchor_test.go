@@ -0,0 +1,131 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A client with nothing cached can bootstrap from an embedded anchor, even
+// once the root has been rotated past the anchored version
+func TestUpdateWithAnchor(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	anchor, err := repo.MinimalRootAnchor()
+	require.NoError(t, err)
+	root, err := repo.cache.GetSized(data.CanonicalRootRole.String(), -1)
+	require.NoError(t, err)
+	require.True(t, len(anchor) < len(root), "the anchor should be smaller than the root")
+
+	parsed := data.RootAnchor{}
+	require.NoError(t, json.Unmarshal(anchor, &parsed))
+	require.Equal(t, data.GUN("docker.com/notary"), parsed.GUN)
+	require.Equal(t, 1, parsed.Version)
+	require.Equal(t, 1, parsed.Threshold)
+	require.Len(t, parsed.Keys, 1)
+
+	repo2, dir := newBundleTestRepo(t, ts.URL, trustpinning.TrustPinConfig{})
+	defer os.RemoveAll(dir)
+	require.NoError(t, repo2.UpdateWithAnchor(anchor))
+	targets, err := repo2.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	require.NoError(t, repo.RotateKey(data.CanonicalRootRole, false, nil))
+	require.NoError(t, repo.Publish())
+
+	repo3, dir3 := newBundleTestRepo(t, ts.URL, trustpinning.TrustPinConfig{})
+	defer os.RemoveAll(dir3)
+	require.NoError(t, repo3.UpdateWithAnchor(anchor))
+	require.Equal(t, 2, repo3.tufRepo.Root.Signed.Version)
+	require.Equal(t, repo.tufRepo.Root.Signed.Roles[data.CanonicalRootRole].KeyIDs,
+		repo3.tufRepo.Root.Signed.Roles[data.CanonicalRootRole].KeyIDs)
+}
+
+// An anchor for another GUN, or whose keys did not sign the anchored root, is
+// rejected and nothing is cached
+func TestUpdateWithAnchorRejected(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	other, _, otherDir := initializeRepo(t, data.ECDSAKey, "docker.com/other", ts.URL, false)
+	defer os.RemoveAll(otherDir)
+	require.NoError(t, other.Publish())
+
+	anchor, err := repo.MinimalRootAnchor()
+	require.NoError(t, err)
+	otherAnchor, err := other.MinimalRootAnchor()
+	require.NoError(t, err)
+
+	// the other repository's keys, relabelled as this GUN's
+	forged := data.RootAnchor{}
+	require.NoError(t, json.Unmarshal(otherAnchor, &forged))
+	forged.GUN = "docker.com/notary"
+	forgedAnchor, err := json.Marshal(forged)
+	require.NoError(t, err)
+
+	repo2, dir := newBundleTestRepo(t, ts.URL, trustpinning.TrustPinConfig{})
+	defer os.RemoveAll(dir)
+	requireNotInstalled := func() {
+		_, err := repo2.cache.GetSized(data.CanonicalRootRole.String(), -1)
+		require.Error(t, err)
+	}
+
+	err = repo2.UpdateWithAnchor(otherAnchor)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidRootAnchor{}, err)
+	requireNotInstalled()
+
+	require.Error(t, repo2.UpdateWithAnchor(forgedAnchor))
+	requireNotInstalled()
+
+	err = repo2.UpdateWithAnchor([]byte("not an anchor"))
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidRootAnchor{}, err)
+	requireNotInstalled()
+
+	require.NoError(t, repo2.UpdateWithAnchor(anchor))
+}
+
+// An anchor takes the place of the pinned root certificates, so that they are
+// not checked, but the other pins still apply
+func TestUpdateWithAnchorTrustPinning(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	anchor, err := repo.MinimalRootAnchor()
+	require.NoError(t, err)
+
+	pinned := trustpinning.TrustPinConfig{
+		Certs:       map[string][]string{"docker.com/notary": {"not-the-root-cert"}},
+		DisableTOFU: true,
+	}
+	pinnedRepo, dir := newBundleTestRepo(t, ts.URL, pinned)
+	defer os.RemoveAll(dir)
+	_, err = pinnedRepo.ListTargets()
+	require.Error(t, err)
+	require.NoError(t, pinnedRepo.UpdateWithAnchor(anchor))
+
+	pinned.TimestampKeys = map[string][]string{"docker.com/notary": {"not-the-timestamp-key"}}
+	timestampPinnedRepo, dir2 := newBundleTestRepo(t, ts.URL, pinned)
+	defer os.RemoveAll(dir2)
+	err = timestampPinnedRepo.UpdateWithAnchor(anchor)
+	require.Error(t, err)
+	require.IsType(t, ErrTimestampKeyNotPinned{}, err)
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"fmt"
+
+	store "github.com/theupdateframework/notary/storage"
+)
+
+// ServerAPIVersion asks the notary server for the version of its API, and
+// adjusts all subsequent requests to the server for that version.  If the
+// server serves a version this client does not know, a warning is logged and
+// requests continue to be made as for the current version.
+func (r *repository) ServerAPIVersion() (string, error) {
+	remote, ok := r.remoteStore.(store.APIVersionStore)
+	if !ok {
+		return "", fmt.Errorf("the remote store for %s cannot report the server's API version", r.gun)
+	}
+	return remote.ServerAPIVersion()
+}
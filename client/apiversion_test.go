@@ -0,0 +1,41 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// The test server advertises the current API version, and the repository
+// continues to work against it after detecting the version
+func TestServerAPIVersion(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	version, err := repo.ServerAPIVersion()
+	require.NoError(t, err)
+	require.Equal(t, notary.NotaryServerAPIVersion, version)
+
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+}
+
+// Without a connection to the server, the API version cannot be detected
+func TestServerAPIVersionOffline(t *testing.T) {
+	repo, baseDir := newBlankRepo(t, "https://localhost")
+	defer os.RemoveAll(baseDir)
+	repo.remoteStore = store.OfflineStore{}
+
+	_, err := repo.ServerAPIVersion()
+	require.IsType(t, store.ErrOffline{}, err)
+}
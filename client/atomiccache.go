@@ -0,0 +1,184 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrUnverifiedMetadata is returned when a metadata set provided to replace
+// the cache contains a file which could not be verified as part of the set
+type ErrUnverifiedMetadata struct {
+	Name string
+}
+
+func (err ErrUnverifiedMetadata) Error() string {
+	return fmt.Sprintf("%s could not be verified as part of the metadata set", err.Name)
+}
+
+const (
+	// suffix of the directory a replacement cache is written to
+	newCacheSuffix = ".new"
+	// suffix of the directory the replaced cache is moved to while swapping
+	oldCacheSuffix = ".old"
+)
+
+// renameCacheDir is used to swap the cache directories, and can be replaced
+// to simulate a failure while swapping
+var renameCacheDir = os.Rename
+
+// AtomicReplaceCache replaces the entire cached metadata of the repository
+// with the provided metadata set, keyed by role name.  The set is first
+// verified as a whole, against the currently cached root if there is one and
+// otherwise against the trust pinning configuration, and every file in it must
+// be verified.  It is then written to a new directory which is renamed into
+// place, so that an interruption leaves either the old or the new cache, and
+// never a mix of both.  Only file caches can be replaced.
+func (r *repository) AtomicReplaceCache(meta map[string][]byte) error {
+	fileCache, ok := r.cache.(*store.FilesystemStore)
+	if !ok {
+		return fmt.Errorf("only a file cache can be atomically replaced, not %s", r.cache.Location())
+	}
+	if err := r.verifyMetadataSet(meta); err != nil {
+		return err
+	}
+
+	cacheDir := fileCache.Location()
+	if err := recoverInterruptedCacheSwap(cacheDir); err != nil {
+		return err
+	}
+	newDir, oldDir := cacheDir+newCacheSuffix, cacheDir+oldCacheSuffix
+
+	// a leftover replacement from an interrupted call was never swapped in
+	if err := os.RemoveAll(newDir); err != nil {
+		return err
+	}
+	newCache, err := store.NewFileStore(newDir, "json")
+	if err != nil {
+		return err
+	}
+	if err := newCache.SetMulti(meta); err != nil {
+		os.RemoveAll(newDir)
+		return err
+	}
+
+	if err := renameCacheDir(cacheDir, oldDir); err != nil {
+		os.RemoveAll(newDir)
+		return err
+	}
+	if err := renameCacheDir(newDir, cacheDir); err != nil {
+		// put the old cache back, or leave it for recoverInterruptedCacheSwap
+		if restoreErr := renameCacheDir(oldDir, cacheDir); restoreErr != nil {
+			logrus.Errorf("could not restore the cache from %s: %s", oldDir, restoreErr)
+		}
+		return err
+	}
+	if err := os.RemoveAll(oldDir); err != nil {
+		logrus.Warnf("could not remove the replaced cache at %s: %s", oldDir, err)
+	}
+	return nil
+}
+
+// recoverInterruptedCacheSwap restores the old cache if a previous cache
+// replacement was interrupted after moving it aside but before moving its
+// replacement into place
+func recoverInterruptedCacheSwap(cacheDir string) error {
+	oldDir := cacheDir + oldCacheSuffix
+	if _, err := os.Stat(oldDir); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(cacheDir); err == nil {
+		// the swap completed, and only removing the old cache was interrupted
+		return os.RemoveAll(oldDir)
+	}
+	logrus.Warnf("restoring the cache at %s, as replacing it was interrupted", cacheDir)
+	return renameCacheDir(oldDir, cacheDir)
+}
+
+// verifyMetadataSet verifies the metadata set as a whole, as an update
+// against a server holding only that set would, and requires that every file
+// in it was verified
+func (r *repository) verifyMetadataSet(meta map[string][]byte) error {
+	if _, ok := meta[data.CanonicalRootRole.String()]; !ok {
+		return ErrUnverifiedMetadata{Name: data.CanonicalRootRole.String()}
+	}
+	verified := store.NewMemoryStore(nil)
+
+	trustedRoot, err := r.verifyReplacementRoot(meta[data.CanonicalRootRole.String()])
+	if err != nil {
+		return err
+	}
+	if trustedRoot != nil {
+		if err := verified.Set(data.CanonicalRootRole.String(), trustedRoot); err != nil {
+			return err
+		}
+	}
+
+	remote := metadataSetStore{store.NewMemoryStore(nil)}
+	if err := remote.SetMulti(meta); err != nil {
+		return err
+	}
+
+	opts := r.tufLoadOptions(false)
+	opts.Cache = verified
+	opts.RemoteStore = remote
+	opts.Timing = nil
+	if _, _, err := LoadTUFRepo(opts); err != nil {
+		return err
+	}
+
+	for name, blob := range meta {
+		got, err := verified.GetSized(name, store.NoSizeLimit)
+		if err != nil || !bytes.Equal(got, blob) {
+			return ErrUnverifiedMetadata{Name: name}
+		}
+	}
+	return nil
+}
+
+// metadataSetStore serves a metadata set as though it were a remote server
+// which holds no public keys
+type metadataSetStore struct {
+	*store.MemoryStore
+}
+
+func (metadataSetStore) GetKey(role data.RoleName) ([]byte, error) {
+	return nil, store.ErrOffline{}
+}
+
+func (metadataSetStore) RotateKey(role data.RoleName) ([]byte, error) {
+	return nil, store.ErrOffline{}
+}
+
+// verifyReplacementRoot returns the root to trust when verifying a metadata
+// set.  If a root is already cached and the set contains a different one, the
+// new root must be signed by the cached root's keys to be trusted.  With no
+// cached root, nil is returned so that the new root is verified against the
+// trust pinning configuration.
+func (r *repository) verifyReplacementRoot(newRoot []byte) ([]byte, error) {
+	cachedRoot, err := r.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil, nil
+	}
+	if bytes.Equal(cachedRoot, newRoot) {
+		return cachedRoot, nil
+	}
+	unpinned := trustpinning.TrustPinConfig{IgnoreRootCertExpiry: r.trustPinning.IgnoreRootCertExpiry}
+	oldBuilder := tuf.NewRepoBuilder(r.gun, r.cryptoService, unpinned)
+	if err := oldBuilder.Load(data.CanonicalRootRole, cachedRoot, 1, true); err != nil {
+		return nil, err
+	}
+	// the cached root pins trust, so the trust pinning configuration is not used
+	newBuilder := oldBuilder.BootstrapNewBuilder()
+	minVersion := oldBuilder.GetLoadedVersion(data.CanonicalRootRole)
+	if err := newBuilder.Load(data.CanonicalRootRole, newRoot, minVersion, false); err != nil {
+		return nil, err
+	}
+	return newRoot, nil
+}
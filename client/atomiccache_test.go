@@ -0,0 +1,148 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// cachedMetadataSet reads the cached metadata of the base roles of a repo
+func cachedMetadataSet(t *testing.T, repo *repository) map[string][]byte {
+	meta := make(map[string][]byte)
+	for _, role := range data.BaseRoles {
+		blob, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		meta[role.String()] = blob
+	}
+	return meta
+}
+
+// requireCacheMatches asserts that the cache of a repo holds exactly the
+// provided metadata for the base roles
+func requireCacheMatches(t *testing.T, repo *repository, meta map[string][]byte) {
+	require.Equal(t, meta, cachedMetadataSet(t, repo))
+}
+
+// setUpStaleCache returns a repo whose cache is a version behind the server,
+// and the server's current metadata set
+func setUpStaleCache(t *testing.T, url string) (*repository, *repository, map[string][]byte, []string) {
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", url, false)
+	require.NoError(t, repo.Publish())
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	_, err := repo2.ListTargets()
+	require.NoError(t, err)
+
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	return repo, repo2, cachedMetadataSet(t, repo), []string{baseDir, baseDir2}
+}
+
+func TestAtomicReplaceCache(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	_, repo2, meta, dirs := setUpStaleCache(t, ts.URL)
+	for _, dir := range dirs {
+		defer os.RemoveAll(dir)
+	}
+
+	require.NoError(t, repo2.AtomicReplaceCache(meta))
+	requireCacheMatches(t, repo2, meta)
+
+	// the replacement directories are cleaned up
+	cacheDir := repo2.cache.Location()
+	for _, dir := range []string{cacheDir + newCacheSuffix, cacheDir + oldCacheSuffix} {
+		_, err := os.Stat(dir)
+		require.True(t, os.IsNotExist(err))
+	}
+
+	// the replaced cache loads without the server
+	repo2.remoteStore = store.OfflineStore{}
+	targets, err := repo2.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+}
+
+// A set which does not verify, or which contains files that weren't verified,
+// is rejected without touching the cache
+func TestAtomicReplaceCacheRejectsInvalidSets(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	_, repo2, meta, dirs := setUpStaleCache(t, ts.URL)
+	for _, dir := range dirs {
+		defer os.RemoveAll(dir)
+	}
+	old := cachedMetadataSet(t, repo2)
+
+	invalidSets := []func(map[string][]byte){
+		func(m map[string][]byte) { delete(m, data.CanonicalRootRole.String()) },
+		func(m map[string][]byte) { delete(m, data.CanonicalTimestampRole.String()) },
+		func(m map[string][]byte) { m[data.CanonicalTargetsRole.String()] = old[data.CanonicalTargetsRole.String()] },
+		func(m map[string][]byte) { m["targets/unreferenced"] = m[data.CanonicalTargetsRole.String()] },
+	}
+	for i, invalidate := range invalidSets {
+		invalid := make(map[string][]byte)
+		for name, blob := range meta {
+			invalid[name] = blob
+		}
+		invalidate(invalid)
+		require.Error(t, repo2.AtomicReplaceCache(invalid), fmt.Sprintf("invalid set %d", i))
+		requireCacheMatches(t, repo2, old)
+	}
+
+	err := repo2.AtomicReplaceCache(map[string][]byte{
+		data.CanonicalRootRole.String(): meta[data.CanonicalRootRole.String()],
+	})
+	require.Error(t, err)
+	requireCacheMatches(t, repo2, old)
+}
+
+// A crash after writing the new cache but before renaming it into place, or
+// between moving the old cache aside and renaming the new one into place,
+// leaves the old cache intact
+func TestAtomicReplaceCacheInterrupted(t *testing.T) {
+	defer func() { renameCacheDir = os.Rename }()
+
+	for crashAfter := 0; crashAfter < 2; crashAfter++ {
+		ts := fullTestServer(t)
+		repo, repo2, meta, dirs := setUpStaleCache(t, ts.URL)
+		old := cachedMetadataSet(t, repo2)
+		cacheDir := repo2.cache.Location()
+
+		// every rename after the first crashAfter fails, including attempts to
+		// restore the old cache, as though the process had died
+		renames := 0
+		renameCacheDir = func(from, to string) error {
+			renames++
+			if renames > crashAfter {
+				return fmt.Errorf("crashed before renaming %s", filepath.Base(from))
+			}
+			return os.Rename(from, to)
+		}
+		require.Error(t, repo2.AtomicReplaceCache(meta))
+		renameCacheDir = os.Rename
+
+		// reopening the repository finds the old cache
+		repo3, _, _ := newRepoToTestRepo(t, repo, dirs[1])
+		requireCacheMatches(t, repo3, old)
+		_, err := os.Stat(cacheDir + oldCacheSuffix)
+		require.True(t, os.IsNotExist(err))
+
+		// and a later replacement succeeds
+		require.NoError(t, repo3.AtomicReplaceCache(meta))
+		requireCacheMatches(t, repo3, meta)
+
+		for _, dir := range dirs {
+			os.RemoveAll(dir)
+		}
+		ts.Close()
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// SetRefreshErrorHandler sets the function which auto refreshes started after
+// this call report their errors to.  It is called from the refresh goroutine.
+// Without a handler, errors are only logged.
+func (r *repository) SetRefreshErrorHandler(handler func(err error)) {
+	r.refreshErrorHandler = handler
+}
+
+// StartAutoRefresh starts a goroutine which, every interval, polls the remote
+// timestamp and, whenever it has advanced since the last poll, runs a full
+// update, so that the local cache is kept fresh for a long running verifier.
+// Refreshes only write to the cache, and never change the state of the
+// repository itself, whose next update picks up the refreshed metadata.  The
+// update options, and the error handler, are those set when the refresh is
+// started.  Errors during a refresh are reported to the error handler, and the
+// next refresh is attempted as usual.
+//
+// The returned function stops the refreshes, waiting for one in progress to
+// finish.  It is safe to call more than once.
+func (r *repository) StartAutoRefresh(interval time.Duration) (stop func()) {
+	options := r.tufLoadOptions(false)
+	handler := r.refreshErrorHandler
+	newTicker := r.newTicker
+	if newTicker == nil {
+		newTicker = func(interval time.Duration) (<-chan time.Time, func()) {
+			ticker := time.NewTicker(interval)
+			return ticker.C, ticker.Stop
+		}
+	}
+	ticks, stopTicker := newTicker(interval)
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		defer stopTicker()
+		lastSeen := 0
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticks:
+			}
+			version, err := refresh(options, lastSeen)
+			if err != nil {
+				logrus.Debugf("auto refresh of %s failed: %s", options.GUN, err)
+				if handler != nil {
+					handler(err)
+				}
+				continue
+			}
+			lastSeen = version
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-finished
+	}
+}
+
+// refresh polls the remote timestamp, and updates the cache if it is newer than
+// lastSeen, or newer than the cached timestamp if nothing has been seen yet.
+// It returns the version of the timestamp it polled.
+func refresh(options TUFLoadOptions, lastSeen int) (int, error) {
+	c, err := bootstrapClient(options)
+	if err != nil {
+		return lastSeen, err
+	}
+	if lastSeen == 0 {
+		lastSeen = c.cachedVersion(data.CanonicalTimestampRole, notary.MaxTimestampSize)
+	}
+	version, err := c.pollTimestamp(lastSeen)
+	if err != nil {
+		return lastSeen, err
+	}
+	if version > lastSeen {
+		if _, _, err := LoadTUFRepo(options); err != nil {
+			return lastSeen, err
+		}
+	}
+	return version, nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// fakeTicker delivers ticks only when the test sends them
+type fakeTicker struct {
+	ticks   chan time.Time
+	stopped bool
+}
+
+func useFakeTicker(repo *repository) *fakeTicker {
+	ticker := &fakeTicker{ticks: make(chan time.Time)}
+	repo.newTicker = func(interval time.Duration) (<-chan time.Time, func()) {
+		return ticker.ticks, func() { ticker.stopped = true }
+	}
+	return ticker
+}
+
+func cachedTimestampVersion(t *testing.T, repo *repository) int {
+	c, err := bootstrapClient(repo.tufLoadOptions(false))
+	require.NoError(t, err)
+	return c.cachedVersion(data.CanonicalTimestampRole, notary.MaxTimestampSize)
+}
+
+// The cache is updated when a tick finds the timestamp has advanced, and
+// stopping terminates the refreshes
+func TestStartAutoRefresh(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	writer, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, writer, "first", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, writer.Publish())
+
+	verifier, _, readerDir := newRepoToTestRepo(t, writer, "")
+	defer os.RemoveAll(readerDir)
+	require.NoError(t, verifier.updateTUF(false))
+	before := cachedTimestampVersion(t, verifier)
+
+	var errs []error
+	verifier.SetRefreshErrorHandler(func(err error) { errs = append(errs, err) })
+	ticker := useFakeTicker(verifier)
+
+	// nothing has changed, so nothing is refreshed
+	stop := verifier.StartAutoRefresh(time.Minute)
+	ticker.ticks <- time.Now()
+	stop()
+	require.True(t, ticker.stopped)
+	require.Equal(t, before, cachedTimestampVersion(t, verifier))
+
+	// a tick finds the new timestamp, and refreshes the cache, which has
+	// happened by the time stopping returns
+	addTarget(t, writer, "second", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, writer.Publish())
+	stop = verifier.StartAutoRefresh(time.Minute)
+	ticker.ticks <- time.Now()
+	ticker.ticks <- time.Now()
+	stop()
+	require.Empty(t, errs)
+	require.True(t, cachedTimestampVersion(t, verifier) > before)
+
+	// the refreshed cache is what the next read sees, even offline
+	offline, err := NewRepository("docker.com/notary", ts.URL, nil, verifier.cache,
+		verifier.trustPinning, verifier.cryptoService, verifier.changelist)
+	require.NoError(t, err)
+	targets, err := offline.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	// stopping again is harmless
+	stop()
+}
+
+// Errors during a refresh are reported to the error handler, and refreshes
+// carry on
+func TestStartAutoRefreshReportsErrors(t *testing.T) {
+	ts := fullTestServer(t)
+
+	writer, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, writer.Publish())
+
+	verifier, _, readerDir := newRepoToTestRepo(t, writer, "")
+	defer os.RemoveAll(readerDir)
+	require.NoError(t, verifier.updateTUF(false))
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	verifier.SetRefreshErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	})
+	ticker := useFakeTicker(verifier)
+	stop := verifier.StartAutoRefresh(time.Minute)
+	defer stop()
+
+	ts.Close()
+	ticker.ticks <- time.Now()
+	ticker.ticks <- time.Now()
+	ticker.ticks <- time.Now()
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, errs, 3)
+}
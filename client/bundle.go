@@ -0,0 +1,117 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrInvalidTrustBundle is returned when a trust bundle is malformed, or does
+// not describe the root it holds
+type ErrInvalidTrustBundle struct {
+	Reason string
+}
+
+func (err ErrInvalidTrustBundle) Error() string {
+	return fmt.Sprintf("invalid trust bundle: %s", err.Reason)
+}
+
+// BootstrapFromBundle fetches the trust bundle at url, such as one served by
+// a notary server at /v2/<gun>/_trust/bundle.json or distributed by some
+// other means, and installs its root as the trusted root of the repository.
+//
+// Unlike trusting the first root downloaded from the server, the root must
+// validate against the certificates or CA pinned for the GUN in the trust
+// pinning configuration, which is required to have a pin.  If a root is
+// already cached, the bundle's root must also be a valid rotation of it.  The
+// bundle is fetched with the repository's RoundTripper, so an offline
+// repository returns ErrOffline.
+func (r *repository) BootstrapFromBundle(url string) error {
+	if r.roundTrip == nil {
+		return store.ErrOffline{}
+	}
+	bundle, err := fetchTrustBundle(url, r.roundTrip)
+	if err != nil {
+		return err
+	}
+	if bundle.GUN != r.gun {
+		return ErrInvalidTrustBundle{Reason: fmt.Sprintf("bundle is for %s, not %s", bundle.GUN, r.gun)}
+	}
+
+	signed := &data.Signed{}
+	if err := json.Unmarshal(bundle.Root, signed); err != nil {
+		return ErrInvalidTrustBundle{Reason: "could not parse the root"}
+	}
+	// without a pin this would be trust on first use, which a bundle is meant
+	// to avoid
+	pinned := r.trustPinning
+	pinned.DisableTOFU = true
+	root, err := trustpinning.ValidateRoot(nil, signed, r.gun, pinned)
+	if err != nil {
+		return err
+	}
+	if !sameStrings(bundle.CertIDs, root.Signed.Roles[data.CanonicalRootRole].KeyIDs) {
+		return ErrInvalidTrustBundle{Reason: "certificate IDs do not match those of the root"}
+	}
+
+	if _, err := r.verifyReplacementRoot(bundle.Root); err != nil {
+		return err
+	}
+	if err := r.cache.Set(data.CanonicalRootRole.String(), bundle.Root); err != nil {
+		return err
+	}
+	logrus.Debugf("bootstrapped trust in %s from the bundle at %s", r.gun, url)
+	return nil
+}
+
+// fetchTrustBundle downloads and parses a trust bundle
+func fetchTrustBundle(url string, rt http.RoundTripper) (*data.TrustBundle, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, store.NetworkError{Wrapped: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch the trust bundle at %s: HTTP status %d", url, resp.StatusCode)
+	}
+	// the bundle holds a root, base64 encoded
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 2*notary.MaxDownloadSize))
+	if err != nil {
+		return nil, err
+	}
+	bundle := &data.TrustBundle{}
+	if err := json.Unmarshal(body, bundle); err != nil || len(bundle.Root) == 0 {
+		return nil, ErrInvalidTrustBundle{Reason: "could not parse the bundle"}
+	}
+	return bundle, nil
+}
+
+// sameStrings determines whether two lists hold the same strings, ignoring
+// order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
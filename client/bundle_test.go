@@ -0,0 +1,120 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// newBundleTestRepo creates a repository in a new directory, with the given
+// trust pinning configuration
+func newBundleTestRepo(t *testing.T, url string, trustPinning trustpinning.TrustPinConfig) (*repository, string) {
+	dir, err := ioutil.TempDir("", "notary-bundle-test-")
+	require.NoError(t, err)
+	r, err := NewFileCachedRepository(dir, "docker.com/notary", url, http.DefaultTransport,
+		passphraseRetriever, trustPinning)
+	require.NoError(t, err)
+	return r.(*repository), dir
+}
+
+// A bundle whose root validates against the pinned certificates is installed,
+// and the repository can then be updated
+func TestBootstrapFromBundle(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	bundleURL := ts.URL + "/v2/docker.com/notary/_trust/bundle.json"
+
+	certIDs := repo.tufRepo.Root.Signed.Roles[data.CanonicalRootRole].KeyIDs
+	pinned := trustpinning.TrustPinConfig{Certs: map[string][]string{"docker.com/notary": certIDs}}
+	repo2, dir := newBundleTestRepo(t, ts.URL, pinned)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, repo2.BootstrapFromBundle(bundleURL))
+	cached, err := repo2.cache.GetSized(data.CanonicalRootRole.String(), -1)
+	require.NoError(t, err)
+	published, err := repo.cache.GetSized(data.CanonicalRootRole.String(), -1)
+	require.NoError(t, err)
+	require.Equal(t, published, cached)
+
+	targets, err := repo2.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+
+	// bootstrapping again from the same bundle is allowed
+	require.NoError(t, repo2.BootstrapFromBundle(bundleURL))
+}
+
+// A bundle is rejected, and nothing is installed, if its root does not match
+// the pin, if there is no pin, or if the bundle does not describe its root
+func TestBootstrapFromBundleRejected(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	bundleURL := ts.URL + "/v2/docker.com/notary/_trust/bundle.json"
+
+	requireNotInstalled := func(r *repository) {
+		_, err := r.cache.GetSized(data.CanonicalRootRole.String(), -1)
+		require.Error(t, err)
+	}
+
+	mismatched := trustpinning.TrustPinConfig{Certs: map[string][]string{"docker.com/notary": {"not-the-root-cert-id"}}}
+	repo2, dir := newBundleTestRepo(t, ts.URL, mismatched)
+	defer os.RemoveAll(dir)
+	require.Error(t, repo2.BootstrapFromBundle(bundleURL))
+	requireNotInstalled(repo2)
+
+	repo3, dir3 := newBundleTestRepo(t, ts.URL, trustpinning.TrustPinConfig{})
+	defer os.RemoveAll(dir3)
+	require.Error(t, repo3.BootstrapFromBundle(bundleURL))
+	requireNotInstalled(repo3)
+
+	// bundles altered to lie about the GUN or the certificate IDs
+	resp, err := http.Get(bundleURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var bundle data.TrustBundle
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&bundle))
+
+	certIDs := repo.tufRepo.Root.Signed.Roles[data.CanonicalRootRole].KeyIDs
+	pinned := trustpinning.TrustPinConfig{Certs: map[string][]string{"docker.com/notary": certIDs}}
+	for _, alter := range []func(b *data.TrustBundle){
+		func(b *data.TrustBundle) { b.GUN = "docker.com/other" },
+		func(b *data.TrustBundle) { b.CertIDs = append(b.CertIDs, "extra") },
+		func(b *data.TrustBundle) { b.Root = []byte("{}") },
+	} {
+		altered := bundle
+		alter(&altered)
+		alteredJSON, err := json.Marshal(altered)
+		require.NoError(t, err)
+		bundleServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(alteredJSON)
+		}))
+
+		repo4, dir4 := newBundleTestRepo(t, ts.URL, pinned)
+		err = repo4.BootstrapFromBundle(bundleServer.URL)
+		bundleServer.Close()
+		os.RemoveAll(dir4)
+		require.Error(t, err)
+		requireNotInstalled(repo4)
+	}
+
+	// a GUN the server has no trust data for has no bundle
+	repo5, dir5 := newBundleTestRepo(t, ts.URL, pinned)
+	defer os.RemoveAll(dir5)
+	require.Error(t, repo5.BootstrapFromBundle(ts.URL+"/v2/docker.com/missing/_trust/bundle.json"))
+}
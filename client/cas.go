@@ -0,0 +1,90 @@
+package client
+
+import (
+	"io"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ContentStore is a content-addressable store, such as an artifact registry,
+// from which content is retrieved by its hash
+type ContentStore interface {
+	// Get returns the content whose hash, computed with the given algorithm
+	// such as notary.SHA256, is digest, or an error if the store does not
+	// hold it
+	Get(algorithm string, digest []byte) (io.ReadCloser, error)
+}
+
+// CASMismatch describes a target, as signed into a single role, whose content
+// could not be retrieved from a content store by its hashes, or whose content
+// in the store does not match its TUF metadata
+type CASMismatch struct {
+	Name string
+	Role data.RoleName
+	// Algorithm is the hash algorithm of the digest the content was retrieved
+	// by, and is empty if it could not be retrieved by any
+	Algorithm string
+	// Missing is true if the store holds no content for any of the target's
+	// hashes
+	Missing bool
+	// Err describes why the content is missing, or does not match
+	Err error
+}
+
+// VerifyAgainstCAS checks that the content of every target signed into any
+// role of the repository can be retrieved from the content store by the hashes
+// in its TUF metadata, and that the content so retrieved has the length and
+// hashes of the target.  The content is retrieved by the first of the
+// target's hashes, in order of algorithm, which the store holds, subject to
+// any hash preference set with SetHashPreference.  Only the targets which
+// fail are reported, once for each role they are signed into, and targets
+// which the store does not hold are reported as missing rather than causing
+// VerifyAgainstCAS to fail.
+func (r *repository) VerifyAgainstCAS(cas ContentStore) ([]CASMismatch, error) {
+	targets, err := r.GetAllTargetMetadataByName("")
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []CASMismatch
+	for _, t := range targets {
+		mismatch := CASMismatch{Name: t.Target.Name, Role: t.Role.Name}
+		target, err := r.preferredHashes(t.Target)
+		if err != nil {
+			mismatch.Err = err
+			mismatches = append(mismatches, mismatch)
+			continue
+		}
+		mismatch.Algorithm, mismatch.Err = verifyTargetInCAS(cas, target)
+		if mismatch.Err != nil {
+			mismatch.Missing = mismatch.Algorithm == ""
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches, nil
+}
+
+// verifyTargetInCAS retrieves the content of the target from the store by the
+// first of its hashes which the store holds, and verifies it, returning the
+// algorithm of that hash.  If the store holds none of them, no algorithm is
+// returned, along with the error from the last attempt.
+func verifyTargetInCAS(cas ContentStore, target Target) (string, error) {
+	algorithms := make([]string, 0, len(target.Hashes))
+	for alg := range target.Hashes {
+		algorithms = append(algorithms, alg)
+	}
+	sort.Strings(algorithms)
+
+	var err error
+	for _, alg := range algorithms {
+		var content io.ReadCloser
+		content, err = cas.Get(alg, target.Hashes[alg])
+		if err != nil {
+			continue
+		}
+		defer content.Close()
+		return alg, verifyTargetContent(target, content)
+	}
+	return "", err
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// fakeCAS holds content by hash algorithm and hex digest
+type fakeCAS map[string][]byte
+
+func (c fakeCAS) put(algorithm string, digest []byte, content []byte) {
+	c[algorithm+":"+hex.EncodeToString(digest)] = content
+}
+
+func (c fakeCAS) Get(algorithm string, digest []byte) (io.ReadCloser, error) {
+	content, ok := c[algorithm+":"+hex.EncodeToString(digest)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+// Only targets which the store does not hold, or holds altered content for,
+// are reported, whichever of their hashes the store holds them by
+func TestVerifyAgainstCAS(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	bySHA256 := addTarget(t, repo, "by-sha256", "../fixtures/intermediate-ca.crt", data.CanonicalTargetsRole, "targets/a")
+	bySHA512 := addTarget(t, repo, "by-sha512", "../fixtures/root-ca.crt")
+	altered := addTarget(t, repo, "altered", "../fixtures/secure.example.com.crt")
+	addTarget(t, repo, "missing", "../fixtures/notary-server.crt", data.CanonicalTargetsRole, "targets/a")
+	require.NoError(t, repo.Publish())
+
+	intermediate, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	root, err := ioutil.ReadFile("../fixtures/root-ca.crt")
+	require.NoError(t, err)
+	cas := fakeCAS{}
+	cas.put(notary.SHA256, bySHA256.Hashes[notary.SHA256], intermediate)
+	cas.put(notary.SHA512, bySHA512.Hashes[notary.SHA512], root)
+	cas.put(notary.SHA256, altered.Hashes[notary.SHA256], root)
+
+	mismatches, err := repo.VerifyAgainstCAS(cas)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 3)
+
+	byTarget := make(map[string]map[data.RoleName]CASMismatch)
+	for _, m := range mismatches {
+		if byTarget[m.Name] == nil {
+			byTarget[m.Name] = make(map[data.RoleName]CASMismatch)
+		}
+		byTarget[m.Name][m.Role] = m
+	}
+	require.Len(t, byTarget, 2)
+
+	alteredMismatch := byTarget["altered"][data.CanonicalTargetsRole]
+	require.False(t, alteredMismatch.Missing)
+	require.Equal(t, notary.SHA256, alteredMismatch.Algorithm)
+	require.Error(t, alteredMismatch.Err)
+
+	for _, role := range []data.RoleName{data.CanonicalTargetsRole, "targets/a"} {
+		missing := byTarget["missing"][role]
+		require.True(t, missing.Missing, "missing target in %s", role)
+		require.Empty(t, missing.Algorithm)
+		require.True(t, os.IsNotExist(missing.Err))
+	}
+
+	// with a preference for sha512, content held only by sha256 is missing
+	require.NoError(t, repo.SetHashPreference([]string{notary.SHA512}))
+	mismatches, err = repo.VerifyAgainstCAS(cas)
+	require.NoError(t, err)
+	missingBySHA256 := 0
+	for _, m := range mismatches {
+		if m.Name == "by-sha256" {
+			require.True(t, m.Missing)
+			missingBySHA256++
+		}
+	}
+	require.Equal(t, 2, missingBySHA256)
+}
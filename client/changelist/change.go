@@ -88,6 +88,9 @@ type TUFDelegation struct {
 	AddPaths      []string      `json:"add_paths,omitempty"`
 	RemovePaths   []string      `json:"remove_paths,omitempty"`
 	ClearAllPaths bool          `json:"clear_paths,omitempty"`
+
+	AddExcludePaths    []string `json:"add_exclude_paths,omitempty"`
+	RemoveExcludePaths []string `json:"remove_exclude_paths,omitempty"`
 }
 
 // ToNewRole creates a fresh role object from the TUFDelegation data
@@ -96,5 +99,10 @@ func (td TUFDelegation) ToNewRole(scope data.RoleName) (*data.Role, error) {
 	if td.NewName != "" {
 		name = td.NewName
 	}
-	return data.NewRole(name, td.NewThreshold, td.AddKeys.IDs(), td.AddPaths)
+	role, err := data.NewRole(name, td.NewThreshold, td.AddKeys.IDs(), td.AddPaths)
+	if err != nil {
+		return nil, err
+	}
+	role.AddExcludePaths(td.AddExcludePaths)
+	return role, nil
 }
@@ -0,0 +1,88 @@
+package changelist
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Conflict is a pair of changes, one from each of two changelists being
+// merged, which both affect the same entry in different ways
+type Conflict struct {
+	A Change
+	B Change
+}
+
+// ErrMergeConflict is returned when two changelists cannot be merged because
+// they make conflicting changes
+type ErrMergeConflict struct {
+	Conflicts []Conflict
+}
+
+func (e ErrMergeConflict) Error() string {
+	descriptions := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		descriptions = append(descriptions, fmt.Sprintf("%s %s %q in %s", c.A.Action(), c.A.Type(), c.A.Path(), c.A.Scope()))
+	}
+	return fmt.Sprintf("changelists have %d conflicting change(s): %s",
+		len(e.Conflicts), strings.Join(descriptions, ", "))
+}
+
+// changeEntry identifies the entry within the TUF repo that a change affects
+type changeEntry struct {
+	scope      data.RoleName
+	changeType string
+	path       string
+}
+
+func entryOf(c Change) changeEntry {
+	return changeEntry{scope: c.Scope(), changeType: c.Type(), path: c.Path()}
+}
+
+// sameChange determines whether two changes would have the same effect
+func sameChange(a, b Change) bool {
+	return a.Action() == b.Action() && bytes.Equal(a.Content(), b.Content())
+}
+
+// Merge combines two changelists into a new in-memory changelist containing
+// the changes of a, followed by those of b.  Changes in b which are identical
+// to a change of a to the same entry are only included once.  If a and b make
+// different changes to the same entry, such as adding and deleting the same
+// target, none are merged and an ErrMergeConflict listing every conflict is
+// returned instead.  Neither a nor b is modified.
+func Merge(a, b Changelist) (Changelist, error) {
+	aChanges := a.List()
+	byEntry := make(map[changeEntry][]Change)
+	for _, c := range aChanges {
+		byEntry[entryOf(c)] = append(byEntry[entryOf(c)], c)
+	}
+
+	var conflicts []Conflict
+	var fromB []Change
+	for _, c := range b.List() {
+		duplicate := false
+		for _, existing := range byEntry[entryOf(c)] {
+			if sameChange(existing, c) {
+				duplicate = true
+			} else {
+				conflicts = append(conflicts, Conflict{A: existing, B: c})
+			}
+		}
+		if !duplicate {
+			fromB = append(fromB, c)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, ErrMergeConflict{Conflicts: conflicts}
+	}
+
+	merged := NewMemChangelist()
+	for _, c := range append(append([]Change{}, aChanges...), fromB...) {
+		if err := merged.Add(c); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,63 @@
+package changelist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeWithoutConflicts(t *testing.T) {
+	a := NewMemChangelist()
+	require.NoError(t, a.Add(NewTUFChange(ActionCreate, ScopeTargets, TypeTargetsTarget, "a", []byte{1})))
+	require.NoError(t, a.Add(NewTUFChange(ActionCreate, ScopeTargets, TypeTargetsTarget, "shared", []byte{2})))
+
+	b := NewMemChangelist()
+	require.NoError(t, b.Add(NewTUFChange(ActionCreate, ScopeTargets, TypeTargetsTarget, "shared", []byte{2})))
+	require.NoError(t, b.Add(NewTUFChange(ActionDelete, ScopeTargets, TypeTargetsTarget, "b", nil)))
+	// the same target name in another role is a different entry
+	require.NoError(t, b.Add(NewTUFChange(ActionDelete, "targets/releases", TypeTargetsTarget, "a", nil)))
+
+	merged, err := Merge(a, b)
+	require.NoError(t, err)
+	changes := merged.List()
+	require.Len(t, changes, 4)
+	require.Equal(t, "a", changes[0].Path())
+	require.Equal(t, "shared", changes[1].Path())
+	require.Equal(t, "b", changes[2].Path())
+	require.Equal(t, "a", changes[3].Path())
+	require.EqualValues(t, "targets/releases", changes[3].Scope())
+
+	// the merged changelists are unchanged
+	require.Len(t, a.List(), 2)
+	require.Len(t, b.List(), 3)
+
+	// merging with an empty changelist gives the same changes
+	merged, err = Merge(NewMemChangelist(), a)
+	require.NoError(t, err)
+	require.Equal(t, a.List(), merged.List())
+}
+
+func TestMergeWithConflicts(t *testing.T) {
+	addX := NewTUFChange(ActionCreate, ScopeTargets, TypeTargetsTarget, "x", []byte{1})
+	addY := NewTUFChange(ActionCreate, ScopeTargets, TypeTargetsTarget, "y", []byte{1})
+	deleteX := NewTUFChange(ActionDelete, ScopeTargets, TypeTargetsTarget, "x", nil)
+	otherY := NewTUFChange(ActionCreate, ScopeTargets, TypeTargetsTarget, "y", []byte{2})
+
+	a := NewMemChangelist()
+	require.NoError(t, a.Add(addX))
+	require.NoError(t, a.Add(addY))
+	b := NewMemChangelist()
+	require.NoError(t, b.Add(deleteX))
+	require.NoError(t, b.Add(otherY))
+	require.NoError(t, b.Add(NewTUFChange(ActionCreate, ScopeTargets, TypeTargetsTarget, "z", []byte{1})))
+
+	merged, err := Merge(a, b)
+	require.Nil(t, merged)
+	require.Error(t, err)
+	require.IsType(t, ErrMergeConflict{}, err)
+	conflicts := err.(ErrMergeConflict).Conflicts
+	require.Len(t, conflicts, 2)
+	require.Equal(t, Conflict{A: addX, B: deleteX}, conflicts[0])
+	require.Equal(t, Conflict{A: addY, B: otherY}, conflicts[1])
+	require.Contains(t, err.Error(), "2 conflicting")
+}
@@ -46,8 +46,42 @@ type repository struct {
 	tufRepo        *tuf.Repo
 	invalid        *tuf.Repo // known data that was parsable but deemed invalid
 	roundTrip      http.RoundTripper
+	userAgent      *userAgentTransport // sets the User-Agent of requests through roundTrip
 	trustPinning   trustpinning.TrustPinConfig
 	LegacyVersions int // number of versions back to fetch roots to sign with
+	updateOptions  UpdateOptions
+	targetQuorum   int
+	// hash algorithms to verify target content with, most preferred first
+	hashPreference []string
+	// sign with only enough keys to meet each role's threshold when publishing
+	thresholdKeysOnly bool
+	// version of the timestamp seen by the last PollTimestamp
+	lastPolledTimestamp int
+	// timestamp authority to countersign published targets metadata with
+	tsaURL       string
+	tsaRoundTrip http.RoundTripper
+	// signed metadata of a publish not yet acknowledged by the server
+	journal *publishJournal
+	// delegations to download on update even when delegations are lazy
+	resolvedDelegations map[data.RoleName]bool
+	// verifyOnly is set on the repositories of verifiers, which can't sign
+	verifyOnly bool
+	// delegations verified by previous updates, for skipping re-verification
+	verificationCache *tuf.VerificationCache
+	// totals of the metadata fetched and served from cache by updates
+	stats *updateCounters
+	// attributes which the keys signing a looked up target must carry
+	requiredKeyAttributes map[string]string
+	// the only keys trusted to sign each role, if restricted by the consumer
+	delegationKeyAllowlists map[data.RoleName]map[string]bool
+	// where auto refreshes report their errors
+	refreshErrorHandler func(err error)
+	// newTicker, if set, replaces time.NewTicker for auto refreshes
+	newTicker func(interval time.Duration) (<-chan time.Time, func())
+	// now, if set, replaces time.Now as the local clock when diagnosing expiry
+	now func() time.Time
+	// matches target paths against delegation paths, by prefix if nil
+	pathMatcher data.PathMatcher
 }
 
 // NewFileCachedRepository is a wrapper for NewRepository that initializes
@@ -60,10 +94,15 @@ type repository struct {
 func NewFileCachedRepository(baseDir string, gun data.GUN, baseURL string, rt http.RoundTripper,
 	retriever notary.PassRetriever, trustPinning trustpinning.TrustPinConfig) (Repository, error) {
 
-	cache, err := store.NewFileStore(
-		filepath.Join(baseDir, tufDir, filepath.FromSlash(gun.String()), "metadata"),
-		"json",
-	)
+	layout, err := TrustDirLayout(baseDir, gun.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recoverInterruptedCacheSwap(layout.Metadata); err != nil {
+		return nil, err
+	}
+	cache, err := store.NewFileStore(layout.Metadata, "json")
 	if err != nil {
 		return nil, err
 	}
@@ -75,20 +114,29 @@ func NewFileCachedRepository(baseDir string, gun data.GUN, baseURL string, rt ht
 
 	cryptoService := cryptoservice.NewCryptoService(keyStores...)
 
+	userAgent := newUserAgentTransport(rt)
+	if userAgent != nil {
+		rt = userAgent
+	}
 	remoteStore, err := getRemoteStore(baseURL, gun, rt)
 	if err != nil {
 		// baseURL is syntactically invalid
 		return nil, err
 	}
 
-	cl, err := changelist.NewFileChangelist(filepath.Join(
-		filepath.Join(baseDir, tufDir, filepath.FromSlash(gun.String()), "changelist"),
-	))
+	cl, err := changelist.NewFileChangelist(layout.Changelist)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewRepository(gun, baseURL, remoteStore, cache, trustPinning, cryptoService, cl)
+	repo, err := NewRepository(gun, baseURL, remoteStore, cache, trustPinning, cryptoService, cl)
+	if err != nil {
+		return nil, err
+	}
+	repo.(*repository).roundTrip = rt
+	repo.(*repository).userAgent = userAgent
+	repo.(*repository).journal.path = layout.PublishJournal
+	return repo, nil
 }
 
 // NewRepository is the base method that returns a new notary repository.
@@ -115,6 +163,10 @@ func NewRepository(gun data.GUN, baseURL string, remoteStore store.RemoteStore,
 		cryptoService:  cryptoService,
 		trustPinning:   trustPinning,
 		LegacyVersions: 0, // By default, don't sign with legacy roles
+		journal:        &publishJournal{},
+
+		verificationCache: tuf.NewVerificationCache(),
+		stats:             &updateCounters{},
 	}
 
 	return nRepo, nil
@@ -125,15 +177,30 @@ func (r *repository) GetGUN() data.GUN {
 	return r.gun
 }
 
-func (r *repository) updateTUF(forWrite bool) error {
-	repo, invalid, err := LoadTUFRepo(TUFLoadOptions{
+// tufLoadOptions returns the options with which to load this repository's
+// TUF metadata
+func (r *repository) tufLoadOptions(forWrite bool) TUFLoadOptions {
+	updateOptions := r.updateOptions
+	updateOptions.resolvedDelegations = r.resolvedDelegations
+	if forWrite {
+		updateOptions.LazyDelegations = false
+	}
+	return TUFLoadOptions{
 		GUN:                    r.gun,
 		TrustPinning:           r.trustPinning,
 		CryptoService:          r.cryptoService,
 		Cache:                  r.cache,
 		RemoteStore:            r.remoteStore,
 		AlwaysCheckInitialized: forWrite,
-	})
+		UpdateOptions:          updateOptions,
+		VerificationCache:      r.verificationCache,
+		stats:                  r.stats,
+		PathMatcher:            r.pathMatcher,
+	}
+}
+
+func (r *repository) updateTUF(forWrite bool) error {
+	repo, invalid, err := LoadTUFRepo(r.tufLoadOptions(forWrite))
 	if err != nil {
 		return err
 	}
@@ -142,29 +209,108 @@ func (r *repository) updateTUF(forWrite bool) error {
 	return nil
 }
 
-// ListTargets calls update first before listing targets
+// ListTargets calls update first before listing targets.  Targets which fail
+// the trust requirements set on the repository, such as a target quorum,
+// required key attributes or a key allowlist, are left out.
 func (r *repository) ListTargets(roles ...data.RoleName) ([]*TargetWithRole, error) {
 	if err := r.updateTUF(false); err != nil {
 		return nil, err
 	}
-	return NewReadOnly(r.tufRepo).ListTargets(roles...)
+	targets, err := NewReadOnly(r.tufRepo).ListTargets(roles...)
+	if err != nil {
+		return nil, err
+	}
+	var trusted []*TargetWithRole
+	for _, target := range targets {
+		err := r.checkTargetTrusted(target, roles)
+		if isUntrustedTarget(err) {
+			logrus.Debugf("not listing target %s in %s: %s", target.Name, target.Role, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		trusted = append(trusted, target)
+	}
+	return trusted, nil
 }
 
-// GetTargetByName calls update first before getting target by name
+// GetTargetByName calls update first before getting target by name.  A target
+// which is not published under exactly that name is looked up by the name as
+// normalized by data.NormalizeTargetName, just as AddTarget normalizes it.
 func (r *repository) GetTargetByName(name string, roles ...data.RoleName) (*TargetWithRole, error) {
 	if err := r.updateTUF(false); err != nil {
 		return nil, err
 	}
-	return NewReadOnly(r.tufRepo).GetTargetByName(name, roles...)
+	target, err := NewReadOnly(r.tufRepo).GetTargetByName(name, roles...)
+	if _, ok := err.(ErrNoSuchTarget); ok {
+		normalized := data.NormalizeTargetName(name)
+		if normalized == "" {
+			return nil, data.ErrInvalidTargetName{Name: name}
+		}
+		if normalized != name {
+			target, err = NewReadOnly(r.tufRepo).GetTargetByName(normalized, roles...)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkTargetTrusted(target, roles); err != nil {
+		return nil, err
+	}
+	return target, nil
 }
 
-// GetAllTargetMetadataByName calls update first before getting targets by name
+// GetAllTargetMetadataByName calls update first before getting targets by name.
+// Targets which fail the trust requirements set on the repository, such as a
+// target quorum, required key attributes or a key allowlist, are left out.
 func (r *repository) GetAllTargetMetadataByName(name string) ([]TargetSignedStruct, error) {
 	if err := r.updateTUF(false); err != nil {
 		return nil, err
 	}
-	return NewReadOnly(r.tufRepo).GetAllTargetMetadataByName(name)
+	targets, err := NewReadOnly(r.tufRepo).GetAllTargetMetadataByName(name)
+	if err != nil {
+		return nil, err
+	}
+	var trusted []TargetSignedStruct
+	for _, t := range targets {
+		target := &TargetWithRole{Target: t.Target, Role: t.Role.Name}
+		err := r.checkTargetTrusted(target, nil)
+		if isUntrustedTarget(err) {
+			logrus.Debugf("leaving out target %s in %s: %s", target.Name, target.Role, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		trusted = append(trusted, t)
+	}
+	if len(trusted) == 0 {
+		return nil, ErrNoSuchTarget(name)
+	}
+	return trusted, nil
+}
 
+// checkTargetTrusted verifies that a target found by a lookup, which may be
+// restricted to some roles, meets the trust requirements set on the repository
+func (r *repository) checkTargetTrusted(target *TargetWithRole, roles []data.RoleName) error {
+	if err := r.checkTargetQuorum(target, roles); err != nil {
+		return err
+	}
+	if err := r.checkKeyAttributes(target); err != nil {
+		return err
+	}
+	return r.checkKeyAllowlist(target)
+}
+
+// isUntrustedTarget determines whether err is returned by checkTargetTrusted
+// for a target which does not meet the trust requirements
+func isUntrustedTarget(err error) bool {
+	switch err.(type) {
+	case ErrTargetQuorumNotMet, ErrKeyAttributeMissing, ErrKeyNotAllowlisted:
+		return true
+	}
+	return false
 }
 
 // ListRoles calls update first before getting roles
@@ -228,7 +374,6 @@ func (r *repository) initialize(rootKeyIDs []string, rootCerts []data.PublicKey,
 	// nothing else - timestamps are always managed by the server, and implicit
 	// (do not have to be passed in as part of `serverManagedRoles`, so that
 	// the API of Initialize doesn't change).
-	var serverManagesSnapshot bool
 	locallyManagedKeys := []data.RoleName{
 		data.CanonicalTargetsRole,
 		data.CanonicalSnapshotRole,
@@ -245,12 +390,20 @@ func (r *repository) initialize(rootKeyIDs []string, rootCerts []data.PublicKey,
 			locallyManagedKeys = []data.RoleName{data.CanonicalTargetsRole}
 			remotelyManagedKeys = append(
 				remotelyManagedKeys, data.CanonicalSnapshotRole)
-			serverManagesSnapshot = true
 		default:
 			return ErrInvalidRemoteRole{Role: role}
 		}
 	}
 
+	return r.initializeWithRoles(rootKeyIDs, rootCerts, locallyManagedKeys, remotelyManagedKeys)
+}
+
+// initializeWithRoles initializes the notary repository with a set of
+// rootkeys, root certificates, and the roles whose keys are managed locally
+// and by the server.
+func (r *repository) initializeWithRoles(rootKeyIDs []string, rootCerts []data.PublicKey,
+	locallyManagedKeys, remotelyManagedKeys []data.RoleName) error {
+
 	// gets valid public keys corresponding to the rootKeyIDs or generate if necessary
 	var publicKeys []data.PublicKey
 	var err error
@@ -274,6 +427,7 @@ func (r *repository) initialize(rootKeyIDs []string, rootCerts []data.PublicKey,
 	}
 
 	r.tufRepo = tuf.NewRepo(r.GetCryptoService())
+	r.tufRepo.SetPathMatcher(r.pathMatcher)
 
 	if err := r.tufRepo.InitRoot(
 		rootRole,
@@ -293,8 +447,14 @@ func (r *repository) initialize(rootKeyIDs []string, rootCerts []data.PublicKey,
 		logrus.Debug("Error on InitSnapshot: ", err.Error())
 		return err
 	}
+	if containsRole(locallyManagedKeys, data.CanonicalTimestampRole) {
+		if err := r.tufRepo.InitTimestamp(); err != nil {
+			logrus.Debug("Error on InitTimestamp: ", err.Error())
+			return err
+		}
+	}
 
-	return r.saveMetadata(serverManagesSnapshot)
+	return r.saveMetadata(containsRole(remotelyManagedKeys, data.CanonicalSnapshotRole))
 }
 
 // createNewPublicKeyFromKeyIDs generates a set of public keys corresponding to the given list of
@@ -432,6 +592,12 @@ func (r *repository) initializeRoles(rootKeys []data.PublicKey, localRoles, remo
 				notary.MinThreshold,
 				key,
 			)
+		case data.CanonicalTimestampRole:
+			timestamp = data.NewBaseRole(
+				role,
+				notary.MinThreshold,
+				key,
+			)
 		}
 	}
 
@@ -500,33 +666,59 @@ func addChange(cl changelist.Changelist, c changelist.Change, roles ...data.Role
 
 // AddTarget creates new changelist entries to add a target to the given roles
 // in the repository when the changelist gets applied at publish time.
-// If roles are unspecified, the default role is "targets"
+// If roles are unspecified, the default role is "targets".  The target is
+// added under its name as normalized by data.NormalizeTargetName.
 func (r *repository) AddTarget(target *Target, roles ...data.RoleName) error {
+	template, err := newAddTargetChange(target)
+	if err != nil {
+		return err
+	}
+	return addChange(r.changelist, template, roles...)
+}
+
+// newAddTargetChange creates a changelist entry template to add the target
+// under its normalized name
+func newAddTargetChange(target *Target) (*changelist.TUFChange, error) {
 	if len(target.Hashes) == 0 {
-		return fmt.Errorf("no hashes specified for target \"%s\"", target.Name)
+		return nil, fmt.Errorf("no hashes specified for target \"%s\"", target.Name)
 	}
-	logrus.Debugf("Adding target \"%s\" with sha256 \"%x\" and size %d bytes.\n", target.Name, target.Hashes["sha256"], target.Length)
+	name := data.NormalizeTargetName(target.Name)
+	if name == "" {
+		return nil, data.ErrInvalidTargetName{Name: target.Name}
+	}
+	logrus.Debugf("Adding target \"%s\" with sha256 \"%x\" and size %d bytes.\n", name, target.Hashes["sha256"], target.Length)
 
 	meta := data.FileMeta{Length: target.Length, Hashes: target.Hashes, Custom: target.Custom}
 	metaJSON, err := json.Marshal(meta)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	template := changelist.NewTUFChange(
+	return changelist.NewTUFChange(
 		changelist.ActionCreate, "", changelist.TypeTargetsTarget,
-		target.Name, metaJSON)
-	return addChange(r.changelist, template, roles...)
+		name, metaJSON), nil
 }
 
 // RemoveTarget creates new changelist entries to remove a target from the given
 // roles in the repository when the changelist gets applied at publish time.
-// If roles are unspecified, the default role is "target".
+// If roles are unspecified, the default role is "target".  The target is
+// removed both under exactly that name, which targets published before AddTarget
+// normalized names may have, and under its name as normalized by
+// data.NormalizeTargetName.
 func (r *repository) RemoveTarget(targetName string, roles ...data.RoleName) error {
-	logrus.Debugf("Removing target \"%s\"", targetName)
-	template := changelist.NewTUFChange(changelist.ActionDelete, "",
-		changelist.TypeTargetsTarget, targetName, nil)
-	return addChange(r.changelist, template, roles...)
+	names := []string{targetName}
+	if name := data.NormalizeTargetName(targetName); name != "" && name != targetName {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		logrus.Debugf("Removing target \"%s\"", name)
+		template := changelist.NewTUFChange(changelist.ActionDelete, "",
+			changelist.TypeTargetsTarget, name, nil)
+		if err := addChange(r.changelist, template, roles...); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetChangelist returns the list of the repository's unpublished changes
@@ -549,7 +741,27 @@ func (r *repository) getRemoteStore() store.RemoteStore {
 // Publish pushes the local changes in signed material to the remote notary-server
 // Conceptually it performs an operation similar to a `git rebase`
 func (r *repository) Publish() error {
-	if err := r.publish(r.changelist); err != nil {
+	return r.PublishWithIdempotencyKey("")
+}
+
+// PublishWithIdempotencyKey publishes the changelist exactly as Publish does,
+// sending the key with the signed metadata so that a server which receives the
+// publish more than once, such as when it is retried over a flaky network,
+// applies it only once.  If a publish with the same key failed before the
+// server acknowledged it, its signed metadata is sent again with the key,
+// rather than the changelist being signed again, so that every retry of one
+// logical publish is identical.  An empty key sends no idempotency key.
+func (r *repository) PublishWithIdempotencyKey(key string) error {
+	if key != "" {
+		entry, err := r.journal.load()
+		if err != nil {
+			return err
+		}
+		if entry != nil && entry.IdempotencyKey == key {
+			return r.ResumePublish()
+		}
+	}
+	if err := r.publishWithKey(r.changelist, key); err != nil {
 		return err
 	}
 	if err := r.changelist.Clear(""); err != nil {
@@ -564,6 +776,49 @@ func (r *repository) Publish() error {
 // publish pushes the changes in the given changelist to the remote notary-server
 // Conceptually it performs an operation similar to a `git rebase`
 func (r *repository) publish(cl changelist.Changelist) error {
+	return r.publishWithKey(cl, "")
+}
+
+// publishWithKey publishes the changes in the given changelist, sending the
+// idempotency key, if there is one, with the signed metadata
+func (r *repository) publishWithKey(cl changelist.Changelist, key string) error {
+	entry := &journalEntry{IdempotencyKey: key}
+	if cl == r.changelist {
+		entry.Changes = len(cl.List())
+	}
+	return r.publishJournaled(cl, entry)
+}
+
+// publishJournaled publishes the changes in the given changelist, recording
+// the signed metadata in the journal entry until the server acknowledges it
+func (r *repository) publishJournaled(cl changelist.Changelist, entry *journalEntry) error {
+	files, err := r.signChangelist(cl)
+	if err != nil {
+		return err
+	}
+
+	// record the signed metadata, so that the publish can be resumed if it
+	// fails before the server acknowledges it
+	entry.Files = files
+	if err := r.journal.record(entry); err != nil {
+		return err
+	}
+
+	if err := setMultiWithKey(r.getRemoteStore(), files, entry.IdempotencyKey); err != nil {
+		return err
+	}
+	if err := r.journal.clear(); err != nil {
+		logrus.Warnf("unable to clear the publish journal: %s", err)
+	}
+	return nil
+}
+
+// signChangelist updates the repository, applies the changes in the given
+// changelist, and returns the signed metadata to send to the server
+func (r *repository) signChangelist(cl changelist.Changelist) (map[string][]byte, error) {
+	if r.verifyOnly {
+		return nil, ErrVerifierReadOnly{}
+	}
 	var initialPublish bool
 	// update first before publishing
 	if err := r.updateTUF(true); err != nil {
@@ -578,7 +833,7 @@ func (r *repository) publish(cl changelist.Changelist) error {
 
 			if err != nil {
 				logrus.WithError(err).Debugf("Unable to load or initialize repository during first publish: %s", err.Error())
-				return err
+				return nil, err
 			}
 
 			// Ensure we will push the initial root and targets file.  Either or
@@ -589,13 +844,15 @@ func (r *repository) publish(cl changelist.Changelist) error {
 		} else {
 			// We could not update, so we cannot publish.
 			logrus.Error("Could not publish Repository since we could not update: ", err.Error())
-			return err
+			return nil, err
 		}
 	}
+	r.tufRepo.SetSignWithAllKeys(!r.thresholdKeysOnly)
+
 	// apply the changelist to the repo
 	if err := applyChangelist(r.tufRepo, r.invalid, cl); err != nil {
 		logrus.Debug("Error applying changelist")
-		return err
+		return nil, err
 	}
 
 	// these are the TUF files we will need to update, serialized as JSON before
@@ -605,18 +862,21 @@ func (r *repository) publish(cl changelist.Changelist) error {
 	// Fetch old keys to support old clients
 	legacyKeys, err := r.oldKeysForLegacyClientSupport(r.LegacyVersions, initialPublish)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// check if our root file is nearing expiry or dirty. Resign if it is.  If
 	// root is not dirty but we are publishing for the first time, then just
 	// publish the existing root we have.
 	if err := signRootIfNecessary(updatedFiles, r.tufRepo, legacyKeys, initialPublish); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := signTargets(updatedFiles, r.tufRepo, initialPublish); err != nil {
-		return err
+		return nil, err
+	}
+	if err := r.countersignTargets(updatedFiles); err != nil {
+		return nil, err
 	}
 
 	// if we initialized the repo while designating the server as the snapshot
@@ -624,7 +884,7 @@ func (r *repository) publish(cl changelist.Changelist) error {
 	// have a local key (if there was a rotation), so initialize one.
 	if r.tufRepo.Snapshot == nil {
 		if err := r.tufRepo.InitSnapshot(); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -640,12 +900,26 @@ func (r *repository) publish(cl changelist.Changelist) error {
 			"Assuming that server should sign the snapshot.")
 	} else {
 		logrus.Debugf("Client was unable to sign the snapshot: %s", err.Error())
-		return err
+		return nil, err
 	}
 
-	remote := r.getRemoteStore()
+	// if we hold the timestamp key, the server won't sign a timestamp for us,
+	// so sign one over the snapshot signed above
+	if _, ok := updatedFiles[data.CanonicalSnapshotRole]; ok && r.holdsKey(data.CanonicalTimestampRole) {
+		if r.tufRepo.Timestamp == nil {
+			if err := r.tufRepo.InitTimestamp(); err != nil {
+				return nil, err
+			}
+		}
+		timestampJSON, err := serializeCanonicalRole(r.tufRepo, data.CanonicalTimestampRole, nil)
+		if err != nil {
+			logrus.Debugf("Client was unable to sign the timestamp: %s", err.Error())
+			return nil, err
+		}
+		updatedFiles[data.CanonicalTimestampRole] = timestampJSON
+	}
 
-	return remote.SetMulti(data.MetadataRoleMapToStringMap(updatedFiles))
+	return data.MetadataRoleMapToStringMap(updatedFiles), nil
 }
 
 func signRootIfNecessary(updates map[data.RoleName][]byte, repo *tuf.Repo, extraSigningKeys data.KeyList, initialPublish bool) error {
@@ -791,6 +1065,7 @@ func (r *repository) bootstrapRepo() error {
 
 	tufRepo, _, err := b.Finish()
 	if err == nil {
+		tufRepo.SetPathMatcher(r.pathMatcher)
 		r.tufRepo = tufRepo
 	}
 	return nil
@@ -996,3 +1271,13 @@ func DeleteTrustData(baseDir string, gun data.GUN, URL string, rt http.RoundTrip
 func (r *repository) SetLegacyVersions(n int) {
 	r.LegacyVersions = n
 }
+
+// SetSignWithAllKeys determines whether publishing signs each role with every
+// available key authorized for it, or with only as many keys as are needed to
+// meet the role's threshold.  Publishing has always signed with every key,
+// which maximizes the chance of meeting thresholds during a key rotation
+// overlap, and still does by default; only passing false changes what is
+// published.
+func (r *repository) SetSignWithAllKeys(all bool) {
+	r.thresholdKeysOnly = !all
+}
@@ -1022,6 +1022,100 @@ func TestAddTargetWithInvalidTarget(t *testing.T) {
 	require.Error(t, repo.AddTarget(target, data.CanonicalTargetsRole))
 }
 
+// Ensures that AddTarget stages targets under their normalized names, so that
+// messy names for the same artifact publish as one target, and rejects names
+// which normalize to nothing or escape the root
+func TestAddTargetNormalizesName(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	for _, name := range []string{"/app/latest", "./app/latest", "app\\latest", "app//v1/../latest/"} {
+		addTarget(t, repo, name, "../fixtures/intermediate-ca.crt")
+	}
+	for _, change := range getChanges(t, repo) {
+		require.Equal(t, "app/latest", change.Path())
+	}
+	require.NoError(t, repo.Publish())
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "app/latest", targets[0].Name)
+
+	for _, name := range []string{"", "/", "./", "..", "../outside", "app/../../outside", "\\..\\outside"} {
+		target, err := NewTarget(name, "../fixtures/intermediate-ca.crt", nil)
+		require.NoError(t, err)
+		require.Equal(t, data.ErrInvalidTargetName{Name: name}, repo.AddTarget(target))
+	}
+	require.Empty(t, getChanges(t, repo))
+}
+
+// Targets are looked up and removed by their normalized names too
+func TestGetAndRemoveTargetNormalizeName(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "app/latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	for _, name := range []string{"/app/latest", "./app/latest", "app\\latest", "app//v1/../latest/"} {
+		target, err := repo.GetTargetByName(name)
+		require.NoError(t, err, name)
+		require.Equal(t, "app/latest", target.Name)
+	}
+	_, err := repo.GetTargetByName("../outside")
+	require.Equal(t, data.ErrInvalidTargetName{Name: "../outside"}, err)
+
+	require.NoError(t, repo.RemoveTarget("/app//latest"))
+	changes := getChanges(t, repo)
+	require.Len(t, changes, 2)
+	require.Equal(t, "/app//latest", changes[0].Path())
+	require.Equal(t, "app/latest", changes[1].Path())
+	require.NoError(t, repo.Publish())
+	_, err = repo.GetTargetByName("app/latest")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
+
+// Targets which were published before AddTarget normalized names can still be
+// looked up and removed by their exact names
+func TestGetAndRemoveTargetWithUnnormalizedName(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	_, content := sha256Hex(t, "../fixtures/intermediate-ca.crt")
+	meta, err := data.NewFileMeta(bytes.NewBuffer(content), data.NotaryDefaultHashes...)
+	require.NoError(t, err)
+	metaJSON, err := json.Marshal(meta)
+	require.NoError(t, err)
+	for _, name := range []string{"/app/latest", "../outside"} {
+		require.NoError(t, repo.changelist.Add(changelist.NewTUFChange(changelist.ActionCreate,
+			data.CanonicalTargetsRole, changelist.TypeTargetsTarget, name, metaJSON)))
+	}
+	require.NoError(t, repo.Publish())
+
+	for _, name := range []string{"/app/latest", "../outside"} {
+		target, err := repo.GetTargetByName(name)
+		require.NoError(t, err, name)
+		require.Equal(t, name, target.Name)
+	}
+	// the normalized name is not published
+	_, err = repo.GetTargetByName("app/latest")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+
+	require.NoError(t, repo.RemoveTarget("../outside"))
+	require.NoError(t, repo.RemoveTarget("/app/latest"))
+	require.NoError(t, repo.Publish())
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Empty(t, targets)
+}
+
 // TestAddTargetErrorWritingChanges expects errors writing a change to file
 // to be propagated.
 func TestAddTargetErrorWritingChanges(t *testing.T) {
@@ -2268,6 +2362,29 @@ func testPublishDelegations(t *testing.T, clearCache, x509Keys bool) {
 	}
 }
 
+// Publishing to a delegation with a key imported without a role asks for the
+// key's passphrase with the delegation's name, rather than the generic alias
+// of imported keys
+func TestPublishDelegationAsksForDelegationPassphrase(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, true)
+	defer os.RemoveAll(baseDir)
+	privKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, repo.GetCryptoService().AddKey(notary.DefaultImportRole, "", privKey))
+	require.NoError(t, repo.AddDelegation("targets/releases",
+		[]data.PublicKey{data.PublicKeyFromPrivate(privKey)}, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	// a new repository object, so that no key is cached decrypted
+	delgRepo, rec, _ := newRepoToTestRepo(t, repo, baseDir)
+	addTarget(t, delgRepo, "latest", "../fixtures/intermediate-ca.crt", "targets/releases")
+	require.NoError(t, delgRepo.Publish())
+	require.Equal(t, []string{"targets/releases"}, rec.rolesAsked)
+}
+
 // If a changelist specifies a particular role to push targets to, and there
 // is a role but no key, publish should just fail outright.
 func TestPublishTargetsDelegationScopeFailIfNoKeys(t *testing.T) {
@@ -842,10 +842,11 @@ func testUpdateRemoteFileChecksumWrong(t *testing.T, opts updateOpts, errExpecte
 // timestamps, so can be malicious) ---
 
 var waysToMessUpServerBadMeta = []swizzleExpectations{
-	{desc: "invalid JSON", expectErrs: []interface{}{&trustpinning.ErrValidationFail{}, &json.SyntaxError{}},
+	// targets roles which the snapshot references are reported as such
+	{desc: "invalid JSON", expectErrs: []interface{}{&trustpinning.ErrValidationFail{}, &json.SyntaxError{}, ErrEmptyReferencedRole{}},
 		swizzle: (*testutils.MetadataSwizzler).SetInvalidJSON},
 
-	{desc: "an invalid Signed", expectErrs: []interface{}{&trustpinning.ErrValidationFail{}, &json.UnmarshalTypeError{}},
+	{desc: "an invalid Signed", expectErrs: []interface{}{&trustpinning.ErrValidationFail{}, &json.UnmarshalTypeError{}, ErrEmptyReferencedRole{}},
 		swizzle: (*testutils.MetadataSwizzler).SetInvalidSigned},
 
 	{desc: "an invalid SignedMeta",
@@ -1470,6 +1471,10 @@ func testUpdateRemoteKeyRotated(t *testing.T, role data.RoleName) {
 		require.IsType(t, &trustpinning.ErrValidationFail{}, err,
 			"expected trustpinning.ErrValidationFail when %s: got %s",
 			msg, reflect.TypeOf(err))
+	case data.CanonicalTargetsRole:
+		require.IsType(t, ErrTargetsKeyUnauthorized{}, err,
+			"expected ErrTargetsKeyUnauthorized when %s: got %s",
+			msg, reflect.TypeOf(err))
 	default:
 		require.IsType(t, signed.ErrRoleThreshold{}, err,
 			"expected ErrRoleThreshold when %s: got %s",
@@ -1997,3 +2002,32 @@ func TestLoadTUFRepoBadURL(t *testing.T) {
 	// value
 	require.EqualError(t, err1, err2.Error())
 }
+
+// If a delegation lists a signing key that its parent does not declare, the
+// update fails rather than silently ignoring the key
+func TestUpdateRejectsUndeclaredDelegationKey(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	require.NoError(t, serverSwizzler.MutateTargets(func(tgs *data.Targets) {
+		for _, role := range tgs.Delegations.Roles {
+			if role.Name == "targets/a" {
+				role.KeyIDs = append(role.KeyIDs, "undeclared")
+			}
+		}
+	}))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes(data.CanonicalTargetsRole))
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	err := repo.updateTUF(false)
+	require.Error(t, err)
+	require.Equal(t, data.ErrUndeclaredDelegationKey{
+		Role:       data.CanonicalTargetsRole,
+		Delegation: "targets/a",
+		KeyID:      "undeclared",
+	}, err)
+}
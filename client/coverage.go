@@ -0,0 +1,51 @@
+package client
+
+import (
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// DelegationCoverage updates the repository, and then maps each of the given
+// target paths to the delegation which would be responsible for signing it, or
+// to "" if no delegation covers it.  The responsible delegation is found the
+// way a lookup searches the delegation tree: from the targets role, the first
+// delegation in priority order whose paths cover the path is descended into,
+// until reaching a delegation none of whose own delegations cover it.
+// Delegations whose metadata has not been downloaded can't be descended into.
+func (r *repository) DelegationCoverage(paths []string) (map[string]string, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	targetsRole, err := r.tufRepo.GetBaseRole(data.CanonicalTargetsRole)
+	if err != nil {
+		return nil, err
+	}
+	top := data.DelegationRole{BaseRole: targetsRole, Paths: []string{""}}
+
+	coverage := make(map[string]string, len(paths))
+	for _, path := range paths {
+		coverage[path] = r.responsibleDelegation(top, path).String()
+	}
+	return coverage, nil
+}
+
+// responsibleDelegation descends from role to the delegation responsible for
+// path, returning "" if no delegation of role covers it
+func (r *repository) responsibleDelegation(role data.DelegationRole, path string) data.RoleName {
+	var responsible data.RoleName
+	for {
+		tgts, ok := r.tufRepo.Targets[role.Name]
+		if !ok {
+			return responsible
+		}
+		descended := false
+		for _, child := range tgts.GetValidDelegations(role) {
+			if child.CheckPaths(path) {
+				role, responsible, descended = child, child.Name, true
+				break
+			}
+		}
+		if !descended {
+			return responsible
+		}
+	}
+}
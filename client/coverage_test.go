@@ -0,0 +1,47 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Paths are mapped to the most deeply nested delegation covering them, with
+// earlier delegations taking priority, and uncovered paths mapped to ""
+func TestDelegationCoverage(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	for _, delegation := range []struct {
+		role  data.RoleName
+		paths []string
+	}{
+		{"targets/a", []string{"a/"}},
+		{"targets/b", []string{"b/", "shared/"}},
+		{"targets/c", []string{"shared/"}},
+	} {
+		key := createKey(t, repo, delegation.role, false)
+		require.NoError(t, repo.AddDelegation(delegation.role, []data.PublicKey{key}, delegation.paths))
+	}
+	require.NoError(t, repo.Publish())
+	key := createKey(t, repo, "targets/a/x", false)
+	require.NoError(t, repo.AddDelegation("targets/a/x", []data.PublicKey{key}, []string{"a/x/"}))
+	require.NoError(t, repo.Publish())
+
+	coverage, err := repo.DelegationCoverage([]string{
+		"a/file", "a/x/file", "b/file", "shared/file", "uncovered/file",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"a/file":         "targets/a",
+		"a/x/file":       "targets/a/x",
+		"b/file":         "targets/b",
+		"shared/file":    "targets/b",
+		"uncovered/file": "",
+	}, coverage)
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Discrepancy is a role whose verified metadata differs between the primary
+// server of a repository and a mirror of it
+type Discrepancy struct {
+	Role data.RoleName
+	// PrimaryVersion and MirrorVersion are the versions of the role's metadata
+	// from each, or 0 if one of them does not have it
+	PrimaryVersion int
+	MirrorVersion  int
+	// PrimaryChecksum and MirrorChecksum are the hex encoded SHA256 checksums
+	// of the role's metadata from each, or empty if one of them does not have
+	// it
+	PrimaryChecksum string
+	MirrorChecksum  string
+}
+
+// CrossValidate updates the repository from its server, and then separately
+// from the independent mirror at mirrorURL, verifying the mirror's metadata
+// against the same trusted root.  It reports every role whose verified
+// metadata is not identical on both, in the order of the update: root,
+// timestamp, snapshot, and then targets roles.  Since metadata can't be
+// changed without signing it again, a discrepancy means that the server or
+// mirror is showing clients a different view of the repository, either
+// because one of them is out of date or because it is compromised.  An error
+// is returned if the mirror's metadata does not verify at all.
+func (r *repository) CrossValidate(mirrorURL string) ([]Discrepancy, error) {
+	if r.roundTrip == nil {
+		return nil, store.ErrOffline{}
+	}
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	trustedRoot, err := r.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	mirrorRemote, err := getRemoteStore(mirrorURL, r.gun, r.roundTrip)
+	if err != nil {
+		return nil, err
+	}
+	mirrorCache := store.NewMemoryStore(map[data.RoleName][]byte{data.CanonicalRootRole: trustedRoot})
+	options := r.tufLoadOptions(false)
+	options.Cache = mirrorCache
+	options.RemoteStore = mirrorRemote
+	mirrorRepo, _, err := LoadTUFRepo(options)
+	if err != nil {
+		return nil, err
+	}
+
+	primary, err := loadedMetadata(r.tufRepo, r.cache)
+	if err != nil {
+		return nil, err
+	}
+	mirror, err := loadedMetadata(mirrorRepo, mirrorCache)
+	if err != nil {
+		return nil, err
+	}
+	var discrepancies []Discrepancy
+	seen := make(map[data.RoleName]bool)
+	for _, role := range append(loadedRoles(r.tufRepo), loadedRoles(mirrorRepo)...) {
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		p, m := primary[role], mirror[role]
+		if p.checksum == m.checksum {
+			continue
+		}
+		discrepancies = append(discrepancies, Discrepancy{
+			Role:            role,
+			PrimaryVersion:  p.version,
+			MirrorVersion:   m.version,
+			PrimaryChecksum: p.checksum,
+			MirrorChecksum:  m.checksum,
+		})
+	}
+	return discrepancies, nil
+}
+
+// roleMetadata summarizes the metadata of a role
+type roleMetadata struct {
+	version  int
+	checksum string
+}
+
+// loadedMetadata summarizes the metadata of every role loaded in repo, as
+// cached in cache
+func loadedMetadata(repo *tuf.Repo, cache store.MetadataStore) (map[data.RoleName]roleMetadata, error) {
+	summaries := make(map[data.RoleName]roleMetadata)
+	for _, role := range loadedRoles(repo) {
+		raw, err := cache.GetSized(role.String(), store.NoSizeLimit)
+		if err != nil {
+			continue
+		}
+		common := struct {
+			Signed data.SignedCommon `json:"signed"`
+		}{}
+		if err := json.Unmarshal(raw, &common); err != nil {
+			return nil, err
+		}
+		checksum := sha256.Sum256(raw)
+		summaries[role] = roleMetadata{version: common.Signed.Version, checksum: hex.EncodeToString(checksum[:])}
+	}
+	return summaries, nil
+}
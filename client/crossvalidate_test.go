@@ -0,0 +1,98 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// Identical metadata on the server and the mirror produces no discrepancies,
+// but validly signed metadata which differs is reported by role
+func TestCrossValidate(t *testing.T) {
+	serverMeta, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+	mirrorSwizzler := testutils.NewMetadataSwizzler("docker.com/notary", serverMeta, serverSwizzler.CryptoService)
+	mirror := readOnlyServer(t, mirrorSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer mirror.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	discrepancies, err := repo.CrossValidate(mirror.URL)
+	require.NoError(t, err)
+	require.Empty(t, discrepancies)
+
+	// the mirror shows a different set of targets, signed with the real keys
+	require.NoError(t, mirrorSwizzler.MutateTargets(func(tgs *data.Targets) {
+		tgs.Targets["injected"] = data.FileMeta{Length: 1, Hashes: data.Hashes{"sha256": make([]byte, 32)}}
+	}))
+	require.NoError(t, mirrorSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, mirrorSwizzler.UpdateTimestampHash())
+
+	discrepancies, err = repo.CrossValidate(mirror.URL)
+	require.NoError(t, err)
+	require.Len(t, discrepancies, 3)
+	for i, role := range []data.RoleName{data.CanonicalTimestampRole, data.CanonicalSnapshotRole, data.CanonicalTargetsRole} {
+		require.Equal(t, role, discrepancies[i].Role)
+		require.NotEmpty(t, discrepancies[i].PrimaryChecksum)
+		require.NotEmpty(t, discrepancies[i].MirrorChecksum)
+		require.NotEqual(t, discrepancies[i].PrimaryChecksum, discrepancies[i].MirrorChecksum)
+	}
+	require.Equal(t, discrepancies[0].PrimaryVersion, discrepancies[0].MirrorVersion)
+
+	// the primary's view is unaffected
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	for _, target := range targets {
+		require.NotEqual(t, "injected", target.Name)
+	}
+}
+
+// A mirror whose metadata does not verify against the trusted root fails
+// cross validation
+func TestCrossValidateInvalidMirror(t *testing.T) {
+	serverMeta, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+	mirrorSwizzler := testutils.NewMetadataSwizzler("docker.com/notary", serverMeta, serverSwizzler.CryptoService)
+	mirror := readOnlyServer(t, mirrorSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer mirror.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	require.NoError(t, mirrorSwizzler.InvalidateMetadataSignatures(data.CanonicalTargetsRole))
+	require.NoError(t, mirrorSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, mirrorSwizzler.UpdateTimestampHash())
+
+	_, err := repo.CrossValidate(mirror.URL)
+	require.Error(t, err)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+}
+
+// Cached metadata which can't be parsed is reported rather than summarized as
+// version 0
+func TestLoadedMetadataInvalidJSON(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	summaries, err := loadedMetadata(repo.tufRepo, repo.cache)
+	require.NoError(t, err)
+	require.NotZero(t, summaries[data.CanonicalTargetsRole].version)
+
+	require.NoError(t, repo.cache.Set(data.CanonicalTargetsRole.String(), []byte("not json")))
+	_, err = loadedMetadata(repo.tufRepo, repo.cache)
+	require.Error(t, err)
+}
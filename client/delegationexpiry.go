@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrDelegationOutlivesParent is returned by an update which checks delegation
+// expiry when a delegation expires later than the targets role delegating to
+// it, by more than the configured margin.  A delegation is only trusted for as
+// long as its parent is, so one which outlives its parent suggests metadata
+// which was not signed with its parent in mind.
+type ErrDelegationOutlivesParent struct {
+	Role          data.RoleName
+	Parent        data.RoleName
+	Expires       time.Time
+	ParentExpires time.Time
+}
+
+func (err ErrDelegationOutlivesParent) Error() string {
+	return fmt.Sprintf("%s expires at %s, after its parent %s, which expires at %s",
+		err.Role, err.Expires, err.Parent, err.ParentExpires)
+}
+
+// checkDelegationExpiry ensures, if enabled, that no delegation of an updated
+// repo expires more than margin after its parent.  Delegations are checked in
+// order of name, and those whose parent was not downloaded are skipped.
+func checkDelegationExpiry(repo *tuf.Repo, enabled bool, margin time.Duration) error {
+	if !enabled {
+		return nil
+	}
+	roles := make([]data.RoleName, 0, len(repo.Targets))
+	for role := range repo.Targets {
+		if data.IsDelegation(role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+
+	for _, role := range roles {
+		parent, ok := repo.Targets[role.Parent()]
+		if !ok {
+			continue
+		}
+		expires := repo.Targets[role].Signed.Expires
+		parentExpires := parent.Signed.Expires
+		if expires.After(parentExpires.Add(margin)) {
+			return ErrDelegationOutlivesParent{
+				Role:          role,
+				Parent:        role.Parent(),
+				Expires:       expires,
+				ParentExpires: parentExpires,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Delegations which expire with their parent pass the check
+func TestDelegationExpiryCheckAcceptsNormalExpiry(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetUpdateOptions(UpdateOptions{CheckDelegationExpiry: true, DelegationExpiryMargin: time.Hour})
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// A delegation which expires long after its parent fails the update when the
+// check is enabled, unless the margin allows for it, and is accepted otherwise
+func TestDelegationExpiryCheckRejectsDelegationOutlivingParent(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	parentExpires := time.Now().Add(24 * time.Hour).UTC().Round(time.Second)
+	require.NoError(t, serverSwizzler.MutateTargets(func(tgs *data.Targets) {
+		tgs.Expires = parentExpires
+	}))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes(data.CanonicalTargetsRole))
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	repo.SetUpdateOptions(UpdateOptions{CheckDelegationExpiry: true})
+	_, err = repo.ListTargets()
+	require.IsType(t, ErrDelegationOutlivesParent{}, err)
+	outlives := err.(ErrDelegationOutlivesParent)
+	require.Equal(t, data.RoleName("targets/a"), outlives.Role)
+	require.Equal(t, data.CanonicalTargetsRole, outlives.Parent)
+	require.True(t, parentExpires.Equal(outlives.ParentExpires))
+	require.True(t, outlives.Expires.After(parentExpires))
+
+	// a margin covering how long the delegations outlive their parents lets
+	// the update succeed
+	margin := outlives.Expires.Sub(parentExpires) + time.Hour
+	repo.SetVerificationPolicy(VerificationPolicy{CheckDelegationExpiry: true, DelegationExpiryMargin: margin})
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+}
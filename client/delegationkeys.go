@@ -0,0 +1,139 @@
+package client
+
+import (
+	"sort"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// DelegationKeyChange describes how the keys of a delegation changed during an
+// update.  Since the builder only loads the metadata of the parent role which
+// owns the delegation once a threshold of the parent's keys has signed it, every
+// reported change is signed by the parent role.
+type DelegationKeyChange struct {
+	Role   data.RoleName
+	Parent data.RoleName
+	// Added and Removed are the IDs of the keys which the delegation gained
+	// and lost
+	Added   []string
+	Removed []string
+}
+
+// UpdateResult reports what an update changed in the trust data
+type UpdateResult struct {
+	// DelegationKeyChanges are the delegations which existed both before and
+	// after the update, but with different keys, in order of role name
+	DelegationKeyChanges []DelegationKeyChange
+	// AlgorithmDowngrades are the roles which are signed with a weaker
+	// signature algorithm than before the update, in order of role name
+	AlgorithmDowngrades []AlgorithmDowngrade
+	// TimestampAdvisory is set if the timestamp is not signed by exactly the
+	// single authorized key recommended for it
+	TimestampAdvisory *TimestampAdvisory
+}
+
+// UpdateWithResult updates the TUF metadata for the repository exactly as any
+// other read or write operation would, and reports what the update changed.
+// The keys of each delegation which was already trusted are compared with its
+// keys after the update, and each change is reported.  Roles which are signed with a weaker
+// signature algorithm than before are reported too, but only fail the update
+// if UpdateOptions.RejectAlgorithmDowngrades is set, and a timestamp signed by
+// other than its single authorized key is reported as an advisory.  If the
+// repository has not been updated since it was created, the metadata in the
+// cache is what the update is compared with.
+func (r *repository) UpdateWithResult(forWrite bool) (*tuf.Repo, UpdateResult, error) {
+	previous := r.tufRepo
+	if previous == nil {
+		options := r.tufLoadOptions(false)
+		options.RemoteStore = store.OfflineStore{}
+		// with nothing usable in the cache, every delegation is new
+		previous, _, _ = LoadTUFRepo(options)
+	}
+
+	repo, invalid, err := LoadTUFRepo(r.tufLoadOptions(forWrite))
+	if err != nil {
+		return nil, UpdateResult{}, err
+	}
+	changes := checkDelegationKeyChanges(previous, repo)
+	downgrades, err := checkAlgorithmDowngrades(previous, repo, r.updateOptions.RejectAlgorithmDowngrades)
+	if err != nil {
+		return nil, UpdateResult{}, err
+	}
+	r.tufRepo = repo
+	r.invalid = invalid
+	return repo, UpdateResult{
+		DelegationKeyChanges: changes,
+		AlgorithmDowngrades:  downgrades,
+		TimestampAdvisory:    checkTimestampSignatures(repo),
+	}, nil
+}
+
+// checkDelegationKeyChanges finds the delegations whose keys differ between the
+// previous and updated repos
+func checkDelegationKeyChanges(previous, updated *tuf.Repo) []DelegationKeyChange {
+	if previous == nil {
+		return nil
+	}
+	var changes []DelegationKeyChange
+	for parent, tgts := range updated.Targets {
+		oldTgts, ok := previous.Targets[parent]
+		if !ok {
+			continue
+		}
+		oldKeys := delegationKeyIDs(oldTgts)
+		for role, keyIDs := range delegationKeyIDs(tgts) {
+			old, ok := oldKeys[role]
+			if !ok || sameKeyIDs(old, keyIDs) {
+				continue
+			}
+			changes = append(changes, DelegationKeyChange{
+				Role:    role,
+				Parent:  parent,
+				Added:   keyIDDifference(keyIDs, old),
+				Removed: keyIDDifference(old, keyIDs),
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Role < changes[j].Role })
+	return changes
+}
+
+// delegationKeyIDs maps each delegation of a targets role to its sorted key IDs
+func delegationKeyIDs(tgts *data.SignedTargets) map[data.RoleName][]string {
+	keyIDs := make(map[data.RoleName][]string, len(tgts.Signed.Delegations.Roles))
+	for _, delegation := range tgts.Signed.Delegations.Roles {
+		ids := append([]string{}, delegation.KeyIDs...)
+		sort.Strings(ids)
+		keyIDs[delegation.Name] = ids
+	}
+	return keyIDs
+}
+
+func sameKeyIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// keyIDDifference returns the sorted key IDs in a which are not in b
+func keyIDDifference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+	}
+	diff := []string{}
+	for _, id := range a {
+		if !inB[id] {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
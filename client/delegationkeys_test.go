@@ -0,0 +1,72 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// A delegation key rotation which is signed by the parent role is reported
+func TestUpdateWithResultReportsAuthorizedKeyChange(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, result, err := repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Empty(t, result.DelegationKeyChanges)
+	oldKeyIDs := repo.tufRepo.Targets[data.CanonicalTargetsRole].Signed.Delegations.Roles[0].KeyIDs
+
+	// the swizzler re-signs the top level targets with its own key
+	newKey, err := serverSwizzler.CryptoService.Create("targets/a", "docker.com/notary", data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, serverSwizzler.RotateKey("targets/a", newKey))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes(data.CanonicalTargetsRole))
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	_, result, err = repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Equal(t, []DelegationKeyChange{{
+		Role:    "targets/a",
+		Parent:  data.CanonicalTargetsRole,
+		Added:   []string{newKey.ID()},
+		Removed: oldKeyIDs,
+	}}, result.DelegationKeyChanges)
+
+	// an update that doesn't change the keys reports nothing
+	_, result, err = repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Empty(t, result.DelegationKeyChanges)
+}
+
+// A delegation key rotation which is not signed by the parent's keys fails the
+// update, and the previously trusted keys remain in use
+func TestUpdateWithResultRejectsForgedKeyChange(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, _, err := repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	trusted := repo.tufRepo
+
+	newKey, err := serverSwizzler.CryptoService.Create("targets/a", "docker.com/notary", data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, serverSwizzler.RotateKey("targets/a", newKey))
+	require.NoError(t, serverSwizzler.SignMetadataWithInvalidKey(data.CanonicalTargetsRole))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes(data.CanonicalTargetsRole))
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	_, _, err = repo.UpdateWithResult(false)
+	require.Error(t, err)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+	require.True(t, trusted == repo.tufRepo)
+}
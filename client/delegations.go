@@ -75,6 +75,28 @@ func (r *repository) AddDelegationPaths(name data.RoleName, paths []string) erro
 	return addChange(r.changelist, template, name)
 }
 
+// AddDelegationExcludePaths creates a changelist entry to exclude an existing
+// delegation from the provided path prefixes, so that it is not trusted for
+// targets under them even where they fall under its paths.
+func (r *repository) AddDelegationExcludePaths(name data.RoleName, paths []string) error {
+
+	if !data.IsDelegation(name) {
+		return data.ErrInvalidRole{Role: name, Reason: "invalid delegation role name"}
+	}
+
+	logrus.Debugf(`Excluding %s paths from delegation %s\n`, paths, name)
+
+	tdJSON, err := json.Marshal(&changelist.TUFDelegation{
+		AddExcludePaths: paths,
+	})
+	if err != nil {
+		return err
+	}
+
+	template := newCreateDelegationChange(name, tdJSON)
+	return addChange(r.changelist, template, name)
+}
+
 // RemoveDelegationKeysAndPaths creates changelist entries to remove provided delegation key IDs and paths.
 // This method composes RemoveDelegationPaths and RemoveDelegationKeys (each creates one changelist entry if called).
 func (r *repository) RemoveDelegationKeysAndPaths(name data.RoleName, keyIDs, paths []string) error {
@@ -126,6 +148,27 @@ func (r *repository) RemoveDelegationPaths(name data.RoleName, paths []string) e
 	return addChange(r.changelist, template, name)
 }
 
+// RemoveDelegationExcludePaths creates a changelist entry to stop excluding an existing delegation
+// from the provided path prefixes.
+func (r *repository) RemoveDelegationExcludePaths(name data.RoleName, paths []string) error {
+
+	if !data.IsDelegation(name) {
+		return data.ErrInvalidRole{Role: name, Reason: "invalid delegation role name"}
+	}
+
+	logrus.Debugf(`Removing %s excluded paths from delegation "%s"\n`, paths, name)
+
+	tdJSON, err := json.Marshal(&changelist.TUFDelegation{
+		RemoveExcludePaths: paths,
+	})
+	if err != nil {
+		return err
+	}
+
+	template := newUpdateDelegationChange(name, tdJSON)
+	return addChange(r.changelist, template, name)
+}
+
 // RemoveDelegationKeys creates a changelist entry to remove provided keys from an existing delegation.
 // When this changelist is applied, if the specified keys are the only keys left in the role,
 // the role itself will be deleted in its entirety.
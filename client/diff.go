@@ -0,0 +1,138 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// UpdateOptions configures optional behaviours of the TUF update performed
+// before any read or write operation on a repository
+type UpdateOptions struct {
+	// AllowDiffs lets the client ask the server for targets and delegation
+	// metadata as a delta against the version it has cached, rather than
+	// downloading the full file.  The metadata reconstructed from the delta
+	// must still match the checksum in the snapshot.  If the server cannot
+	// serve a delta, the full file is downloaded instead.
+	AllowDiffs bool
+
+	// FreshnessWindows is the maximum age, per role, of timestamp and snapshot
+	// metadata that an update will accept.  The age is inferred from the
+	// expiry, assuming notary's default expiry for the role, so the window is
+	// only enforced for servers which sign with the default expiries.  See
+	// SetFreshnessWindow.
+	FreshnessWindows map[data.RoleName]time.Duration
+
+	// TransparencyLog, if set, must verify that updated metadata is included
+	// in a transparency log.  See SetTransparencyLog.
+	TransparencyLog LogVerifier
+
+	// LazyDelegations defers downloading delegations until they are resolved
+	// with ResolveDelegation, so that an update only downloads the top level
+	// targets and the delegations resolved so far.  Lookups of targets only
+	// search the roles which have been downloaded.  Publishing always
+	// downloads every delegation, since changes may be applied to any of them.
+	LazyDelegations bool
+
+	// CheckTimestampExpiry rejects a timestamp which expires far later than
+	// the snapshot it references, which can be a sign of a server trying to
+	// freeze the repository at the snapshot.  See ErrSuspiciousExpiry.
+	CheckTimestampExpiry bool
+
+	// CheckDelegationExpiry rejects a delegation which expires more than
+	// DelegationExpiryMargin later than its parent.  See
+	// ErrDelegationOutlivesParent.
+	CheckDelegationExpiry bool
+
+	// DelegationExpiryMargin is how much later than its parent a delegation
+	// may expire when CheckDelegationExpiry is set
+	DelegationExpiryMargin time.Duration
+
+	// RejectAlgorithmDowngrades fails UpdateWithResult if any role is signed
+	// with a weaker signature algorithm than before the update, rather than
+	// only reporting it.  See ErrAlgorithmDowngrade.
+	RejectAlgorithmDowngrades bool
+
+	// MaxSnapshotVersionDelta is how far ahead of the cached snapshot the
+	// version of a downloaded snapshot may be before the update rejects it
+	// with ErrImplausibleVersion.  Zero selects
+	// DefaultMaxSnapshotVersionDelta, and a negative value removes the bound.
+	MaxSnapshotVersionDelta int
+
+	// StrictParsing rejects metadata whose signed portion has fields which
+	// are not part of its role's metadata format.  See VerificationPolicy.
+	StrictParsing bool
+
+	// RequireCanonicalJSON rejects metadata whose signed portion is not
+	// serialized in canonical JSON, which is the form it is signed in.
+	RequireCanonicalJSON bool
+
+	// KeyPolicy, if set, is enforced on the keys of every role after each
+	// update, as by EnforceKeyPolicy.
+	KeyPolicy *KeyPolicy
+
+	// resolvedDelegations are the delegations which are downloaded even when
+	// delegations are lazy, along with their ancestors
+	resolvedDelegations map[data.RoleName]bool
+}
+
+// SetUpdateOptions configures optional behaviours of all subsequent updates
+func (r *repository) SetUpdateOptions(opts UpdateOptions) {
+	r.updateOptions = opts
+}
+
+func isTargetsRole(role data.RoleName) bool {
+	return role == data.CanonicalTargetsRole || data.IsDelegation(role)
+}
+
+// diffBase returns the cached version of a targets role regardless of its size,
+// to be used as the base of a delta, if deltas are allowed
+func (c *tufClient) diffBase(consistentInfo tuf.ConsistentInfo) []byte {
+	if !c.options.AllowDiffs || !isTargetsRole(consistentInfo.RoleName) {
+		return nil
+	}
+	base, err := c.cache.GetSized(consistentInfo.RoleName.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil
+	}
+	return base
+}
+
+// tryLoadRemoteDiff downloads a delta against the base and uses it to
+// reconstruct the latest version of the role, which is then verified exactly
+// as if it had been downloaded in full
+func (c *tufClient) tryLoadRemoteDiff(consistentInfo tuf.ConsistentInfo, base []byte) ([]byte, error) {
+	diffStore, ok := c.remote.(store.DiffStore)
+	if !ok {
+		return nil, fmt.Errorf("remote store does not support deltas")
+	}
+	consistentName := consistentInfo.ConsistentName()
+	checksum := sha256.Sum256(base)
+
+	rawDelta, err := diffStore.GetDiffSized(consistentName, hex.EncodeToString(checksum[:]), consistentInfo.Length())
+	c.stats.recordFetch(rawDelta, err)
+	if err != nil {
+		return nil, err
+	}
+	delta := &utils.Delta{}
+	if err := json.Unmarshal(rawDelta, delta); err != nil {
+		return nil, err
+	}
+	if delta.Length > consistentInfo.Length() {
+		return nil, store.ErrMaliciousServer{}
+	}
+	raw, err := utils.ApplyDelta(base, delta)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("reconstructed %s from a %d byte delta", consistentName, len(rawDelta))
+	return c.loadDownloaded(consistentInfo, raw, base)
+}
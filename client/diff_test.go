@@ -0,0 +1,160 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// recordingTransport records every request that passes through it, along with
+// the status code of the response
+type recordingTransport struct {
+	lock     sync.Mutex
+	requests []*http.Request
+	statuses []int
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	rt.requests = append(rt.requests, req)
+	if err == nil {
+		rt.statuses = append(rt.statuses, resp.StatusCode)
+	} else {
+		rt.statuses = append(rt.statuses, 0)
+	}
+	return resp, err
+}
+
+// diffRequests returns the statuses of all the requests which asked for a delta
+func (rt *recordingTransport) diffRequests() []int {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	var statuses []int
+	for i, req := range rt.requests {
+		if req.URL.Query().Get(notary.DiffFromQueryParam) != "" {
+			statuses = append(statuses, rt.statuses[i])
+		}
+	}
+	return statuses
+}
+
+func useRecordingTransport(t *testing.T, repo *repository) *recordingTransport {
+	rt := &recordingTransport{}
+	remote, err := getRemoteStore(repo.baseURL, repo.gun, rt)
+	require.NoError(t, err)
+	repo.remoteStore = remote
+	return rt
+}
+
+// When diffs are allowed, an updated targets file is reconstructed from a delta
+// against the cached version, and the result is byte-for-byte identical to what
+// a full download produces.
+func TestUpdateWithDiffMatchesFullFetch(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	publisher, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	for i := 0; i < 20; i++ {
+		addTarget(t, publisher, fmt.Sprintf("target%d", i), "../fixtures/intermediate-ca.crt")
+	}
+	require.NoError(t, publisher.Publish())
+
+	// the reader caches the first version of targets
+	reader, _, readerDir := newRepoToTestRepo(t, publisher, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+
+	addTarget(t, publisher, "one-more", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, publisher.Publish())
+
+	reader.SetUpdateOptions(UpdateOptions{AllowDiffs: true})
+	rt := useRecordingTransport(t, reader)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 21)
+	require.Equal(t, []int{http.StatusOK}, rt.diffRequests(), "targets should have been fetched as a delta")
+
+	// a reader with no cache must fetch everything in full
+	full, _, fullDir := newRepoToTestRepo(t, publisher, "")
+	defer os.RemoveAll(fullDir)
+	full.SetUpdateOptions(UpdateOptions{AllowDiffs: true})
+	fullRT := useRecordingTransport(t, full)
+	_, err = full.ListTargets()
+	require.NoError(t, err)
+	require.Empty(t, fullRT.diffRequests())
+
+	fromDiff, err := reader.cache.GetSized(data.CanonicalTargetsRole.String(), -1)
+	require.NoError(t, err)
+	fromFull, err := full.cache.GetSized(data.CanonicalTargetsRole.String(), -1)
+	require.NoError(t, err)
+	require.Equal(t, fromFull, fromDiff)
+}
+
+// By default, deltas are never requested
+func TestUpdateWithoutDiffsFetchesInFull(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	publisher, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, publisher, "first", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, publisher.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, publisher, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+
+	addTarget(t, publisher, "second", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, publisher.Publish())
+
+	rt := useRecordingTransport(t, reader)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Empty(t, rt.diffRequests())
+}
+
+// If the server cannot serve a delta, or serves one which does not reconstruct
+// the metadata in the snapshot, the client falls back to fetching in full
+func TestUpdateWithBadDiffFallsBackToFullFetch(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	publisher, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, publisher, "first", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, publisher.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, publisher, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+
+	addTarget(t, publisher, "second", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, publisher.Publish())
+
+	// corrupt the cached base, so the server does not know it and no delta can be served
+	cached, err := reader.cache.GetSized(data.CanonicalTargetsRole.String(), -1)
+	require.NoError(t, err)
+	require.NoError(t, reader.cache.Set(data.CanonicalTargetsRole.String(),
+		[]byte(strings.Replace(string(cached), "first", "frist", 1))))
+
+	reader.SetUpdateOptions(UpdateOptions{AllowDiffs: true})
+	rt := useRecordingTransport(t, reader)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Equal(t, []int{http.StatusNotFound}, rt.diffRequests())
+}
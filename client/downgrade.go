@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrAlgorithmDowngrade is returned, when downgrades are rejected, if the
+// metadata of a role was signed with a weaker signature algorithm after an
+// update than before it
+type ErrAlgorithmDowngrade struct {
+	AlgorithmDowngrade
+}
+
+func (err ErrAlgorithmDowngrade) Error() string {
+	return fmt.Sprintf("%s was signed with %s, but is now signed with the weaker %s", err.Role, err.Previous, err.Current)
+}
+
+// AlgorithmDowngrade describes a role whose strongest signature algorithm
+// became weaker during an update, which may be a sign of a downgrade attack or
+// a misconfigured signer
+type AlgorithmDowngrade struct {
+	Role     data.RoleName
+	Previous data.SigAlgorithm
+	Current  data.SigAlgorithm
+}
+
+// signatureStrength ranks the signature algorithms, with the strongest
+// highest.  Unrecognized algorithms rank lowest.
+var signatureStrength = map[data.SigAlgorithm]int{
+	data.RSAPKCS1v15Signature: 1,
+	data.RSAPSSSignature:      2,
+	data.PyCryptoSignature:    2,
+	data.ECDSASignature:       3,
+	data.EDDSASignature:       3,
+}
+
+// checkAlgorithmDowngrades compares the strongest signature algorithm of each
+// role in the previous and updated repos, and reports those which became
+// weaker, in order of role name.  Downgrades are logged, and are only fatal if
+// reject is set.
+func checkAlgorithmDowngrades(previous, updated *tuf.Repo, reject bool) ([]AlgorithmDowngrade, error) {
+	if previous == nil {
+		return nil, nil
+	}
+	before := strongestAlgorithms(previous)
+	var downgrades []AlgorithmDowngrade
+	for role, current := range strongestAlgorithms(updated) {
+		old, ok := before[role]
+		if !ok || signatureStrength[current] >= signatureStrength[old] {
+			continue
+		}
+		downgrades = append(downgrades, AlgorithmDowngrade{Role: role, Previous: old, Current: current})
+	}
+	sort.Slice(downgrades, func(i, j int) bool { return downgrades[i].Role < downgrades[j].Role })
+
+	for _, downgrade := range downgrades {
+		if reject {
+			return nil, ErrAlgorithmDowngrade{downgrade}
+		}
+		logrus.Warnf("%s was signed with %s, but is now signed with the weaker %s",
+			downgrade.Role, downgrade.Previous, downgrade.Current)
+	}
+	return downgrades, nil
+}
+
+// strongestAlgorithms maps each role loaded in the repo to the strongest
+// algorithm its metadata is signed with
+func strongestAlgorithms(repo *tuf.Repo) map[data.RoleName]data.SigAlgorithm {
+	signatures := make(map[data.RoleName][]data.Signature)
+	if repo.Root != nil {
+		signatures[data.CanonicalRootRole] = repo.Root.Signatures
+	}
+	if repo.Snapshot != nil {
+		signatures[data.CanonicalSnapshotRole] = repo.Snapshot.Signatures
+	}
+	if repo.Timestamp != nil {
+		signatures[data.CanonicalTimestampRole] = repo.Timestamp.Signatures
+	}
+	for role, tgts := range repo.Targets {
+		signatures[role] = tgts.Signatures
+	}
+
+	strongest := make(map[data.RoleName]data.SigAlgorithm, len(signatures))
+	for role, sigs := range signatures {
+		for i, sig := range sigs {
+			if i == 0 || signatureStrength[sig.Method] > signatureStrength[strongest[role]] {
+				strongest[role] = sig.Method
+			}
+		}
+	}
+	return strongest
+}
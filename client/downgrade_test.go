@@ -0,0 +1,73 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+	testkeys "github.com/theupdateframework/notary/tuf/testutils/keys"
+)
+
+// resignWithRSA re-signs the metadata of a delegation with a new RSA key, which
+// its parent authorizes in place of its ECDSA key
+func resignWithRSA(t *testing.T, s *testutils.MetadataSwizzler, role data.RoleName) {
+	for _, keyID := range s.CryptoService.ListKeys(role) {
+		require.NoError(t, s.CryptoService.RemoveKey(keyID))
+	}
+	rsaKey, err := testkeys.GetRSAKey(2048)
+	require.NoError(t, err)
+	require.NoError(t, s.CryptoService.AddKey(role, s.Gun, rsaKey))
+	require.NoError(t, s.RotateKey(role, data.PublicKeyFromPrivate(rsaKey)))
+	require.NoError(t, s.OffsetMetadataVersion(role, 1))
+	require.NoError(t, s.UpdateSnapshotHashes(role.Parent(), role))
+	require.NoError(t, s.UpdateTimestampHash())
+}
+
+// A role re-signed with a weaker algorithm is reported, but the update succeeds
+func TestUpdateWithResultReportsAlgorithmDowngrade(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, result, err := repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Empty(t, result.AlgorithmDowngrades)
+
+	resignWithRSA(t, serverSwizzler, "targets/a")
+	_, result, err = repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Equal(t, []AlgorithmDowngrade{{
+		Role:     "targets/a",
+		Previous: data.ECDSASignature,
+		Current:  data.RSAPSSSignature,
+	}}, result.AlgorithmDowngrades)
+
+	// once accepted, the weaker algorithm is no longer a downgrade
+	_, result, err = repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Empty(t, result.AlgorithmDowngrades)
+}
+
+// Downgrades fail the update when they are rejected
+func TestUpdateWithResultRejectsAlgorithmDowngrade(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetUpdateOptions(UpdateOptions{RejectAlgorithmDowngrades: true})
+	_, _, err := repo.UpdateWithResult(false)
+	require.NoError(t, err)
+
+	resignWithRSA(t, serverSwizzler, "targets/a")
+	_, _, err = repo.UpdateWithResult(false)
+	require.Error(t, err)
+	require.IsType(t, ErrAlgorithmDowngrade{}, err)
+	require.Equal(t, data.RoleName("targets/a"), err.(ErrAlgorithmDowngrade).Role)
+}
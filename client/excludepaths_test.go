@@ -0,0 +1,61 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A target under a path prefix which its delegation is excluded from is not
+// attributed to the delegation when looking targets up
+func TestDelegationExcludePaths(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/prod", false)
+	require.NoError(t, repo.AddDelegation("targets/prod", []data.PublicKey{key}, []string{"prod/"}))
+	addTarget(t, repo, "prod/app", "../fixtures/intermediate-ca.crt", "targets/prod")
+	addTarget(t, repo, "prod/secret/key", "../fixtures/intermediate-ca.crt", "targets/prod")
+	require.NoError(t, repo.Publish())
+	_, err := repo.GetTargetByName("prod/secret/key")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.AddDelegationExcludePaths("targets/prod", []string{"prod/secret/"}))
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	roles, err := reader.GetDelegationRoles()
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	require.Equal(t, []string{"prod/secret/"}, roles[0].ExcludePaths)
+
+	// the excluded target is still in the delegation's metadata, but is not
+	// trusted
+	_, err = reader.GetTargetByName("prod/secret/key")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+	target, err := reader.GetTargetByName("prod/app")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName("targets/prod"), target.Role)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "prod/app", targets[0].Name)
+
+	// no new targets can be added under the excluded prefix
+	addTarget(t, repo, "prod/secret/other", "../fixtures/intermediate-ca.crt", "targets/prod")
+	require.Error(t, repo.Publish())
+	require.NoError(t, repo.changelist.Clear(""))
+
+	// once the exclusion is removed, the target is trusted again
+	require.NoError(t, repo.RemoveDelegationExcludePaths("targets/prod", []string{"prod/secret/"}))
+	require.NoError(t, repo.Publish())
+	target, err = reader.GetTargetByName("prod/secret/key")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName("targets/prod"), target.Role)
+}
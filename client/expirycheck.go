@@ -0,0 +1,42 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf"
+)
+
+// suspiciousExpiryMargin is how much later than the snapshot a timestamp may
+// expire before it is considered suspicious.  A timestamp signed shortly before
+// the snapshot expires legitimately outlives it by up to the timestamp expiry.
+const suspiciousExpiryMargin = notary.NotaryTimestampExpiry
+
+// ErrSuspiciousExpiry is returned by an update which checks timestamp expiry
+// when the timestamp expires far later than the snapshot it references.  Since
+// the timestamp is what proves an update is fresh, a server which gives it a
+// long expiry could keep clients from noticing that it has stopped updating.
+type ErrSuspiciousExpiry struct {
+	TimestampExpires time.Time
+	SnapshotExpires  time.Time
+}
+
+func (err ErrSuspiciousExpiry) Error() string {
+	return fmt.Sprintf("timestamp expires at %s, suspiciously long after its snapshot, which expires at %s",
+		err.TimestampExpires, err.SnapshotExpires)
+}
+
+// checkTimestampExpiry ensures, if enabled, that the timestamp of an updated
+// repo does not expire more than suspiciousExpiryMargin after its snapshot
+func checkTimestampExpiry(repo *tuf.Repo, enabled bool) error {
+	if !enabled || repo.Timestamp == nil || repo.Snapshot == nil {
+		return nil
+	}
+	timestampExpires := repo.Timestamp.Signed.Expires
+	snapshotExpires := repo.Snapshot.Signed.Expires
+	if timestampExpires.After(snapshotExpires.Add(suspiciousExpiryMargin)) {
+		return ErrSuspiciousExpiry{TimestampExpires: timestampExpires, SnapshotExpires: snapshotExpires}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A timestamp with the usual expiry passes the check
+func TestTimestampExpiryCheckAcceptsNormalExpiry(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetUpdateOptions(UpdateOptions{CheckTimestampExpiry: true})
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// A timestamp which expires long after its snapshot fails the update when the
+// check is enabled, and is accepted otherwise
+func TestTimestampExpiryCheckRejectsLongExpiry(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	snapshotExpires := time.Now().Add(30 * 24 * time.Hour).UTC().Round(time.Second)
+	require.NoError(t, serverSwizzler.MutateSnapshot(func(sn *data.Snapshot) {
+		sn.Expires = snapshotExpires
+	}))
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+	timestampExpires := snapshotExpires.Add(10 * notary.Year)
+	require.NoError(t, serverSwizzler.MutateTimestamp(func(ts *data.Timestamp) {
+		ts.Expires = timestampExpires
+	}))
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	repo.SetUpdateOptions(UpdateOptions{CheckTimestampExpiry: true})
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrSuspiciousExpiry{}, err)
+	suspicious := err.(ErrSuspiciousExpiry)
+	require.True(t, timestampExpires.Equal(suspicious.TimestampExpires))
+	require.True(t, snapshotExpires.Equal(suspicious.SnapshotExpires))
+}
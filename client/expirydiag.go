@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ExpiryDiagnosis reports whether the metadata of a role has expired according
+// to the local clock, and whether that expiry may be caused by the local clock
+// being ahead rather than by the metadata being stale
+type ExpiryDiagnosis struct {
+	Role    data.RoleName
+	Expires time.Time
+	Expired bool
+	// ExpiredBy is how long ago the role expired, or, if it has not yet
+	// expired, the negated time until it does
+	ExpiredBy time.Duration
+	// LikelyClockSkew is set for an expired role when the timestamp just
+	// fetched from the server appears expired by at least as long.  The server
+	// re-signs the timestamp before it expires, so the local clock must be
+	// ahead by at least that much, which accounts for the role's expiry.
+	LikelyClockSkew bool
+}
+
+// diagnosedMetadata holds the parts of any role's metadata which are needed
+// to diagnose its expiry and find its delegations
+type diagnosedMetadata struct {
+	Signed struct {
+		Expires     time.Time `json:"expires"`
+		Delegations struct {
+			Roles []struct {
+				Name data.RoleName `json:"name"`
+			} `json:"roles"`
+		} `json:"delegations"`
+	} `json:"signed"`
+}
+
+// DiagnoseExpiry reports, for the base roles and every delegation reachable
+// from the targets role, when its metadata expires and whether it has expired
+// according to the local clock.  Metadata is fetched from the server where
+// possible, falling back to the cache, and is not verified, so that it can be
+// diagnosed even when an update fails because it has expired.  The diagnosis
+// compares each expiry against that of the timestamp fetched from the server
+// to tell a genuine expiry from one caused by the local clock running ahead.
+// Roles with no metadata on the server or in the cache are left out.  If no
+// role has any, ErrRepositoryNotExist is returned.
+func (r *repository) DiagnoseExpiry() ([]ExpiryDiagnosis, error) {
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+
+	// the local clock is ahead by at least as long as a timestamp fresh from
+	// the server appears to have been expired
+	var minSkew time.Duration
+	if meta, remote, err := r.diagnosedMetadata(data.CanonicalTimestampRole, notary.MaxTimestampSize); err == nil && remote {
+		minSkew = now.Sub(meta.Signed.Expires)
+	}
+
+	var diagnoses []ExpiryDiagnosis
+	roles := []data.RoleName{data.CanonicalRootRole, data.CanonicalTimestampRole,
+		data.CanonicalSnapshotRole, data.CanonicalTargetsRole}
+	seen := make(map[data.RoleName]bool)
+	for len(roles) > 0 {
+		role := roles[0]
+		roles = roles[1:]
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+
+		meta, _, err := r.diagnosedMetadata(role, notary.MaxDownloadSize)
+		if err != nil {
+			continue
+		}
+		expiredBy := now.Sub(meta.Signed.Expires)
+		diagnoses = append(diagnoses, ExpiryDiagnosis{
+			Role:            role,
+			Expires:         meta.Signed.Expires,
+			Expired:         expiredBy > 0,
+			ExpiredBy:       expiredBy,
+			LikelyClockSkew: expiredBy > 0 && expiredBy <= minSkew,
+		})
+		if role == data.CanonicalTargetsRole || data.IsDelegation(role) {
+			for _, delegation := range meta.Signed.Delegations.Roles {
+				roles = append(roles, delegation.Name)
+			}
+		}
+	}
+	if len(diagnoses) == 0 {
+		return nil, ErrRepositoryNotExist{remote: r.baseURL, gun: r.gun}
+	}
+	return diagnoses, nil
+}
+
+// diagnosedMetadata fetches a role's metadata from the server, or if that fails
+// from the cache, reporting whether it came from the server
+func (r *repository) diagnosedMetadata(role data.RoleName, size int64) (*diagnosedMetadata, bool, error) {
+	meta := &diagnosedMetadata{}
+	remote, err := r.unverifiedMetadata(role, size, meta)
+	if err != nil {
+		return nil, false, err
+	}
+	return meta, remote, nil
+}
+
+// unverifiedMetadata fetches a role's metadata from the server, or if that
+// fails from the cache, and unmarshals it into meta without verifying it,
+// reporting whether it came from the server
+func (r *repository) unverifiedMetadata(role data.RoleName, size int64, meta interface{}) (bool, error) {
+	raw, err := r.getRemoteStore().GetSized(role.String(), size)
+	remote := err == nil
+	if !remote {
+		if raw, err = r.cache.GetSized(role.String(), size); err != nil {
+			return false, err
+		}
+	}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		return false, err
+	}
+	return remote, nil
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func diagnosesByRole(t *testing.T, repo *repository) map[data.RoleName]ExpiryDiagnosis {
+	diagnoses, err := repo.DiagnoseExpiry()
+	require.NoError(t, err)
+	byRole := make(map[data.RoleName]ExpiryDiagnosis)
+	for _, d := range diagnoses {
+		byRole[d.Role] = d
+	}
+	return byRole
+}
+
+// A local clock running ahead makes metadata appear expired, which is reported
+// as likely clock skew, since the timestamp just served appears expired too
+func TestDiagnoseExpiryClockSkew(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	byRole := diagnosesByRole(t, repo)
+	roles := append([]data.RoleName{}, data.BaseRoles...)
+	for _, role := range append(roles, delegationsWithNonEmptyMetadata...) {
+		require.Contains(t, byRole, role)
+		require.False(t, byRole[role].Expired, "%s should not be expired", role)
+		require.True(t, byRole[role].ExpiredBy < 0)
+	}
+
+	// a month ahead, only the timestamp has spuriously expired
+	repo.now = func() time.Time { return time.Now().AddDate(0, 1, 0) }
+	byRole = diagnosesByRole(t, repo)
+	timestamp := byRole[data.CanonicalTimestampRole]
+	require.True(t, timestamp.Expired)
+	require.True(t, timestamp.LikelyClockSkew)
+	require.True(t, timestamp.ExpiredBy > 0 && timestamp.ExpiredBy < 31*24*time.Hour)
+	require.False(t, byRole[data.CanonicalTargetsRole].Expired)
+
+	// decades ahead, everything has spuriously expired
+	repo.now = func() time.Time { return time.Now().AddDate(50, 0, 0) }
+	for role, d := range diagnosesByRole(t, repo) {
+		require.True(t, d.Expired, "%s should be expired", role)
+		require.True(t, d.LikelyClockSkew, "%s should be likely clock skew", role)
+	}
+}
+
+// Metadata which has expired while the timestamp is still valid is a genuine
+// expiry, which is not put down to clock skew
+func TestDiagnoseExpiryGenuine(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	require.NoError(t, serverSwizzler.ExpireMetadata("targets/a"))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	byRole := diagnosesByRole(t, repo)
+	expired := byRole["targets/a"]
+	require.True(t, expired.Expired)
+	require.False(t, expired.LikelyClockSkew)
+	require.True(t, expired.ExpiredBy > 365*24*time.Hour)
+	for _, role := range data.BaseRoles {
+		require.False(t, byRole[role].Expired, "%s should not be expired", role)
+	}
+
+	// a clock ahead by less than the expiry can't explain it
+	repo.now = func() time.Time { return time.Now().AddDate(0, 1, 0) }
+	byRole = diagnosesByRole(t, repo)
+	require.True(t, byRole[data.CanonicalTimestampRole].LikelyClockSkew)
+	require.False(t, byRole["targets/a"].LikelyClockSkew)
+}
+
+// With nothing on the server or in the cache, there is nothing to diagnose
+func TestDiagnoseExpiryNoMetadata(t *testing.T) {
+	ts := readOnlyServer(t, store.NewMemoryStore(nil), http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err := repo.DiagnoseExpiry()
+	require.IsType(t, ErrRepositoryNotExist{}, err)
+}
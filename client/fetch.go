@@ -0,0 +1,118 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrTargetHashMismatch is returned when the content of a target does not
+// match the hash recorded in its TUF metadata
+type ErrTargetHashMismatch struct {
+	Name      string
+	Algorithm string
+}
+
+func (err ErrTargetHashMismatch) Error() string {
+	return fmt.Sprintf("%s checksum of the content of target %s did not match", err.Algorithm, err.Name)
+}
+
+// FetchAndVerifyTarget looks up the named target, and obtains its content with
+// fetch, returning a reader which verifies the content against the length and
+// hashes of the target as it streams.  Content beyond the target's length fails
+// the read immediately, and if the content does not match its hashes, the read
+// which reaches the end of the content fails instead of returning io.EOF.  So
+// callers must read to io.EOF, and must not trust any of the content if any
+// read fails.  Closing the reader closes the fetched content.
+func (r *repository) FetchAndVerifyTarget(name string, fetch func(t *Target) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	tgt, err := r.GetTargetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	target, err := r.preferredHashes(tgt.Target)
+	if err != nil {
+		return nil, err
+	}
+	hashers := make(map[string]hash.Hash)
+	for alg := range target.Hashes {
+		switch alg {
+		case notary.SHA256:
+			hashers[alg] = sha256.New()
+		case notary.SHA512:
+			hashers[alg] = sha512.New()
+		}
+	}
+	// without a supported hash, the content could never be verified
+	if len(hashers) == 0 {
+		return nil, data.ErrMissingMeta{Role: name}
+	}
+
+	content, err := fetch(&target)
+	if err != nil {
+		return nil, err
+	}
+	return &verifyingReader{target: target, content: content, hashers: hashers}, nil
+}
+
+// verifyingReader verifies the content of a target as it is read
+type verifyingReader struct {
+	target  Target
+	content io.ReadCloser
+	hashers map[string]hash.Hash
+	read    int64
+	// err is the verification failure, which is returned by every read once
+	// it has happened
+	err error
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	// read at most one byte more than remains, so that oversized content is
+	// detected without reading all of it
+	if remaining := v.target.Length - v.read + 1; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := v.content.Read(p)
+	v.read += int64(n)
+	if v.read > v.target.Length {
+		v.err = ErrTargetLengthMismatch{Name: v.target.Name, Expected: v.target.Length, Actual: v.read}
+		return 0, v.err
+	}
+	for _, hasher := range v.hashers {
+		hasher.Write(p[:n])
+	}
+	if err == io.EOF {
+		v.err = v.verify()
+		if v.err == nil {
+			v.err = io.EOF
+		}
+		return n, v.err
+	}
+	return n, err
+}
+
+// verify checks, once the content has all been read, that it matched the
+// target's length and hashes
+func (v *verifyingReader) verify() error {
+	if v.read != v.target.Length {
+		return ErrTargetLengthMismatch{Name: v.target.Name, Expected: v.target.Length, Actual: v.read}
+	}
+	for alg, hasher := range v.hashers {
+		if subtle.ConstantTimeCompare(hasher.Sum(nil), v.target.Hashes[alg]) == 0 {
+			return ErrTargetHashMismatch{Name: v.target.Name, Algorithm: alg}
+		}
+	}
+	return nil
+}
+
+func (v *verifyingReader) Close() error {
+	return v.content.Close()
+}
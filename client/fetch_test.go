@@ -0,0 +1,104 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// closeRecorder records whether the fetched content was closed
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+// Content matching the target streams through, and reading to the end
+// succeeds, while tampered, truncated and oversized content fails the read
+func TestFetchAndVerifyTarget(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	good, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	tampered := append([]byte{}, good...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	fetchOf := func(content []byte, fetched **closeRecorder) func(*Target) (io.ReadCloser, error) {
+		return func(target *Target) (io.ReadCloser, error) {
+			require.Equal(t, "current", target.Name)
+			*fetched = &closeRecorder{Reader: bytes.NewReader(content)}
+			return *fetched, nil
+		}
+	}
+
+	var fetched *closeRecorder
+	reader, err := repo.FetchAndVerifyTarget("current", fetchOf(good, &fetched))
+	require.NoError(t, err)
+	read, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, good, read)
+	require.NoError(t, reader.Close())
+	require.True(t, fetched.closed)
+
+	reader, err = repo.FetchAndVerifyTarget("current", fetchOf(tampered, &fetched))
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	require.Error(t, err)
+	require.IsType(t, ErrTargetHashMismatch{}, err)
+	// the failure persists, rather than turning into io.EOF
+	_, err = reader.Read(make([]byte, 1))
+	require.IsType(t, ErrTargetHashMismatch{}, err)
+
+	reader, err = repo.FetchAndVerifyTarget("current", fetchOf(good[:len(good)-1], &fetched))
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	require.Error(t, err)
+	require.IsType(t, ErrTargetLengthMismatch{}, err)
+
+	reader, err = repo.FetchAndVerifyTarget("current", fetchOf(append(good, 'x'), &fetched))
+	require.NoError(t, err)
+	read, err = ioutil.ReadAll(reader)
+	require.Error(t, err)
+	require.IsType(t, ErrTargetLengthMismatch{}, err)
+	require.True(t, len(read) <= len(good))
+}
+
+// Targets which don't exist aren't fetched, and fetch failures are returned
+func TestFetchAndVerifyTargetErrors(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	_, err := repo.FetchAndVerifyTarget("nonexistent", func(*Target) (io.ReadCloser, error) {
+		require.FailNow(t, "a nonexistent target should not be fetched")
+		return nil, nil
+	})
+	require.Error(t, err)
+	require.IsType(t, ErrNoSuchTarget(""), err)
+
+	fetchErr := errors.New("unavailable")
+	_, err = repo.FetchAndVerifyTarget("current", func(*Target) (io.ReadCloser, error) {
+		return nil, fetchErr
+	})
+	require.Equal(t, fetchErr, err)
+}
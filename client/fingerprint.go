@@ -0,0 +1,76 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	store "github.com/theupdateframework/notary/storage"
+)
+
+// cacheFingerprintPrefix starts every cache fingerprint, naming its hash
+const cacheFingerprintPrefix = "sha256:"
+
+// ErrCacheFingerprintMismatch is returned when the local metadata cache does
+// not have the expected fingerprint, because it has drifted from or been
+// tampered with since the known-good state
+type ErrCacheFingerprintMismatch struct {
+	Expected string
+	Actual   string
+}
+
+func (err ErrCacheFingerprintMismatch) Error() string {
+	return fmt.Sprintf("the metadata cache has fingerprint %s, not the expected %s", err.Actual, err.Expected)
+}
+
+// CacheFingerprint returns a stable hash over the name and contents of every
+// file in the local metadata cache, without updating it, so that deployment
+// tooling can record a known-good state of the cache, such as one baked into
+// an image, and later check it with VerifyCacheFingerprint.  The fingerprint
+// is "sha256:" followed by the hex encoded hash, and is the same for the same
+// cached files regardless of the order they were written in.
+func (r *repository) CacheFingerprint() (string, error) {
+	lister, ok := r.cache.(fileLister)
+	if !ok {
+		return "", fmt.Errorf("the metadata cache does not support listing its contents")
+	}
+	names := lister.ListFiles()
+	for i, name := range names {
+		names[i] = filepath.ToSlash(name)
+	}
+	sort.Strings(names)
+
+	// each file is hashed as the lengths and bytes of its name and contents,
+	// so that no two different caches hash the same bytes
+	h := sha256.New()
+	for _, name := range names {
+		raw, err := r.cache.GetSized(name, store.NoSizeLimit)
+		if err != nil {
+			return "", err
+		}
+		for _, field := range [][]byte{[]byte(name), raw} {
+			if err := binary.Write(h, binary.BigEndian, uint64(len(field))); err != nil {
+				return "", err
+			}
+			h.Write(field)
+		}
+	}
+	return cacheFingerprintPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyCacheFingerprint returns ErrCacheFingerprintMismatch unless the local
+// metadata cache has exactly the expected fingerprint, as returned by
+// CacheFingerprint
+func (r *repository) VerifyCacheFingerprint(expected string) error {
+	actual, err := r.CacheFingerprint()
+	if err != nil {
+		return err
+	}
+	if actual != expected {
+		return ErrCacheFingerprintMismatch{Expected: expected, Actual: actual}
+	}
+	return nil
+}
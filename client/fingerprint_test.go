@@ -0,0 +1,64 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// The fingerprint of the cache is stable across reads, verifies, and changes
+// when an update or tampering changes the cache
+func TestCacheFingerprint(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+
+	fingerprint, err := reader.CacheFingerprint()
+	require.NoError(t, err)
+	require.Regexp(t, "^sha256:[0-9a-f]{64}$", fingerprint)
+
+	// reading from the cache changes nothing
+	_, err = reader.ListTargets()
+	require.NoError(t, err)
+	again, err := reader.CacheFingerprint()
+	require.NoError(t, err)
+	require.Equal(t, fingerprint, again)
+	require.NoError(t, reader.VerifyCacheFingerprint(fingerprint))
+
+	// nor does the order in which the cache was written
+	copied, copiedDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(copiedDir)
+	for _, role := range []data.RoleName{data.CanonicalTimestampRole, data.CanonicalTargetsRole,
+		data.CanonicalSnapshotRole, data.CanonicalRootRole} {
+		raw, err := reader.cache.GetSized(role.String(), -1)
+		require.NoError(t, err)
+		require.NoError(t, copied.cache.Set(role.String(), raw))
+	}
+	require.NoError(t, copied.VerifyCacheFingerprint(fingerprint))
+
+	// an update which fetches new metadata changes the fingerprint
+	addTarget(t, repo, "app", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	_, err = reader.ListTargets()
+	require.NoError(t, err)
+	err = reader.VerifyCacheFingerprint(fingerprint)
+	require.IsType(t, ErrCacheFingerprintMismatch{}, err)
+	updated := err.(ErrCacheFingerprintMismatch).Actual
+
+	// as does tampering with or adding to the cache
+	require.NoError(t, reader.cache.Set("targets/extra", []byte("{}")))
+	tampered, err := reader.CacheFingerprint()
+	require.NoError(t, err)
+	require.NotEqual(t, updated, tampered)
+	require.NoError(t, reader.cache.Remove("targets/extra"))
+	require.NoError(t, reader.VerifyCacheFingerprint(updated))
+}
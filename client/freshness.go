@@ -0,0 +1,89 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrStaleMetadata is returned by an update when a timestamp or snapshot is
+// still valid according to its expiry, but is older than the freshness window
+// configured for that role
+type ErrStaleMetadata struct {
+	Role data.RoleName
+	Age  time.Duration
+}
+
+func (err ErrStaleMetadata) Error() string {
+	return fmt.Sprintf("%s metadata is %s old, which is older than its allowed freshness window", err.Role, err.Age)
+}
+
+// TUF metadata does not record when it was signed, so the time at which it was
+// signed is derived from its expiry and the expiry notary uses when signing
+// that role.  This is only an estimate: metadata signed with a longer expiry
+// than notary's, such as by a server configured otherwise or by a third party,
+// appears younger than it is, and metadata signed with a shorter one, older.
+var signingExpiry = map[data.RoleName]time.Duration{
+	data.CanonicalTimestampRole: notary.NotaryTimestampExpiry,
+	data.CanonicalSnapshotRole:  notary.NotarySnapshotExpiry,
+}
+
+// SetFreshnessWindow rejects, during any subsequent update, timestamp or snapshot
+// metadata that was signed more than maxAge ago, even if it has not yet expired.
+// A maxAge of 0 removes the window for that role.  Only the timestamp and
+// snapshot roles support freshness windows.
+//
+// As the metadata does not record when it was signed, its age is inferred from
+// its expiry, assuming it was signed with the expiry notary gives that role:
+// 14 days for the timestamp and 3 years for the snapshot.  A server which signs
+// with a longer expiry can therefore serve metadata older than the window
+// without it being rejected, so the window is only a guarantee for servers
+// which use notary's default expiries.
+func (r *repository) SetFreshnessWindow(role string, maxAge time.Duration) error {
+	roleName := data.RoleName(role)
+	if _, ok := signingExpiry[roleName]; !ok {
+		return data.ErrInvalidRole{Role: roleName, Reason: "freshness windows are only supported for timestamp and snapshot"}
+	}
+	if maxAge < 0 {
+		return fmt.Errorf("freshness window for %s must not be negative", role)
+	}
+	if maxAge == 0 {
+		delete(r.updateOptions.FreshnessWindows, roleName)
+		return nil
+	}
+	if r.updateOptions.FreshnessWindows == nil {
+		r.updateOptions.FreshnessWindows = make(map[data.RoleName]time.Duration)
+	}
+	r.updateOptions.FreshnessWindows[roleName] = maxAge
+	return nil
+}
+
+// checkFreshness ensures that the timestamp and snapshot of an updated repo
+// were signed within their configured freshness windows
+func checkFreshness(repo *tuf.Repo, windows map[data.RoleName]time.Duration) error {
+	for role, maxAge := range windows {
+		var expires time.Time
+		switch role {
+		case data.CanonicalTimestampRole:
+			if repo.Timestamp == nil {
+				continue
+			}
+			expires = repo.Timestamp.Signed.Expires
+		case data.CanonicalSnapshotRole:
+			if repo.Snapshot == nil {
+				continue
+			}
+			expires = repo.Snapshot.Signed.Expires
+		default:
+			continue
+		}
+		signedAt := expires.Add(-signingExpiry[role])
+		if age := time.Since(signedAt); age > maxAge {
+			return ErrStaleMetadata{Role: role, Age: age}
+		}
+	}
+	return nil
+}
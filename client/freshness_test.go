@@ -0,0 +1,131 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A timestamp that has not expired, but was signed longer ago than the
+// freshness window allows, fails the update
+func TestFreshnessWindowRejectsOldTimestamp(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	// the timestamp was signed two hours ago
+	signedAt := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, serverSwizzler.MutateTimestamp(func(ts *data.Timestamp) {
+		ts.Expires = signedAt.Add(notary.NotaryTimestampExpiry)
+	}))
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	// without a freshness window the timestamp is fine
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), time.Hour))
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrStaleMetadata{}, err)
+	stale := err.(ErrStaleMetadata)
+	require.Equal(t, data.CanonicalTimestampRole, stale.Role)
+	require.True(t, stale.Age > 2*time.Hour-time.Minute && stale.Age < 2*time.Hour+time.Minute)
+
+	// a wider window accepts it again
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), 3*time.Hour))
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	// and a window of 0 removes the restriction
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), time.Hour))
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), 0))
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// Freshness windows are enforced independently for the snapshot
+func TestFreshnessWindowRejectsOldSnapshot(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	require.NoError(t, serverSwizzler.MutateSnapshot(func(sn *data.Snapshot) {
+		sn.Expires = time.Now().Add(-48 * time.Hour).Add(notary.NotarySnapshotExpiry)
+	}))
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	// the timestamp was just signed, so only the snapshot is stale
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), time.Hour))
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalSnapshotRole.String(), 24*time.Hour))
+	_, err := repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrStaleMetadata{}, err)
+	require.Equal(t, data.CanonicalSnapshotRole, err.(ErrStaleMetadata).Role)
+
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalSnapshotRole.String(), 72*time.Hour))
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// The signing time is inferred from notary's default expiry, so metadata signed
+// with a longer expiry appears younger than it is, and with a shorter one, older
+func TestFreshnessWindowAssumesDefaultExpiry(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		expiry time.Duration
+		stale  bool
+	}{
+		// signed two hours ago with a day more than the default expiry, so it
+		// appears to have been signed in the future and passes the window
+		{name: "longer expiry", expiry: notary.NotaryTimestampExpiry + 24*time.Hour},
+		// signed just now, but with a day less than the default expiry, so it
+		// appears to be a day old
+		{name: "shorter expiry", expiry: notary.NotaryTimestampExpiry - 24*time.Hour, stale: true},
+	} {
+		_, serverSwizzler := newServerSwizzler(t)
+		signedAt := time.Now()
+		if !tc.stale {
+			signedAt = signedAt.Add(-2 * time.Hour)
+		}
+		require.NoError(t, serverSwizzler.MutateTimestamp(func(ts *data.Timestamp) {
+			ts.Expires = signedAt.Add(tc.expiry)
+		}))
+		ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+		defer ts.Close()
+
+		repo, baseDir := newBlankRepo(t, ts.URL)
+		defer os.RemoveAll(baseDir)
+		require.NoError(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), time.Hour))
+		_, err := repo.ListTargets()
+		if !tc.stale {
+			require.NoError(t, err, tc.name)
+			continue
+		}
+		require.IsType(t, ErrStaleMetadata{}, err, tc.name)
+		age := err.(ErrStaleMetadata).Age
+		require.True(t, age > 24*time.Hour-time.Minute && age < 24*time.Hour+time.Minute, tc.name)
+	}
+}
+
+// Only timestamp and snapshot freshness windows can be configured
+func TestSetFreshnessWindowInvalid(t *testing.T) {
+	repo, baseDir := newBlankRepo(t, "https://localhost")
+	defer os.RemoveAll(baseDir)
+
+	for _, role := range []data.RoleName{data.CanonicalRootRole, data.CanonicalTargetsRole, "targets/a"} {
+		err := repo.SetFreshnessWindow(role.String(), time.Hour)
+		require.Error(t, err)
+		require.IsType(t, data.ErrInvalidRole{}, err)
+	}
+	require.Error(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), -time.Hour))
+	require.Empty(t, repo.updateOptions.FreshnessWindows)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// ErrTreeVerification is returned by VerifyFullTree when the metadata of a
+// role in the delegation tree fails verification
+type ErrTreeVerification struct {
+	Role data.RoleName
+	// Delegator is the role which delegates to Role, and so whose keys and
+	// threshold Role was verified against
+	Delegator data.RoleName
+	Err       error
+}
+
+func (err ErrTreeVerification) Error() string {
+	if err.Delegator == "" {
+		return fmt.Sprintf("verification of %s failed: %s", err.Role, err.Err)
+	}
+	return fmt.Sprintf("verification of %s, as delegated by %s, failed: %s", err.Role, err.Delegator, err.Err)
+}
+
+// VerifyFullTree updates the repository, and then verifies the metadata of the
+// targets role and of every delegation reachable from it, in the same pre-order
+// as an update walks the tree.  Each role's metadata must match its checksum in
+// the snapshot, be signed by a threshold of the keys its delegator assigned it,
+// and not be expired.  An update skips delegations which fail verification, so
+// their targets silently can't be found, whereas VerifyFullTree returns
+// ErrTreeVerification for the first one.  Roles which the snapshot does not
+// list, because they were never published, are skipped.
+func (r *repository) VerifyFullTree() error {
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	if r.tufRepo.Snapshot == nil {
+		return ErrRepositoryNotExist{remote: r.baseURL, gun: r.gun}
+	}
+	targetsRole, err := r.tufRepo.GetBaseRole(data.CanonicalTargetsRole)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		role      data.DelegationRole
+		delegator data.RoleName
+	}
+	toVerify := []pending{{role: data.DelegationRole{BaseRole: targetsRole, Paths: []string{""}}}}
+	for len(toVerify) > 0 {
+		next := toVerify[0]
+		toVerify = toVerify[1:]
+
+		children, err := r.verifyTreeRole(next.role)
+		if err != nil {
+			return ErrTreeVerification{Role: next.role.Name, Delegator: next.delegator, Err: err}
+		}
+		delegated := make([]pending, 0, len(children))
+		for _, child := range children {
+			delegated = append(delegated, pending{role: child, delegator: next.role.Name})
+		}
+		toVerify = append(delegated, toVerify...)
+	}
+	return nil
+}
+
+// verifyTreeRole verifies the metadata of a single role of the delegation tree,
+// and returns the roles it delegates to.  The metadata is taken from the cache
+// if it matches the snapshot, and is otherwise downloaded.
+func (r *repository) verifyTreeRole(role data.DelegationRole) ([]data.DelegationRole, error) {
+	meta, ok := r.tufRepo.Snapshot.Signed.Meta[role.Name.String()]
+	if !ok {
+		return nil, nil
+	}
+	raw, err := r.cache.GetSized(role.Name.String(), meta.Length)
+	if err != nil || data.CheckHashes(raw, role.Name.String(), meta.Hashes) != nil {
+		if raw, err = r.getRemoteStore().GetSized(role.Name.String(), meta.Length); err != nil {
+			return nil, err
+		}
+		if err := data.CheckHashes(raw, role.Name.String(), meta.Hashes); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, err
+	}
+	if err := signed.VerifySignatures(s, role.BaseRole); err != nil {
+		return nil, err
+	}
+	targets, err := data.TargetsFromSigned(s, role.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := signed.VerifyExpiry(&targets.Signed.SignedCommon, role.Name); err != nil {
+		return nil, err
+	}
+	return targets.GetValidDelegations(role), nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// A tree whose every delegation is validly signed verifies
+func TestVerifyFullTreeValid(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	require.NoError(t, repo.VerifyFullTree())
+
+	// a corrupted cache is not trusted, but is replaced by the valid metadata
+	// on the server
+	require.NoError(t, repo.cache.Set("targets/a/b", []byte("{}")))
+	require.NoError(t, repo.VerifyFullTree())
+}
+
+// A deep delegation with invalid signatures is skipped by updates, but fails
+// full tree verification, which names it and its delegator
+func TestVerifyFullTreeTamperedDelegation(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	require.NoError(t, serverSwizzler.InvalidateMetadataSignatures("targets/a/b"))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	err = repo.VerifyFullTree()
+	require.Error(t, err)
+	require.IsType(t, ErrTreeVerification{}, err)
+	verifyErr := err.(ErrTreeVerification)
+	require.Equal(t, data.RoleName("targets/a/b"), verifyErr.Role)
+	require.Equal(t, data.RoleName("targets/a"), verifyErr.Delegator)
+	require.IsType(t, signed.ErrRoleThreshold{}, verifyErr.Err)
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrPreferredHashMissing is returned when a target's metadata carries none of
+// the hash algorithms in the hash preference, so its content can't be verified
+type ErrPreferredHashMissing struct {
+	Name       string
+	Preference []string
+}
+
+func (err ErrPreferredHashMissing) Error() string {
+	return fmt.Sprintf("target %s has none of the preferred hashes: %s", err.Name, strings.Join(err.Preference, ", "))
+}
+
+// SetHashPreference restricts the verification of target content, as done by
+// VerifyAllTargets, VerifyDirectory and FetchAndVerifyTarget, to the first of
+// the given hash algorithms that each target's metadata carries, so listing
+// notary.SHA512 before notary.SHA256 prefers the stronger hash.  A target which
+// carries none of them fails verification, so a preference of only
+// notary.SHA512 requires it.  An empty preference, the default, verifies every
+// supported hash that the target carries.
+func (r *repository) SetHashPreference(algorithms []string) error {
+	for _, alg := range algorithms {
+		if alg != notary.SHA256 && alg != notary.SHA512 {
+			return fmt.Errorf("unsupported hash algorithm: %s", alg)
+		}
+	}
+	r.hashPreference = append([]string(nil), algorithms...)
+	return nil
+}
+
+// preferredHashes returns the target with only the hash that the hash
+// preference selects, or the target unchanged if there is no preference
+func (r *repository) preferredHashes(target Target) (Target, error) {
+	if len(r.hashPreference) == 0 {
+		return target, nil
+	}
+	for _, alg := range r.hashPreference {
+		if checksum, ok := target.Hashes[alg]; ok {
+			target.Hashes = data.Hashes{alg: checksum}
+			return target, nil
+		}
+	}
+	return target, ErrPreferredHashMissing{Name: target.Name, Preference: r.hashPreference}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Targets with one or both hashes verify according to the hash preference
+func TestHashPreference(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	content, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	sum256 := sha256.Sum256(content)
+	sum512 := sha512.Sum512(content)
+	length := int64(len(content))
+	// "wrong256" has a correct sha512, but not a correct sha256
+	for _, target := range []*Target{
+		{Name: "both", Length: length, Hashes: data.Hashes{notary.SHA256: sum256[:], notary.SHA512: sum512[:]}},
+		{Name: "sha256only", Length: length, Hashes: data.Hashes{notary.SHA256: sum256[:]}},
+		{Name: "wrong256", Length: length, Hashes: data.Hashes{notary.SHA256: make([]byte, sha256.Size), notary.SHA512: sum512[:]}},
+	} {
+		require.NoError(t, repo.AddTarget(target))
+	}
+	require.NoError(t, repo.Publish())
+
+	verify := func() map[string]error {
+		results, err := repo.VerifyAllTargets(func(name string) (io.Reader, error) {
+			return bytes.NewReader(content), nil
+		})
+		require.NoError(t, err)
+		errs := make(map[string]error)
+		for _, result := range results {
+			errs[result.Name] = result.Err
+		}
+		return errs
+	}
+
+	// by default every hash is verified
+	errs := verify()
+	require.NoError(t, errs["both"])
+	require.NoError(t, errs["sha256only"])
+	require.IsType(t, data.ErrMismatchedChecksum{}, errs["wrong256"])
+
+	// preferring sha512 verifies only it where it is present
+	require.NoError(t, repo.SetHashPreference([]string{notary.SHA512, notary.SHA256}))
+	errs = verify()
+	require.NoError(t, errs["both"])
+	require.NoError(t, errs["sha256only"])
+	require.NoError(t, errs["wrong256"])
+
+	// requiring sha512 fails the target without it
+	require.NoError(t, repo.SetHashPreference([]string{notary.SHA512}))
+	errs = verify()
+	require.NoError(t, errs["both"])
+	require.IsType(t, ErrPreferredHashMissing{}, errs["sha256only"])
+	_, err = repo.FetchAndVerifyTarget("sha256only", func(*Target) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	})
+	require.IsType(t, ErrPreferredHashMissing{}, err)
+
+	// preferring sha256 verifies only it
+	require.NoError(t, repo.SetHashPreference([]string{notary.SHA256}))
+	errs = verify()
+	require.NoError(t, errs["sha256only"])
+	require.IsType(t, data.ErrMismatchedChecksum{}, errs["wrong256"])
+
+	require.Error(t, repo.SetHashPreference([]string{"md5"}))
+}
@@ -17,13 +17,7 @@ import (
 
 // Use this to initialize remote HTTPStores from the config settings
 func getRemoteStore(baseURL string, gun data.GUN, rt http.RoundTripper) (store.RemoteStore, error) {
-	s, err := store.NewHTTPStore(
-		baseURL+"/v2/"+gun.String()+"/_trust/tuf/",
-		"",
-		"json",
-		"key",
-		rt,
-	)
+	s, err := store.NewNotaryServerStore(baseURL, gun, rt)
 	if err != nil {
 		return store.OfflineStore{}, err
 	}
@@ -88,7 +82,13 @@ func changeTargetsDelegation(repo *tuf.Repo, c changelist.Change) error {
 		if err != nil {
 			return err
 		}
-		return repo.UpdateDelegationPaths(c.Scope(), td.AddPaths, []string{}, false)
+		if err := repo.UpdateDelegationPaths(c.Scope(), td.AddPaths, []string{}, false); err != nil {
+			return err
+		}
+		if len(td.AddExcludePaths) == 0 {
+			return nil
+		}
+		return repo.UpdateDelegationExcludePaths(c.Scope(), td.AddExcludePaths, nil)
 	case changelist.ActionUpdate:
 		td := changelist.TUFDelegation{}
 		err := json.Unmarshal(c.Content(), &td)
@@ -123,7 +123,19 @@ func changeTargetsDelegation(repo *tuf.Repo, c changelist.Change) error {
 		if err != nil {
 			return err
 		}
-		return repo.UpdateDelegationPaths(c.Scope(), td.AddPaths, td.RemovePaths, td.ClearAllPaths)
+		// updating an existing role only changes its threshold if one is explicitly provided
+		if td.NewThreshold > 0 {
+			if err := repo.SetDelegationThreshold(c.Scope(), td.NewThreshold); err != nil {
+				return err
+			}
+		}
+		if err := repo.UpdateDelegationPaths(c.Scope(), td.AddPaths, td.RemovePaths, td.ClearAllPaths); err != nil {
+			return err
+		}
+		if len(td.AddExcludePaths) == 0 && len(td.RemoveExcludePaths) == 0 {
+			return nil
+		}
+		return repo.UpdateDelegationExcludePaths(c.Scope(), td.AddExcludePaths, td.RemoveExcludePaths)
 	case changelist.ActionDelete:
 		return repo.DeleteDelegation(c.Scope())
 	default:
@@ -256,6 +268,10 @@ func serializeCanonicalRole(tufRepo *tuf.Repo, role data.RoleName, extraSigningK
 		s, err = tufRepo.SignRoot(data.DefaultExpires(role), extraSigningKeys)
 	case role == data.CanonicalSnapshotRole:
 		s, err = tufRepo.SignSnapshot(data.DefaultExpires(role))
+	case role == data.CanonicalTimestampRole:
+		s, err = tufRepo.SignTimestamp(data.DefaultExpires(role))
+	case tufRepo.Targets[role] != nil && len(tufRepo.Targets[role].Signed.Targets) >= largeTargetsThreshold:
+		return largeTargetsJSON(tufRepo, role)
 	case tufRepo.Targets[role] != nil:
 		s, err = tufRepo.SignTargets(
 			role, data.DefaultExpires(data.CanonicalTargetsRole))
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrUnverifiableHistory is returned by VerifyAtVersion when archived metadata
+// can't form a verified chain to the requested snapshot version
+type ErrUnverifiableHistory struct {
+	SnapshotVersion int
+	Reason          string
+}
+
+func (err ErrUnverifiableHistory) Error() string {
+	return fmt.Sprintf("unable to verify the repository as of snapshot version %d: %s", err.SnapshotVersion, err.Reason)
+}
+
+// VerifyAtVersion reconstructs the state of the repository as of the given
+// snapshot version from archived consistent metadata, and verifies it, so that
+// past trust decisions can be reproduced.  The source must hold the snapshot as
+// "<version>.snapshot", every root version up to the one which the snapshot
+// references as "<version>.root", and the targets and delegations metadata
+// under their checksummed names, as the notary server serves them.
+//
+// The root chain is verified from the first version, which must satisfy the
+// trust pinning of the repository, with every root verified by its
+// predecessor as in a key rotation.  The snapshot and every targets role
+// reachable from it must then verify against that chain.  Since the metadata
+// is historical, its expiry is not checked.  Nothing is written to the cache.
+func (r *repository) VerifyAtVersion(snapshotVersion int, source store.MetadataStore) (*tuf.Repo, error) {
+	rawSnapshot, err := source.GetSized(fmt.Sprintf("%d.%s", snapshotVersion, data.CanonicalSnapshotRole), store.NoSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	// the snapshot isn't verified yet, and is only read to find which root it
+	// was published under
+	s := &data.Signed{}
+	if err := json.Unmarshal(rawSnapshot, s); err != nil {
+		return nil, err
+	}
+	unverified, err := data.SnapshotFromSigned(s)
+	if err != nil {
+		return nil, err
+	}
+	rootMeta := unverified.Signed.Meta[data.CanonicalRootRole.String()]
+
+	builder := tuf.NewRepoBuilder(r.gun, r.cryptoService, r.trustPinning)
+	for version := 1; ; version++ {
+		raw, err := source.GetSized(fmt.Sprintf("%d.%s", version, data.CanonicalRootRole), store.NoSizeLimit)
+		if err != nil {
+			return nil, err
+		}
+		if err := builder.LoadRootForUpdate(raw, version, false); err != nil {
+			return nil, err
+		}
+		if loaded := builder.GetLoadedVersion(data.CanonicalRootRole); loaded != version {
+			return nil, ErrUnverifiableHistory{
+				SnapshotVersion: snapshotVersion,
+				Reason:          fmt.Sprintf("archived root version %d is actually version %d", version, loaded),
+			}
+		}
+		if data.CheckHashes(raw, data.CanonicalRootRole.String(), rootMeta.Hashes) == nil {
+			break
+		}
+	}
+
+	if err := builder.Load(data.CanonicalSnapshotRole, rawSnapshot, snapshotVersion, true); err != nil {
+		return nil, err
+	}
+	if loaded := builder.GetLoadedVersion(data.CanonicalSnapshotRole); loaded != snapshotVersion {
+		return nil, ErrUnverifiableHistory{
+			SnapshotVersion: snapshotVersion,
+			Reason:          fmt.Sprintf("archived snapshot is actually version %d", loaded),
+		}
+	}
+
+	toLoad := []data.DelegationRole{{
+		BaseRole: data.BaseRole{Name: data.CanonicalTargetsRole},
+		Paths:    []string{""},
+	}}
+	for len(toLoad) > 0 {
+		role := toLoad[0]
+		toLoad = toLoad[1:]
+
+		consistentInfo := builder.GetConsistentInfo(role.Name)
+		if !consistentInfo.ChecksumKnown() {
+			continue
+		}
+		raw, err := source.GetSized(consistentInfo.ConsistentName(), consistentInfo.Length())
+		if err != nil {
+			return nil, err
+		}
+		if err := builder.Load(role.Name, raw, 0, true); err != nil {
+			return nil, err
+		}
+		// it unmarshals, since the builder has loaded it
+		tgs := &data.SignedTargets{}
+		json.Unmarshal(raw, tgs)
+		toLoad = append(tgs.GetValidDelegations(role), toLoad...)
+	}
+
+	repo, _, err := builder.Finish()
+	return repo, err
+}
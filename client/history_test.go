@@ -0,0 +1,116 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// archiveMetadata copies the current root, snapshot and targets metadata from
+// the server into the archive, which also stores them under their versioned and
+// checksummed names
+func archiveMetadata(t *testing.T, repo *repository, archive store.MetadataStore) {
+	for _, role := range data.BaseRoles {
+		if role == data.CanonicalTimestampRole {
+			continue
+		}
+		raw, err := repo.getRemoteStore().GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		require.NoError(t, archive.Set(role.String(), raw))
+	}
+}
+
+// An older snapshot version can be verified from the archive, across a root
+// key rotation, and reflects the targets as of that version
+func TestVerifyAtVersion(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	archive := store.NewMemoryStore(nil)
+
+	addTarget(t, repo, "first", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	archiveMetadata(t, repo, archive)
+	first := repo.tufRepo.Snapshot.Signed.Version
+
+	addTarget(t, repo, "second", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	archiveMetadata(t, repo, archive)
+
+	require.NoError(t, repo.RotateKey(data.CanonicalRootRole, false, nil))
+	require.NoError(t, repo.updateTUF(false))
+	archiveMetadata(t, repo, archive)
+	latest := repo.tufRepo.Snapshot.Signed.Version
+	require.Equal(t, 2, repo.tufRepo.Root.Signed.Version)
+
+	older, err := repo.VerifyAtVersion(first, archive)
+	require.NoError(t, err)
+	require.Equal(t, first, older.Snapshot.Signed.Version)
+	require.Equal(t, 1, older.Root.Signed.Version)
+	require.Contains(t, older.Targets[data.CanonicalTargetsRole].Signed.Targets, "first")
+	require.NotContains(t, older.Targets[data.CanonicalTargetsRole].Signed.Targets, "second")
+
+	// the latest snapshot was published under the rotated root, which is
+	// verified by the first
+	newer, err := repo.VerifyAtVersion(latest, archive)
+	require.NoError(t, err)
+	require.Equal(t, 2, newer.Root.Signed.Version)
+	require.Contains(t, newer.Targets[data.CanonicalTargetsRole].Signed.Targets, "second")
+
+	// versions which were never archived can't be verified
+	_, err = repo.VerifyAtVersion(latest+1, archive)
+	require.Error(t, err)
+	require.IsType(t, store.ErrMetaNotFound{}, err)
+}
+
+// The archived history must verify fully: a snapshot not signed by the keys of
+// its root, or a root chain with a version missing, is rejected
+func TestVerifyAtVersionRejectsBrokenHistory(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	require.NoError(t, repo.RotateKey(data.CanonicalRootRole, false, nil))
+	require.NoError(t, repo.updateTUF(false))
+	latest := repo.tufRepo.Snapshot.Signed.Version
+
+	// the first root is missing, so the chain can't be verified
+	remote := repo.getRemoteStore()
+	incomplete := store.NewMemoryStore(nil)
+	for _, name := range []string{"2.root", "snapshot", "targets"} {
+		raw, err := remote.GetSized(name, store.NoSizeLimit)
+		require.NoError(t, err)
+		require.NoError(t, incomplete.Set(name, raw))
+	}
+	_, err := repo.VerifyAtVersion(latest, incomplete)
+	require.Error(t, err)
+	require.IsType(t, store.ErrMetaNotFound{}, err)
+
+	// a snapshot whose signature has been tampered with
+	tampered := store.NewMemoryStore(nil)
+	for _, name := range []string{"1.root", "2.root", "targets"} {
+		raw, err := remote.GetSized(name, store.NoSizeLimit)
+		require.NoError(t, err)
+		require.NoError(t, tampered.Set(name, raw))
+	}
+	raw, err := remote.GetSized(data.CanonicalSnapshotRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+	s.Signatures[0].Signature[0] ^= 0xff
+	raw, err = json.Marshal(s)
+	require.NoError(t, err)
+	require.NoError(t, tampered.Set(data.CanonicalSnapshotRole.String(), raw))
+	_, err = repo.VerifyAtVersion(latest, tampered)
+	require.Error(t, err)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// postRecorder proxies requests to a server, recording the idempotency key
+// and metadata files of every POST, and failing POSTs while fail is set
+type postRecorder struct {
+	mu    sync.Mutex
+	fail  bool
+	keys  []string
+	files []map[string][]byte
+}
+
+func (p *postRecorder) serve(t *testing.T, target string) *httptest.Server {
+	targetURL, err := url.Parse(target)
+	require.NoError(t, err)
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			require.NoError(t, err)
+			files := make(map[string][]byte)
+			reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+			for part, err := reader.NextPart(); err == nil; part, err = reader.NextPart() {
+				files[part.FileName()], err = ioutil.ReadAll(part)
+				require.NoError(t, err)
+			}
+			p.mu.Lock()
+			p.keys = append(p.keys, r.Header.Get(notary.IdempotencyKeyHeader))
+			p.files = append(p.files, files)
+			fail := p.fail
+			p.mu.Unlock()
+			if fail {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+}
+
+func (p *postRecorder) setFail(fail bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fail = fail
+}
+
+// A publish with an idempotency key sends it in the header, and a publish
+// without one sends no header
+func TestPublishWithIdempotencyKeySendsHeader(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	recorder := &postRecorder{}
+	proxy := recorder.serve(t, ts.URL)
+	defer proxy.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", proxy.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	addTarget(t, repo, "first", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.PublishWithIdempotencyKey("publish-1"))
+	addTarget(t, repo, "second", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	require.Equal(t, []string{"publish-1", ""}, recorder.keys)
+}
+
+// Retrying a failed publish with the same idempotency key sends the same key
+// and the same signed metadata, and publishes only once
+func TestPublishWithIdempotencyKeyRetryReusesKey(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	recorder := &postRecorder{}
+	flaky := recorder.serve(t, ts.URL)
+	defer flaky.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", flaky.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	recorder.setFail(true)
+	require.Error(t, repo.PublishWithIdempotencyKey("publish-1"))
+	require.Len(t, getChanges(t, repo), 1)
+
+	recorder.setFail(false)
+	require.NoError(t, repo.PublishWithIdempotencyKey("publish-1"))
+	require.Equal(t, []string{"publish-1", "publish-1"}, recorder.keys)
+	require.Len(t, recorder.files, 2)
+	require.NotEmpty(t, recorder.files[0])
+	require.Equal(t, recorder.files[0], recorder.files[1])
+	require.Len(t, getChanges(t, repo), 0)
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "current", targets[0].Name)
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/theupdateframework/notary"
+)
+
+// ErrInvalidImageDigest is returned when a digest is not an OCI digest of the
+// form "sha256:" followed by 64 lowercase hex characters
+type ErrInvalidImageDigest struct {
+	Digest string
+}
+
+func (err ErrInvalidImageDigest) Error() string {
+	return fmt.Sprintf("invalid image digest %q: expected sha256:<64 hex characters>", err.Digest)
+}
+
+// ErrImageDigestMismatch is returned when the signed SHA256 of an image tag is
+// not the digest it was expected to be, or the tag has no signed SHA256
+type ErrImageDigestMismatch struct {
+	Tag    string
+	Digest string
+	Signed string
+}
+
+func (err ErrImageDigestMismatch) Error() string {
+	if err.Signed == "" {
+		return fmt.Sprintf("tag %s has no signed sha256 digest to compare with %s", err.Tag, err.Digest)
+	}
+	return fmt.Sprintf("tag %s is signed with digest %s, not %s", err.Tag, err.Signed, err.Digest)
+}
+
+// VerifyImageDigest updates the repository, and checks that the target named
+// for an image tag, as found by GetTargetByName, is signed with the SHA256 hash
+// of the OCI digest, such as the digest of an image manifest pulled from a
+// registry.  This is how Docker Content Trust signs tags, with the target's
+// hash being that of the manifest.  Only sha256 digests are supported.
+func (r *repository) VerifyImageDigest(tag string, digest string) error {
+	expected, err := parseImageDigest(digest)
+	if err != nil {
+		return err
+	}
+	target, err := r.GetTargetByName(tag)
+	if err != nil {
+		return err
+	}
+	signed, ok := target.Hashes[notary.SHA256]
+	if !ok {
+		return ErrImageDigestMismatch{Tag: tag, Digest: digest}
+	}
+	if !bytes.Equal(signed, expected) {
+		return ErrImageDigestMismatch{Tag: tag, Digest: digest, Signed: notary.SHA256 + ":" + hex.EncodeToString(signed)}
+	}
+	return nil
+}
+
+// parseImageDigest returns the hash of a "sha256:<hex>" OCI digest
+func parseImageDigest(digest string) ([]byte, error) {
+	hexHash := strings.TrimPrefix(digest, notary.SHA256+":")
+	if hexHash == digest || len(hexHash) != notary.SHA256HexSize || strings.ToLower(hexHash) != hexHash {
+		return nil, ErrInvalidImageDigest{Digest: digest}
+	}
+	hash, err := hex.DecodeString(hexHash)
+	if err != nil {
+		return nil, ErrInvalidImageDigest{Digest: digest}
+	}
+	return hash, nil
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A tag verifies against the digest of the content it was signed for, and
+// fails against any other digest
+func TestVerifyImageDigest(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	content, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	require.NoError(t, repo.VerifyImageDigest("latest", digest))
+
+	other := sha256.Sum256([]byte("another manifest"))
+	otherDigest := "sha256:" + hex.EncodeToString(other[:])
+	err = repo.VerifyImageDigest("latest", otherDigest)
+	require.Error(t, err)
+	require.IsType(t, ErrImageDigestMismatch{}, err)
+	require.Equal(t, ErrImageDigestMismatch{Tag: "latest", Digest: otherDigest, Signed: digest}, err)
+
+	// a tag which is not signed at all can't be verified
+	require.Error(t, repo.VerifyImageDigest("missing", digest))
+}
+
+// Digests which are not sha256 OCI digests are rejected before any lookup
+func TestVerifyImageDigestInvalidDigest(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	hexHash := strings.Repeat("ab", 32)
+	for _, digest := range []string{
+		hexHash,
+		"sha512:" + hexHash,
+		"sha256:" + hexHash[2:],
+		"sha256:" + strings.ToUpper(hexHash),
+		"sha256:" + strings.Repeat("zz", 32),
+	} {
+		err := repo.VerifyImageDigest("latest", digest)
+		require.Error(t, err)
+		require.IsType(t, ErrInvalidImageDigest{}, err, digest)
+	}
+}
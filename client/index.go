@@ -0,0 +1,68 @@
+package client
+
+import (
+	"encoding/json"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// TargetIndexType is the "_type" of a target index
+const TargetIndexType = "TargetIndex"
+
+// TargetIndex is the signed portion of the document produced by
+// SignedTargetIndex, which summarizes every target of a repository
+type TargetIndex struct {
+	Type string   `json:"_type"`
+	GUN  data.GUN `json:"gun"`
+	// SnapshotVersion is the version of the snapshot which the index was
+	// produced from
+	SnapshotVersion int                         `json:"snapshot_version"`
+	Targets         map[string]TargetIndexEntry `json:"targets"`
+}
+
+// TargetIndexEntry describes a single target in a target index
+type TargetIndexEntry struct {
+	Length int64         `json:"length"`
+	Hashes data.Hashes   `json:"hashes"`
+	Role   data.RoleName `json:"role"`
+}
+
+// SignedTargetIndex updates the repository, and then produces a single
+// document listing the length, hashes and role of every target that
+// ListTargets returns, signed with the keys of the targets role which the
+// local crypto service holds.  The document has the same form as TUF metadata:
+// its signed portion is a canonically encoded TargetIndex, and its signatures
+// verify against the targets role of the repository.  It is produced locally,
+// and is never published.
+func (r *repository) SignedTargetIndex() ([]byte, error) {
+	targets, err := r.ListTargets()
+	if err != nil {
+		return nil, err
+	}
+	targetsRole, err := r.tufRepo.GetBaseRole(data.CanonicalTargetsRole)
+	if err != nil {
+		return nil, err
+	}
+
+	index := TargetIndex{
+		Type:            TargetIndexType,
+		GUN:             r.gun,
+		SnapshotVersion: r.tufRepo.Snapshot.Signed.Version,
+		Targets:         make(map[string]TargetIndexEntry, len(targets)),
+	}
+	for _, target := range targets {
+		index.Targets[target.Name] = TargetIndexEntry{Length: target.Length, Hashes: target.Hashes, Role: target.Role}
+	}
+	canonical, err := canonicaljson.MarshalCanonical(index)
+	if err != nil {
+		return nil, err
+	}
+	raw := canonicaljson.RawMessage(canonical)
+	s := &data.Signed{Signed: &raw}
+	if err := signed.Sign(r.cryptoService, s, targetsRole.ListKeys(), targetsRole.Threshold, nil); err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// The index is signed by the targets key, and lists exactly the targets that
+// ListTargets returns
+func TestSignedTargetIndex(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	addTarget(t, repo, "shadowed", "../fixtures/intermediate-ca.crt", data.CanonicalTargetsRole)
+	addTarget(t, repo, "shadowed", "../fixtures/root-ca.crt", "targets/a")
+	addTarget(t, repo, "delegated", "../fixtures/root-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+
+	raw, err := repo.SignedTargetIndex()
+	require.NoError(t, err)
+
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+	targetsRole, err := repo.tufRepo.GetBaseRole(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.NoError(t, signed.VerifySignatures(s, targetsRole))
+
+	var index TargetIndex
+	require.NoError(t, json.Unmarshal(*s.Signed, &index))
+	require.Equal(t, TargetIndexType, index.Type)
+	require.Equal(t, repo.gun, index.GUN)
+	require.Equal(t, repo.tufRepo.Snapshot.Signed.Version, index.SnapshotVersion)
+
+	listed, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, index.Targets, len(listed))
+	for _, target := range listed {
+		entry, ok := index.Targets[target.Name]
+		require.True(t, ok, target.Name)
+		require.Equal(t, TargetIndexEntry{Length: target.Length, Hashes: target.Hashes, Role: target.Role}, entry)
+	}
+	require.Equal(t, data.CanonicalTargetsRole, index.Targets["shadowed"].Role)
+
+	// tampering with the index invalidates its signature
+	index.Targets["delegated"] = index.Targets["shadowed"]
+	tampered, err := json.Marshal(index)
+	require.NoError(t, err)
+	tamperedRaw := canonicaljson.RawMessage(tampered)
+	s.Signed = &tamperedRaw
+	require.Error(t, signed.VerifySignatures(s, targetsRole))
+}
+
+// An index can't be produced without the targets key
+func TestSignedTargetIndexRequiresTargetsKey(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	reader, readerDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(readerDir)
+	_, err := reader.SignedTargetIndex()
+	require.Error(t, err)
+}
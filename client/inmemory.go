@@ -0,0 +1,85 @@
+package client
+
+import (
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+)
+
+// UpdateInMemory updates the TUF metadata for the repository exactly as any
+// other read or write operation would, but without ever writing to the local
+// cache, for consumers whose filesystems are read only.  Metadata already in
+// the cache is still read.  Once it is called, the repository keeps all newly
+// downloaded metadata in memory instead, so that every later operation,
+// including lookups, uses and updates the in memory metadata, and the cache
+// is left exactly as it was.
+func (r *repository) UpdateInMemory(forWrite bool) (*tuf.Repo, error) {
+	if _, ok := r.cache.(*memoryOverlay); !ok {
+		r.cache = newMemoryOverlay(r.cache)
+	}
+	if err := r.updateTUF(forWrite); err != nil {
+		return nil, err
+	}
+	return r.tufRepo, nil
+}
+
+// memoryOverlay is a metadata store which reads through to a base store, but
+// holds everything written to it, and any removals, in memory, so that the base
+// store is never modified
+type memoryOverlay struct {
+	base    store.MetadataStore
+	mem     store.MetadataStore
+	removed map[string]bool
+	// baseRemoved hides all of the base store, after RemoveAll
+	baseRemoved bool
+}
+
+func newMemoryOverlay(base store.MetadataStore) *memoryOverlay {
+	return &memoryOverlay{base: base, mem: store.NewMemoryStore(nil), removed: make(map[string]bool)}
+}
+
+// GetSized returns the metadata written to the overlay, or otherwise that in
+// the base store, unless it has been removed
+func (m *memoryOverlay) GetSized(name string, size int64) ([]byte, error) {
+	meta, err := m.mem.GetSized(name, size)
+	if _, ok := err.(store.ErrMetaNotFound); !ok {
+		return meta, err
+	}
+	if m.baseRemoved || m.removed[name] {
+		return nil, store.ErrMetaNotFound{Resource: name}
+	}
+	return m.base.GetSized(name, size)
+}
+
+// Set holds the metadata in memory
+func (m *memoryOverlay) Set(name string, blob []byte) error {
+	delete(m.removed, name)
+	return m.mem.Set(name, blob)
+}
+
+// SetMulti holds all the metadata in memory
+func (m *memoryOverlay) SetMulti(metas map[string][]byte) error {
+	for name, blob := range metas {
+		if err := m.Set(name, blob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove hides the metadata, without removing it from the base store
+func (m *memoryOverlay) Remove(name string) error {
+	m.removed[name] = true
+	return m.mem.Remove(name)
+}
+
+// RemoveAll hides all the metadata, without removing any from the base store
+func (m *memoryOverlay) RemoveAll() error {
+	m.baseRemoved = true
+	m.removed = make(map[string]bool)
+	return m.mem.RemoveAll()
+}
+
+// Location returns the location of the base store, marked as in memory
+func (m *memoryOverlay) Location() string {
+	return "memory overlay of " + m.base.Location()
+}
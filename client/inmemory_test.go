@@ -0,0 +1,108 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// snapshotDir records the content of every file under dir
+func snapshotDir(t *testing.T, dir string) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		files[path] = string(content)
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+// makeReadOnly removes write permission from everything under dir, and
+// returns a function which restores it
+func makeReadOnly(t *testing.T, dir string) func() {
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chmod(path, info.Mode()&^0222)
+	}))
+	return func() {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil {
+				os.Chmod(path, info.Mode()|0200)
+			}
+			return nil
+		})
+	}
+}
+
+// An in memory update of a repository with nothing cached writes nothing, and
+// later lookups use the metadata held in memory
+func TestUpdateInMemoryWithEmptyCache(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	reader, readerDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(readerDir)
+	before := snapshotDir(t, readerDir)
+	defer makeReadOnly(t, readerDir)()
+
+	tufRepo, err := reader.UpdateInMemory(false)
+	require.NoError(t, err)
+	require.NotNil(t, tufRepo.Targets[data.CanonicalTargetsRole])
+
+	target, err := reader.GetTargetByName("current")
+	require.NoError(t, err)
+	require.Equal(t, "current", target.Name)
+	require.Equal(t, before, snapshotDir(t, readerDir))
+}
+
+// An in memory update of a repository which is already cached reads the
+// cache, but leaves it unchanged as the repository changes
+func TestUpdateInMemoryWithExistingCache(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "old", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+	before := snapshotDir(t, readerDir)
+	defer makeReadOnly(t, readerDir)()
+
+	addTarget(t, repo, "new", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	_, err = reader.UpdateInMemory(false)
+	require.NoError(t, err)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Equal(t, before, snapshotDir(t, readerDir))
+
+	// a fresh client of the unchanged cache only sees the old target offline
+	offline, err := NewFileCachedRepository(readerDir, "docker.com/notary", ts.URL, nil, passphraseRetriever, reader.trustPinning)
+	require.NoError(t, err)
+	targets, err = offline.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "old", targets[0].Name)
+}
@@ -1,7 +1,13 @@
 package client
 
 import (
+	"io"
+	"net/http"
+	"time"
+
 	"github.com/theupdateframework/notary/client/changelist"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/signed"
 )
@@ -54,11 +60,269 @@ type Repository interface {
 	// GetGUN returns the GUN associated with the repository
 	GetGUN() data.GUN
 
+	// ReadOnly returns a handle to the repository that can read, but not modify, it
+	ReadOnly() ReadOnlyRepository
+
 	// SetLegacyVersion sets the number of versions back to fetch roots to sign with
 	SetLegacyVersions(int)
 
+	// SetSignWithAllKeys determines whether publishing signs each role with every
+	// available authorized key, as it does by default, or only with enough keys
+	// to meet its threshold
+	SetSignWithAllKeys(all bool)
+
+	// SetUserAgent sets the User-Agent header of every request to the server
+	SetUserAgent(ua string)
+
+	// SetTSA countersigns the signatures of published targets metadata with
+	// timestamp tokens from the RFC 3161 timestamp authority at url
+	SetTSA(url string, rt http.RoundTripper)
+
+	// ----- Update operations -----
+
+	// BootstrapFromBundle installs the root of the trust bundle at url as the
+	// trusted root, after validating it against the pinned certificates
+	BootstrapFromBundle(url string) error
+
+	// MinimalRootAnchor returns a compact anchor of just the keys and threshold
+	// of the current root role, for embedding in applications
+	MinimalRootAnchor() ([]byte, error)
+
+	// UpdateWithAnchor updates, trusting the root anchored by an anchor from
+	// MinimalRootAnchor rather than any cached root or pinned root certificates
+	UpdateWithAnchor(anchor []byte) error
+
+	// ServerAPIVersion detects the version of the notary server API, and
+	// adjusts subsequent requests to the server accordingly
+	ServerAPIVersion() (string, error)
+
+	// UpdateWithTiming updates the TUF metadata for the repository and reports
+	// a breakdown of the time spent fetching and verifying each role
+	UpdateWithTiming(forWrite bool) (*tuf.Repo, UpdateTiming, error)
+
+	// SetUpdateOptions configures optional behaviours of all subsequent updates
+	SetUpdateOptions(opts UpdateOptions)
+
+	// SetVerificationPolicy configures in one place how strictly all
+	// subsequent updates verify metadata, beyond what TUF requires
+	SetVerificationPolicy(p VerificationPolicy)
+
+	// ResolveDelegation downloads and verifies a delegation, and the
+	// delegations leading to it, when delegations are lazily downloaded
+	ResolveDelegation(role string) error
+
+	// SetTransparencyLog requires all subsequent updates to verify that the
+	// metadata is included in a transparency log
+	SetTransparencyLog(verifier LogVerifier)
+
+	// PollTimestamp downloads and verifies only the remote timestamp, and
+	// reports its version and whether it advanced since the last poll
+	PollTimestamp() (version int, changed bool, err error)
+
+	// UpdateWithResult updates the TUF metadata, and reports what changed,
+	// such as which delegations' keys their parent roles changed
+	UpdateWithResult(forWrite bool) (*tuf.Repo, UpdateResult, error)
+
+	// UpdateInMemory updates the TUF metadata without writing to the local
+	// cache, keeping it and all later metadata in memory instead
+	UpdateInMemory(forWrite bool) (*tuf.Repo, error)
+
+	// UpdateStats reports the requests made, bytes fetched, and bytes served
+	// from the cache, by all updates since creation or the last reset
+	UpdateStats() UpdateStats
+
+	// ResetUpdateStats sets the totals reported by UpdateStats back to zero
+	ResetUpdateStats()
+
+	// SetRefreshErrorHandler sets the function which subsequently started
+	// auto refreshes report their errors to
+	SetRefreshErrorHandler(handler func(err error))
+
+	// StartAutoRefresh polls the timestamp every interval, updating the cache
+	// whenever it advances, until the returned function is called
+	StartAutoRefresh(interval time.Duration) (stop func())
+
+	// ----- Verification operations -----
+
+	// SetTargetQuorum requires that a target looked up or listed be present,
+	// with matching hashes, in at least n distinct delegation roles
+	SetTargetQuorum(n int)
+
+	// SetFreshnessWindow rejects timestamp or snapshot metadata signed more than
+	// maxAge ago during subsequent updates, even if it has not yet expired.  The
+	// signing time is inferred from the expiry, assuming notary's default expiry.
+	SetFreshnessWindow(role string, maxAge time.Duration) error
+
+	// RequireKeyAttribute requires a target looked up or listed be in a role
+	// signed by a threshold of keys carrying the attribute with the value
+	RequireKeyAttribute(key, value string)
+
+	// SetPathMatcher replaces the prefix matching of target paths against
+	// delegation paths when looking up and adding targets
+	SetPathMatcher(matcher data.PathMatcher)
+
+	// SetDelegationKeyAllowlist requires a target looked up or listed in the
+	// role be signed by a threshold of the allowlisted keys
+	SetDelegationKeyAllowlist(role string, keyIDs []string)
+
+	// SetHashPreference verifies target content with only the first of the
+	// given hash algorithms that each target carries, failing if it has none
+	SetHashPreference(algorithms []string) error
+
+	// VerifyAllTargets verifies the content of every target, as obtained from
+	// source, against its TUF metadata, returning a result per target per role
+	VerifyAllTargets(source func(name string) (io.Reader, error)) ([]TargetVerificationResult, error)
+
+	// VerifyDirectory verifies the files under a local directory against the
+	// targets they are named for, flagging files which are not targets
+	VerifyDirectory(root string) ([]TargetVerificationResult, error)
+
+	// VerifyAgainstCAS checks that the content of every target can be
+	// retrieved from a content-addressable store by its hashes, and matches
+	VerifyAgainstCAS(cas ContentStore) ([]CASMismatch, error)
+
+	// FetchAndVerifyTarget obtains the content of a target with fetch, and
+	// returns a reader which verifies it against the target as it streams
+	FetchAndVerifyTarget(name string, fetch func(t *Target) (io.ReadCloser, error)) (io.ReadCloser, error)
+
+	// VerifyImageDigest checks that the target named for an image tag is
+	// signed with the SHA256 hash of an OCI "sha256:..." digest
+	VerifyImageDigest(tag string, digest string) error
+
+	// TargetsMerkleRoot returns the root of a Merkle tree over every target
+	TargetsMerkleRoot() (data.Hashes, error)
+
+	// TargetInclusionProof returns a proof that the named target is included
+	// in the tree whose root TargetsMerkleRoot returns
+	TargetInclusionProof(name string) (*TargetMerkleProof, error)
+
+	// ValidateTargetResolution reports, without updating, the targets which
+	// are not reachable through a verified delegation chain
+	ValidateTargetResolution() ([]UnresolvableTarget, error)
+
+	// ExportTrustChain writes the signed metadata which establishes trust in
+	// a target as JSON, which VerifyTrustChain can verify offline
+	ExportTrustChain(targetName string, w io.Writer) error
+
+	// VerifyFullTree updates, and then verifies the signatures and thresholds
+	// of every role in the delegation tree, returning the first failure
+	VerifyFullTree() error
+
+	// CrossValidate compares the verified metadata from the server with that
+	// from an independent mirror, reporting the roles on which they disagree
+	CrossValidate(mirrorURL string) ([]Discrepancy, error)
+
+	// VerifyAtVersion reconstructs and verifies the repository as of a past
+	// snapshot version, from archived consistent metadata
+	VerifyAtVersion(snapshotVersion int, source store.MetadataStore) (*tuf.Repo, error)
+
 	// ----- General management operations -----
 
+	// AtomicReplaceCache verifies a complete metadata set and atomically
+	// replaces the cached metadata with it
+	AtomicReplaceCache(meta map[string][]byte) error
+
+	// UpdateFromSnapshot updates the repository from a metadata set supplied
+	// by the caller instead of the server, anchored in the cached root
+	UpdateFromSnapshot(meta map[string][]byte, forWrite bool) (*tuf.Repo, error)
+
+	// CacheFingerprint returns a stable hash over all cached metadata
+	CacheFingerprint() (string, error)
+
+	// VerifyCacheFingerprint checks that the cached metadata has the expected
+	// fingerprint
+	VerifyCacheFingerprint(expected string) error
+
+	// PruneStaleMetadata removes consistently named metadata files from the local
+	// cache which are no longer referenced by the current snapshot or timestamp,
+	// returning the number of files removed
+	PruneStaleMetadata() (int, error)
+
+	// FindOrphanedTargets reports the targets in cached delegation metadata
+	// whose role is no longer part of the current delegation tree
+	FindOrphanedTargets() ([]*TargetWithRole, error)
+
+	// CheckDelegationPathOverlaps reports the pairs of sibling delegations
+	// whose path prefixes overlap
+	CheckDelegationPathOverlaps() ([]PathOverlap, error)
+
+	// DelegationCoverage maps each target path to the delegation responsible
+	// for signing it, or to "" if no delegation covers it
+	DelegationCoverage(paths []string) (map[string]string, error)
+
+	// MissingKeys reports, for each role, the authorized keys which the local
+	// crypto service does not hold, and whether that blocks signing the role
+	MissingKeys() ([]RoleKeyGap, error)
+
+	// DiagnoseExpiry reports, for each role, whether its metadata has expired
+	// and by how much, and whether the expiry may be due to clock skew
+	DiagnoseExpiry() ([]ExpiryDiagnosis, error)
+
+	// RenewableRoles lists the roles which have expired or are nearing expiry,
+	// and which enough keys are held locally to re-sign
+	RenewableRoles() ([]data.RoleName, error)
+
+	// BackupKeys writes every locally held private key to a single archive
+	// encrypted with the passphrase
+	BackupKeys(w io.Writer, passphrase string) error
+
+	// RestoreKeys imports the private keys from an archive written by
+	// BackupKeys, importing none if the archive can't be decrypted
+	RestoreKeys(r io.Reader, passphrase string) error
+
+	// PruneUnusedKeys removes the private keys held for this repository which
+	// no role is authorized to use any more, returning the removed key IDs
+	PruneUnusedKeys() ([]string, error)
+
+	// EnforceKeyPolicy checks that the keys of every role satisfy the policy,
+	// returning an ErrKeyPolicyViolations listing those which do not
+	EnforceKeyPolicy(policy KeyPolicy) error
+
+	// RootDiffAgainst compares the cached root with a self-consistent root
+	// supplied from elsewhere, reporting key and threshold differences per role
+	RootDiffAgainst(rootBytes []byte) (RootDiff, error)
+
+	// ExportRolePretty writes the metadata of a role indented for human
+	// review, which is never what is signed or stored
+	ExportRolePretty(role string, w io.Writer) error
+
+	// ExportRoleWithStatus returns the metadata of a role wrapped with its
+	// verification status against the repository's trusted state
+	ExportRoleWithStatus(role string) ([]byte, error)
+
+	// ExportTUFLayout writes the verified metadata, as notary signs it, under
+	// a directory in the layout of a TUF repository
+	ExportTUFLayout(dir string) error
+
+	// ThresholdAudit reports the roles whose threshold suggests misconfigured
+	// multi-signature, or which have no redundant keys
+	ThresholdAudit() []ThresholdWarning
+
+	// StreamTargets writes the targets that ListTargets returns to w as
+	// newline delimited JSON, in a stable order, without collecting them first
+	StreamTargets(w io.Writer) error
+
+	// GetTargetBySemver returns the target with the given name prefix whose
+	// semantic version, from its custom data, is the highest matching constraint
+	GetTargetBySemver(namePrefix, constraint string) (*TargetWithRole, error)
+
+	// SignedTargetIndex produces a single document, signed locally with the
+	// targets key, listing the length and hashes of every target
+	SignedTargetIndex() ([]byte, error)
+
+	// NormalizeAndResign canonically re-encodes the cached metadata of the given
+	// roles, such as metadata imported from other TUF tools, and re-signs it with
+	// the roles' local keys
+	NormalizeAndResign(roles ...string) error
+
+	// GenerateSigningRequest produces a request carrying the canonical bytes of
+	// the next version of a role's metadata, to be signed offline
+	GenerateSigningRequest(role string) (*SigningRequest, error)
+
+	// ApplySignature verifies a signature produced offline for a signing
+	// request, and attaches it to the request's metadata
+	ApplySignature(req *SigningRequest, keyID string, sig []byte) error
+
 	// Initialize creates a new repository by using rootKey as the root Key for the
 	// TUF repository. The remote store/server must be reachable (and is asked to
 	// generate a timestamp key and possibly other serverManagedRoles), but the
@@ -70,10 +334,31 @@ type Repository interface {
 	// corresponding certificates
 	InitializeWithCertificate(rootKeyIDs []string, rootCerts []data.PublicKey, serverManagedRoles ...data.RoleName) error
 
+	// InitializeWithOwnership creates a new repository like Initialize, with
+	// the server or client ownership of the snapshot and timestamp keys given
+	// explicitly, so that a repository can be fully client managed
+	InitializeWithOwnership(rootKeyIDs []string, serverManaged map[string]bool) error
+
 	// Publish pushes the local changes in signed material to the remote notary-server
 	// Conceptually it performs an operation similar to a `git rebase`
 	Publish() error
 
+	// PublishSelective publishes only the staged changes matching the
+	// filter, leaving the rest staged
+	PublishSelective(filter func(changelist.Change) bool) error
+
+	// ResumePublish re-sends the already signed metadata of a publish which
+	// failed before the server acknowledged it
+	ResumePublish() error
+
+	// PublishWithIdempotencyKey publishes like Publish, sending a key which
+	// lets the server dedupe retries, and which every retry of it reuses
+	PublishWithIdempotencyKey(key string) error
+
+	// PublishToMirrors signs the local changes once, and publishes the signed
+	// metadata to each of several servers, reporting the outcome for each
+	PublishToMirrors(urls []string) ([]PublishOutcome, error)
+
 	// ----- Target Operations -----
 
 	// AddTarget creates new changelist entries to add a target to the given roles
@@ -81,16 +366,32 @@ type Repository interface {
 	// If roles are unspecified, the default role is "targets"
 	AddTarget(target *Target, roles ...data.RoleName) error
 
+	// YankTarget creates a changelist entry to mark a target in the given role
+	// as yanked, without removing it
+	YankTarget(role, name string) error
+
 	// RemoveTarget creates new changelist entries to remove a target from the given
 	// roles in the repository when the changelist gets applied at publish time.
 	// If roles are unspecified, the default role is "target".
 	RemoveTarget(targetName string, roles ...data.RoleName) error
 
+	// RemapTargets stages the renaming of every target under prefix to be under
+	// newPrefix instead, returning how many targets were remapped
+	RemapTargets(prefix, newPrefix string) (int, error)
+
+	// AddTargetsFromManifest stages every file listed in a sha256sum or JSON
+	// manifest as a target of role, returning how many were staged
+	AddTargetsFromManifest(role string, manifest io.Reader, format string) (int, error)
+
 	// ----- Changelist operations -----
 
 	// GetChangelist returns the list of the repository's unpublished changes
 	GetChangelist() (changelist.Changelist, error)
 
+	// PendingTargetChanges resolves the changelist into the targets which
+	// publishing it would add and remove
+	PendingTargetChanges() (added, removed []*Target, err error)
+
 	// ----- Role operations -----
 
 	// AddDelegation creates changelist entries to add provided delegation public keys and paths.
@@ -107,6 +408,10 @@ type Repository interface {
 	// creation.
 	AddDelegationPaths(name data.RoleName, paths []string) error
 
+	// AddDelegationExcludePaths creates a changelist entry to exclude an existing delegation from
+	// provided path prefixes, so that it is not trusted for targets under them.
+	AddDelegationExcludePaths(name data.RoleName, paths []string) error
+
 	// RemoveDelegationKeysAndPaths creates changelist entries to remove provided delegation key IDs and
 	// paths. This method composes RemoveDelegationPaths and RemoveDelegationKeys (each creates one
 	// changelist entry if called).
@@ -119,6 +424,10 @@ type Repository interface {
 	// RemoveDelegationPaths creates a changelist entry to remove provided paths from an existing delegation.
 	RemoveDelegationPaths(name data.RoleName, paths []string) error
 
+	// RemoveDelegationExcludePaths creates a changelist entry to stop excluding an existing delegation
+	// from provided path prefixes.
+	RemoveDelegationExcludePaths(name data.RoleName, paths []string) error
+
 	// RemoveDelegationKeys creates a changelist entry to remove provided keys from an existing delegation.
 	// When this changelist is applied, if the specified keys are the only keys left in the role,
 	// the role itself will be deleted in its entirety.
@@ -129,6 +438,15 @@ type Repository interface {
 	// ClearDelegationPaths creates a changelist entry to remove all paths from an existing delegation.
 	ClearDelegationPaths(name data.RoleName) error
 
+	// RebuildDelegations stages changes that make the delegation tree match the given
+	// definitions exactly: undefined delegations are deleted, existing ones have their keys,
+	// paths and threshold replaced while keeping their targets, and missing ones are created.
+	RebuildDelegations(defs []DelegationDef) error
+
+	// StageFromTargetSet stages the fewest changes that make the targets signed
+	// directly into the role exactly the desired targets once published
+	StageFromTargetSet(role string, desired []*Target) error
+
 	// ----- Witness and other re-signing operations -----
 
 	// Witness creates change objects to witness (i.e. re-sign) the given
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	store "github.com/theupdateframework/notary/storage"
+)
+
+// ErrNoPublishToResume is returned by ResumePublish when there is no
+// interrupted publish to resume
+type ErrNoPublishToResume struct{}
+
+func (err ErrNoPublishToResume) Error() string {
+	return "there is no interrupted publish to resume"
+}
+
+// journalEntry is the signed metadata of a publish which has not yet been
+// acknowledged by the server
+type journalEntry struct {
+	// Files is the signed metadata to send to the server, keyed by role name
+	Files map[string][]byte `json:"files"`
+	// Changes is how many changes at the start of the repository's changelist
+	// were applied to produce the metadata
+	Changes int `json:"changes"`
+	// Published, if set, are the indices in the repository's changelist of
+	// the changes which were applied, when they were not all at its start
+	Published []int `json:"published,omitempty"`
+	// IdempotencyKey is the key the publish is sent with, if it has one
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// publishJournal records the signed metadata of a publish until the server
+// acknowledges it.  If it has a path, it persists the metadata to that file so
+// that the publish can be resumed by another process, and otherwise it only
+// keeps the metadata in memory.
+type publishJournal struct {
+	path    string
+	pending *journalEntry
+}
+
+// record saves the signed metadata of a publish which is about to be sent
+func (j *publishJournal) record(entry *journalEntry) error {
+	j.pending = entry
+	if j.path == "" {
+		return nil
+	}
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	// write it aside first, so that an interruption never leaves a partial
+	// journal behind
+	tmp := j.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, out, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// load returns the recorded publish, or nil if there is none
+func (j *publishJournal) load() (*journalEntry, error) {
+	if j.pending != nil || j.path == "" {
+		return j.pending, nil
+	}
+	raw, err := ioutil.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := &journalEntry{}
+	if err := json.Unmarshal(raw, entry); err != nil {
+		return nil, err
+	}
+	j.pending = entry
+	return entry, nil
+}
+
+// clear removes the recorded publish, once the server has acknowledged it
+func (j *publishJournal) clear() error {
+	j.pending = nil
+	if j.path == "" {
+		return nil
+	}
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ResumePublish re-sends the signed metadata of a publish which failed or was
+// interrupted before the server acknowledged it.  Nothing is signed again, so
+// no passphrases are needed.  On success the journal of the publish is
+// cleared, and so are the changes it published from the changelist.  If the
+// server rejects the metadata, for instance because the repository has been
+// published to since, the changes must be published again with Publish.
+//
+// If the publish was sent with an idempotency key, it is sent again with the
+// same key.
+func (r *repository) ResumePublish() error {
+	entry, err := r.journal.load()
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return ErrNoPublishToResume{}
+	}
+	if err := setMultiWithKey(r.getRemoteStore(), entry.Files, entry.IdempotencyKey); err != nil {
+		return err
+	}
+	if err := r.journal.clear(); err != nil {
+		logrus.Warnf("unable to clear the publish journal: %s", err)
+	}
+
+	published := entry.Published
+	if published == nil {
+		published = make([]int, 0, entry.Changes)
+		for i := 0; i < entry.Changes; i++ {
+			published = append(published, i)
+		}
+	}
+	if err := r.changelist.Remove(published); err != nil {
+		logrus.Warn("Unable to clear changelist. You may want to manually delete the folder ", r.changelist.Location())
+	}
+	return nil
+}
+
+// setMultiWithKey sends the signed metadata of a publish to the remote store,
+// with the idempotency key if there is one and the store is able to send it
+func setMultiWithKey(remote store.RemoteStore, files map[string][]byte, key string) error {
+	if key == "" {
+		return remote.SetMulti(files)
+	}
+	if idempotent, ok := remote.(store.IdempotentStore); ok {
+		return idempotent.SetMultiWithIdempotencyKey(files, key)
+	}
+	logrus.Debugf("the remote store can't send idempotency keys, publishing without one")
+	return remote.SetMulti(files)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// newFlakyProxy proxies requests to the server at target, failing every POST
+// while failPosts is set
+func newFlakyProxy(t *testing.T, target string, failPosts *int32) *httptest.Server {
+	targetURL, err := url.Parse(target)
+	require.NoError(t, err)
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && atomic.LoadInt32(failPosts) != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+}
+
+// A publish whose POST fails can be resumed, by another client using the same
+// trust directory, without signing again
+func TestResumePublish(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	var failPosts int32
+	flaky := newFlakyProxy(t, ts.URL, &failPosts)
+	defer flaky.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", flaky.URL, false)
+	defer os.RemoveAll(baseDir)
+	layout, err := TrustDirLayout(baseDir, "docker.com/notary")
+	require.NoError(t, err)
+
+	// there is nothing to resume before a publish has failed
+	require.IsType(t, ErrNoPublishToResume{}, repo.ResumePublish())
+
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	atomic.StoreInt32(&failPosts, 1)
+	require.Error(t, repo.Publish())
+	_, err = os.Stat(layout.PublishJournal)
+	require.NoError(t, err, "the signed metadata should have been journaled")
+	require.Len(t, getChanges(t, repo), 1)
+
+	// a change staged after the failed publish is not part of it
+	addTarget(t, repo, "later", "../fixtures/intermediate-ca.crt")
+
+	resumer, rec, _ := newRepoToTestRepo(t, repo, baseDir)
+	require.Error(t, resumer.ResumePublish())
+
+	atomic.StoreInt32(&failPosts, 0)
+	require.NoError(t, resumer.ResumePublish())
+	rec.requireAsked(t, nil, "resuming should not need any passphrases")
+	_, err = os.Stat(layout.PublishJournal)
+	require.True(t, os.IsNotExist(err), "the journal should have been cleared")
+
+	changes := getChanges(t, resumer)
+	require.Len(t, changes, 1)
+	require.Equal(t, "later", changes[0].Path())
+	require.IsType(t, ErrNoPublishToResume{}, resumer.ResumePublish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "current", targets[0].Name)
+
+	// a successful publish leaves no journal behind
+	require.NoError(t, resumer.Publish())
+	_, err = os.Stat(layout.PublishJournal)
+	require.True(t, os.IsNotExist(err))
+	require.IsType(t, ErrNoPublishToResume{}, resumer.ResumePublish())
+}
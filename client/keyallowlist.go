@@ -0,0 +1,74 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// ErrKeyNotAllowlisted is returned when a target is found in a role which is
+// not signed by a threshold of the keys allowlisted for that role
+type ErrKeyNotAllowlisted struct {
+	Name string
+	Role data.RoleName
+}
+
+func (err ErrKeyNotAllowlisted) Error() string {
+	return fmt.Sprintf("target %s is in %s, which is not signed by enough allowlisted keys", err.Name, err.Role)
+}
+
+// SetDelegationKeyAllowlist restricts the keys trusted for a role to the given
+// key IDs, which may be either TUF or canonical key IDs.  When looking up a
+// target by name, the role the target is found in must be signed by a
+// threshold of allowlisted keys, even if the metadata authorizes other keys
+// for the role, and the targets of a role which is not are left out of
+// listings.  Keys which are allowlisted but not authorized for the role are
+// not trusted.  An empty list removes the role's allowlist.
+func (r *repository) SetDelegationKeyAllowlist(role string, keyIDs []string) {
+	roleName := data.RoleName(role)
+	if len(keyIDs) == 0 {
+		delete(r.delegationKeyAllowlists, roleName)
+		return
+	}
+	if r.delegationKeyAllowlists == nil {
+		r.delegationKeyAllowlists = make(map[data.RoleName]map[string]bool)
+	}
+	allowed := make(map[string]bool, len(keyIDs))
+	for _, keyID := range keyIDs {
+		allowed[keyID] = true
+	}
+	r.delegationKeyAllowlists[roleName] = allowed
+}
+
+// checkKeyAllowlist verifies that the role the target was found in is signed
+// by a threshold of the keys allowlisted for it, if it has an allowlist
+func (r *repository) checkKeyAllowlist(target *TargetWithRole) error {
+	allowed, ok := r.delegationKeyAllowlists[target.Role]
+	if !ok {
+		return nil
+	}
+	role, signatures, err := r.targetRoleSignatures(target)
+	if err != nil {
+		return err
+	}
+
+	signers := make(map[string]bool)
+	for _, sig := range signatures {
+		key, ok := role.Keys[sig.KeyID]
+		if !sig.IsValid || !ok {
+			continue
+		}
+		if allowed[sig.KeyID] {
+			signers[sig.KeyID] = true
+			continue
+		}
+		if canonicalID, err := utils.CanonicalKeyID(key); err == nil && allowed[canonicalID] {
+			signers[sig.KeyID] = true
+		}
+	}
+	if len(signers) < role.Threshold {
+		return ErrKeyNotAllowlisted{Name: target.Name, Role: target.Role}
+	}
+	return nil
+}
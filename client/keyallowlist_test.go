@@ -0,0 +1,109 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A target is found only if its role is signed by an allowlisted key, even
+// though the role's metadata authorizes the key which signed it
+func TestSetDelegationKeyAllowlist(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	signingKey := createKey(t, repo, "targets/release", false)
+	idleKey := createKey(t, repo, "targets/release", false)
+	require.NoError(t, repo.AddDelegation("targets/release",
+		[]data.PublicKey{signingKey, idleKey}, []string{"release/"}))
+	require.NoError(t, repo.AddDelegation("targets/dev",
+		[]data.PublicKey{createKey(t, repo, "targets/dev", false)}, []string{"dev/"}))
+	addTarget(t, repo, "release/app", "../fixtures/intermediate-ca.crt", "targets/release")
+	addTarget(t, repo, "dev/app", "../fixtures/intermediate-ca.crt", "targets/dev")
+	// only the signing key is held when publishing, so the idle key is
+	// authorized but has not signed
+	require.NoError(t, repo.GetCryptoService().RemoveKey(idleKey.ID()))
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.GetTargetByName("release/app")
+	require.NoError(t, err)
+
+	reader.SetDelegationKeyAllowlist("targets/release", []string{idleKey.ID()})
+	_, err = reader.GetTargetByName("release/app")
+	require.Error(t, err)
+	require.Equal(t, ErrKeyNotAllowlisted{Name: "release/app", Role: "targets/release"}, err)
+
+	// roles without an allowlist are unaffected
+	_, err = reader.GetTargetByName("dev/app")
+	require.NoError(t, err)
+
+	reader.SetDelegationKeyAllowlist("targets/release", []string{signingKey.ID()})
+	target, err := reader.GetTargetByName("release/app")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName("targets/release"), target.Role)
+
+	reader.SetDelegationKeyAllowlist("targets/release", nil)
+	reader.SetDelegationKeyAllowlist("targets/dev", []string{signingKey.ID()})
+	_, err = reader.GetTargetByName("release/app")
+	require.NoError(t, err)
+	_, err = reader.GetTargetByName("dev/app")
+	require.Equal(t, ErrKeyNotAllowlisted{Name: "dev/app", Role: "targets/dev"}, err)
+}
+
+// Targets in a role which is not signed by an allowlisted key are left out of
+// listings, and so are not resolved by version either
+func TestDelegationKeyAllowlistAppliesToListings(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	signingKey := createKey(t, repo, "targets/release", false)
+	require.NoError(t, repo.AddDelegation("targets/release", []data.PublicKey{signingKey}, []string{"release/"}))
+	require.NoError(t, repo.AddDelegation("targets/dev",
+		[]data.PublicKey{createKey(t, repo, "targets/dev", false)}, []string{"dev/"}))
+	version, err := canonicaljson.MarshalCanonical(map[string]string{"version": "1.0.0"})
+	require.NoError(t, err)
+	custom := canonicaljson.RawMessage(version)
+	addTargetWithCustom(t, repo, "release/app-1.0.0", "../fixtures/intermediate-ca.crt", &custom, "targets/release")
+	addTarget(t, repo, "dev/app", "../fixtures/intermediate-ca.crt", "targets/dev")
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	reader.SetDelegationKeyAllowlist("targets/release", []string{"not-a-key-id"})
+
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "dev/app", targets[0].Name)
+
+	_, err = reader.GetAllTargetMetadataByName("release/app-1.0.0")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+	all, err := reader.GetAllTargetMetadataByName("")
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	require.Equal(t, "dev/app", all[0].Target.Name)
+
+	_, err = reader.GetTargetBySemver("release/", "*")
+	require.IsType(t, ErrNoMatchingVersion{}, err)
+
+	reader.SetDelegationKeyAllowlist("targets/release", []string{signingKey.ID()})
+	targets, err = reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	_, err = reader.GetAllTargetMetadataByName("release/app-1.0.0")
+	require.NoError(t, err)
+	target, err := reader.GetTargetBySemver("release/", "*")
+	require.NoError(t, err)
+	require.Equal(t, "release/app-1.0.0", target.Name)
+}
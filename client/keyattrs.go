@@ -0,0 +1,97 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrKeyAttributeMissing is returned when a target is found in a role which is
+// not signed by a threshold of keys carrying a required key attribute
+type ErrKeyAttributeMissing struct {
+	Name      string
+	Role      data.RoleName
+	Attribute string
+	Value     string
+}
+
+func (err ErrKeyAttributeMissing) Error() string {
+	return fmt.Sprintf("target %s is in %s, which is not signed by enough keys with the attribute %s=%s",
+		err.Name, err.Role, err.Attribute, err.Value)
+}
+
+// RequireKeyAttribute requires that, when looking up a target by name, the
+// role the target is found in be signed by a threshold of keys which carry the
+// attribute with the given value, such as "team" and "release", and leaves the
+// targets of roles which are not out of listings.  A key's
+// attributes are part of the signed metadata which lists the key, and are set
+// with data.WithKeyAttributes before the key is added to a role.  Calling it
+// again with the same attribute replaces the required value, and an empty
+// value removes the requirement.
+func (r *repository) RequireKeyAttribute(key, value string) {
+	if value == "" {
+		delete(r.requiredKeyAttributes, key)
+		return
+	}
+	if r.requiredKeyAttributes == nil {
+		r.requiredKeyAttributes = make(map[string]string)
+	}
+	r.requiredKeyAttributes[key] = value
+}
+
+// checkKeyAttributes verifies that the role the target was found in is signed
+// by a threshold of keys carrying each of the required attributes
+func (r *repository) checkKeyAttributes(target *TargetWithRole) error {
+	if len(r.requiredKeyAttributes) == 0 {
+		return nil
+	}
+	role, signatures, err := r.targetRoleSignatures(target)
+	if err != nil {
+		return err
+	}
+
+	attributes := make([]string, 0, len(r.requiredKeyAttributes))
+	for attribute := range r.requiredKeyAttributes {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+	for _, attribute := range attributes {
+		value := r.requiredKeyAttributes[attribute]
+		signers := make(map[string]bool)
+		for _, sig := range signatures {
+			key, ok := role.Keys[sig.KeyID]
+			if sig.IsValid && ok && data.KeyAttributes(key)[attribute] == value {
+				signers[sig.KeyID] = true
+			}
+		}
+		if len(signers) < role.Threshold {
+			return ErrKeyAttributeMissing{Name: target.Name, Role: target.Role, Attribute: attribute, Value: value}
+		}
+	}
+	return nil
+}
+
+// targetRoleSignatures returns the role the target was found in, and the
+// signatures on that role's metadata
+func (r *repository) targetRoleSignatures(target *TargetWithRole) (data.BaseRole, []data.Signature, error) {
+	var role data.BaseRole
+	if target.Role == data.CanonicalTargetsRole {
+		baseRole, err := r.tufRepo.GetBaseRole(target.Role)
+		if err != nil {
+			return data.BaseRole{}, nil, err
+		}
+		role = baseRole
+	} else {
+		delegation, err := r.tufRepo.GetDelegationRole(target.Role)
+		if err != nil {
+			return data.BaseRole{}, nil, err
+		}
+		role = delegation.BaseRole
+	}
+	tgts, ok := r.tufRepo.Targets[target.Role]
+	if !ok {
+		return data.BaseRole{}, nil, data.ErrInvalidRole{Role: target.Role}
+	}
+	return role, tgts.Signatures, nil
+}
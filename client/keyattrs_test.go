@@ -0,0 +1,72 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A target is found only if its role is signed by keys carrying the required
+// attribute, whether the attribute is missing or has a different value
+func TestRequireKeyAttribute(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	releaseKey := data.WithKeyAttributes(createKey(t, repo, "targets/release", false),
+		map[string]string{"team": "release"})
+	require.NoError(t, repo.AddDelegation("targets/release", []data.PublicKey{releaseKey}, []string{"release/"}))
+	devKey := data.WithKeyAttributes(createKey(t, repo, "targets/dev", false),
+		map[string]string{"team": "dev"})
+	require.NoError(t, repo.AddDelegation("targets/dev", []data.PublicKey{devKey}, []string{"dev/"}))
+	plainKey := createKey(t, repo, "targets/plain", false)
+	require.NoError(t, repo.AddDelegation("targets/plain", []data.PublicKey{plainKey}, []string{"plain/"}))
+
+	addTarget(t, repo, "top", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "release/app", "../fixtures/intermediate-ca.crt", "targets/release")
+	addTarget(t, repo, "dev/app", "../fixtures/intermediate-ca.crt", "targets/dev")
+	addTarget(t, repo, "plain/app", "../fixtures/intermediate-ca.crt", "targets/plain")
+	require.NoError(t, repo.Publish())
+
+	// the attributes are read back from the published metadata
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	reader.RequireKeyAttribute("team", "release")
+
+	target, err := reader.GetTargetByName("release/app")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName("targets/release"), target.Role)
+
+	for name, role := range map[string]data.RoleName{
+		"dev/app":   "targets/dev",
+		"plain/app": "targets/plain",
+		"top":       data.CanonicalTargetsRole,
+	} {
+		_, err := reader.GetTargetByName(name)
+		require.Error(t, err)
+		require.Equal(t, ErrKeyAttributeMissing{Name: name, Role: role, Attribute: "team", Value: "release"}, err)
+	}
+
+	// only the targets of the roles signed by keys with the attribute are
+	// listed
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "release/app", targets[0].Name)
+	all, err := reader.GetAllTargetMetadataByName("")
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	require.Equal(t, "release/app", all[0].Target.Name)
+
+	// removing the requirement makes every target available again
+	reader.RequireKeyAttribute("team", "")
+	_, err = reader.GetTargetByName("dev/app")
+	require.NoError(t, err)
+	targets, err = reader.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 4)
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// keyBackupMagic starts every key backup archive
+	keyBackupMagic = "NOTARY-KEY-BACKUP"
+	// keyBackupVersion is the version of the archive format written by
+	// BackupKeys
+	keyBackupVersion uint16 = 1
+	// keyBackupIterations is how many PBKDF2 iterations derive the archive's
+	// key from its passphrase
+	keyBackupIterations uint32 = 100000
+	keyBackupSaltSize          = 16
+)
+
+// ErrInvalidKeyBackup is returned when restoring from something which is not a
+// key backup archive, or is one in a version which is not supported
+type ErrInvalidKeyBackup struct {
+	Reason string
+}
+
+func (err ErrInvalidKeyBackup) Error() string {
+	return fmt.Sprintf("invalid key backup: %s", err.Reason)
+}
+
+// ErrKeyBackupUnauthenticated is returned when a key backup archive can't be
+// decrypted, because the passphrase is wrong or the archive has been altered
+type ErrKeyBackupUnauthenticated struct{}
+
+func (err ErrKeyBackupUnauthenticated) Error() string {
+	return "key backup could not be decrypted: the passphrase is wrong or the archive has been altered"
+}
+
+// backedUpKey is a private key in a key backup archive, PEM encoded with its
+// role and GUN
+type backedUpKey struct {
+	Role data.RoleName `json:"role"`
+	GUN  data.GUN      `json:"gun"`
+	PEM  []byte        `json:"pem"`
+}
+
+// keyBackupHeader is the unencrypted start of a key backup archive, which is
+// authenticated along with the encrypted keys
+type keyBackupHeader struct {
+	Magic      [len(keyBackupMagic)]byte
+	Version    uint16
+	Iterations uint32
+	Salt       [keyBackupSaltSize]byte
+	Nonce      [12]byte
+}
+
+// BackupKeys writes every private key held by the local crypto service, for
+// any GUN, to w in a single archive encrypted with the passphrase, from which
+// RestoreKeys can recover them.  The archive starts with a header holding its
+// format version and key derivation parameters, followed by the keys encrypted
+// with AES-256-GCM under a key derived from the passphrase with PBKDF2.  The
+// GCM tag is the archive's integrity MAC, covering the header as well.
+func (r *repository) BackupKeys(w io.Writer, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("a passphrase is required to back up keys")
+	}
+	infoService, _ := r.cryptoService.(keyInfoService)
+
+	allKeys := r.cryptoService.ListAllKeys()
+	keyIDs := make([]string, 0, len(allKeys))
+	for keyID := range allKeys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	keys := make([]backedUpKey, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		privKey, role, err := r.cryptoService.GetPrivateKey(keyID)
+		if err != nil {
+			return err
+		}
+		gun := r.gun
+		if infoService != nil {
+			info, err := infoService.GetKeyInfo(keyID)
+			if err != nil {
+				return err
+			}
+			gun = info.Gun
+		} else if role == data.CanonicalRootRole || data.IsDelegation(role) {
+			gun = ""
+		}
+		pemBytes, err := utils.ConvertPrivateKeyToPKCS8(privKey, role, gun, "")
+		if err != nil {
+			return err
+		}
+		keys = append(keys, backedUpKey{Role: role, GUN: gun, PEM: pemBytes})
+	}
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	header := keyBackupHeader{Version: keyBackupVersion, Iterations: keyBackupIterations}
+	copy(header.Magic[:], keyBackupMagic)
+	if _, err := io.ReadFull(rand.Reader, header.Salt[:]); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(rand.Reader, header.Nonce[:]); err != nil {
+		return err
+	}
+	aead, err := keyBackupCipher(passphrase, header)
+	if err != nil {
+		return err
+	}
+	var headerBytes bytes.Buffer
+	if err := binary.Write(&headerBytes, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(headerBytes.Bytes()); err != nil {
+		return err
+	}
+	_, err = w.Write(aead.Seal(nil, header.Nonce[:], plaintext, headerBytes.Bytes()))
+	return err
+}
+
+// RestoreKeys reads a key backup archive written by BackupKeys from rd, and
+// adds each of its keys to the local crypto service with its role and GUN.
+// The whole archive is decrypted, authenticated and parsed before any key is
+// added, so a wrong passphrase or an altered archive imports nothing, and if
+// adding a key fails, the keys which this restore added are removed again.
+// Keys which are already held are left as they are.
+func (r *repository) RestoreKeys(rd io.Reader, passphrase string) error {
+	archive, err := ioutil.ReadAll(io.LimitReader(rd, notary.MaxDownloadSize))
+	if err != nil {
+		return err
+	}
+	header, err := unmarshalKeyBackupHeader(archive)
+	if err != nil {
+		return err
+	}
+	aead, err := keyBackupCipher(passphrase, header)
+	if err != nil {
+		return err
+	}
+	headerBytes := archive[:binary.Size(header)]
+	plaintext, err := aead.Open(nil, header.Nonce[:], archive[len(headerBytes):], headerBytes)
+	if err != nil {
+		return ErrKeyBackupUnauthenticated{}
+	}
+
+	var keys []backedUpKey
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return ErrInvalidKeyBackup{Reason: "could not parse the keys"}
+	}
+	privKeys := make([]data.PrivateKey, 0, len(keys))
+	for _, key := range keys {
+		privKey, err := utils.ParsePEMPrivateKey(key.PEM, "")
+		if err != nil {
+			return ErrInvalidKeyBackup{Reason: fmt.Sprintf("could not parse a %s key: %s", key.Role, err)}
+		}
+		privKeys = append(privKeys, privKey)
+	}
+
+	var added []string
+	for i, key := range keys {
+		keyID := privKeys[i].ID()
+		if r.cryptoService.GetKey(keyID) != nil {
+			continue
+		}
+		if err := r.cryptoService.AddKey(key.Role, key.GUN, privKeys[i]); err != nil {
+			for _, addedID := range added {
+				r.cryptoService.RemoveKey(addedID)
+			}
+			return err
+		}
+		added = append(added, keyID)
+	}
+	return nil
+}
+
+// keyBackupCipher derives the AEAD which encrypts an archive from the
+// passphrase and the parameters in the archive's header
+func keyBackupCipher(passphrase string, header keyBackupHeader) (cipher.AEAD, error) {
+	key := pbkdf2.Key([]byte(passphrase), header.Salt[:], int(header.Iterations), 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// unmarshalKeyBackupHeader reads and checks the header at the start of an
+// archive
+func unmarshalKeyBackupHeader(archive []byte) (keyBackupHeader, error) {
+	var header keyBackupHeader
+	if err := binary.Read(bytes.NewReader(archive), binary.BigEndian, &header); err != nil ||
+		string(header.Magic[:]) != keyBackupMagic {
+		return header, ErrInvalidKeyBackup{Reason: "not a key backup archive"}
+	}
+	if header.Version != keyBackupVersion {
+		return header, ErrInvalidKeyBackup{Reason: fmt.Sprintf("unsupported archive version %d", header.Version)}
+	}
+	if header.Iterations == 0 || header.Iterations > 100*keyBackupIterations {
+		return header, ErrInvalidKeyBackup{Reason: fmt.Sprintf("unreasonable key derivation iterations %d", header.Iterations)}
+	}
+	return header, nil
+}
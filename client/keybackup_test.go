@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Every key, with its role and GUN, is restored from a backup into an empty
+// crypto service, which can then publish to the repository
+func TestBackupRestoreKeysRoundTrip(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	createKey(t, repo, "targets/releases", false)
+	require.NoError(t, repo.Publish())
+
+	var archive bytes.Buffer
+	require.NoError(t, repo.BackupKeys(&archive, "backup passphrase"))
+	require.Error(t, repo.BackupKeys(&bytes.Buffer{}, ""))
+
+	restored, restoredDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(restoredDir)
+	require.Empty(t, restored.GetCryptoService().ListAllKeys())
+	require.NoError(t, restored.RestoreKeys(bytes.NewReader(archive.Bytes()), "backup passphrase"))
+
+	original := repo.GetCryptoService().ListAllKeys()
+	require.Len(t, original, 4)
+	require.Equal(t, original, restored.GetCryptoService().ListAllKeys())
+	for keyID := range original {
+		expected, err := repo.cryptoService.(keyInfoService).GetKeyInfo(keyID)
+		require.NoError(t, err)
+		info, err := restored.cryptoService.(keyInfoService).GetKeyInfo(keyID)
+		require.NoError(t, err)
+		require.Equal(t, expected, info)
+	}
+
+	// restoring again leaves the keys as they are
+	require.NoError(t, restored.RestoreKeys(bytes.NewReader(archive.Bytes()), "backup passphrase"))
+	require.Equal(t, original, restored.GetCryptoService().ListAllKeys())
+
+	addTarget(t, restored, "restored", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, restored.Publish())
+}
+
+// A wrong passphrase, or an altered or foreign archive, fails cleanly without
+// importing any key
+func TestRestoreKeysRejectsWrongPassphrase(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	var archive bytes.Buffer
+	require.NoError(t, repo.BackupKeys(&archive, "backup passphrase"))
+
+	restored, restoredDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(restoredDir)
+
+	err := restored.RestoreKeys(bytes.NewReader(archive.Bytes()), "wrong passphrase")
+	require.Equal(t, ErrKeyBackupUnauthenticated{}, err)
+	require.Empty(t, restored.GetCryptoService().ListAllKeys())
+
+	// altering the header, which is authenticated too, or the keys
+	for _, offset := range []int{len(keyBackupMagic) + 7, archive.Len() - 1} {
+		altered := append([]byte{}, archive.Bytes()...)
+		altered[offset] ^= 0xff
+		err = restored.RestoreKeys(bytes.NewReader(altered), "backup passphrase")
+		require.Equal(t, ErrKeyBackupUnauthenticated{}, err)
+	}
+	require.Empty(t, restored.GetCryptoService().ListAllKeys())
+
+	err = restored.RestoreKeys(bytes.NewReader([]byte("not a backup")), "backup passphrase")
+	require.IsType(t, ErrInvalidKeyBackup{}, err)
+
+	// a newer archive version is refused rather than misread
+	newer := append([]byte{}, archive.Bytes()...)
+	newer[len(keyBackupMagic)+1]++
+	err = restored.RestoreKeys(bytes.NewReader(newer), "backup passphrase")
+	require.Equal(t, ErrInvalidKeyBackup{Reason: "unsupported archive version 2"}, err)
+	require.Empty(t, restored.GetCryptoService().ListAllKeys())
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// KeyRule constrains the keys of a role.  Empty fields impose no constraint.
+type KeyRule struct {
+	// Algorithms are the allowed key algorithms, such as data.RSAKey.  Keys
+	// in certificates are treated as keys of the certificate's algorithm.
+	Algorithms []string
+	// MinRSABits is the smallest allowed RSA modulus, in bits
+	MinRSABits int
+	// Curves are the names of the allowed elliptic curves for ECDSA keys, such
+	// as "P-384"
+	Curves []string
+}
+
+// KeyPolicy constrains the keys of the roles of a repository
+type KeyPolicy struct {
+	// Roles are the rules for specific roles, including delegations
+	Roles map[data.RoleName]KeyRule
+	// Default, if set, is the rule for roles not listed in Roles
+	Default *KeyRule
+}
+
+// KeyPolicyViolation describes a key of a role which does not satisfy the
+// rule for the role
+type KeyPolicyViolation struct {
+	Role   data.RoleName
+	KeyID  string
+	Reason string
+}
+
+// ErrKeyPolicyViolations is returned by EnforceKeyPolicy when any key of any
+// role does not satisfy the policy
+type ErrKeyPolicyViolations struct {
+	Violations []KeyPolicyViolation
+}
+
+func (err ErrKeyPolicyViolations) Error() string {
+	reasons := make([]string, 0, len(err.Violations))
+	for _, v := range err.Violations {
+		reasons = append(reasons, fmt.Sprintf("%s key %s %s", v.Role, v.KeyID, v.Reason))
+	}
+	return fmt.Sprintf("%d key(s) violate the key policy: %s", len(err.Violations), strings.Join(reasons, "; "))
+}
+
+// EnforceKeyPolicy updates the repository, and then checks every key of every
+// role in the trust data, including the delegations, against the rule the
+// policy has for the role.  If any key violates its rule, an
+// ErrKeyPolicyViolations listing every violation is returned, with the base
+// roles first, then the delegations in order of name, and the keys of each
+// role in order of ID.
+func (r *repository) EnforceKeyPolicy(policy KeyPolicy) error {
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	return policy.enforce(r.tufRepo)
+}
+
+// enforce checks every key of every role in the repo against the policy
+func (p KeyPolicy) enforce(repo *tuf.Repo) error {
+	var violations []KeyPolicyViolation
+	for _, role := range data.BaseRoles {
+		baseRole, err := repo.GetBaseRole(role)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, p.check(role, baseRole.Keys)...)
+	}
+
+	var delegationViolations []KeyPolicyViolation
+	for _, tgts := range repo.Targets {
+		for _, delegation := range tgts.Signed.Delegations.Roles {
+			keys := make(map[string]data.PublicKey, len(delegation.KeyIDs))
+			for _, keyID := range delegation.KeyIDs {
+				if key, ok := tgts.Signed.Delegations.Keys[keyID]; ok {
+					keys[keyID] = key
+				}
+			}
+			delegationViolations = append(delegationViolations, p.check(delegation.Name, keys)...)
+		}
+	}
+	sort.SliceStable(delegationViolations, func(i, j int) bool {
+		return delegationViolations[i].Role < delegationViolations[j].Role
+	})
+	violations = append(violations, delegationViolations...)
+
+	if len(violations) > 0 {
+		return ErrKeyPolicyViolations{Violations: violations}
+	}
+	return nil
+}
+
+// check returns the violations of the rule for the role by its keys
+func (p KeyPolicy) check(role data.RoleName, keys map[string]data.PublicKey) []KeyPolicyViolation {
+	rule, ok := p.Roles[role]
+	if !ok {
+		if p.Default == nil {
+			return nil
+		}
+		rule = *p.Default
+	}
+
+	keyIDs := make([]string, 0, len(keys))
+	for keyID := range keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	var violations []KeyPolicyViolation
+	for _, keyID := range keyIDs {
+		if reason := rule.violation(keys[keyID]); reason != "" {
+			violations = append(violations, KeyPolicyViolation{Role: role, KeyID: keyID, Reason: reason})
+		}
+	}
+	return violations
+}
+
+// violation returns why the key violates the rule, or "" if it doesn't
+func (k KeyRule) violation(key data.PublicKey) string {
+	algorithm := key.Algorithm()
+	var public interface{}
+	switch algorithm {
+	case data.RSAx509Key, data.ECDSAx509Key:
+		algorithm = strings.TrimSuffix(algorithm, "-x509")
+		cert, err := utils.LoadCertFromPEM(key.Public())
+		if err != nil {
+			return fmt.Sprintf("has an unreadable certificate: %s", err)
+		}
+		public = cert.PublicKey
+	case data.RSAKey, data.ECDSAKey:
+		parsed, err := x509.ParsePKIXPublicKey(key.Public())
+		if err != nil {
+			return fmt.Sprintf("is unreadable: %s", err)
+		}
+		public = parsed
+	}
+
+	if len(k.Algorithms) > 0 && !utils.StrSliceContains(k.Algorithms, algorithm) {
+		return fmt.Sprintf("uses %s, which is not one of the allowed algorithms %s", algorithm, strings.Join(k.Algorithms, ", "))
+	}
+	switch typed := public.(type) {
+	case *rsa.PublicKey:
+		if bits := typed.N.BitLen(); bits < k.MinRSABits {
+			return fmt.Sprintf("is %d bits, but must be at least %d bits", bits, k.MinRSABits)
+		}
+	case *ecdsa.PublicKey:
+		if curve := typed.Curve.Params().Name; len(k.Curves) > 0 && !utils.StrSliceContains(k.Curves, curve) {
+			return fmt.Sprintf("uses curve %s, which is not one of the allowed curves %s", curve, strings.Join(k.Curves, ", "))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	testkeys "github.com/theupdateframework/notary/tuf/testutils/keys"
+)
+
+// A repository whose keys all satisfy the policy passes, and one whose root
+// and delegation keys don't reports each violation
+func TestEnforceKeyPolicy(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	rsaKey, err := testkeys.GetRSAKey(2048)
+	require.NoError(t, err)
+	rsaPub := data.PublicKeyFromPrivate(rsaKey)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{rsaPub}, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	// every key is a P-256 ECDSA key, apart from the 2048 bit RSA delegation key
+	require.NoError(t, repo.EnforceKeyPolicy(KeyPolicy{
+		Roles: map[data.RoleName]KeyRule{
+			"targets/a": {Algorithms: []string{data.RSAKey}, MinRSABits: 2048},
+		},
+		Default: &KeyRule{Algorithms: []string{data.ECDSAKey}, Curves: []string{"P-256"}},
+	}))
+
+	rootKeyIDs := repo.tufRepo.Root.Signed.Roles[data.CanonicalRootRole].KeyIDs
+	require.Len(t, rootKeyIDs, 1)
+	err = repo.EnforceKeyPolicy(KeyPolicy{
+		Roles: map[data.RoleName]KeyRule{
+			data.CanonicalRootRole: {Algorithms: []string{data.RSAKey}, MinRSABits: 4096},
+			"targets/a":            {MinRSABits: 4096},
+		},
+	})
+	require.IsType(t, ErrKeyPolicyViolations{}, err)
+	violations := err.(ErrKeyPolicyViolations).Violations
+	require.Len(t, violations, 2)
+	require.Equal(t, data.CanonicalRootRole, violations[0].Role)
+	require.Equal(t, rootKeyIDs[0], violations[0].KeyID)
+	require.True(t, strings.Contains(violations[0].Reason, data.ECDSAKey))
+	require.Equal(t, data.RoleName("targets/a"), violations[1].Role)
+	require.Equal(t, rsaPub.ID(), violations[1].KeyID)
+	require.True(t, strings.Contains(violations[1].Reason, "2048"))
+
+	// no P-256 keys are allowed for any role
+	err = repo.EnforceKeyPolicy(KeyPolicy{Default: &KeyRule{Curves: []string{"P-384", "P-521"}}})
+	require.IsType(t, ErrKeyPolicyViolations{}, err)
+	violations = err.(ErrKeyPolicyViolations).Violations
+	require.Len(t, violations, len(data.BaseRoles))
+	for i, role := range data.BaseRoles {
+		require.Equal(t, role, violations[i].Role)
+		require.True(t, strings.Contains(violations[i].Reason, "P-256"))
+	}
+}
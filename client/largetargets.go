@@ -0,0 +1,56 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// largeTargetsThreshold is how many targets a targets role must list for its
+// metadata to be built by largeTargetsJSON when publishing
+var largeTargetsThreshold = 10000
+
+// htmlEscapedChars are the characters json.Marshal escapes in the JSON it
+// encodes, even in a json.RawMessage
+const htmlEscapedChars = "<>&\u2028\u2029"
+
+// largeTargetsJSON signs and serializes the metadata for a targets role,
+// producing exactly the bytes serializeCanonicalRole would, but allocating
+// about half the memory for roles with very many targets.  It is an allocation
+// optimisation, not streaming: the targets, and the serialized metadata, are
+// still all held in memory.  What it saves is serializing the signed portion
+// into one buffer, and then having json.Marshal copy and re-encode it along
+// with the signatures.  Instead the signed portion is built one target at a
+// time straight into the output, where it is signed, and the signatures are
+// then written after it.  Only if the signed portion has characters which
+// json.Marshal would escape is it copied again, to escape them.
+func largeTargetsJSON(tufRepo *tuf.Repo, role data.RoleName) ([]byte, error) {
+	const prefix, separator, suffix = `{"signed":`, `,"signatures":`, `}`
+	out := &bytes.Buffer{}
+	out.WriteString(prefix)
+	s, err := tufRepo.SignTargetsStreamed(role, data.DefaultExpires(data.CanonicalTargetsRole), out)
+	if err != nil {
+		return nil, err
+	}
+	sigs, err := json.Marshal(s.Signatures)
+	if err != nil {
+		return nil, err
+	}
+
+	// the signed portion is already compact canonical JSON, so escaping it is
+	// all that encoding it with json.Marshal would do, and most signed portions
+	// have nothing to escape
+	if bytes.ContainsAny(*s.Signed, htmlEscapedChars) {
+		escaped := &bytes.Buffer{}
+		escaped.Grow(out.Len() + len(separator) + len(sigs) + len(suffix))
+		escaped.WriteString(prefix)
+		json.HTMLEscape(escaped, *s.Signed)
+		out = escaped
+	}
+	out.WriteString(separator)
+	out.Write(sigs)
+	out.WriteString(suffix)
+	return out.Bytes(), nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// repoWithTargets returns a repo whose targets role lists n targets
+func repoWithTargets(t testing.TB, n int) *tuf.Repo {
+	repo, _, err := testutils.EmptyRepo("docker.com/notary")
+	require.NoError(t, err)
+	files := make(data.Files, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("releases/%d/latest", i)
+		files[name] = data.FileMeta{Length: int64(i), Hashes: data.Hashes{"sha256": []byte(name)}}
+	}
+	_, err = repo.AddTargets(data.CanonicalTargetsRole, files)
+	require.NoError(t, err)
+	return repo
+}
+
+// The build for large targets roles produces exactly what the usual build
+// does, and is used for targets roles with enough targets
+func TestLargeTargetsJSONMatchesUsualBuild(t *testing.T) {
+	repo := repoWithTargets(t, 1000)
+
+	built, err := largeTargetsJSON(repo, data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	s, err := repo.Targets[data.CanonicalTargetsRole].ToSigned()
+	require.NoError(t, err)
+	inMemory, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.Equal(t, string(inMemory), string(built))
+
+	// json.Marshal escapes some characters the canonical JSON does not
+	_, err = repo.AddTargets(data.CanonicalTargetsRole, data.Files{
+		"<html>&\u2028": {Length: 1, Hashes: data.Hashes{"sha256": []byte("html")}},
+	})
+	require.NoError(t, err)
+	built, err = largeTargetsJSON(repo, data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	s, err = repo.Targets[data.CanonicalTargetsRole].ToSigned()
+	require.NoError(t, err)
+	inMemory, err = json.Marshal(s)
+	require.NoError(t, err)
+	require.Equal(t, string(inMemory), string(built))
+	require.Contains(t, string(built), `\u003chtml\u003e\u0026\u2028`)
+
+	defer func(threshold int) { largeTargetsThreshold = threshold }(largeTargetsThreshold)
+	largeTargetsThreshold = 1000
+	serialized, err := serializeCanonicalRole(repo, data.CanonicalTargetsRole, nil)
+	require.NoError(t, err)
+	s, err = repo.Targets[data.CanonicalTargetsRole].ToSigned()
+	require.NoError(t, err)
+	inMemory, err = json.Marshal(s)
+	require.NoError(t, err)
+	require.Equal(t, string(inMemory), string(serialized))
+}
+
+// Targets published with the build for large targets roles can be read by
+// another client
+func TestPublishLargeTargets(t *testing.T) {
+	defer func(threshold int) { largeTargetsThreshold = threshold }(largeTargetsThreshold)
+	largeTargetsThreshold = 1
+
+	ts := fullTestServer(t)
+	defer ts.Close()
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "<latest>", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	other, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+	targets, err := other.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+}
+
+// BenchmarkSerializeTargets compares the memory allocated building the
+// metadata of a targets role with very many targets as usual and as a large
+// targets role
+func BenchmarkSerializeTargets(b *testing.B) {
+	repo := repoWithTargets(b, 100000)
+	defer func(threshold int) { largeTargetsThreshold = threshold }(largeTargetsThreshold)
+
+	for name, threshold := range map[string]int{"usual": 100001, "large": 1} {
+		b.Run(name, func(b *testing.B) {
+			largeTargetsThreshold = threshold
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := serializeCanonicalRole(repo, data.CanonicalTargetsRole, nil)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrInvalidGUN is returned when a GUN cannot be mapped to a directory within
+// a trust directory
+type ErrInvalidGUN struct {
+	GUN data.GUN
+}
+
+func (err ErrInvalidGUN) Error() string {
+	return fmt.Sprintf("%q is not a valid GUN for a trust directory", err.GUN.String())
+}
+
+// TrustLayout describes where the trust data for a GUN is stored under a
+// trust directory
+type TrustLayout struct {
+	// Metadata is the directory holding the cached TUF metadata of the GUN
+	Metadata string
+	// Keys is the directory holding the private keys.  It is shared by every
+	// GUN in the trust directory, so it also holds keys for other GUNs.
+	Keys string
+	// Changelist is the directory holding the unpublished changes of the GUN
+	Changelist string
+	// PublishJournal is the file holding the signed metadata of a publish of
+	// the GUN which the server has not yet acknowledged
+	PublishJournal string
+}
+
+// TrustDirLayout returns the paths under the trust directory baseDir at which
+// a file cached repository, as created by NewFileCachedRepository, stores the
+// trust data of the GUN.  The paths are not required to exist.
+func TrustDirLayout(baseDir, gun string) (TrustLayout, error) {
+	gunDir, err := gunTUFDir(baseDir, data.GUN(gun))
+	if err != nil {
+		return TrustLayout{}, err
+	}
+	return TrustLayout{
+		Metadata:       filepath.Join(gunDir, "metadata"),
+		Keys:           filepath.Join(baseDir, notary.PrivDir),
+		Changelist:     filepath.Join(gunDir, "changelist"),
+		PublishJournal: filepath.Join(gunDir, "publish_journal.json"),
+	}, nil
+}
+
+// gunTUFDir returns the directory holding the metadata and changelist of the
+// GUN, refusing any GUN which would resolve to a directory outside of the TUF
+// directory of baseDir
+func gunTUFDir(baseDir string, gun data.GUN) (string, error) {
+	tufBase := filepath.Join(baseDir, tufDir)
+	gunDir := filepath.Join(tufBase, filepath.FromSlash(gun.String()))
+	rel, err := filepath.Rel(tufBase, gunDir)
+	if gun == "" || err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidGUN{GUN: gun}
+	}
+	return gunDir, nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// After initializing a repository, the metadata, keys and changelist
+// directories returned for the GUN exist and hold its trust data
+func TestTrustDirLayoutAfterInit(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	gun := "docker.com/notary"
+	repo, rootKeyID, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+
+	layout, err := TrustDirLayout(baseDir, gun)
+	require.NoError(t, err)
+	for _, dir := range []string{layout.Metadata, layout.Keys, layout.Changelist} {
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+	}
+
+	_, err = os.Stat(filepath.Join(layout.Metadata, data.CanonicalRootRole.String()+".json"))
+	require.NoError(t, err)
+
+	found := false
+	require.NoError(t, filepath.Walk(layout.Keys, func(path string, info os.FileInfo, err error) error {
+		if err == nil && filepath.Base(path) == rootKeyID+".key" {
+			found = true
+		}
+		return err
+	}))
+	require.True(t, found, "root key not found under %s", layout.Keys)
+
+	changes, err := os.ReadDir(layout.Changelist)
+	require.NoError(t, err)
+	require.Len(t, changes, len(getChanges(t, repo)))
+}
+
+// GUNs which do not map to a directory within the trust directory are rejected
+func TestTrustDirLayoutInvalidGUN(t *testing.T) {
+	for _, gun := range []string{"", ".", "..", "../other", "docker.com/../../other"} {
+		_, err := TrustDirLayout("/trust", gun)
+		require.IsType(t, ErrInvalidGUN{}, err, "GUN %q", gun)
+	}
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// delegationsToDownload filters the delegations of a role which has just been
+// downloaded down to those an update should download too.  Unless delegations
+// are lazy, that is all of them.
+func (o UpdateOptions) delegationsToDownload(children []data.DelegationRole) []data.DelegationRole {
+	if !o.LazyDelegations {
+		return children
+	}
+	wanted := make([]data.DelegationRole, 0, len(children))
+	for _, child := range children {
+		for resolved := range o.resolvedDelegations {
+			if resolved == child.Name || strings.HasPrefix(resolved.String(), child.Name.String()+"/") {
+				wanted = append(wanted, child)
+				break
+			}
+		}
+	}
+	return wanted
+}
+
+// ResolveDelegation downloads and verifies the metadata of a delegation, and of
+// the delegations between it and the targets role, so that its targets can be
+// looked up.  It is needed only when UpdateOptions.LazyDelegations is set,
+// after which every update keeps the resolved delegation up to date.  The
+// metadata is verified against the snapshot like that of any other role, and
+// an error is returned if it does not verify, or if the role is not delegated
+// to.
+func (r *repository) ResolveDelegation(role string) error {
+	roleName := data.RoleName(role)
+	if !data.IsDelegation(roleName) {
+		return data.ErrInvalidRole{Role: roleName, Reason: "only delegations can be resolved"}
+	}
+	if r.resolvedDelegations == nil {
+		r.resolvedDelegations = make(map[data.RoleName]bool)
+	}
+	r.resolvedDelegations[roleName] = true
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	if _, ok := r.tufRepo.Targets[roleName]; ok {
+		return nil
+	}
+
+	if _, err := r.tufRepo.GetDelegationRole(roleName); err != nil {
+		delete(r.resolvedDelegations, roleName)
+		return err
+	}
+	if _, published := r.tufRepo.Snapshot.Signed.Meta[role]; published {
+		delete(r.resolvedDelegations, roleName)
+		return data.ErrInvalidRole{Role: roleName, Reason: "its metadata could not be verified"}
+	}
+	// the role is delegated to, but has not been published yet, so it has no
+	// targets to resolve until it is
+	return nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// recordingStore records the names of the metadata read from it
+type recordingStore struct {
+	store.MetadataStore
+	mu    sync.Mutex
+	names []string
+}
+
+func (s *recordingStore) GetSized(name string, size int64) ([]byte, error) {
+	s.mu.Lock()
+	s.names = append(s.names, name)
+	s.mu.Unlock()
+	return s.MetadataStore.GetSized(name, size)
+}
+
+// fetched determines whether the metadata of a role, under any name, was read
+func (s *recordingStore) fetched(role data.RoleName) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range s.names {
+		if name == role.String() || strings.HasPrefix(name, role.String()+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// With lazy delegations, an update downloads no delegations until one is
+// resolved, and then only that delegation and its ancestors
+func TestLazyDelegations(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	served := &recordingStore{MetadataStore: serverSwizzler.MetadataCache}
+	ts := readOnlyServer(t, served, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetUpdateOptions(UpdateOptions{LazyDelegations: true})
+
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.True(t, served.fetched(data.CanonicalTargetsRole))
+	for _, role := range metadataDelegations {
+		require.False(t, served.fetched(role), "%s should not have been fetched", role)
+	}
+	require.Len(t, repo.tufRepo.Targets, 1)
+
+	require.NoError(t, repo.ResolveDelegation("targets/a/b"))
+	require.True(t, served.fetched("targets/a"))
+	require.True(t, served.fetched("targets/a/b"))
+	require.False(t, served.fetched("targets/b"))
+	require.False(t, served.fetched("targets/a/b/c"))
+	require.Contains(t, repo.tufRepo.Targets, data.RoleName("targets/a/b"))
+
+	// resolved delegations stay loaded on later updates
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	require.Contains(t, repo.tufRepo.Targets, data.RoleName("targets/a/b"))
+	require.NotContains(t, repo.tufRepo.Targets, data.RoleName("targets/b"))
+
+	// a role which is not delegated to can't be resolved
+	err = repo.ResolveDelegation("targets/z")
+	require.Error(t, err)
+	require.Error(t, repo.ResolveDelegation("snapshot"))
+}
+
+// A resolved delegation must verify against the snapshot and its delegator
+func TestResolveDelegationInvalid(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetUpdateOptions(UpdateOptions{LazyDelegations: true})
+
+	// metadata which is larger than the snapshot says fails the update
+	require.NoError(t, serverSwizzler.AddExtraSpace("targets/b"))
+	err := repo.ResolveDelegation("targets/b")
+	require.Error(t, err)
+	require.IsType(t, store.ErrMaliciousServer{}, err)
+
+	// metadata which matches the snapshot, but isn't validly signed, is not
+	// loaded
+	require.NoError(t, serverSwizzler.InvalidateMetadataSignatures("targets/b"))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+	err = repo.ResolveDelegation("targets/b")
+	require.Error(t, err)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+	require.NotContains(t, repo.tufRepo.Targets, data.RoleName("targets/b"))
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Formats of the manifests accepted by AddTargetsFromManifest
+const (
+	// ManifestSHA256Sum is the output of sha256sum, one "<hex sha256>  <path>"
+	// line per file, where binary mode lines mark the path with "*" instead
+	// of the second space
+	ManifestSHA256Sum = "sha256sum"
+	// ManifestJSON is a JSON list of objects, each with the "name" and
+	// "length" of a target and its hex encoded "sha256", and optionally its
+	// "sha512"
+	ManifestJSON = "json"
+)
+
+// ErrMalformedManifest is returned by AddTargetsFromManifest when a manifest
+// can't be parsed
+type ErrMalformedManifest struct {
+	Line   int
+	Reason string
+}
+
+func (err ErrMalformedManifest) Error() string {
+	return fmt.Sprintf("malformed manifest at line %d: %s", err.Line, err.Reason)
+}
+
+var sha256SumLine = regexp.MustCompile(`^([0-9a-fA-F]{64}) [ *](.+)$`)
+
+// manifestEntry is a target listed in a JSON manifest
+type manifestEntry struct {
+	Name   string `json:"name"`
+	Length int64  `json:"length"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+}
+
+// AddTargetsFromManifest parses a manifest of existing files in the given
+// format, and creates changelist entries to add each of them as a target of
+// role, returning how many targets were staged.  Since sha256sum output does
+// not record lengths, each file it lists must exist at its path, which is
+// relative to the working directory as for "sha256sum -c", to find its length.
+// The hashes are always taken from the manifest.  Nothing is staged unless the
+// whole manifest parses, and otherwise ErrMalformedManifest reports the first
+// malformed line.
+func (r *repository) AddTargetsFromManifest(role string, manifest io.Reader, format string) (int, error) {
+	var (
+		targets []*Target
+		err     error
+	)
+	switch format {
+	case ManifestSHA256Sum:
+		targets, err = parseSHA256SumManifest(manifest)
+	case ManifestJSON:
+		targets, err = parseJSONManifest(manifest)
+	default:
+		return 0, fmt.Errorf("unsupported manifest format %q", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for i, target := range targets {
+		if err := r.AddTarget(target, data.RoleName(role)); err != nil {
+			return i, err
+		}
+	}
+	return len(targets), nil
+}
+
+func parseSHA256SumManifest(manifest io.Reader) ([]*Target, error) {
+	var targets []*Target
+	scanner := bufio.NewScanner(manifest)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if len(bytes.TrimSpace([]byte(text))) == 0 {
+			continue
+		}
+		match := sha256SumLine.FindStringSubmatch(text)
+		if match == nil {
+			return nil, ErrMalformedManifest{Line: line, Reason: "expected a sha256 checksum followed by a path"}
+		}
+		checksum, _ := hex.DecodeString(match[1])
+		path := match[2]
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, ErrMalformedManifest{Line: line, Reason: fmt.Sprintf("unable to find the length of %s: %s", path, err)}
+		}
+		if info.IsDir() {
+			return nil, ErrMalformedManifest{Line: line, Reason: fmt.Sprintf("%s is a directory", path)}
+		}
+		targets = append(targets, &Target{
+			Name:   filepath.ToSlash(path),
+			Hashes: data.Hashes{notary.SHA256: checksum},
+			Length: info.Size(),
+		})
+	}
+	return targets, scanner.Err()
+}
+
+func parseJSONManifest(manifest io.Reader) ([]*Target, error) {
+	raw, err := ioutil.ReadAll(manifest)
+	if err != nil {
+		return nil, err
+	}
+	// lineAt finds the line of a position in the manifest, skipping the
+	// whitespace and separators before the value which starts there
+	lineAt := func(offset int64) int {
+		for offset < int64(len(raw)) && bytes.IndexByte([]byte(" \t\r\n,"), raw[offset]) >= 0 {
+			offset++
+		}
+		return bytes.Count(raw[:offset], []byte("\n")) + 1
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, ErrMalformedManifest{Line: lineAt(0), Reason: "expected a JSON list of targets"}
+	}
+	var targets []*Target
+	for decoder.More() {
+		line := lineAt(decoder.InputOffset())
+		var entry manifestEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, ErrMalformedManifest{Line: line, Reason: err.Error()}
+		}
+		target, reason := entry.toTarget()
+		if target == nil {
+			return nil, ErrMalformedManifest{Line: line, Reason: reason}
+		}
+		targets = append(targets, target)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, ErrMalformedManifest{Line: lineAt(decoder.InputOffset()), Reason: err.Error()}
+	}
+	return targets, nil
+}
+
+// toTarget validates a JSON manifest entry, returning why it's invalid if it is
+func (e manifestEntry) toTarget() (*Target, string) {
+	if e.Name == "" {
+		return nil, "the target has no name"
+	}
+	if e.Length <= 0 {
+		return nil, fmt.Sprintf("%s has no length", e.Name)
+	}
+	hashes := make(data.Hashes)
+	for alg, encoded := range map[string]string{notary.SHA256: e.SHA256, notary.SHA512: e.SHA512} {
+		if encoded == "" {
+			continue
+		}
+		checksum, err := hex.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Sprintf("%s has an invalid %s checksum", e.Name, alg)
+		}
+		hashes[alg] = checksum
+	}
+	if _, ok := hashes[notary.SHA256]; !ok {
+		return nil, fmt.Sprintf("%s has no sha256 checksum", e.Name)
+	}
+	if err := data.CheckValidHashStructures(hashes); err != nil {
+		return nil, err.Error()
+	}
+	return &Target{Name: e.Name, Hashes: hashes, Length: e.Length}, ""
+}
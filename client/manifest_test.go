@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func sha256Hex(t *testing.T, path string) (string, []byte) {
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	checksum := sha256.Sum256(content)
+	return hex.EncodeToString(checksum[:]), content
+}
+
+// Every file listed by sha256sum is staged with its checksum and length, and
+// the staged targets publish
+func TestAddTargetsFromSHA256SumManifest(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	intermediate, intermediateContent := sha256Hex(t, "../fixtures/intermediate-ca.crt")
+	root, rootContent := sha256Hex(t, "../fixtures/root-ca.crt")
+
+	// the listed paths name the targets, so copies of the fixtures are listed
+	// by absolute paths rather than by paths reaching up out of the root
+	dir, err := ioutil.TempDir("", "manifest-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	intermediatePath, rootPath := filepath.Join(dir, "intermediate-ca.crt"), filepath.Join(dir, "root-ca.crt")
+	require.NoError(t, ioutil.WriteFile(intermediatePath, intermediateContent, 0600))
+	require.NoError(t, ioutil.WriteFile(rootPath, rootContent, 0600))
+	manifest := fmt.Sprintf("%s  %s\n\n%s *%s\n", intermediate, intermediatePath, root, rootPath)
+
+	count, err := repo.AddTargetsFromManifest(data.CanonicalTargetsRole.String(), strings.NewReader(manifest), ManifestSHA256Sum)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.NoError(t, repo.Publish())
+
+	target, err := repo.GetTargetByName(intermediatePath)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(intermediateContent)), target.Length)
+	require.NoError(t, verifyTargetContent(target.Target, bytes.NewReader(intermediateContent)))
+	_, err = repo.GetTargetByName(rootPath)
+	require.NoError(t, err)
+
+	// malformed lines are reported by line number, and nothing is staged
+	for line, manifest := range map[int]string{
+		2: fmt.Sprintf("%s  %s\nnot a checksum line\n", root, rootPath),
+		1: fmt.Sprintf("%s  %s\n", root, filepath.Join(dir, "nonexistent")),
+	} {
+		count, err = repo.AddTargetsFromManifest(data.CanonicalTargetsRole.String(), strings.NewReader(manifest), ManifestSHA256Sum)
+		require.Error(t, err)
+		require.IsType(t, ErrMalformedManifest{}, err)
+		require.Equal(t, line, err.(ErrMalformedManifest).Line)
+		require.Equal(t, 0, count)
+	}
+	cl, err := repo.GetChangelist()
+	require.NoError(t, err)
+	require.Len(t, cl.List(), 0)
+}
+
+// Every target listed in a JSON manifest is staged, and malformed entries are
+// reported by line number
+func TestAddTargetsFromJSONManifest(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	checksum, content := sha256Hex(t, "../fixtures/intermediate-ca.crt")
+	manifest := fmt.Sprintf(`[
+  {"name": "first", "length": %d, "sha256": "%s"},
+  {"name": "second", "length": %d, "sha256": "%s"}
+]`, len(content), checksum, len(content), checksum)
+
+	count, err := repo.AddTargetsFromManifest(data.CanonicalTargetsRole.String(), strings.NewReader(manifest), ManifestJSON)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	cl, err := repo.GetChangelist()
+	require.NoError(t, err)
+	require.Len(t, cl.List(), 2)
+	require.NoError(t, repo.Publish())
+
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	for _, target := range targets {
+		require.NoError(t, verifyTargetContent(target.Target, bytes.NewReader(content)))
+	}
+
+	for line, manifest := range map[int]string{
+		3: fmt.Sprintf("[\n  {\"name\": \"first\", \"length\": %d, \"sha256\": \"%s\"},\n  {\"name\": \"second\", \"length\": %d}\n]",
+			len(content), checksum, len(content)),
+		2: "[\n  {\"name\": \"first\", \"length\": 1, \"sha256\": \"abcd\"}\n]",
+		1: `{"name": "first"}`,
+	} {
+		count, err = repo.AddTargetsFromManifest(data.CanonicalTargetsRole.String(), strings.NewReader(manifest), ManifestJSON)
+		require.Error(t, err)
+		require.IsType(t, ErrMalformedManifest{}, err)
+		require.Equal(t, line, err.(ErrMalformedManifest).Line)
+		require.Equal(t, 0, count)
+	}
+
+	_, err = repo.AddTargetsFromManifest(data.CanonicalTargetsRole.String(), strings.NewReader(manifest), "yaml")
+	require.Error(t, err)
+}
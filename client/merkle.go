@@ -0,0 +1,104 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/translog"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// TargetMerkleProof proves that a target's entry is included in the Merkle
+// tree whose root is returned by TargetsMerkleRoot
+type TargetMerkleProof struct {
+	Target Target                  `json:"target"`
+	Proof  translog.InclusionProof `json:"proof"`
+}
+
+// merkleLeaf is a target's entry as a leaf of the targets Merkle tree
+type merkleLeaf struct {
+	Name string        `json:"name"`
+	Meta data.FileMeta `json:"meta"`
+}
+
+// targetMerkleLeaf returns the canonical JSON encoding of a target's entry,
+// which is hashed as its leaf of the tree
+func targetMerkleLeaf(target Target) ([]byte, error) {
+	return canonicaljson.MarshalCanonical(merkleLeaf{
+		Name: target.Name,
+		Meta: data.FileMeta{Length: target.Length, Hashes: target.Hashes, Custom: target.Custom},
+	})
+}
+
+// TargetsMerkleRoot updates the repository, and returns the root of a Merkle
+// tree over every target, as listed by ListTargets, as a single commitment to
+// the whole set of targets.  The leaves are the canonical JSON encodings of
+// the targets' names and entries, in order of name, hashed as in RFC 6962, so
+// the same set of targets always has the same root.  See
+// TargetInclusionProof.
+func (r *repository) TargetsMerkleRoot() (data.Hashes, error) {
+	tree, _, err := r.targetsMerkleTree()
+	if err != nil {
+		return nil, err
+	}
+	return data.Hashes{notary.SHA256: tree.RootHash()}, nil
+}
+
+// TargetInclusionProof updates the repository, and returns a proof that the
+// named target is included in the tree whose root TargetsMerkleRoot returns,
+// which VerifyTargetInclusion checks.
+func (r *repository) TargetInclusionProof(name string) (*TargetMerkleProof, error) {
+	tree, targets, err := r.targetsMerkleTree()
+	if err != nil {
+		return nil, err
+	}
+	index := sort.Search(len(targets), func(i int) bool { return targets[i].Name >= name })
+	if index == len(targets) || targets[index].Name != name {
+		return nil, ErrNoSuchTarget(name)
+	}
+	proof, err := tree.Prove(int64(index))
+	if err != nil {
+		return nil, err
+	}
+	return &TargetMerkleProof{Target: targets[index], Proof: proof}, nil
+}
+
+// VerifyTargetInclusion verifies that a proof proves its target's entry to be
+// included in the targets Merkle tree with the given root
+func VerifyTargetInclusion(proof TargetMerkleProof, root data.Hashes) error {
+	rootHash, ok := root[notary.SHA256]
+	if !ok {
+		return fmt.Errorf("the targets Merkle root has no %s hash", notary.SHA256)
+	}
+	leaf, err := targetMerkleLeaf(proof.Target)
+	if err != nil {
+		return err
+	}
+	return translog.VerifyInclusion(leaf, proof.Proof, rootHash)
+}
+
+// targetsMerkleTree returns the Merkle tree over the repository's targets,
+// and the targets in the order of its leaves
+func (r *repository) targetsMerkleTree() (*translog.Tree, []Target, error) {
+	listed, err := r.ListTargets()
+	if err != nil {
+		return nil, nil, err
+	}
+	targets := make([]Target, 0, len(listed))
+	for _, target := range listed {
+		targets = append(targets, target.Target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+
+	tree := &translog.Tree{}
+	for _, target := range targets {
+		leaf, err := targetMerkleLeaf(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		tree.Append(leaf)
+	}
+	return tree, targets, nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/translog"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Every target, including delegated ones, has an inclusion proof which
+// verifies against the targets Merkle root, and the root commits to every
+// entry
+func TestTargetInclusionProofs(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.AddDelegation("targets/a",
+		[]data.PublicKey{createKey(t, repo, "targets/a", false)}, []string{""}))
+	names := []string{"e", "d", "c", "b", "a"}
+	for i, name := range names {
+		if i%2 == 0 {
+			addTarget(t, repo, name, "../fixtures/intermediate-ca.crt")
+		} else {
+			addTarget(t, repo, name, "../fixtures/root-ca.crt", "targets/a")
+		}
+	}
+	require.NoError(t, repo.Publish())
+
+	root, err := repo.TargetsMerkleRoot()
+	require.NoError(t, err)
+	for _, name := range names {
+		proof, err := repo.TargetInclusionProof(name)
+		require.NoError(t, err)
+		require.Equal(t, name, proof.Target.Name)
+		require.Equal(t, int64(len(names)), proof.Proof.TreeSize)
+		require.NoError(t, VerifyTargetInclusion(*proof, root))
+
+		// altering the entry, or the proof, fails verification
+		altered := *proof
+		altered.Target.Length++
+		require.IsType(t, translog.ErrInvalidProof{}, VerifyTargetInclusion(altered, root))
+		altered = *proof
+		altered.Proof.LeafIndex = (proof.Proof.LeafIndex + 1) % proof.Proof.TreeSize
+		require.IsType(t, translog.ErrInvalidProof{}, VerifyTargetInclusion(altered, root))
+	}
+
+	_, err = repo.TargetInclusionProof("missing")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+	proof, err := repo.TargetInclusionProof("a")
+	require.NoError(t, err)
+	require.Error(t, VerifyTargetInclusion(*proof, data.Hashes{"sha512": root["sha256"]}))
+
+	// the root is deterministic, and changes with the targets
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	readerRoot, err := reader.TargetsMerkleRoot()
+	require.NoError(t, err)
+	require.Equal(t, root, readerRoot)
+
+	require.NoError(t, repo.RemoveTarget("c"))
+	require.NoError(t, repo.Publish())
+	newRoot, err := repo.TargetsMerkleRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root, newRoot)
+	require.Error(t, VerifyTargetInclusion(*proof, newRoot))
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	store "github.com/theupdateframework/notary/storage"
+)
+
+// PublishOutcome is the result of publishing to a single server
+type PublishOutcome struct {
+	URL string
+	// Err is nil if the server accepted the published metadata, and otherwise
+	// describes why it didn't
+	Err error
+}
+
+// Succeeded returns true if the server accepted the published metadata
+func (o PublishOutcome) Succeeded() bool {
+	return o.Err == nil
+}
+
+// PublishToMirrors applies the local changes like Publish, but sends the same
+// signed metadata to each of the notary servers at urls, such as the servers of
+// a geo-redundant deployment, reporting for each whether it accepted the
+// metadata.  The metadata is signed once, after updating from the repository's
+// own server, which should normally be one of the urls.  An error is only
+// returned if the changes could not be signed, so failures of some servers
+// must be found in the outcomes.  The changelist is only cleared if every
+// server accepted the metadata, so that publishing again brings the servers
+// which failed up to date.
+func (r *repository) PublishToMirrors(urls []string) ([]PublishOutcome, error) {
+	if r.roundTrip == nil {
+		return nil, store.ErrOffline{}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no servers to publish to")
+	}
+	files, err := r.signChangelist(r.changelist)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]PublishOutcome, 0, len(urls))
+	allSucceeded := true
+	for _, url := range urls {
+		outcome := PublishOutcome{URL: url}
+		remote, err := getRemoteStore(url, r.gun, r.roundTrip)
+		if err == nil {
+			err = remote.SetMulti(files)
+		}
+		if err != nil {
+			logrus.Warnf("unable to publish %s to %s: %s", r.gun, url, err)
+			outcome.Err = err
+			allSucceeded = false
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	if allSucceeded {
+		if err := r.changelist.Clear(""); err != nil {
+			logrus.Warn("Unable to clear changelist. You may want to manually delete the folder ", r.changelist.Location())
+		}
+	}
+	return outcomes, nil
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// mirrorRecorder is a mock notary server which records the metadata published
+// to it, or rejects every publish
+type mirrorRecorder struct {
+	reject    bool
+	mu        sync.Mutex
+	published map[string][]byte
+}
+
+func (m *mirrorRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if m.reject {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = make(map[string][]byte)
+	for _, headers := range req.MultipartForm.File {
+		for _, header := range headers {
+			f, err := header.Open()
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, err := ioutil.ReadAll(f)
+			f.Close()
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			m.published[header.Filename] = content
+		}
+	}
+}
+
+// The metadata is signed once and sent to every server, with the failure of
+// one server reported alongside the successes of the others
+func TestPublishToMirrors(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	accepting := &mirrorRecorder{}
+	acceptingServer := httptest.NewServer(accepting)
+	defer acceptingServer.Close()
+	rejectingServer := httptest.NewServer(&mirrorRecorder{reject: true})
+	defer rejectingServer.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+
+	outcomes, err := repo.PublishToMirrors([]string{ts.URL, acceptingServer.URL, rejectingServer.URL})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+	require.Equal(t, ts.URL, outcomes[0].URL)
+	require.True(t, outcomes[0].Succeeded())
+	require.Equal(t, acceptingServer.URL, outcomes[1].URL)
+	require.True(t, outcomes[1].Succeeded())
+	require.Equal(t, rejectingServer.URL, outcomes[2].URL)
+	require.False(t, outcomes[2].Succeeded())
+	require.IsType(t, store.ErrInvalidOperation{}, outcomes[2].Err)
+
+	// the mirror received exactly the metadata the primary server did
+	primaryTargets, err := repo.getRemoteStore().GetSized(data.CanonicalTargetsRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, primaryTargets, accepting.published[data.CanonicalTargetsRole.String()])
+
+	// since one server failed, the changes remain to be published again
+	cl, err := repo.GetChangelist()
+	require.NoError(t, err)
+	require.Len(t, cl.List(), 1)
+
+	outcomes, err = repo.PublishToMirrors([]string{ts.URL, acceptingServer.URL})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 2)
+	for _, outcome := range outcomes {
+		require.True(t, outcome.Succeeded())
+	}
+	cl, err = repo.GetChangelist()
+	require.NoError(t, err)
+	require.Len(t, cl.List(), 0)
+
+	target, err := repo.GetTargetByName("current")
+	require.NoError(t, err)
+	require.Equal(t, "current", target.Name)
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// RoleKeyGap describes the keys authorized to sign a role whose private keys
+// the local crypto service does not hold
+type RoleKeyGap struct {
+	Role      data.RoleName
+	Threshold int
+	// Held is how many of the role's authorized keys are held locally
+	Held int
+	// Missing are the IDs, as listed in the TUF metadata, of the role's
+	// authorized keys which are not held locally
+	Missing []string
+}
+
+// Blocking returns true if too few of the role's keys are held locally to
+// meet its threshold, so that the role can't be signed on this machine
+func (g RoleKeyGap) Blocking() bool {
+	return g.Held < g.Threshold
+}
+
+// MissingKeys updates the repository, and then reports, for every role in the
+// trust data which has authorized keys that the local crypto service does not
+// hold, which keys those are.  The base roles are reported first, followed by
+// the delegations in order of name.  Roles whose keys are all held are not
+// reported.  The timestamp key, and the snapshot key if the server manages it,
+// are normally held by the server, and so are normally reported.
+func (r *repository) MissingKeys() ([]RoleKeyGap, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+
+	var gaps []RoleKeyGap
+	for _, role := range data.BaseRoles {
+		baseRole, err := r.tufRepo.GetBaseRole(role)
+		if err != nil {
+			return nil, err
+		}
+		if gap, ok := r.keyGap(baseRole.Name, baseRole.Keys, baseRole.Threshold); ok {
+			gaps = append(gaps, gap)
+		}
+	}
+
+	var delegationGaps []RoleKeyGap
+	for _, tgts := range r.tufRepo.Targets {
+		for _, delegation := range tgts.Signed.Delegations.Roles {
+			keys := make(map[string]data.PublicKey, len(delegation.KeyIDs))
+			for _, keyID := range delegation.KeyIDs {
+				if key, ok := tgts.Signed.Delegations.Keys[keyID]; ok {
+					keys[keyID] = key
+				}
+			}
+			if gap, ok := r.keyGap(delegation.Name, keys, delegation.Threshold); ok {
+				delegationGaps = append(delegationGaps, gap)
+			}
+		}
+	}
+	sort.Slice(delegationGaps, func(i, j int) bool { return delegationGaps[i].Role < delegationGaps[j].Role })
+	return append(gaps, delegationGaps...), nil
+}
+
+// keyGap determines which of a role's keys the crypto service does not hold,
+// returning false if it holds them all
+func (r *repository) keyGap(role data.RoleName, keys map[string]data.PublicKey, threshold int) (RoleKeyGap, bool) {
+	gap := RoleKeyGap{Role: role, Threshold: threshold, Missing: []string{}}
+	service := signed.ForRole(r.cryptoService, role)
+	for keyID, key := range keys {
+		// private keys are stored under their canonical ID, which differs
+		// from the ID of a certificate
+		canonicalID, err := utils.CanonicalKeyID(key)
+		if err == nil {
+			if _, _, err = service.GetPrivateKey(canonicalID); err == nil {
+				gap.Held++
+				continue
+			}
+		}
+		gap.Missing = append(gap.Missing, keyID)
+	}
+	sort.Strings(gap.Missing)
+	return gap, len(gap.Missing) > 0
+}
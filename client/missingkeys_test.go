@@ -0,0 +1,55 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Keys which are authorized but not held locally are reported per role, with
+// whether the gap blocks signing
+func TestMissingKeys(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	other, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+
+	// targets/a has one key held locally, and one held elsewhere
+	heldKey := createKey(t, repo, "targets/a", false)
+	elsewhereKey := createKey(t, other, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{heldKey, elsewhereKey}, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	// with every client key held, only the server's timestamp key and the
+	// delegation key held elsewhere are missing
+	gaps, err := repo.MissingKeys()
+	require.NoError(t, err)
+	require.Len(t, gaps, 2)
+	require.Equal(t, data.CanonicalTimestampRole, gaps[0].Role)
+	require.True(t, gaps[0].Blocking())
+	require.Equal(t, data.RoleName("targets/a"), gaps[1].Role)
+	require.Equal(t, []string{elsewhereKey.ID()}, gaps[1].Missing)
+	require.Equal(t, 1, gaps[1].Held)
+	require.Equal(t, 1, gaps[1].Threshold)
+	require.False(t, gaps[1].Blocking())
+
+	// once the targets key is removed locally, the targets role can't be signed
+	targetsKeys := repo.tufRepo.Root.Signed.Roles[data.CanonicalTargetsRole].KeyIDs
+	require.Len(t, targetsKeys, 1)
+	require.NoError(t, repo.GetCryptoService().RemoveKey(targetsKeys[0]))
+
+	gaps, err = repo.MissingKeys()
+	require.NoError(t, err)
+	require.Len(t, gaps, 3)
+	require.Equal(t, data.CanonicalTargetsRole, gaps[0].Role)
+	require.Equal(t, targetsKeys, gaps[0].Missing)
+	require.Equal(t, 0, gaps[0].Held)
+	require.True(t, gaps[0].Blocking())
+	require.Equal(t, data.CanonicalTimestampRole, gaps[1].Role)
+	require.Equal(t, data.RoleName("targets/a"), gaps[2].Role)
+}
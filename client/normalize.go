@@ -0,0 +1,282 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/sirupsen/logrus"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// NormalizeAndResign rewrites the cached metadata for the given roles, such as
+// metadata imported from another TUF implementation, so that the signed portion
+// is canonically encoded, and re-signs it with the local keys of each role.
+// The signing keys of the roles must be available locally.
+//
+// Since normalizing a role changes its checksum, any cached snapshot or
+// timestamp referencing a normalized role is also updated and re-signed.  If
+// the key for that snapshot or timestamp is not available locally, and it was
+// not explicitly listed, it is removed from the cache instead, so that the
+// server may regenerate it.
+func (r *repository) NormalizeAndResign(roles ...string) error {
+	listed := make(map[data.RoleName]bool)
+	for _, role := range roles {
+		roleName := data.RoleName(role)
+		if !data.ValidRole(roleName) {
+			return data.ErrInvalidRole{Role: roleName, Reason: "cannot normalize an invalid role"}
+		}
+		listed[roleName] = true
+	}
+
+	n := &normalizer{
+		r:       r,
+		changed: make(map[data.RoleName][]byte),
+		removed: make(map[data.RoleName]bool),
+		targets: make(map[data.RoleName]*data.SignedTargets),
+	}
+
+	// the root provides the keys for all other base roles, so normalize it first
+	if listed[data.CanonicalRootRole] {
+		if err := n.normalizeRoot(); err != nil {
+			return err
+		}
+	}
+	root, err := n.loadRoot()
+	if err != nil {
+		return err
+	}
+
+	// normalize targets roles parents first, so that delegation keys are
+	// always read from normalized parents
+	var targetsRoles []data.RoleName
+	for role := range listed {
+		if role == data.CanonicalTargetsRole || data.IsDelegation(role) {
+			targetsRoles = append(targetsRoles, role)
+		}
+	}
+	sort.Slice(targetsRoles, func(i, j int) bool {
+		return strings.Count(targetsRoles[i].String(), "/") < strings.Count(targetsRoles[j].String(), "/")
+	})
+	for _, role := range targetsRoles {
+		var baseRole data.BaseRole
+		if role == data.CanonicalTargetsRole {
+			baseRole, err = root.BuildBaseRole(role)
+		} else {
+			baseRole, err = n.delegationRole(role)
+		}
+		if err != nil {
+			return err
+		}
+		if err := n.normalize(role, baseRole); err != nil {
+			return err
+		}
+	}
+
+	if err := n.updateReferencing(root, data.CanonicalSnapshotRole, listed[data.CanonicalSnapshotRole]); err != nil {
+		return err
+	}
+	return n.updateReferencing(root, data.CanonicalTimestampRole, listed[data.CanonicalTimestampRole])
+}
+
+// normalizer tracks the roles rewritten by a single NormalizeAndResign call
+type normalizer struct {
+	r       *repository
+	changed map[data.RoleName][]byte
+	removed map[data.RoleName]bool
+	targets map[data.RoleName]*data.SignedTargets
+}
+
+// loadSigned reads the cached metadata for a role, preferring any version of
+// it already normalized
+func (n *normalizer) loadSigned(role data.RoleName) (*data.Signed, error) {
+	raw, ok := n.changed[role]
+	if !ok {
+		var err error
+		raw, err = n.r.cache.GetSized(role.String(), store.NoSizeLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (n *normalizer) loadRoot() (*data.SignedRoot, error) {
+	s, err := n.loadSigned(data.CanonicalRootRole)
+	if err != nil {
+		return nil, err
+	}
+	return data.RootFromSigned(s)
+}
+
+// normalizeRoot normalizes the root, which is signed with the keys it lists
+// for itself
+func (n *normalizer) normalizeRoot() error {
+	root, err := n.loadRoot()
+	if err != nil {
+		return err
+	}
+	rootRole, err := root.BuildBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return err
+	}
+	return n.normalize(data.CanonicalRootRole, rootRole)
+}
+
+// delegationRole builds a delegation role from its parent's cached metadata
+func (n *normalizer) delegationRole(role data.RoleName) (data.BaseRole, error) {
+	parent := role.Parent()
+	tgts, ok := n.targets[parent]
+	if !ok {
+		s, err := n.loadSigned(parent)
+		if err != nil {
+			return data.BaseRole{}, err
+		}
+		tgts, err = data.TargetsFromSigned(s, parent)
+		if err != nil {
+			return data.BaseRole{}, err
+		}
+		n.targets[parent] = tgts
+	}
+	delgRole, err := tgts.BuildDelegationRole(role)
+	if err != nil {
+		return data.BaseRole{}, err
+	}
+	return delgRole.BaseRole, nil
+}
+
+// normalize canonically re-encodes the signed portion of a role's metadata,
+// re-signs it, and saves it back to the cache
+func (n *normalizer) normalize(role data.RoleName, baseRole data.BaseRole) error {
+	s, err := n.loadSigned(role)
+	if err != nil {
+		return err
+	}
+	canonical, err := data.SignedBytes(s)
+	if err != nil {
+		return err
+	}
+	raw := canonicaljson.RawMessage(canonical)
+	s.Signed = &raw
+	if err := n.sign(role, s, baseRole); err != nil {
+		return err
+	}
+	delete(n.targets, role)
+	logrus.Debugf("normalized and re-signed %s", role)
+	return nil
+}
+
+// sign validates the structure of the metadata, signs it with the available
+// keys of the role, and saves it
+func (n *normalizer) sign(role data.RoleName, s *data.Signed, baseRole data.BaseRole) error {
+	var err error
+	switch role {
+	case data.CanonicalRootRole:
+		_, err = data.RootFromSigned(s)
+	case data.CanonicalSnapshotRole:
+		_, err = data.SnapshotFromSigned(s)
+	case data.CanonicalTimestampRole:
+		_, err = data.TimestampFromSigned(s)
+	default:
+		_, err = data.TargetsFromSigned(s, role)
+	}
+	if err != nil {
+		return err
+	}
+	if err := signed.Sign(signed.ForRole(n.r.cryptoService, role), s, baseRole.ListKeys(), baseRole.Threshold, nil); err != nil {
+		return err
+	}
+	out, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := n.r.cache.Set(role.String(), out); err != nil {
+		return err
+	}
+	n.changed[role] = out
+	return nil
+}
+
+// updateReferencing updates the cached snapshot or timestamp with the new
+// checksums of any roles which have been normalized, and re-signs it.  If it
+// doesn't need updating, it is only normalized if it was explicitly listed.
+func (n *normalizer) updateReferencing(root *data.SignedRoot, role data.RoleName, listed bool) error {
+	s, err := n.loadSigned(role)
+	if _, ok := err.(store.ErrMetaNotFound); ok && !listed {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	baseRole, err := root.BuildBaseRole(role)
+	if err != nil {
+		return err
+	}
+
+	var meta data.Files
+	var toSigned func() (*data.Signed, error)
+	switch role {
+	case data.CanonicalSnapshotRole:
+		sn, err := data.SnapshotFromSigned(s)
+		if err != nil {
+			return err
+		}
+		meta, toSigned = sn.Signed.Meta, sn.ToSigned
+	default:
+		ts, err := data.TimestampFromSigned(s)
+		if err != nil {
+			return err
+		}
+		meta, toSigned = ts.Signed.Meta, ts.ToSigned
+	}
+
+	for name := range n.removed {
+		if _, ok := meta[name.String()]; ok {
+			logrus.Warnf("%s references %s, which was removed from the cache, so removing it too", role, name)
+			return n.remove(role)
+		}
+	}
+
+	stale := false
+	for name, raw := range n.changed {
+		if _, ok := meta[name.String()]; !ok {
+			continue
+		}
+		fileMeta, err := data.NewFileMeta(bytes.NewReader(raw), data.NotaryDefaultHashes...)
+		if err != nil {
+			return err
+		}
+		meta[name.String()] = fileMeta
+		stale = true
+	}
+	if !stale {
+		if listed {
+			return n.normalize(role, baseRole)
+		}
+		return nil
+	}
+
+	updated, err := toSigned()
+	if err != nil {
+		return err
+	}
+	err = n.sign(role, updated, baseRole)
+	if sigErr, ok := err.(signed.ErrInsufficientSignatures); ok && sigErr.FoundKeys == 0 && !listed {
+		logrus.Warnf("no local key to re-sign %s, so removing it from the cache to be regenerated by the server", role)
+		return n.remove(role)
+	}
+	return err
+}
+
+func (n *normalizer) remove(role data.RoleName) error {
+	n.removed[role] = true
+	return n.r.cache.Remove(role.String())
+}
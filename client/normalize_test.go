@@ -0,0 +1,125 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"testing"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// importNonCanonical replaces the cached metadata for a role with a
+// non-canonically encoded version of it, signed over those non-canonical bytes
+// as a different TUF implementation might have done
+func importNonCanonical(t *testing.T, repo *repository, role data.RoleName) {
+	raw, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(*s.Signed, &decoded))
+	nonCanonical, err := json.MarshalIndent(decoded, "", "    ")
+	require.NoError(t, err)
+	signedBytes := canonicaljson.RawMessage(nonCanonical)
+	s.Signed = &signedBytes
+
+	keyIDs := repo.GetCryptoService().ListKeys(role)
+	require.Len(t, keyIDs, 1)
+	privKey, _, err := repo.GetCryptoService().GetPrivateKey(keyIDs[0])
+	require.NoError(t, err)
+	sig, err := privKey.Sign(rand.Reader, nonCanonical, nil)
+	require.NoError(t, err)
+	s.Signatures = []data.Signature{{KeyID: privKey.ID(), Method: privKey.SignatureAlgorithm(), Signature: sig}}
+
+	imported, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.NoError(t, repo.cache.Set(role.String(), imported))
+}
+
+// verifyCachedRole checks the signatures on the cached metadata for a role
+// against the keys the root lists for it
+func verifyCachedRole(t *testing.T, repo *repository, role data.RoleName) error {
+	raw, err := repo.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+	root, err := data.RootFromSigned(s)
+	require.NoError(t, err)
+	baseRole, err := root.BuildBaseRole(role)
+	require.NoError(t, err)
+
+	raw, err = repo.cache.GetSized(role.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	s = &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+	return signed.VerifySignatures(s, baseRole)
+}
+
+// Non-canonical metadata signed by another tool doesn't verify, but does once
+// it has been normalized, and the snapshot is updated to reference it
+func TestNormalizeAndResign(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	importNonCanonical(t, repo, data.CanonicalTargetsRole)
+	require.Error(t, verifyCachedRole(t, repo, data.CanonicalTargetsRole))
+
+	require.NoError(t, repo.NormalizeAndResign(data.CanonicalTargetsRole.String()))
+	require.NoError(t, verifyCachedRole(t, repo, data.CanonicalTargetsRole))
+	require.NoError(t, verifyCachedRole(t, repo, data.CanonicalSnapshotRole))
+
+	// the signed portion is now canonically encoded
+	raw, err := repo.cache.GetSized(data.CanonicalTargetsRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(*s.Signed, &decoded))
+	canonical, err := canonicaljson.MarshalCanonical(decoded)
+	require.NoError(t, err)
+	require.Equal(t, canonical, []byte(*s.Signed))
+
+	// the timestamp key is held by the server, so the stale timestamp was removed
+	_, err = repo.cache.GetSized(data.CanonicalTimestampRole.String(), store.NoSizeLimit)
+	require.IsType(t, store.ErrMetaNotFound{}, err)
+
+	// and the cached metadata is once again a consistent, loadable repository
+	repo.tufRepo = nil
+	require.NoError(t, repo.bootstrapRepo())
+	require.NotNil(t, repo.tufRepo)
+	require.Contains(t, repo.tufRepo.Targets[data.CanonicalTargetsRole].Signed.Targets, "current")
+}
+
+// Normalizing requires the signing keys of the role to be available locally
+func TestNormalizeAndResignRequiresKeys(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	importNonCanonical(t, repo, data.CanonicalTargetsRole)
+	for _, keyID := range repo.GetCryptoService().ListKeys(data.CanonicalTargetsRole) {
+		require.NoError(t, repo.GetCryptoService().RemoveKey(keyID))
+	}
+
+	err := repo.NormalizeAndResign(data.CanonicalTargetsRole.String())
+	require.Error(t, err)
+	require.IsType(t, signed.ErrInsufficientSignatures{}, err)
+
+	err = repo.NormalizeAndResign("invalid")
+	require.IsType(t, data.ErrInvalidRole{}, err)
+}
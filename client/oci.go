@@ -0,0 +1,50 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// NewOCIRepository returns a repository whose trust data is fetched from an
+// OCI registry, as an artifact referring to the artifact ref, rather than from
+// a notary server.  Its GUN is the registry and repository of ref, and the
+// trust data is verified exactly as it would be if served by a notary server
+// for that GUN.  The trust data is cached, and keys held, only in memory, so
+// trust in the root is established on first use by each repository returned.
+// Since a registry can't be published to, the repository is only of use for
+// reading and verifying trust data.
+//
+// In case of a nil RoundTripper, a default offline store is used instead.
+func NewOCIRepository(ref string, rt http.RoundTripper, retriever notary.PassRetriever) (Repository, error) {
+	parsed, err := store.ParseOCIReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	gun := data.GUN(parsed.Registry + "/" + parsed.Repository)
+
+	userAgent := newUserAgentTransport(rt)
+	if userAgent != nil {
+		rt = userAgent
+	}
+	remoteStore, err := store.NewOCIStore(ref, rt)
+	if err != nil {
+		return nil, err
+	}
+
+	cryptoService := cryptoservice.NewCryptoService(trustmanager.NewKeyMemoryStore(retriever))
+	repo, err := NewRepository(gun, parsed.String(), remoteStore, store.NewMemoryStore(nil),
+		trustpinning.TrustPinConfig{}, cryptoService, changelist.NewMemChangelist())
+	if err != nil {
+		return nil, err
+	}
+	repo.(*repository).roundTrip = rt
+	repo.(*repository).userAgent = userAgent
+	return repo, nil
+}
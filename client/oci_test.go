@@ -0,0 +1,296 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/passphrase"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+const ociTestRepository = "org/app"
+
+// mockOCIRegistry serves the manifests and blobs of a single repository, and
+// the referrers of its manifests, from memory
+type mockOCIRegistry struct {
+	sync.Mutex
+	manifests map[string][]byte
+	blobs     map[string][]byte
+	referrers map[string][]map[string]interface{}
+}
+
+func ociDigest(content []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+}
+
+func newMockOCIRegistry() *mockOCIRegistry {
+	return &mockOCIRegistry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[string][]byte),
+		referrers: make(map[string][]map[string]interface{}),
+	}
+}
+
+func (m *mockOCIRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+	prefix := "/v2/" + ociTestRepository + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, prefix), "/", 2)
+	var content []byte
+	var ok bool
+	switch parts[0] {
+	case "manifests":
+		content, ok = m.manifests[parts[1]]
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+	case "blobs":
+		content, ok = m.blobs[parts[1]]
+	case "referrers":
+		// like some registries, ignore the artifactType filter
+		content, ok = mustMarshal(map[string]interface{}{
+			"schemaVersion": 2,
+			"mediaType":     "application/vnd.oci.image.index.v1+json",
+			"manifests":     m.referrers[parts[1]],
+		}), true
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write(content)
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// tag stores an image manifest under the given tag, returning its digest
+func (m *mockOCIRegistry) tag(t *testing.T, tag string) string {
+	m.Lock()
+	defer m.Unlock()
+	config := []byte("{}")
+	m.blobs[ociDigest(config)] = config
+	manifest := mustMarshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config": map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.config.v1+json",
+			"digest":    ociDigest(config),
+			"size":      len(config),
+		},
+		"layers": []interface{}{},
+	})
+	digest := ociDigest(manifest)
+	m.manifests[tag] = manifest
+	m.manifests[digest] = manifest
+	return digest
+}
+
+// attach stores the metadata of the given roles as an artifact referring to
+// the manifest with the given digest
+func (m *mockOCIRegistry) attach(t *testing.T, subject string, cache store.MetadataStore,
+	roles []data.RoleName, created time.Time) {
+
+	m.Lock()
+	defer m.Unlock()
+	var layers []interface{}
+	for _, role := range roles {
+		meta, err := cache.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		m.blobs[ociDigest(meta)] = meta
+		layers = append(layers, map[string]interface{}{
+			"mediaType":   store.OCITUFRoleMediaType,
+			"digest":      ociDigest(meta),
+			"size":        len(meta),
+			"annotations": map[string]string{"org.opencontainers.image.title": role.String() + ".json"},
+		})
+	}
+	manifest := mustMarshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"artifactType":  store.OCITUFArtifactType,
+		"layers":        layers,
+		"subject":       map[string]interface{}{"digest": subject},
+	})
+	digest := ociDigest(manifest)
+	m.manifests[digest] = manifest
+	m.referrers[subject] = append(m.referrers[subject], map[string]interface{}{
+		"mediaType":    "application/vnd.oci.image.manifest.v1+json",
+		"artifactType": store.OCITUFArtifactType,
+		"digest":       digest,
+		"size":         len(manifest),
+		"annotations":  map[string]string{"org.opencontainers.image.created": created.Format(time.RFC3339)},
+	})
+}
+
+// tamper replaces every blob with the given content with other content
+func (m *mockOCIRegistry) tamper(content []byte) {
+	m.Lock()
+	defer m.Unlock()
+	m.blobs[ociDigest(content)] = append([]byte(" "), content...)
+}
+
+// newOCITestRepo starts a mock registry and returns it, with the GUN the
+// metadata for its repository must be signed for
+func newOCITestRepo(t *testing.T) (*mockOCIRegistry, *httptest.Server, data.GUN) {
+	registry := newMockOCIRegistry()
+	ts := httptest.NewTLSServer(registry)
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	return registry, ts, data.GUN(u.Host + "/" + ociTestRepository)
+}
+
+// newOCITestSwizzler returns a swizzler of metadata for gun, with a target in
+// the targets role
+func newOCITestSwizzler(t *testing.T, gun data.GUN) *testutils.MetadataSwizzler {
+	meta, cs, err := testutils.NewRepoMetadata(gun, "targets/a")
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	require.NoError(t, swizzler.MutateTargets(func(tgs *data.Targets) {
+		tgs.Targets["hello"] = data.FileMeta{Length: 5, Hashes: data.Hashes{"sha256": make([]byte, 32)}}
+	}))
+	require.NoError(t, swizzler.UpdateSnapshotHashes(data.CanonicalTargetsRole))
+	require.NoError(t, swizzler.UpdateTimestampHash())
+	return swizzler
+}
+
+func listedTargetNames(t *testing.T, repo Repository) []string {
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Role.String()+":"+target.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listTargetsOverHTTP lists the targets of the metadata in cache, served for
+// gun by a notary server
+func listTargetsOverHTTP(t *testing.T, gun data.GUN, cache store.MetadataStore) ([]*TargetWithRole, error) {
+	ts := readOnlyServer(t, cache, http.StatusNotFound, gun)
+	defer ts.Close()
+	baseDir, err := ioutil.TempDir("", "notary-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(baseDir)
+	repo, err := NewFileCachedRepository(baseDir, gun, ts.URL, http.DefaultTransport,
+		passphrase.ConstantRetriever("pass"), trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	return repo.ListTargets()
+}
+
+// Trust data an OCI artifact refers to is read and verified just as when
+// served by a notary server, and the most recent artifact is used
+func TestOCIRepositoryListsTargetsLikeHTTP(t *testing.T) {
+	registry, ts, gun := newOCITestRepo(t)
+	defer ts.Close()
+
+	swizzler := newOCITestSwizzler(t, gun)
+	subject := registry.tag(t, "v1")
+	// an older artifact with no targets
+	stale := newOCITestSwizzler(t, gun)
+	require.NoError(t, stale.MutateTargets(func(tgs *data.Targets) { tgs.Targets = data.Files{} }))
+	require.NoError(t, stale.UpdateSnapshotHashes(data.CanonicalTargetsRole))
+	require.NoError(t, stale.UpdateTimestampHash())
+	registry.attach(t, subject, swizzler.MetadataCache, swizzler.Roles, time.Now())
+	registry.attach(t, subject, stale.MetadataCache, stale.Roles, time.Now().Add(-time.Hour))
+
+	repo, err := NewOCIRepository(gun.String()+":v1", ts.Client().Transport, passphraseRetriever)
+	require.NoError(t, err)
+	require.Equal(t, gun, repo.GetGUN())
+	names := listedTargetNames(t, repo)
+	require.Equal(t, []string{"targets:hello"}, names)
+
+	overHTTP, err := listTargetsOverHTTP(t, gun, swizzler.MetadataCache)
+	require.NoError(t, err)
+	require.Len(t, overHTTP, 1)
+	viaOCI, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Equal(t, overHTTP, viaOCI)
+
+	// referring to the image by digest finds the same trust data
+	repo, err = NewOCIRepository(gun.String()+"@"+subject, ts.Client().Transport, passphraseRetriever)
+	require.NoError(t, err)
+	require.Equal(t, names, listedTargetNames(t, repo))
+
+	require.IsType(t, store.ErrOCIReadOnly{}, repo.Publish())
+}
+
+// Blobs which don't match their digest, and metadata which doesn't verify,
+// fail the update as they would if served by a notary server
+func TestOCIRepositoryRejectsBadTrustData(t *testing.T) {
+	registry, ts, gun := newOCITestRepo(t)
+	defer ts.Close()
+
+	swizzler := newOCITestSwizzler(t, gun)
+	subject := registry.tag(t, "latest")
+	registry.attach(t, subject, swizzler.MetadataCache, swizzler.Roles, time.Now())
+	targets, err := swizzler.MetadataCache.GetSized(data.CanonicalTargetsRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	registry.tamper(targets)
+
+	repo, err := NewOCIRepository(gun.String(), ts.Client().Transport, passphraseRetriever)
+	require.NoError(t, err)
+	_, err = repo.ListTargets()
+	require.IsType(t, store.ErrMaliciousServer{}, err)
+
+	// targets signed with a key the root does not trust
+	registry, ts, gun = newOCITestRepo(t)
+	defer ts.Close()
+	swizzler = newOCITestSwizzler(t, gun)
+	require.NoError(t, swizzler.SignMetadataWithInvalidKey(data.CanonicalTargetsRole))
+	require.NoError(t, swizzler.UpdateSnapshotHashes(data.CanonicalTargetsRole))
+	require.NoError(t, swizzler.UpdateTimestampHash())
+	registry.attach(t, registry.tag(t, "latest"), swizzler.MetadataCache, swizzler.Roles, time.Now())
+
+	_, httpErr := listTargetsOverHTTP(t, gun, swizzler.MetadataCache)
+	require.Error(t, httpErr)
+	repo, err = NewOCIRepository(gun.String(), ts.Client().Transport, passphraseRetriever)
+	require.NoError(t, err)
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, httpErr, err)
+}
+
+// An image which no trust data refers to is a repository which doesn't exist
+func TestOCIRepositoryWithoutReferrers(t *testing.T) {
+	registry, ts, gun := newOCITestRepo(t)
+	defer ts.Close()
+	registry.tag(t, "latest")
+
+	repo, err := NewOCIRepository(gun.String(), ts.Client().Transport, passphraseRetriever)
+	require.NoError(t, err)
+	_, err = repo.ListTargets()
+	require.IsType(t, ErrRepositoryNotExist{}, err)
+
+	// nor does an image which isn't there
+	repo, err = NewOCIRepository(gun.String()+":missing", ts.Client().Transport, passphraseRetriever)
+	require.NoError(t, err)
+	_, err = repo.ListTargets()
+	require.IsType(t, ErrRepositoryNotExist{}, err)
+
+	_, err = NewOCIRepository("no-repository", ts.Client().Transport, passphraseRetriever)
+	require.Error(t, err)
+}
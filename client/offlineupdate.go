@@ -0,0 +1,45 @@
+package client
+
+import (
+	"fmt"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrNoLocalRoot is returned when updating from a metadata set transferred
+// offline, but there is no cached root to anchor trust in it
+type ErrNoLocalRoot struct {
+	GUN data.GUN
+}
+
+func (err ErrNoLocalRoot) Error() string {
+	return fmt.Sprintf("no root is cached for %s to verify offline metadata against", err.GUN)
+}
+
+// UpdateFromSnapshot updates the repository from the provided metadata set,
+// keyed by the names the server would serve each file under, instead of from
+// the server, such as on an air-gapped system with metadata transferred on
+// removable media.  The set is verified exactly as a network update would
+// verify the same metadata, anchored in the cached root, which must exist:
+// newer roots in the set are trusted only as verified rotations of it.  As
+// with a network update, the verified metadata is written to the cache and
+// becomes the repository's trusted state, which is returned.
+func (r *repository) UpdateFromSnapshot(meta map[string][]byte, forWrite bool) (*tuf.Repo, error) {
+	if _, err := r.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit); err != nil {
+		return nil, ErrNoLocalRoot{GUN: r.gun}
+	}
+	remote := metadataSetStore{store.NewMemoryStore(nil)}
+	remote.SetMulti(meta)
+
+	opts := r.tufLoadOptions(forWrite)
+	opts.RemoteStore = remote
+	repo, invalid, err := LoadTUFRepo(opts)
+	if err != nil {
+		return nil, err
+	}
+	r.tufRepo = repo
+	r.invalid = invalid
+	return repo, nil
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// offlineMetadataSet collects every file in the repository's cache, as though
+// copied onto removable media
+func offlineMetadataSet(t *testing.T, repo *repository) map[string][]byte {
+	meta := make(map[string][]byte)
+	for _, name := range repo.cache.(fileLister).ListFiles() {
+		blob, err := repo.cache.GetSized(name, store.NoSizeLimit)
+		require.NoError(t, err)
+		meta[name] = blob
+	}
+	return meta
+}
+
+// A metadata set transferred offline updates a repository with a cached root,
+// without the server, and becomes its trusted state
+func TestUpdateFromSnapshot(t *testing.T) {
+	ts := fullTestServer(t)
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	airGapped, _, airGappedDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(airGappedDir)
+	require.NoError(t, airGapped.updateTUF(false))
+
+	addTarget(t, repo, "v2", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	require.NoError(t, repo.updateTUF(false))
+	meta := offlineMetadataSet(t, repo)
+	ts.Close()
+
+	tufRepo, err := airGapped.UpdateFromSnapshot(meta, false)
+	require.NoError(t, err)
+	require.Equal(t, airGapped.tufRepo, tufRepo)
+	target, err := NewReadOnly(tufRepo).GetTargetByName("v2")
+	require.NoError(t, err)
+	require.Equal(t, data.CanonicalTargetsRole, target.Role)
+
+	cached, err := airGapped.cache.GetSized(data.CanonicalTargetsRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, meta[data.CanonicalTargetsRole.String()], cached)
+
+	// there must be a cached root to anchor trust
+	blank, blankDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(blankDir)
+	_, err = blank.UpdateFromSnapshot(meta, false)
+	require.Equal(t, ErrNoLocalRoot{GUN: blank.gun}, err)
+}
+
+// A tampered metadata set fails the same checks as a network update would,
+// and leaves the trusted state as it was
+func TestUpdateFromSnapshotTampered(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	airGapped, _, airGappedDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(airGappedDir)
+	require.NoError(t, airGapped.updateTUF(false))
+	trusted := airGapped.tufRepo
+
+	addTarget(t, repo, "v2", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	require.NoError(t, repo.updateTUF(false))
+	meta := offlineMetadataSet(t, repo)
+
+	tampered := make(map[string][]byte)
+	for name, blob := range meta {
+		tampered[name] = blob
+	}
+	targetsName := data.CanonicalTargetsRole.String()
+	tampered[targetsName] = []byte(strings.Replace(string(meta[targetsName]), `"v2"`, `"v3"`, 1))
+	require.NotEqual(t, meta[targetsName], tampered[targetsName])
+
+	_, err := airGapped.UpdateFromSnapshot(tampered, false)
+	require.Error(t, err)
+	require.Equal(t, trusted, airGapped.tufRepo)
+
+	// the set is also rejected if any role is missing from it
+	delete(tampered, targetsName)
+	_, err = airGapped.UpdateFromSnapshot(tampered, false)
+	require.Error(t, err)
+	require.Equal(t, trusted, airGapped.tufRepo)
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// FindOrphanedTargets updates the repository, and then reports the targets
+// listed in cached delegation metadata whose role is no longer part of the
+// current delegation tree, such as after the delegation was removed.  Those
+// targets are unreachable, and so can be cleaned up.  Since an orphaned role
+// is not delegated to, its metadata can't be verified, and its targets must
+// not be trusted.  Results are ordered by role, and then by target name.
+func (r *repository) FindOrphanedTargets() ([]*TargetWithRole, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	lister, ok := r.cache.(fileLister)
+	if !ok {
+		return nil, fmt.Errorf("the metadata cache does not support listing its contents")
+	}
+
+	var orphaned []*TargetWithRole
+	for _, name := range lister.ListFiles() {
+		roleName := data.RoleName(filepath.ToSlash(name))
+		if !data.IsDelegation(roleName) || isConsistentName(roleName.String()) {
+			continue
+		}
+		if _, live := r.tufRepo.Targets[roleName]; live {
+			continue
+		}
+		targets, err := r.cachedTargets(roleName)
+		if err != nil {
+			logrus.Warnf("skipping unparseable cached metadata for %s: %s", roleName, err)
+			continue
+		}
+		for targetName, meta := range targets.Signed.Targets {
+			orphaned = append(orphaned, &TargetWithRole{
+				Target: targetFromMeta(targetName, meta),
+				Role:   roleName,
+			})
+		}
+	}
+
+	sort.Slice(orphaned, func(i, j int) bool {
+		if orphaned[i].Role != orphaned[j].Role {
+			return orphaned[i].Role < orphaned[j].Role
+		}
+		return orphaned[i].Name < orphaned[j].Name
+	})
+	return orphaned, nil
+}
+
+// cachedTargets parses the cached metadata of a targets role without
+// verifying its signatures
+func (r *repository) cachedTargets(role data.RoleName) (*data.SignedTargets, error) {
+	raw, err := r.cache.GetSized(role.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, err
+	}
+	return data.TargetsFromSigned(s, role)
+}
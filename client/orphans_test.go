@@ -0,0 +1,55 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Removing a delegation orphans the targets in its cached metadata, while the
+// targets of the remaining delegations are not reported
+func TestFindOrphanedTargets(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	for _, role := range []data.RoleName{"targets/a", "targets/b", "targets/a/c"} {
+		key := createKey(t, repo, role, false)
+		require.NoError(t, repo.AddDelegation(role, []data.PublicKey{key}, []string{""}))
+	}
+	addTarget(t, repo, "a-only", "../fixtures/intermediate-ca.crt", "targets/a")
+	addTarget(t, repo, "shared", "../fixtures/intermediate-ca.crt", "targets/a", "targets/b")
+	addTarget(t, repo, "c-only", "../fixtures/intermediate-ca.crt", "targets/a/c")
+	addTarget(t, repo, "top", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	orphaned, err := repo.FindOrphanedTargets()
+	require.NoError(t, err)
+	require.Empty(t, orphaned)
+
+	// removing targets/a also cuts targets/a/c out of the tree
+	require.NoError(t, repo.RemoveDelegationRole("targets/a"))
+	require.NoError(t, repo.Publish())
+
+	orphaned, err = repo.FindOrphanedTargets()
+	require.NoError(t, err)
+	require.Len(t, orphaned, 3)
+	require.Equal(t, data.RoleName("targets/a"), orphaned[0].Role)
+	require.Equal(t, "a-only", orphaned[0].Name)
+	require.Equal(t, data.RoleName("targets/a"), orphaned[1].Role)
+	require.Equal(t, "shared", orphaned[1].Name)
+	require.Equal(t, data.RoleName("targets/a/c"), orphaned[2].Role)
+	require.Equal(t, "c-only", orphaned[2].Name)
+
+	// the targets of live roles are still reachable
+	for _, name := range []string{"shared", "top"} {
+		_, err := repo.GetTargetByName(name)
+		require.NoError(t, err)
+	}
+	_, err = repo.GetTargetByName("a-only")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// InitializeWithOwnership creates a new repository like Initialize, but with
+// the ownership of every base role's key given explicitly by serverManaged,
+// which maps role names to whether the server manages their keys.  Roles which
+// are not in the map keep the usual ownership: the client manages the
+// snapshot key and the server manages the timestamp key.
+//
+// The root and targets keys must always be managed by the client.  The
+// timestamp key may be managed by the client, so that the repository can be
+// published entirely offline, but only if the snapshot key is as well, since a
+// timestamp must be signed over the snapshot it refers to.
+func (r *repository) InitializeWithOwnership(rootKeyIDs []string, serverManaged map[string]bool) error {
+	owned := map[data.RoleName]bool{
+		data.CanonicalSnapshotRole:  false,
+		data.CanonicalTimestampRole: true,
+	}
+	for role, remote := range serverManaged {
+		roleName := data.RoleName(role)
+		switch roleName {
+		case data.CanonicalRootRole, data.CanonicalTargetsRole:
+			if remote {
+				return ErrInvalidRemoteRole{Role: roleName}
+			}
+		case data.CanonicalSnapshotRole, data.CanonicalTimestampRole:
+			owned[roleName] = remote
+		default:
+			return ErrInvalidRemoteRole{Role: roleName}
+		}
+	}
+	if owned[data.CanonicalSnapshotRole] && !owned[data.CanonicalTimestampRole] {
+		return ErrInvalidLocalRole{Role: data.CanonicalTimestampRole}
+	}
+
+	// root is also locally managed, but that should have been created already
+	locallyManagedKeys := []data.RoleName{data.CanonicalTargetsRole}
+	var remotelyManagedKeys []data.RoleName
+	for _, role := range []data.RoleName{data.CanonicalSnapshotRole, data.CanonicalTimestampRole} {
+		if owned[role] {
+			remotelyManagedKeys = append(remotelyManagedKeys, role)
+		} else {
+			locallyManagedKeys = append(locallyManagedKeys, role)
+		}
+	}
+	return r.initializeWithRoles(rootKeyIDs, nil, locallyManagedKeys, remotelyManagedKeys)
+}
+
+// holdsKey determines whether the crypto service holds a private key for the
+// given base role
+func (r *repository) holdsKey(role data.RoleName) bool {
+	baseRole, err := r.tufRepo.GetBaseRole(role)
+	if err != nil {
+		return false
+	}
+	for _, key := range baseRole.ListKeys() {
+		canonicalID, err := utils.CanonicalKeyID(key)
+		if err != nil {
+			continue
+		}
+		if _, _, err := r.cryptoService.GetPrivateKey(canonicalID); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRole(roles []data.RoleName, role data.RoleName) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// initializeRepoWithOwnership initializes a repository in a temporary
+// directory, with the given key ownership
+func initializeRepoWithOwnership(t *testing.T, gun, url string, serverManaged map[string]bool) (*repository, *passRoleRecorder, string, error) {
+	tempBaseDir, err := ioutil.TempDir("", "notary-test-")
+	require.NoError(t, err)
+	repo, rec, rootPubKeyID := createRepoAndKey(t, data.ECDSAKey, tempBaseDir, gun, url)
+	return repo, rec, tempBaseDir, repo.InitializeWithOwnership([]string{rootPubKeyID}, serverManaged)
+}
+
+// A fully client managed repository signs its own timestamp, which the server
+// accepts and serves in place of one of its own
+func TestInitializeWithOwnershipFullyClientManaged(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, rec, baseDir, err := initializeRepoWithOwnership(t, "docker.com/notary", ts.URL, map[string]bool{
+		data.CanonicalSnapshotRole.String():  false,
+		data.CanonicalTimestampRole.String(): false,
+	})
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, err)
+	rec.requireCreated(t, []string{data.CanonicalTargetsRole.String(), data.CanonicalSnapshotRole.String(),
+		data.CanonicalTimestampRole.String()})
+	require.True(t, repo.holdsKey(data.CanonicalTimestampRole))
+
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+	targets, err := repo2.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, repo.tufRepo.Root.Signed.Roles[data.CanonicalTimestampRole].KeyIDs,
+		repo2.tufRepo.Root.Signed.Roles[data.CanonicalTimestampRole].KeyIDs)
+	version := repo2.tufRepo.Timestamp.Signed.Version
+
+	// every publish signs a new timestamp
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	targets, err = repo2.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Equal(t, version+1, repo2.tufRepo.Timestamp.Signed.Version)
+}
+
+// Ownership which is not given keeps the defaults, and the server can be
+// given both the snapshot and timestamp keys
+func TestInitializeWithOwnershipMixed(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	for i, serverManaged := range []map[string]bool{
+		nil,
+		{data.CanonicalSnapshotRole.String(): true},
+		{data.CanonicalSnapshotRole.String(): true, data.CanonicalTimestampRole.String(): true},
+		{data.CanonicalRootRole.String(): false, data.CanonicalTargetsRole.String(): false},
+	} {
+		gun := fmt.Sprintf("docker.com/notary%d", i)
+		repo, rec, baseDir, err := initializeRepoWithOwnership(t, gun, ts.URL, serverManaged)
+		defer os.RemoveAll(baseDir)
+		require.NoError(t, err)
+
+		snapshotLocal := !serverManaged[data.CanonicalSnapshotRole.String()]
+		if snapshotLocal {
+			rec.requireCreated(t, []string{data.CanonicalTargetsRole.String(), data.CanonicalSnapshotRole.String()})
+		} else {
+			rec.requireCreated(t, []string{data.CanonicalTargetsRole.String()})
+		}
+		require.Equal(t, snapshotLocal, repo.holdsKey(data.CanonicalSnapshotRole))
+		require.False(t, repo.holdsKey(data.CanonicalTimestampRole))
+
+		addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+		require.NoError(t, repo.Publish())
+		repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+		defer os.RemoveAll(baseDir2)
+		targets, err := repo2.ListTargets()
+		require.NoError(t, err)
+		require.Len(t, targets, 1)
+	}
+}
+
+// The root and targets keys can't be server managed, nor can the timestamp key
+// be client managed if the snapshot key is not
+func TestInitializeWithOwnershipInvalid(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	for serverManaged, expected := range map[string]error{
+		data.CanonicalRootRole.String():    ErrInvalidRemoteRole{Role: data.CanonicalRootRole},
+		data.CanonicalTargetsRole.String(): ErrInvalidRemoteRole{Role: data.CanonicalTargetsRole},
+		"targets/a":                        ErrInvalidRemoteRole{Role: "targets/a"},
+	} {
+		_, _, baseDir, err := initializeRepoWithOwnership(t, "docker.com/notary", ts.URL, map[string]bool{serverManaged: true})
+		defer os.RemoveAll(baseDir)
+		require.Equal(t, expected, err)
+	}
+
+	_, _, baseDir, err := initializeRepoWithOwnership(t, "docker.com/notary", ts.URL, map[string]bool{
+		data.CanonicalSnapshotRole.String():  true,
+		data.CanonicalTimestampRole.String(): false,
+	})
+	defer os.RemoveAll(baseDir)
+	require.Equal(t, ErrInvalidLocalRole{Role: data.CanonicalTimestampRole}, err)
+}
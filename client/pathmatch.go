@@ -0,0 +1,24 @@
+package client
+
+import (
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// SetPathMatcher replaces the prefix matching of target paths against the
+// paths of delegations, for organizations whose naming schemes need other
+// match semantics.  The matcher is used both when looking up targets and when
+// validating the targets added to a delegation.  Since a custom matcher's
+// patterns can't be narrowed to their parent's like prefixes can, a delegation
+// is trusted for a target path only if the path matches the delegation's paths
+// and those of each of its ancestors.  Every client of the repository must use
+// the same deterministic matcher to agree on which roles are trusted for a
+// target.  A nil matcher, or data.PrefixPathMatcher, restores prefix matching.
+func (r *repository) SetPathMatcher(matcher data.PathMatcher) {
+	if _, ok := matcher.(data.PrefixPathMatcher); ok {
+		matcher = nil
+	}
+	r.pathMatcher = matcher
+	if r.tufRepo != nil {
+		r.tufRepo.SetPathMatcher(matcher)
+	}
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// regexMatcher treats delegation paths as regular expressions which must match
+// the whole target path
+type regexMatcher struct{}
+
+func (regexMatcher) MatchPath(targetPath, pattern string) bool {
+	matched, err := regexp.MatchString("^(?:"+pattern+")$", targetPath)
+	return err == nil && matched
+}
+
+// Targets are looked up, and validated when added, using the custom matcher
+func TestSetPathMatcherRegex(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	repo.SetPathMatcher(regexMatcher{})
+
+	releaseKey := createKey(t, repo, "targets/releases", false)
+	require.NoError(t, repo.AddDelegation("targets/releases",
+		[]data.PublicKey{releaseKey}, []string{`v[0-9]+\.[0-9]+/.*`}))
+	addTarget(t, repo, "v1.2/app", "../fixtures/intermediate-ca.crt", "targets/releases")
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+
+	// by prefix, the delegation is not trusted for the target
+	_, err := reader.GetTargetByName("v1.2/app")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+
+	reader.SetPathMatcher(regexMatcher{})
+	target, err := reader.GetTargetByName("v1.2/app")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName("targets/releases"), target.Role)
+
+	// the delegation is not trusted for a target its pattern doesn't match
+	addTarget(t, repo, "latest/app", "../fixtures/intermediate-ca.crt", "targets/releases")
+	require.Error(t, repo.Publish())
+
+	// the default matcher restores prefix matching
+	reader.SetPathMatcher(data.PrefixPathMatcher{})
+	_, err = reader.GetTargetByName("v1.2/app")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// PathOverlap reports a pair of sibling delegations which are both trusted for
+// some target names, because a path prefix of one overlaps with a path prefix
+// of the other
+type PathOverlap struct {
+	// Parent is the role delegating to both roles
+	Parent data.RoleName
+	// RoleA and RoleB are the overlapping roles, in the order they are
+	// delegated to by the parent, so RoleA takes precedence
+	RoleA, RoleB data.RoleName
+	// PathA and PathB are the overlapping path prefixes of the roles
+	PathA, PathB string
+	// Intersection is the path prefix shared by every target name which both
+	// roles are trusted for
+	Intersection string
+}
+
+// CheckDelegationPathOverlaps updates the repository, and reports every pair
+// of path prefixes by which sibling delegations overlap.  A target whose name
+// matches overlapping delegations is resolved only by the delegation listed
+// first, which can make trust in it ambiguous.  Overlaps are ordered by
+// parent, and then by the order of the roles and paths in the parent.
+func (r *repository) CheckDelegationPathOverlaps() ([]PathOverlap, error) {
+	roles, err := r.GetDelegationRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	var parents []data.RoleName
+	siblings := make(map[data.RoleName][]data.Role)
+	for _, role := range roles {
+		parent := role.Name.Parent()
+		if _, ok := siblings[parent]; !ok {
+			parents = append(parents, parent)
+		}
+		siblings[parent] = append(siblings[parent], role)
+	}
+
+	var overlaps []PathOverlap
+	for _, parent := range parents {
+		children := siblings[parent]
+		for i := range children {
+			for j := i + 1; j < len(children); j++ {
+				overlaps = append(overlaps, pathOverlaps(parent, children[i], children[j])...)
+			}
+		}
+	}
+	return overlaps, nil
+}
+
+// pathOverlaps returns the overlaps between the paths of two roles.  Since
+// paths are prefixes, two paths overlap exactly when one is a prefix of the
+// other, and the target names they share are those with the longer prefix.
+func pathOverlaps(parent data.RoleName, a, b data.Role) []PathOverlap {
+	var overlaps []PathOverlap
+	for _, pathA := range a.Paths {
+		for _, pathB := range b.Paths {
+			var intersection string
+			switch {
+			case strings.HasPrefix(pathB, pathA):
+				intersection = pathB
+			case strings.HasPrefix(pathA, pathB):
+				intersection = pathA
+			default:
+				continue
+			}
+			overlaps = append(overlaps, PathOverlap{
+				Parent:       parent,
+				RoleA:        a.Name,
+				RoleB:        b.Name,
+				PathA:        pathA,
+				PathB:        pathB,
+				Intersection: intersection,
+			})
+		}
+	}
+	return overlaps
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Only sibling delegations with a path prefix in common are reported
+func TestCheckDelegationPathOverlaps(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	delegations := []struct {
+		role  data.RoleName
+		paths []string
+	}{
+		{"targets/a", []string{"apps/", "docs/"}},
+		{"targets/b", []string{"apps/web/", "lib/"}},
+		{"targets/c", []string{"tools/"}},
+		// children of targets/a, which may only be delegated its own paths
+		{"targets/a/x", []string{"docs/"}},
+		{"targets/a/y", []string{"docs/api"}},
+	}
+	for _, d := range delegations {
+		key := createKey(t, repo, d.role, false)
+		require.NoError(t, repo.AddDelegation(d.role, []data.PublicKey{key}, d.paths))
+	}
+	require.NoError(t, repo.Publish())
+
+	overlaps, err := repo.CheckDelegationPathOverlaps()
+	require.NoError(t, err)
+	require.Equal(t, []PathOverlap{
+		{
+			Parent: data.CanonicalTargetsRole,
+			RoleA:  "targets/a", PathA: "apps/",
+			RoleB: "targets/b", PathB: "apps/web/",
+			Intersection: "apps/web/",
+		},
+		{
+			Parent: "targets/a",
+			RoleA:  "targets/a/x", PathA: "docs/",
+			RoleB: "targets/a/y", PathB: "docs/api",
+			Intersection: "docs/api",
+		},
+	}, overlaps)
+
+	// once paths are disjoint, nothing is reported
+	require.NoError(t, repo.RemoveDelegationPaths("targets/b", []string{"apps/web/"}))
+	require.NoError(t, repo.RemoveDelegationPaths("targets/a/x", []string{"docs/"}))
+	require.NoError(t, repo.AddDelegationPaths("targets/a/x", []string{"docs/guide"}))
+	require.NoError(t, repo.Publish())
+
+	overlaps, err = repo.CheckDelegationPathOverlaps()
+	require.NoError(t, err)
+	require.Empty(t, overlaps)
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// pendingKey identifies a target in a targets role
+type pendingKey struct {
+	role data.RoleName
+	name string
+}
+
+// PendingTargetChanges updates the repository, and resolves the staged
+// changelist into the targets which publishing it would add and remove, so
+// that the change can be previewed.  Changes to the same target are applied
+// in order, so that a target added and then removed again is in neither list.
+// A target whose entry is replaced is in the added list, with its new entry,
+// and a removal of a target which does not exist is left out.  The lists are
+// sorted by name, and a target is listed once for each role it is changed in.
+func (r *repository) PendingTargetChanges() (added, removed []*Target, err error) {
+	current := make(map[pendingKey]data.FileMeta)
+	if err := r.updateTUF(false); err != nil {
+		// a repository which has not been published yet has no targets
+		if _, ok := err.(ErrRepositoryNotExist); !ok {
+			return nil, nil, err
+		}
+	} else {
+		for role, tgts := range r.tufRepo.Targets {
+			for name, meta := range tgts.Signed.Targets {
+				current[pendingKey{role: role, name: name}] = meta
+			}
+		}
+	}
+
+	// staged maps each target changed by the changelist to its entry after
+	// the changes, or nil if it is removed
+	staged := make(map[pendingKey]*data.FileMeta)
+	for _, c := range r.changelist.List() {
+		if c.Type() != changelist.TypeTargetsTarget {
+			continue
+		}
+		key := pendingKey{role: c.Scope(), name: c.Path()}
+		switch c.Action() {
+		case changelist.ActionCreate:
+			meta := &data.FileMeta{}
+			if err := json.Unmarshal(c.Content(), meta); err != nil {
+				return nil, nil, err
+			}
+			staged[key] = meta
+		case changelist.ActionDelete:
+			staged[key] = nil
+		}
+	}
+
+	for key, meta := range staged {
+		old, exists := current[key]
+		switch {
+		case meta == nil && exists:
+			removed = append(removed, pendingTarget(key.name, old))
+		case meta != nil && !(exists && old.Equals(*meta)):
+			added = append(added, pendingTarget(key.name, *meta))
+		}
+	}
+	sort.SliceStable(added, func(i, j int) bool { return added[i].Name < added[j].Name })
+	sort.SliceStable(removed, func(i, j int) bool { return removed[i].Name < removed[j].Name })
+	return added, removed, nil
+}
+
+// pendingTarget returns the named target with the given entry
+func pendingTarget(name string, meta data.FileMeta) *Target {
+	target := targetFromMeta(name, meta)
+	return &target
+}
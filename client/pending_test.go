@@ -0,0 +1,65 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// targetNames returns the names of the targets
+func targetNames(targets []*Target) []string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
+// The preview of staged adds and removes matches what publishing changes
+func TestPendingTargetChanges(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	// before the first publish, everything staged is added
+	kept := addTarget(t, repo, "kept", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "old", "../fixtures/intermediate-ca.crt")
+	added, removed, err := repo.PendingTargetChanges()
+	require.NoError(t, err)
+	require.Equal(t, []string{"kept", "old"}, targetNames(added))
+	require.Empty(t, removed)
+	require.Equal(t, kept.Hashes, added[0].Hashes)
+	require.NoError(t, repo.Publish())
+
+	added, removed, err = repo.PendingTargetChanges()
+	require.NoError(t, err)
+	require.Empty(t, added)
+	require.Empty(t, removed)
+
+	addTarget(t, repo, "new", "../fixtures/root-ca.crt")
+	replaced := addTarget(t, repo, "kept", "../fixtures/root-ca.crt")
+	addTarget(t, repo, "transient", "../fixtures/root-ca.crt")
+	require.NoError(t, repo.RemoveTarget("transient"))
+	require.NoError(t, repo.RemoveTarget("old"))
+	require.NoError(t, repo.RemoveTarget("never-published"))
+
+	added, removed, err = repo.PendingTargetChanges()
+	require.NoError(t, err)
+	require.Equal(t, []string{"kept", "new"}, targetNames(added))
+	require.Equal(t, replaced.Hashes, added[0].Hashes)
+	require.Equal(t, []string{"old"}, targetNames(removed))
+
+	// publishing makes exactly the previewed changes
+	require.NoError(t, repo.Publish())
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	require.ElementsMatch(t, []string{"kept", "new"}, names)
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrMetadataPolicy is returned by an update when metadata is valid, but the
+// verification policy is stricter than what it satisfies
+type ErrMetadataPolicy struct {
+	Role   data.RoleName
+	Reason string
+}
+
+func (err ErrMetadataPolicy) Error() string {
+	return fmt.Sprintf("%s metadata violates the verification policy: %s", err.Role, err.Reason)
+}
+
+// VerificationPolicy gathers in one place the checks an update makes beyond
+// those TUF requires.  The zero value is the default behaviour of an update,
+// and each field makes the update stricter.
+type VerificationPolicy struct {
+	// StrictParsing rejects metadata whose signed portion has fields which are
+	// not part of its role's metadata format
+	StrictParsing bool
+	// RequireCanonicalJSON rejects metadata whose signed portion is not in
+	// canonical JSON form
+	RequireCanonicalJSON bool
+	// FreshnessWindows is the maximum age, per role, of the timestamp and
+	// snapshot.  See SetFreshnessWindow.
+	FreshnessWindows map[data.RoleName]time.Duration
+	// CheckTimestampExpiry rejects a timestamp which expires far later than
+	// its snapshot.  See ErrSuspiciousExpiry.
+	CheckTimestampExpiry bool
+	// CheckDelegationExpiry rejects a delegation which expires more than
+	// DelegationExpiryMargin later than its parent.  See
+	// ErrDelegationOutlivesParent.
+	CheckDelegationExpiry bool
+	// DelegationExpiryMargin is how much later than its parent a delegation
+	// may expire
+	DelegationExpiryMargin time.Duration
+	// RejectAlgorithmDowngrades fails UpdateWithResult if a role is signed
+	// with a weaker algorithm than before.  See ErrAlgorithmDowngrade.
+	RejectAlgorithmDowngrades bool
+	// MaxSnapshotVersionDelta bounds how far the snapshot version may jump,
+	// as UpdateOptions.MaxSnapshotVersionDelta does.
+	MaxSnapshotVersionDelta int
+	// KeyPolicy, if set, is enforced on the keys of every role after each
+	// update.  See EnforceKeyPolicy.
+	KeyPolicy *KeyPolicy
+}
+
+// SetVerificationPolicy replaces the strictness settings of all subsequent
+// updates with those of the policy, including any freshness windows set with
+// SetFreshnessWindow.  The update options which do not affect verification,
+// such as deltas and lazy delegations, are left as they are.
+func (r *repository) SetVerificationPolicy(p VerificationPolicy) {
+	var windows map[data.RoleName]time.Duration
+	if len(p.FreshnessWindows) > 0 {
+		windows = make(map[data.RoleName]time.Duration, len(p.FreshnessWindows))
+		for role, maxAge := range p.FreshnessWindows {
+			windows[role] = maxAge
+		}
+	}
+	r.updateOptions.StrictParsing = p.StrictParsing
+	r.updateOptions.RequireCanonicalJSON = p.RequireCanonicalJSON
+	r.updateOptions.FreshnessWindows = windows
+	r.updateOptions.CheckTimestampExpiry = p.CheckTimestampExpiry
+	r.updateOptions.CheckDelegationExpiry = p.CheckDelegationExpiry
+	r.updateOptions.DelegationExpiryMargin = p.DelegationExpiryMargin
+	r.updateOptions.RejectAlgorithmDowngrades = p.RejectAlgorithmDowngrades
+	r.updateOptions.MaxSnapshotVersionDelta = p.MaxSnapshotVersionDelta
+	r.updateOptions.KeyPolicy = p.KeyPolicy
+}
+
+// checkMetadataPolicy checks the form of raw metadata for a role against the
+// strict parsing and canonical JSON settings, before it is verified.  Metadata
+// which can't be parsed at all is left to fail verification.
+func checkMetadataPolicy(role data.RoleName, raw []byte, opts UpdateOptions) error {
+	if !opts.StrictParsing && !opts.RequireCanonicalJSON {
+		return nil
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil || s.Signed == nil {
+		return nil
+	}
+
+	if opts.RequireCanonicalJSON {
+		canonical, err := data.SignedBytes(s)
+		if err != nil {
+			return nil
+		}
+		if !bytes.Equal(canonical, *s.Signed) {
+			return ErrMetadataPolicy{Role: role, Reason: "the signed portion is not in canonical JSON form"}
+		}
+	}
+
+	if opts.StrictParsing {
+		var format interface{}
+		switch {
+		case role == data.CanonicalRootRole:
+			format = &data.Root{}
+		case role == data.CanonicalSnapshotRole:
+			format = &data.Snapshot{}
+		case role == data.CanonicalTimestampRole:
+			format = &data.Timestamp{}
+		case isTargetsRole(role):
+			format = &data.Targets{}
+		default:
+			return nil
+		}
+		decoder := json.NewDecoder(bytes.NewReader(*s.Signed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(format); err != nil {
+			return ErrMetadataPolicy{Role: role, Reason: err.Error()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// rewriteSignedPortion replaces the signed portion of a role's metadata on the
+// server, re-signing it and updating the snapshot and timestamp to match
+func rewriteSignedPortion(t *testing.T, s *testutils.MetadataSwizzler, role data.RoleName, rewrite func([]byte) []byte) {
+	raw, err := s.MetadataCache.GetSized(role.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	meta := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, meta))
+	rewritten := json.RawMessage(rewrite(*meta.Signed))
+
+	// signatures are over the canonical form of the signed portion
+	canonical, err := data.SignedBytes(&data.Signed{Signed: &rewritten})
+	require.NoError(t, err)
+	canonicalPortion := json.RawMessage(canonical)
+	meta.Signed = &canonicalPortion
+	var keys []data.PublicKey
+	for _, keyID := range s.CryptoService.ListKeys(role) {
+		keys = append(keys, s.CryptoService.GetKey(keyID))
+	}
+	meta.Signatures = nil
+	require.NoError(t, signed.Sign(s.CryptoService, meta, keys, 1, nil))
+	meta.Signed = &rewritten
+	raw, err = json.Marshal(meta)
+	require.NoError(t, err)
+	require.NoError(t, s.MetadataCache.Set(role.String(), raw))
+
+	if role != data.CanonicalSnapshotRole {
+		require.NoError(t, s.UpdateSnapshotHashes(role))
+	}
+	require.NoError(t, s.UpdateTimestampHash())
+}
+
+// The zero policy leaves the update options as they were by default, while
+// the options which don't affect verification are kept
+func TestSetVerificationPolicyZeroValue(t *testing.T) {
+	repo, baseDir := newBlankRepo(t, "https://notary.example.com")
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.SetFreshnessWindow(data.CanonicalTimestampRole.String(), time.Hour))
+	repo.SetUpdateOptions(UpdateOptions{AllowDiffs: true, CheckTimestampExpiry: true})
+
+	repo.SetVerificationPolicy(VerificationPolicy{})
+	require.Equal(t, UpdateOptions{AllowDiffs: true}, repo.updateOptions)
+}
+
+// Each metadata defect is accepted by the default policy, as by default
+// updates, and rejected by a policy strict about it
+func TestVerificationPolicyStrictVersusDefault(t *testing.T) {
+	rsaOnly := KeyPolicy{Default: &KeyRule{Algorithms: []string{data.RSAKey}}}
+	defects := []struct {
+		name     string
+		defect   func(*testing.T, *testutils.MetadataSwizzler)
+		policy   VerificationPolicy
+		expected error
+	}{
+		{
+			name: "unknown field",
+			defect: func(t *testing.T, s *testutils.MetadataSwizzler) {
+				rewriteSignedPortion(t, s, data.CanonicalTargetsRole, func(signedPortion []byte) []byte {
+					return append(bytes.TrimSuffix(signedPortion, []byte("}")), []byte(`,"zzz":true}`)...)
+				})
+			},
+			policy:   VerificationPolicy{StrictParsing: true},
+			expected: ErrMetadataPolicy{},
+		},
+		{
+			name: "non-canonical JSON",
+			defect: func(t *testing.T, s *testutils.MetadataSwizzler) {
+				// moving the first key to the end leaves the keys unsorted
+				rewriteSignedPortion(t, s, data.CanonicalSnapshotRole, func(signedPortion []byte) []byte {
+					const first = `{"_type":"Snapshot",`
+					require.True(t, bytes.HasPrefix(signedPortion, []byte(first)))
+					rest := string(bytes.TrimSuffix(signedPortion[len(first):], []byte("}")))
+					return []byte(`{` + rest + `,"_type":"Snapshot"}`)
+				})
+			},
+			policy:   VerificationPolicy{RequireCanonicalJSON: true},
+			expected: ErrMetadataPolicy{},
+		},
+		{
+			name:     "stale timestamp",
+			defect:   func(*testing.T, *testutils.MetadataSwizzler) {},
+			policy:   VerificationPolicy{FreshnessWindows: map[data.RoleName]time.Duration{data.CanonicalTimestampRole: time.Nanosecond}},
+			expected: ErrStaleMetadata{},
+		},
+		{
+			name:     "key algorithm",
+			defect:   func(*testing.T, *testutils.MetadataSwizzler) {},
+			policy:   VerificationPolicy{KeyPolicy: &rsaOnly},
+			expected: ErrKeyPolicyViolations{},
+		},
+	}
+
+	for _, tc := range defects {
+		_, serverSwizzler := newServerSwizzler(t)
+		tc.defect(t, serverSwizzler)
+		ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+
+		repo, baseDir := newBlankRepo(t, ts.URL)
+		repo.SetVerificationPolicy(VerificationPolicy{})
+		require.NoError(t, repo.updateTUF(false), tc.name)
+
+		strict, strictDir := newBlankRepo(t, ts.URL)
+		strict.SetVerificationPolicy(tc.policy)
+		require.IsType(t, tc.expected, strict.updateTUF(false), tc.name)
+
+		// the strict policy also rejects metadata cached under the default one
+		if _, ok := tc.expected.(ErrMetadataPolicy); ok {
+			repo.SetVerificationPolicy(tc.policy)
+			require.IsType(t, tc.expected, repo.updateTUF(false), tc.name)
+		}
+
+		ts.Close()
+		os.RemoveAll(baseDir)
+		os.RemoveAll(strictDir)
+	}
+}
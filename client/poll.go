@@ -0,0 +1,69 @@
+package client
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// PollTimestamp downloads only the remote timestamp and verifies it against
+// the trusted root, without downloading the snapshot or any targets metadata.
+// It returns the version of the timestamp, and whether that version is newer
+// than the one seen by the previous poll.  Before the first poll, the
+// timestamp in the cache, if any, is treated as the one last seen.
+//
+// A timestamp older than the one last seen is rejected, as is one that has
+// expired.  The polled timestamp is not cached, so a full update is still
+// needed to act on a change.  If the timestamp key has been rotated, the
+// timestamp will fail to verify until an update has picked up the new root.
+func (r *repository) PollTimestamp() (int, bool, error) {
+	c, err := bootstrapClient(r.tufLoadOptions(false))
+	if err != nil {
+		return 0, false, err
+	}
+
+	lastSeen := r.lastPolledTimestamp
+	if lastSeen == 0 {
+		lastSeen = c.cachedVersion(data.CanonicalTimestampRole, notary.MaxTimestampSize)
+	}
+
+	version, err := c.pollTimestamp(lastSeen)
+	if err != nil {
+		return 0, false, err
+	}
+	r.lastPolledTimestamp = version
+	return version, version > lastSeen, nil
+}
+
+// cachedVersion returns the version of the cached metadata for the role, or 0
+// if there is no valid cached copy
+func (c *tufClient) cachedVersion(role data.RoleName, size int64) int {
+	cached, err := c.cache.GetSized(role.String(), size)
+	if err != nil {
+		return 0
+	}
+	if err := c.oldBuilder.Load(role, cached, 1, true); err != nil {
+		return 0
+	}
+	return c.oldBuilder.GetLoadedVersion(role)
+}
+
+// pollTimestamp downloads and verifies the remote timestamp, requiring it to
+// be at least minVersion, and returns its version
+func (c *tufClient) pollTimestamp(minVersion int) (int, error) {
+	role := data.CanonicalTimestampRole
+	if minVersion < 1 {
+		minVersion = 1
+	}
+	raw, err := c.remote.GetSized(role.String(), notary.MaxTimestampSize)
+	c.stats.recordFetch(raw, err)
+	if err != nil {
+		logrus.Debugf("error downloading %s: %s", role, err)
+		return 0, err
+	}
+	if err := c.newBuilder.Load(role, raw, minVersion, false); err != nil {
+		logrus.Debugf("downloaded %s is invalid: %s", role, err)
+		return 0, err
+	}
+	return c.newBuilder.GetLoadedVersion(role), nil
+}
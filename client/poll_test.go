@@ -0,0 +1,97 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// Polling reports an unchanged timestamp until a newer one is published, and
+// only then reports the new version as changed
+func TestPollTimestamp(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	// an update caches the current timestamp, which the first poll compares against
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+	cachedVersion := repo.tufRepo.Timestamp.Signed.Version
+
+	version, changed, err := repo.PollTimestamp()
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, cachedVersion, version)
+
+	version, changed, err = repo.PollTimestamp()
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, cachedVersion, version)
+
+	// the snapshot is corrupted, which polling never notices because it only
+	// fetches the timestamp
+	require.NoError(t, serverSwizzler.MutateTimestamp(func(ts *data.Timestamp) { ts.Version++ }))
+	require.NoError(t, serverSwizzler.SetInvalidSignedMeta(data.CanonicalSnapshotRole))
+
+	version, changed, err = repo.PollTimestamp()
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, cachedVersion+1, version)
+
+	// the advanced version is now the last seen one
+	version, changed, err = repo.PollTimestamp()
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, cachedVersion+1, version)
+
+	// polling did not cache the new timestamp
+	cached, err := repo.cache.GetSized(data.CanonicalTimestampRole.String(), notary.MaxTimestampSize)
+	require.NoError(t, err)
+	signedTS := &data.Signed{}
+	require.NoError(t, json.Unmarshal(cached, signedTS))
+	cachedTS, err := data.TimestampFromSigned(signedTS)
+	require.NoError(t, err)
+	require.Equal(t, cachedVersion, cachedTS.Signed.Version)
+}
+
+// A timestamp older than the last one polled, or one that doesn't verify
+// against the trusted root, is rejected and doesn't change the last seen version
+func TestPollTimestampRejectsRollbackAndInvalid(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	original, err := serverSwizzler.MetadataCache.GetSized(data.CanonicalTimestampRole.String(), notary.MaxTimestampSize)
+	require.NoError(t, err)
+	require.NoError(t, serverSwizzler.MutateTimestamp(func(ts *data.Timestamp) { ts.Version++ }))
+	advanced, _, err := repo.PollTimestamp()
+	require.NoError(t, err)
+
+	// roll the server back to the original timestamp
+	require.NoError(t, serverSwizzler.MetadataCache.Set(data.CanonicalTimestampRole.String(), original))
+	_, changed, err := repo.PollTimestamp()
+	require.Error(t, err)
+	require.False(t, changed)
+
+	// a timestamp with a bad signature is rejected too
+	require.NoError(t, serverSwizzler.MutateTimestamp(func(ts *data.Timestamp) { ts.Version = advanced + 1 }))
+	require.NoError(t, serverSwizzler.InvalidateMetadataSignatures(data.CanonicalTimestampRole))
+	_, _, err = repo.PollTimestamp()
+	require.Error(t, err)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+	require.Equal(t, advanced, repo.lastPolledTimestamp)
+}
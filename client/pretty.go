@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ExportRolePretty updates the repository, and then writes the metadata of the
+// given role to w indented for human review.  The pretty form is for display
+// only: it is never signed or stored, and the signatures it shows are over the
+// canonical form of its signed portion, which it parses back to unchanged.
+func (r *repository) ExportRolePretty(role string, w io.Writer) error {
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	roleName := data.RoleName(role)
+	loaded := false
+	for _, name := range loadedRoles(r.tufRepo) {
+		if name == roleName {
+			loaded = true
+			break
+		}
+	}
+	if !loaded {
+		return data.ErrInvalidRole{Role: roleName, Reason: "the role has no metadata to export"}
+	}
+
+	// the update caches each role's metadata once it has verified it, but a
+	// copy which could not be cached is stale, so the cached copy must carry
+	// the signatures of the metadata the update loaded
+	raw, err := r.cache.GetSized(roleName.String(), store.NoSizeLimit)
+	if err != nil {
+		return err
+	}
+	cached := &data.Signed{}
+	if err := json.Unmarshal(raw, cached); err != nil {
+		return err
+	}
+	if !sameSignatures(cached.Signatures, loadedSignatures(r.tufRepo, roleName)) {
+		return fmt.Errorf("the cached %s metadata is not the metadata the update verified", roleName)
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, raw, "", "  "); err != nil {
+		return err
+	}
+	pretty.WriteByte('\n')
+	_, err = pretty.WriteTo(w)
+	return err
+}
+
+// loadedSignatures returns the signatures on the loaded metadata of a role
+func loadedSignatures(repo *tuf.Repo, role data.RoleName) []data.Signature {
+	switch role {
+	case data.CanonicalRootRole:
+		return repo.Root.Signatures
+	case data.CanonicalTimestampRole:
+		return repo.Timestamp.Signatures
+	case data.CanonicalSnapshotRole:
+		return repo.Snapshot.Signatures
+	default:
+		return repo.Targets[role].Signatures
+	}
+}
+
+func sameSignatures(a, b []data.Signature) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].KeyID != b[i].KeyID || !bytes.Equal(a[i].Signature, b[i].Signature) {
+			return false
+		}
+	}
+	return true
+}
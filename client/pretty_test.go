@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// The pretty form of a role's metadata is indented, but parses back to the
+// same canonical bytes that are signed, and leaves the cache untouched
+func TestExportRolePretty(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	for _, role := range data.BaseRoles {
+		var out bytes.Buffer
+		require.NoError(t, repo.ExportRolePretty(role.String(), &out))
+
+		cached, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		require.NotEqual(t, cached, out.Bytes())
+		require.Contains(t, out.String(), "\n  \"signatures\"")
+
+		pretty := &data.Signed{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), pretty))
+		original := &data.Signed{}
+		require.NoError(t, json.Unmarshal(cached, original))
+
+		prettyBytes, err := data.SignedBytes(pretty)
+		require.NoError(t, err)
+		originalBytes, err := data.SignedBytes(original)
+		require.NoError(t, err)
+		require.Equal(t, originalBytes, prettyBytes)
+		require.Equal(t, original.Signatures, pretty.Signatures)
+
+		// exporting does not change what is stored
+		after, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		require.Equal(t, cached, after)
+	}
+
+	// roles without metadata can't be exported
+	err := repo.ExportRolePretty("targets/nonexistent", &bytes.Buffer{})
+	require.Error(t, err)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+}
+
+// Metadata which the update verified, but could not cache, is not exported
+// from the stale copy in the cache
+func TestExportRolePrettyRejectsStaleCache(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	require.NoError(t, reader.ExportRolePretty(data.CanonicalTargetsRole.String(), &bytes.Buffer{}))
+
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	reader.cache = &unwritableStore{MetadataStore: reader.cache, roleToNotWrite: data.CanonicalTargetsRole}
+	var out bytes.Buffer
+	require.Error(t, reader.ExportRolePretty(data.CanonicalTargetsRole.String(), &out))
+	require.Empty(t, out.Bytes())
+
+	// roles which were cached are still exported
+	require.NoError(t, reader.ExportRolePretty(data.CanonicalSnapshotRole.String(), &out))
+	require.NotEmpty(t, out.Bytes())
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// fileLister is implemented by metadata stores which can enumerate their contents
+type fileLister interface {
+	ListFiles() []string
+}
+
+// PruneStaleMetadata removes consistently named (i.e. <role>.<checksum>) metadata
+// files from the local cache which are not referenced by the current timestamp
+// or snapshot, returning the number of files removed.  Files which do not have
+// a consistent name are never removed.
+func (r *repository) PruneStaleMetadata() (int, error) {
+	if err := r.updateTUF(false); err != nil {
+		return 0, err
+	}
+	lister, ok := r.cache.(fileLister)
+	if !ok {
+		return 0, fmt.Errorf("the metadata cache does not support listing its contents")
+	}
+
+	referenced := make(map[string]bool)
+	addReferences := func(files data.Files) {
+		for role, meta := range files {
+			for _, checksum := range meta.Hashes {
+				referenced[utils.ConsistentName(role, checksum)] = true
+			}
+		}
+	}
+	if r.tufRepo.Timestamp != nil {
+		addReferences(r.tufRepo.Timestamp.Signed.Meta)
+	}
+	if r.tufRepo.Snapshot != nil {
+		addReferences(r.tufRepo.Snapshot.Signed.Meta)
+	}
+
+	removed := 0
+	for _, name := range lister.ListFiles() {
+		name = filepath.ToSlash(name)
+		if !isConsistentName(name) || referenced[name] {
+			continue
+		}
+		if err := r.cache.Remove(name); err != nil {
+			return removed, err
+		}
+		logrus.Debugf("pruned stale metadata file %s", name)
+		removed++
+	}
+	return removed, nil
+}
+
+// isConsistentName determines whether a metadata file name is of the form
+// <role>.<hex encoded sha256 or sha512 checksum>
+func isConsistentName(name string) bool {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return false
+	}
+	checksum := name[i+1:]
+	if len(checksum) != notary.SHA256HexSize && len(checksum) != notary.SHA512HexSize {
+		return false
+	}
+	_, err := hex.DecodeString(checksum)
+	return err == nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// Only the consistently named files which are not referenced by the current
+// snapshot and timestamp are pruned
+func TestPruneStaleMetadata(t *testing.T) {
+	serverMeta, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+
+	var referenced []string
+	for _, role := range []data.RoleName{data.CanonicalRootRole, data.CanonicalTargetsRole, "targets/a", data.CanonicalSnapshotRole} {
+		checksum := sha256.Sum256(serverMeta[role])
+		name := utils.ConsistentName(role.String(), checksum[:])
+		require.NoError(t, repo.cache.Set(name, serverMeta[role]))
+		referenced = append(referenced, name)
+	}
+
+	oldSHA256 := sha256.Sum256([]byte("old"))
+	oldSHA512 := sha512.Sum512([]byte("old"))
+	stale := []string{
+		utils.ConsistentName(data.CanonicalTargetsRole.String(), oldSHA256[:]),
+		utils.ConsistentName("targets/a", oldSHA256[:]),
+		utils.ConsistentName(data.CanonicalSnapshotRole.String(), oldSHA512[:]),
+	}
+	for _, name := range stale {
+		require.NoError(t, repo.cache.Set(name, []byte("old")))
+	}
+	// this file is not consistently named, so must never be pruned
+	require.NoError(t, repo.cache.Set("targets.notachecksum", []byte("old")))
+
+	removed, err := repo.PruneStaleMetadata()
+	require.NoError(t, err)
+	require.Equal(t, len(stale), removed)
+
+	for _, name := range stale {
+		_, err := repo.cache.GetSized(name, -1)
+		require.Error(t, err, "%s should have been pruned", name)
+	}
+	for _, name := range append(referenced, "targets.notachecksum", data.CanonicalRootRole.String(),
+		data.CanonicalTargetsRole.String(), data.CanonicalSnapshotRole.String(), data.CanonicalTimestampRole.String()) {
+		_, err := repo.cache.GetSized(name, -1)
+		require.NoError(t, err, "%s should not have been pruned", name)
+	}
+
+	// nothing more to prune
+	removed, err = repo.PruneStaleMetadata()
+	require.NoError(t, err)
+	require.Equal(t, 0, removed)
+}
+
+func TestIsConsistentName(t *testing.T) {
+	sha256Sum := sha256.Sum256([]byte("a"))
+	sha512Sum := sha512.Sum512([]byte("a"))
+	require.True(t, isConsistentName(utils.ConsistentName("targets", sha256Sum[:])))
+	require.True(t, isConsistentName(utils.ConsistentName("targets/a.b", sha512Sum[:])))
+	require.False(t, isConsistentName("targets"))
+	require.False(t, isConsistentName("1.root"))
+	require.False(t, isConsistentName(utils.ConsistentName("", sha256Sum[:])))
+	require.False(t, isConsistentName("targets."+string(make([]byte, 64))))
+}
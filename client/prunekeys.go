@@ -0,0 +1,102 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// keyInfoService is implemented by crypto services which can report the GUN
+// and role that each private key is held for
+type keyInfoService interface {
+	GetKeyInfo(keyID string) (trustmanager.KeyInfo, error)
+}
+
+// PruneUnusedKeys updates the repository, and then removes from the local
+// crypto service the private keys held for this GUN which are no longer
+// authorized for any role in the trust data, such as the keys replaced by a
+// rotation made from another client, returning the sorted IDs of the removed
+// keys.  Keys which are
+// staged in the changelist to become a role's keys are kept.  Root keys and
+// delegation keys are never removed, since they are not held for a single GUN
+// and may be authorized for other repositories.
+func (r *repository) PruneUnusedKeys() ([]string, error) {
+	infoService, ok := r.cryptoService.(keyInfoService)
+	if !ok {
+		return nil, fmt.Errorf("the crypto service can't report which repository its keys are held for")
+	}
+	if err := r.updateTUF(true); err != nil {
+		return nil, err
+	}
+	referenced, err := r.referencedKeyIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for keyID, role := range r.cryptoService.ListAllKeys() {
+		if role == data.CanonicalRootRole || data.IsDelegation(role) || referenced[keyID] {
+			continue
+		}
+		info, err := infoService.GetKeyInfo(keyID)
+		if err != nil || info.Gun != r.gun {
+			continue
+		}
+		if err := r.cryptoService.RemoveKey(keyID); err != nil {
+			return removed, err
+		}
+		removed = append(removed, keyID)
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
+// referencedKeyIDs returns the IDs, and the canonical IDs under which their
+// private keys are held, of every key in the root and in the delegations of
+// the trust data, and of every key staged in the changelist to replace the
+// keys of a base role
+func (r *repository) referencedKeyIDs() (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	add := func(key data.PublicKey) error {
+		referenced[key.ID()] = true
+		canonicalID, err := utils.CanonicalKeyID(key)
+		if err != nil {
+			return err
+		}
+		referenced[canonicalID] = true
+		return nil
+	}
+
+	for _, key := range r.tufRepo.Root.Signed.Keys {
+		if err := add(key); err != nil {
+			return nil, err
+		}
+	}
+	for _, tgts := range r.tufRepo.Targets {
+		for _, key := range tgts.Signed.Delegations.Keys {
+			if err := add(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, c := range r.changelist.List() {
+		if c.Scope() != changelist.ScopeRoot || c.Type() != changelist.TypeBaseRole {
+			continue
+		}
+		staged := &changelist.TUFRootData{}
+		if err := json.Unmarshal(c.Content(), staged); err != nil {
+			return nil, err
+		}
+		for _, key := range staged.Keys {
+			if err := add(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return referenced, nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A collaborator still holding a key after another client rotates the role to
+// a new key prunes the old key, and keeps every key which is still authorized
+func TestPruneUnusedKeysAfterRotation(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	// the collaborator shares the targets and snapshot keys, and holds a
+	// delegation key which is not authorized for any role
+	collaborator, _, collaboratorDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(collaboratorDir)
+	shared := make(map[data.RoleName]string)
+	for _, role := range []data.RoleName{data.CanonicalTargetsRole, data.CanonicalSnapshotRole} {
+		keyIDs := repo.GetCryptoService().ListKeys(role)
+		require.Len(t, keyIDs, 1)
+		privKey, _, err := repo.GetCryptoService().GetPrivateKey(keyIDs[0])
+		require.NoError(t, err)
+		require.NoError(t, collaborator.GetCryptoService().AddKey(role, repo.gun, privKey))
+		shared[role] = keyIDs[0]
+	}
+	delegationKey := createKey(t, collaborator, "targets/unused", false)
+
+	// nothing is pruned while every key is authorized
+	removed, err := collaborator.PruneUnusedKeys()
+	require.NoError(t, err)
+	require.Empty(t, removed)
+
+	require.NoError(t, repo.RotateKey(data.CanonicalTargetsRole, false, nil))
+
+	removed, err = collaborator.PruneUnusedKeys()
+	require.NoError(t, err)
+	require.Equal(t, []string{shared[data.CanonicalTargetsRole]}, removed)
+
+	cs := collaborator.GetCryptoService()
+	require.Empty(t, cs.ListKeys(data.CanonicalTargetsRole))
+	require.Equal(t, []string{shared[data.CanonicalSnapshotRole]}, cs.ListKeys(data.CanonicalSnapshotRole))
+	require.NotNil(t, cs.GetKey(delegationKey.ID()))
+
+	// the rotating client's own keys are all still authorized
+	removed, err = repo.PruneUnusedKeys()
+	require.NoError(t, err)
+	require.Empty(t, removed)
+	require.Len(t, repo.GetCryptoService().ListKeys(data.CanonicalTargetsRole), 1)
+	require.Len(t, repo.GetCryptoService().ListKeys(data.CanonicalRootRole), 1)
+
+	removed, err = collaborator.PruneUnusedKeys()
+	require.NoError(t, err)
+	require.Empty(t, removed)
+}
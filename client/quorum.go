@@ -0,0 +1,72 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrTargetQuorumNotMet is returned when a target is not signed into enough
+// distinct delegation roles to satisfy the configured target quorum
+type ErrTargetQuorumNotMet struct {
+	Name     string
+	Required int
+	Found    int
+}
+
+func (err ErrTargetQuorumNotMet) Error() string {
+	return fmt.Sprintf("target %s is only signed by %d delegation(s) with matching hashes, but %d are required",
+		err.Name, err.Found, err.Required)
+}
+
+// SetTargetQuorum requires that, when looking up or listing targets, a target
+// be present with identical length and hashes in at least n distinct
+// delegation roles before it is trusted, and targets which are not are left
+// out of listings.  If the lookup is restricted to some roles, only those
+// roles and the delegations under them count.  A quorum of 1 or less disables
+// the requirement.
+func (r *repository) SetTargetQuorum(n int) {
+	r.targetQuorum = n
+}
+
+// checkTargetQuorum verifies that the given target appears with identical
+// metadata in enough distinct delegation roles of the repository, within the
+// given roles and their delegations if any are given
+func (r *repository) checkTargetQuorum(target *TargetWithRole, roles []data.RoleName) error {
+	if r.targetQuorum <= 1 {
+		return nil
+	}
+	all, err := NewReadOnly(r.tufRepo).GetAllTargetMetadataByName(target.Name)
+	if err != nil {
+		return err
+	}
+	agreeing := make(map[data.RoleName]bool)
+	for _, t := range all {
+		if !data.IsDelegation(t.Role.Name) || !withinRoles(t.Role.Name, roles) || t.Target.Length != target.Length {
+			continue
+		}
+		if err := data.CompareMultiHashes(t.Target.Hashes, target.Hashes); err != nil {
+			continue
+		}
+		agreeing[t.Role.Name] = true
+	}
+	if len(agreeing) < r.targetQuorum {
+		return ErrTargetQuorumNotMet{Name: target.Name, Required: r.targetQuorum, Found: len(agreeing)}
+	}
+	return nil
+}
+
+// withinRoles determines whether role is one of roles or delegated to under
+// one of them.  Every role is within an empty list of roles.
+func withinRoles(role data.RoleName, roles []data.RoleName) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	for _, r := range roles {
+		if role == r || strings.HasPrefix(role.String(), r.String()+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Under a quorum of 2, a target is only returned once it is signed into two
+// distinct delegations with identical hashes
+func TestGetTargetByNameRequiresQuorum(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	for _, role := range []data.RoleName{"targets/a", "targets/b"} {
+		key := createKey(t, repo, role, false)
+		require.NoError(t, repo.AddDelegation(role, []data.PublicKey{key}, []string{""}))
+	}
+	addTarget(t, repo, "single", "../fixtures/intermediate-ca.crt", "targets/a")
+	addTarget(t, repo, "double", "../fixtures/intermediate-ca.crt", "targets/a", "targets/b")
+	// same name in two delegations, but with different content
+	addTarget(t, repo, "conflict", "../fixtures/intermediate-ca.crt", "targets/a")
+	addTarget(t, repo, "conflict", "../fixtures/root-ca.crt", "targets/b")
+	require.NoError(t, repo.Publish())
+
+	// without a quorum, every target resolves
+	for _, name := range []string{"single", "double", "conflict"} {
+		_, err := repo.GetTargetByName(name)
+		require.NoError(t, err)
+	}
+
+	repo.SetTargetQuorum(2)
+
+	_, err := repo.GetTargetByName("single")
+	require.Error(t, err)
+	require.Equal(t, ErrTargetQuorumNotMet{Name: "single", Required: 2, Found: 1}, err)
+
+	_, err = repo.GetTargetByName("conflict")
+	require.Error(t, err)
+	require.IsType(t, ErrTargetQuorumNotMet{}, err)
+
+	target, err := repo.GetTargetByName("double")
+	require.NoError(t, err)
+	require.Equal(t, "double", target.Name)
+
+	repo.SetTargetQuorum(3)
+	_, err = repo.GetTargetByName("double")
+	require.Equal(t, ErrTargetQuorumNotMet{Name: "double", Required: 3, Found: 2}, err)
+}
+
+// Signing a target into the top level targets role does not count towards
+// the quorum, since the quorum is of delegations
+func TestTargetQuorumOnlyCountsDelegations(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	addTarget(t, repo, "target", "../fixtures/intermediate-ca.crt", data.CanonicalTargetsRole, "targets/a")
+	require.NoError(t, repo.Publish())
+
+	repo.SetTargetQuorum(2)
+	_, err := repo.GetTargetByName("target")
+	require.Equal(t, ErrTargetQuorumNotMet{Name: "target", Required: 2, Found: 1}, err)
+}
+
+// A lookup restricted to some roles only counts the delegations within them
+// towards the quorum
+func TestTargetQuorumWithinRoles(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	roles := []data.RoleName{"targets/a", "targets/a/b", "targets/c"}
+	for _, role := range roles {
+		key := createKey(t, repo, role, false)
+		require.NoError(t, repo.AddDelegation(role, []data.PublicKey{key}, []string{""}))
+	}
+	addTarget(t, repo, "target", "../fixtures/intermediate-ca.crt", roles...)
+	require.NoError(t, repo.Publish())
+
+	repo.SetTargetQuorum(3)
+	_, err := repo.GetTargetByName("target")
+	require.NoError(t, err)
+
+	// targets/a and targets/a/b are within targets/a, but targets/c is not
+	_, err = repo.GetTargetByName("target", "targets/a")
+	require.Equal(t, ErrTargetQuorumNotMet{Name: "target", Required: 3, Found: 2}, err)
+	_, err = repo.GetTargetByName("target", "targets/c")
+	require.Equal(t, ErrTargetQuorumNotMet{Name: "target", Required: 3, Found: 1}, err)
+
+	repo.SetTargetQuorum(2)
+	target, err := repo.GetTargetByName("target", "targets/a")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName("targets/a"), target.Role)
+	_, err = repo.GetTargetByName("target", "targets/a/b", "targets/c")
+	require.NoError(t, err)
+}
+
+// Targets which are not signed into enough delegations are left out of
+// listings, counting only the delegations within the roles listed
+func TestTargetQuorumAppliesToListings(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	for _, role := range []data.RoleName{"targets/a", "targets/b"} {
+		key := createKey(t, repo, role, false)
+		require.NoError(t, repo.AddDelegation(role, []data.PublicKey{key}, []string{""}))
+	}
+	addTarget(t, repo, "single", "../fixtures/intermediate-ca.crt", "targets/a")
+	addTarget(t, repo, "double", "../fixtures/intermediate-ca.crt", "targets/a", "targets/b")
+	require.NoError(t, repo.Publish())
+
+	repo.SetTargetQuorum(2)
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "double", targets[0].Name)
+	targets, err = repo.ListTargets("targets/a")
+	require.NoError(t, err)
+	require.Empty(t, targets)
+
+	all, err := repo.GetAllTargetMetadataByName("")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	for _, signed := range all {
+		require.Equal(t, "double", signed.Target.Name)
+	}
+	_, err = repo.GetAllTargetMetadataByName("single")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
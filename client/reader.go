@@ -17,6 +17,7 @@ type Target struct {
 	Hashes data.Hashes               // the hash of the target
 	Length int64                     // the size in bytes of the target
 	Custom *canonicaljson.RawMessage // the custom data provided to describe the file at TARGETPATH
+	Yanked bool                      // whether the target has been yanked, as recorded in its custom data
 }
 
 // TargetWithRole represents a Target that exists in a particular role - this is
@@ -84,13 +85,8 @@ func (r *reader) ListTargets(roles ...data.RoleName) ([]*TargetWithRole, error)
 					continue
 				}
 				targets[targetName] = &TargetWithRole{
-					Target: Target{
-						Name:   targetName,
-						Hashes: targetMeta.Hashes,
-						Length: targetMeta.Length,
-						Custom: targetMeta.Custom,
-					},
-					Role: validRole.Name,
+					Target: targetFromMeta(targetName, targetMeta),
+					Role:   validRole.Name,
 				}
 			}
 			return nil
@@ -140,7 +136,7 @@ func (r *reader) GetTargetByName(name string, roles ...data.RoleName) (*TargetWi
 		}
 		// Check that we didn't error, and that we assigned to our target
 		if err := r.tufRepo.WalkTargets(name, role, getTargetVisitorFunc, skipRoles...); err == nil && foundTarget {
-			return &TargetWithRole{Target: Target{Name: name, Hashes: resultMeta.Hashes, Length: resultMeta.Length, Custom: resultMeta.Custom}, Role: resultRoleName}, nil
+			return &TargetWithRole{Target: targetFromMeta(name, resultMeta), Role: resultRoleName}, nil
 		}
 	}
 	return nil, ErrNoSuchTarget(name)
@@ -173,7 +169,7 @@ func (r *reader) GetAllTargetMetadataByName(name string) ([]TargetSignedStruct,
 		for targetName, resultMeta := range targetMetaToAdd {
 			targetInfo := TargetSignedStruct{
 				Role:       validRole,
-				Target:     Target{Name: targetName, Hashes: resultMeta.Hashes, Length: resultMeta.Length, Custom: resultMeta.Custom},
+				Target:     targetFromMeta(targetName, resultMeta),
 				Signatures: tgt.Signatures,
 			}
 			targetInfoList = append(targetInfoList, targetInfo)
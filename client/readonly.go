@@ -0,0 +1,41 @@
+package client
+
+import "io"
+
+// ReadOnlyRepository is a handle to a repository that can only update and
+// read its trust data, and verify content against it.  It exposes no methods
+// to stage changes or publish, so it can be handed to consumers that must not
+// modify the repository.
+type ReadOnlyRepository interface {
+	ReadOnly
+
+	// Update fetches the latest TUF metadata for the repository from the
+	// remote server, verifying it against the locally cached metadata
+	Update() error
+
+	// VerifyAllTargets verifies the content of every target, as obtained from
+	// source, against its TUF metadata, returning a result per target per role
+	VerifyAllTargets(source func(name string) (io.Reader, error)) ([]TargetVerificationResult, error)
+}
+
+// readOnlyRepository wraps a repository so that only the read only methods
+// are promoted, and the repository's full interface can't be recovered by a
+// type assertion
+type readOnlyRepository struct {
+	ReadOnly
+	repo *repository
+}
+
+// ReadOnly returns a handle to this repository which can read, but not
+// modify, it.  The handle shares this repository's cache and configuration.
+func (r *repository) ReadOnly() ReadOnlyRepository {
+	return readOnlyRepository{ReadOnly: r, repo: r}
+}
+
+func (ro readOnlyRepository) Update() error {
+	return ro.repo.updateTUF(false)
+}
+
+func (ro readOnlyRepository) VerifyAllTargets(source func(name string) (io.Reader, error)) ([]TargetVerificationResult, error) {
+	return ro.repo.VerifyAllTargets(source)
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// The read only handle can't be converted back into a full repository, and it
+// shares the cache of the repository it was created from
+func TestReadOnlyRepository(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	ro := repo.ReadOnly()
+	var handle interface{} = ro
+	_, ok := handle.(Repository)
+	require.False(t, ok, "read only handle exposes the full repository interface")
+	_, ok = handle.(*repository)
+	require.False(t, ok, "read only handle is the underlying repository")
+
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	// clear the cached targets, so they can only come back by the handle updating
+	require.NoError(t, repo.cache.Remove(data.CanonicalTargetsRole.String()))
+	require.NoError(t, ro.Update())
+	_, err := repo.cache.GetSized(data.CanonicalTargetsRole.String(), -1)
+	require.NoError(t, err)
+
+	target, err := ro.GetTargetByName("current")
+	require.NoError(t, err)
+	require.Equal(t, "current", target.Name)
+
+	targets, err := ro.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+}
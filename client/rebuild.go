@@ -0,0 +1,180 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// DelegationDef declaratively describes a single delegation role: the keys
+// that may sign for it, the paths it is trusted for, and its signing
+// threshold.  A threshold of 0 means notary.MinThreshold.
+type DelegationDef struct {
+	Role      data.RoleName
+	Keys      []data.PublicKey
+	Paths     []string
+	Threshold int
+}
+
+// RebuildDelegations stages changes that make the delegation tree match defs
+// exactly.  Delegations not present in defs are deleted, existing delegations
+// have their keys, paths and threshold replaced (preserving the targets they
+// have signed), and missing delegations are created.  Nothing is changed on the
+// server until Publish is called.
+func (r *repository) RebuildDelegations(defs []DelegationDef) error {
+	wanted, err := validateDelegationDefs(defs)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[data.RoleName]data.Role)
+	roles, err := r.GetDelegationRoles()
+	switch err.(type) {
+	case nil:
+		for _, role := range roles {
+			current[role.Name] = role
+		}
+	case ErrRepositoryNotExist:
+		// nothing has been published yet, so every definition is a new delegation
+	default:
+		return err
+	}
+
+	// Stage everything in memory first so that no changes are added to the
+	// repository's changelist if any of them can't be created.
+	cl := changelist.NewMemChangelist()
+
+	// Delete unwanted delegations deepest first, so that children are gone
+	// before their parents
+	var remove []data.RoleName
+	for name := range current {
+		if _, ok := wanted[name]; !ok {
+			remove = append(remove, name)
+		}
+	}
+	sortByDepth(remove)
+	for i := len(remove) - 1; i >= 0; i-- {
+		logrus.Debugf(`Removing delegation "%s" not present in definitions`, remove[i])
+		if err := addChange(cl, newDeleteDelegationChange(remove[i], nil), remove[i]); err != nil {
+			return err
+		}
+	}
+
+	// Create or update the remaining delegations shallowest first, so that
+	// parents exist before their children
+	var names []data.RoleName
+	for name := range wanted {
+		names = append(names, name)
+	}
+	sortByDepth(names)
+	for _, name := range names {
+		def := wanted[name]
+		threshold := def.Threshold
+		if threshold == 0 {
+			threshold = notary.MinThreshold
+		}
+		td := changelist.TUFDelegation{
+			NewThreshold: threshold,
+			AddKeys:      data.KeyList(def.Keys),
+			AddPaths:     def.Paths,
+		}
+		template := newCreateDelegationChange
+		if _, ok := current[name]; ok {
+			removeKeys, err := r.canonicalDelegationKeyIDs(name)
+			if err != nil {
+				return err
+			}
+			td.RemoveKeys = removeKeys
+			td.ClearAllPaths = true
+			template = newUpdateDelegationChange
+		}
+		logrus.Debugf(`Rebuilding delegation "%s" with threshold %d, %d keys and %d paths`,
+			name, threshold, len(def.Keys), len(def.Paths))
+
+		tdJSON, err := json.Marshal(&td)
+		if err != nil {
+			return err
+		}
+		if err := addChange(cl, template(name, tdJSON), name); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range cl.List() {
+		if err := r.changelist.Add(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalDelegationKeyIDs returns the canonical IDs of the keys of a loaded
+// delegation, which are what a delegation change removes keys by, and which
+// differ from the TUF IDs of certificates
+func (r *repository) canonicalDelegationKeyIDs(name data.RoleName) ([]string, error) {
+	delegation, err := r.tufRepo.GetDelegationRole(name)
+	if err != nil {
+		return nil, err
+	}
+	keyIDs := make([]string, 0, len(delegation.Keys))
+	for _, key := range delegation.Keys {
+		canonicalID, err := utils.CanonicalKeyID(key)
+		if err != nil {
+			return nil, err
+		}
+		keyIDs = append(keyIDs, canonicalID)
+	}
+	sort.Strings(keyIDs)
+	return keyIDs, nil
+}
+
+// validateDelegationDefs checks that the definitions describe a consistent
+// delegation tree, and returns them keyed by role name
+func validateDelegationDefs(defs []DelegationDef) (map[data.RoleName]DelegationDef, error) {
+	wanted := make(map[data.RoleName]DelegationDef, len(defs))
+	for _, def := range defs {
+		if !data.IsDelegation(def.Role) {
+			return nil, data.ErrInvalidRole{Role: def.Role, Reason: "invalid delegation role name"}
+		}
+		if _, ok := wanted[def.Role]; ok {
+			return nil, data.ErrInvalidRole{Role: def.Role, Reason: "delegation defined more than once"}
+		}
+		if def.Threshold < 0 {
+			return nil, data.ErrInvalidRole{Role: def.Role, Reason: "threshold must be positive"}
+		}
+		if len(def.Keys) == 0 || len(def.Keys) < def.Threshold {
+			return nil, data.ErrInvalidRole{
+				Role:   def.Role,
+				Reason: fmt.Sprintf("%d keys is not enough to meet a threshold of %d", len(def.Keys), def.Threshold),
+			}
+		}
+		wanted[def.Role] = def
+	}
+	for name := range wanted {
+		parent := name.Parent()
+		if _, ok := wanted[parent]; !ok && parent != data.CanonicalTargetsRole {
+			return nil, data.ErrInvalidRole{Role: name, Reason: fmt.Sprintf("parent role %s is not defined", parent)}
+		}
+	}
+	return wanted, nil
+}
+
+// sortByDepth orders role names from the shallowest to the deepest delegation,
+// falling back to lexical order for roles at the same depth
+func sortByDepth(names []data.RoleName) {
+	sort.Slice(names, func(i, j int) bool {
+		di := strings.Count(names[i].String(), "/")
+		dj := strings.Count(names[j].String(), "/")
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+}
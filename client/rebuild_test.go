@@ -0,0 +1,163 @@
+package client
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// requireDelegationsMatch asserts that the published delegation roles are
+// exactly those described by the definitions
+func requireDelegationsMatch(t *testing.T, repo *repository, defs []DelegationDef) {
+	roles, err := repo.GetDelegationRoles()
+	require.NoError(t, err)
+	require.Len(t, roles, len(defs))
+
+	byName := make(map[data.RoleName]data.Role)
+	for _, role := range roles {
+		byName[role.Name] = role
+	}
+	for _, def := range defs {
+		role, ok := byName[def.Role]
+		require.True(t, ok, "missing delegation %s", def.Role)
+
+		// the roles are listed with canonical key IDs, which differ from
+		// the TUF IDs of certificates
+		var keyIDs []string
+		for _, k := range def.Keys {
+			keyID, err := utils.CanonicalKeyID(k)
+			require.NoError(t, err)
+			keyIDs = append(keyIDs, keyID)
+		}
+		sort.Strings(keyIDs)
+		sort.Strings(role.KeyIDs)
+		require.Equal(t, keyIDs, role.KeyIDs)
+		require.Equal(t, def.Paths, role.Paths)
+
+		threshold := def.Threshold
+		if threshold == 0 {
+			threshold = 1
+		}
+		require.Equal(t, threshold, role.Threshold)
+	}
+}
+
+// Rebuilding an existing delegation tree deletes, updates and creates roles
+// so the published tree matches the definitions, and keeps the targets of
+// roles that are updated in place
+func TestRebuildDelegations(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	initial := []DelegationDef{
+		{Role: "targets/a", Keys: []data.PublicKey{createKey(t, repo, "targets/a", false)}, Paths: []string{"a"}},
+		{Role: "targets/a/b", Keys: []data.PublicKey{createKey(t, repo, "targets/a/b", false)}, Paths: []string{"a/b"}},
+		{Role: "targets/c", Keys: []data.PublicKey{createKey(t, repo, "targets/c", false)}, Paths: []string{"c"}},
+	}
+	require.NoError(t, repo.RebuildDelegations(initial))
+	require.NoError(t, repo.Publish())
+	requireDelegationsMatch(t, repo, initial)
+
+	addTarget(t, repo, "a/current", "../fixtures/intermediate-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+
+	rebuilt := []DelegationDef{
+		{
+			Role: "targets/a",
+			Keys: []data.PublicKey{
+				createKey(t, repo, "targets/a", false),
+				createKey(t, repo, "targets/a", false),
+			},
+			Paths:     []string{"a", "x"},
+			Threshold: 2,
+		},
+		initial[1],
+		{Role: "targets/d", Keys: []data.PublicKey{createKey(t, repo, "targets/d", false)}, Paths: []string{""}},
+	}
+	require.NoError(t, repo.RebuildDelegations(rebuilt))
+	require.NoError(t, repo.Publish())
+	requireDelegationsMatch(t, repo, rebuilt)
+
+	// a fresh client sees the same tree, and the target in the rebuilt role survived
+	repo2, _, dir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(dir2)
+	requireDelegationsMatch(t, repo2, rebuilt)
+
+	targets, err := repo2.ListTargets("targets/a")
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "a/current", targets[0].Name)
+}
+
+// Rebuilding a delegation whose keys are certificates replaces those keys,
+// rather than keeping them alongside the new ones
+func TestRebuildDelegationsWithCertificateKeys(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	initial := []DelegationDef{
+		{Role: "targets/a", Keys: []data.PublicKey{createKey(t, repo, "targets/a", true)}, Paths: []string{""}},
+	}
+	require.NoError(t, repo.RebuildDelegations(initial))
+	require.NoError(t, repo.Publish())
+	requireDelegationsMatch(t, repo, initial)
+
+	rebuilt := []DelegationDef{
+		{Role: "targets/a", Keys: []data.PublicKey{createKey(t, repo, "targets/a", true)}, Paths: []string{""}},
+	}
+	require.NoError(t, repo.RebuildDelegations(rebuilt))
+	require.NoError(t, repo.Publish())
+	requireDelegationsMatch(t, repo, rebuilt)
+}
+
+// Rebuilding with an empty set of definitions removes every delegation
+func TestRebuildDelegationsToEmpty(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	require.NoError(t, repo.AddDelegation("targets/a/b", []data.PublicKey{key}, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	require.NoError(t, repo.RebuildDelegations(nil))
+	require.NoError(t, repo.Publish())
+	requireDelegationsMatch(t, repo, nil)
+}
+
+// Invalid definitions are rejected without staging any changes
+func TestRebuildDelegationsInvalidDefinitions(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	invalid := [][]DelegationDef{
+		{{Role: "targets", Keys: []data.PublicKey{key}}},
+		{{Role: "targets/a", Keys: []data.PublicKey{key}}, {Role: "targets/a", Keys: []data.PublicKey{key}}},
+		{{Role: "targets/a", Keys: []data.PublicKey{key}, Threshold: 2}},
+		{{Role: "targets/a"}},
+		{{Role: "targets/a/b", Keys: []data.PublicKey{key}}},
+	}
+	for _, defs := range invalid {
+		err := repo.RebuildDelegations(defs)
+		require.Error(t, err)
+		require.IsType(t, data.ErrInvalidRole{}, err)
+	}
+	require.Len(t, getChanges(t, repo), 0)
+}
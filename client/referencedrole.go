@@ -0,0 +1,40 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrEmptyReferencedRole is returned when the snapshot references a targets or
+// delegation role, but the metadata served for it, though it matches the
+// snapshot's checksum, is empty or is not a signed metadata document.  This
+// points at a server which recorded a role in the snapshot without its
+// content.
+type ErrEmptyReferencedRole struct {
+	Role   data.RoleName
+	Reason string
+}
+
+func (err ErrEmptyReferencedRole) Error() string {
+	return fmt.Sprintf("the snapshot references %s, but its metadata %s", err.Role, err.Reason)
+}
+
+// checkReferencedRole checks that the raw metadata fetched for a targets role
+// referenced by the snapshot, which has matched the snapshot's checksum, is a
+// JSON document with a signed portion, before it is verified
+func checkReferencedRole(role data.RoleName, raw []byte) error {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return ErrEmptyReferencedRole{Role: role, Reason: "is empty"}
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return ErrEmptyReferencedRole{Role: role, Reason: "is not a signed metadata document"}
+	}
+	if s.Signed == nil || bytes.Equal(bytes.TrimSpace(*s.Signed), []byte("null")) {
+		return ErrEmptyReferencedRole{Role: role, Reason: "has no signed portion"}
+	}
+	return nil
+}
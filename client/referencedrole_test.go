@@ -0,0 +1,66 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// replaceReferencedRole serves the given content for a role, with the snapshot
+// and timestamp updated to reference it by its checksum
+func replaceReferencedRole(t *testing.T, s *testutils.MetadataSwizzler, role data.RoleName, content []byte) {
+	require.NoError(t, s.MetadataCache.Set(role.String(), content))
+	require.NoError(t, s.UpdateSnapshotHashes(role))
+	require.NoError(t, s.UpdateTimestampHash())
+}
+
+// A delegation which the snapshot references, with the checksum of empty
+// content, is reported rather than failing to parse
+func TestUpdateDetectsEmptyReferencedRole(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.updateTUF(false))
+
+	replaceReferencedRole(t, serverSwizzler, "targets/a", []byte{})
+	require.Equal(t, ErrEmptyReferencedRole{Role: "targets/a", Reason: "is empty"}, repo.updateTUF(false))
+
+	// nor is a document with no signed portion
+	_, serverSwizzler = newServerSwizzler(t)
+	ts2 := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts2.Close()
+	replaceReferencedRole(t, serverSwizzler, "targets/b", []byte(`{"signatures": []}`))
+	repo2, baseDir2 := newBlankRepo(t, ts2.URL)
+	defer os.RemoveAll(baseDir2)
+	require.Equal(t, ErrEmptyReferencedRole{Role: "targets/b", Reason: "has no signed portion"}, repo2.updateTUF(false))
+}
+
+// Empty metadata which does not match the snapshot's checksum is reported as
+// a checksum mismatch, since the snapshot did not reference it
+func TestUpdateEmptyRoleWithMismatchedChecksum(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+	require.NoError(t, serverSwizzler.MetadataCache.Set("targets/a", []byte{}))
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	err := repo.updateTUF(false)
+	require.Error(t, err)
+	require.IsType(t, data.ErrMismatchedChecksum{}, err)
+}
+
+func TestCheckReferencedRole(t *testing.T) {
+	require.NoError(t, checkReferencedRole(data.CanonicalTargetsRole, []byte(`{"signed": {"_type": "Targets"}, "signatures": []}`)))
+	require.IsType(t, ErrEmptyReferencedRole{}, checkReferencedRole(data.CanonicalTargetsRole, []byte(" \n")))
+	require.IsType(t, ErrEmptyReferencedRole{}, checkReferencedRole(data.CanonicalTargetsRole, []byte(`{"signed": null}`)))
+	require.Equal(t, ErrEmptyReferencedRole{Role: data.CanonicalTargetsRole, Reason: "is not a signed metadata document"},
+		checkReferencedRole(data.CanonicalTargetsRole, []byte("not json")))
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrRemapOutsideDelegation is returned by RemapTargets when the new name of a
+// target would not be within the paths delegated to its role
+type ErrRemapOutsideDelegation struct {
+	Role data.RoleName
+	Name string
+}
+
+func (err ErrRemapOutsideDelegation) Error() string {
+	return fmt.Sprintf("cannot remap a target to %s, which is not within the paths delegated to %s", err.Name, err.Role)
+}
+
+// RemapTargets creates changelist entries to rename every target whose name
+// starts with prefix, in every role, so that it starts with newPrefix instead,
+// when the changelist gets applied at publish time.  A renamed target keeps its
+// length, hashes, and custom data.  The number of targets remapped is
+// returned, counting a target once for each role it is in.  If any new name
+// would be outside the paths delegated to its role, nothing is staged.
+func (r *repository) RemapTargets(prefix, newPrefix string) (int, error) {
+	if prefix == newPrefix {
+		return 0, nil
+	}
+	if err := r.updateTUF(false); err != nil {
+		return 0, err
+	}
+
+	type remap struct {
+		role    data.RoleName
+		oldName string
+		target  Target
+	}
+	roles := make([]data.RoleName, 0, len(r.tufRepo.Targets))
+	for role := range r.tufRepo.Targets {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+
+	var remaps []remap
+	for _, role := range roles {
+		var delegation *data.DelegationRole
+		if data.IsDelegation(role) {
+			d, err := r.tufRepo.GetDelegationRole(role)
+			if err != nil {
+				return 0, err
+			}
+			delegation = &d
+		}
+		targets := r.tufRepo.Targets[role].Signed.Targets
+		names := make([]string, 0, len(targets))
+		for name := range targets {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			newName := newPrefix + strings.TrimPrefix(name, prefix)
+			if delegation != nil && !delegation.CheckPaths(newName) {
+				return 0, ErrRemapOutsideDelegation{Role: role, Name: newName}
+			}
+			remaps = append(remaps, remap{role: role, oldName: name, target: targetFromMeta(newName, targets[name])})
+		}
+	}
+
+	// remove every old name before adding any new one, since a new name may
+	// also be the old name of another remapped target
+	for _, m := range remaps {
+		if err := r.RemoveTarget(m.oldName, m.role); err != nil {
+			return 0, err
+		}
+	}
+	for _, m := range remaps {
+		target := m.target
+		if err := r.AddTarget(&target, m.role); err != nil {
+			return 0, err
+		}
+	}
+	return len(remaps), nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Targets under a prefix are renamed in every role they are in, keeping their
+// metadata, and targets not under the prefix are left alone
+func TestRemapTargets(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	key := createKey(t, repo, "targets/docs", false)
+	require.NoError(t, repo.AddDelegation("targets/docs", []data.PublicKey{key}, []string{"docs/"}))
+
+	custom := json.RawMessage(`{"arch":"amd64"}`)
+	addTargetWithCustom(t, repo, "bin/a", "../fixtures/intermediate-ca.crt", &custom)
+	addTarget(t, repo, "bin/b", "../fixtures/root-ca.crt")
+	addTarget(t, repo, "README", "../fixtures/root-ca.crt")
+	addTarget(t, repo, "docs/old/guide", "../fixtures/intermediate-ca.crt", "targets/docs")
+	addTarget(t, repo, "docs/old/docs/old/api", "../fixtures/root-ca.crt", "targets/docs")
+	require.NoError(t, repo.Publish())
+	before, err := repo.ListTargets()
+	require.NoError(t, err)
+	byName := make(map[string]*TargetWithRole)
+	for _, target := range before {
+		byName[target.Name] = target
+	}
+
+	n, err := repo.RemapTargets("bin/", "tools/bin/")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	// the new name of one target is the old name of another
+	n, err = repo.RemapTargets("docs/old/", "docs/old/docs/old/")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.NoError(t, repo.Publish())
+
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+	after, err := repo2.ListTargets()
+	require.NoError(t, err)
+	renamed := map[string]string{
+		"tools/bin/a":                    "bin/a",
+		"tools/bin/b":                    "bin/b",
+		"README":                         "README",
+		"docs/old/docs/old/guide":        "docs/old/guide",
+		"docs/old/docs/old/docs/old/api": "docs/old/docs/old/api",
+	}
+	require.Len(t, after, len(renamed))
+	for _, target := range after {
+		oldName, ok := renamed[target.Name]
+		require.True(t, ok, "unexpected target %s", target.Name)
+		old := byName[oldName]
+		require.Equal(t, old.Role, target.Role)
+		require.Equal(t, old.Length, target.Length)
+		require.Equal(t, old.Hashes, target.Hashes)
+		require.Equal(t, old.Custom, target.Custom)
+	}
+}
+
+// A target can't be remapped outside the paths delegated to its role, and
+// then nothing is staged
+func TestRemapTargetsOutsideDelegation(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	key := createKey(t, repo, "targets/docs", false)
+	require.NoError(t, repo.AddDelegation("targets/docs", []data.PublicKey{key}, []string{"docs/"}))
+	addTarget(t, repo, "docs/guide", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "docs/guide", "../fixtures/intermediate-ca.crt", "targets/docs")
+	require.NoError(t, repo.Publish())
+
+	_, err := repo.RemapTargets("docs/", "manual/")
+	require.Error(t, err)
+	require.Equal(t, ErrRemapOutsideDelegation{Role: "targets/docs", Name: "manual/guide"}, err)
+	require.Empty(t, getChanges(t, repo))
+
+	n, err := repo.RemapTargets("nothing/", "matches/")
+	require.NoError(t, err)
+	require.Zero(t, n)
+	require.Empty(t, getChanges(t, repo))
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"sort"
+	"time"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// RenewableRoles reports which roles can be renewed on this machine right
+// now: those whose metadata has expired or is nearing expiry, by the same six
+// month window as the warnings given on update, and for which the local
+// crypto service holds enough of the authorized keys to meet the role's
+// threshold.  Expiry is found as by DiagnoseExpiry, and each role's keys are
+// read from the root, or from the metadata of the role delegating to it.  As
+// with DiagnoseExpiry, none of that metadata is verified, so that expired
+// roles can be found.  Base roles are reported first, followed by delegations
+// in order of name.
+func (r *repository) RenewableRoles() ([]data.RoleName, error) {
+	diagnoses, err := r.DiagnoseExpiry()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	nearExpiry := now.AddDate(0, 6, 0)
+
+	var root *data.SignedRoot
+	parents := make(map[data.RoleName]*data.SignedTargets)
+	var renewable, renewableDelegations []data.RoleName
+	for _, diagnosis := range diagnoses {
+		if !diagnosis.Expires.Before(nearExpiry) {
+			continue
+		}
+		role := diagnosis.Role
+
+		var keys map[string]data.PublicKey
+		var threshold int
+		if data.IsDelegation(role) {
+			parent, ok := parents[role.Parent()]
+			if !ok {
+				parent = &data.SignedTargets{}
+				if _, err := r.unverifiedMetadata(role.Parent(), notary.MaxDownloadSize, parent); err != nil {
+					parent = nil
+				}
+				parents[role.Parent()] = parent
+			}
+			if parent == nil {
+				continue
+			}
+			for _, delegation := range parent.Signed.Delegations.Roles {
+				if delegation.Name == role {
+					keys = rolesKeys(parent.Signed.Delegations.Keys, delegation.KeyIDs)
+					threshold = delegation.Threshold
+				}
+			}
+		} else {
+			if root == nil {
+				root = &data.SignedRoot{}
+				if _, err := r.unverifiedMetadata(data.CanonicalRootRole, notary.MaxDownloadSize, root); err != nil {
+					return nil, err
+				}
+			}
+			if rootRole, ok := root.Signed.Roles[role]; ok {
+				keys = rolesKeys(root.Signed.Keys, rootRole.KeyIDs)
+				threshold = rootRole.Threshold
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		if gap, _ := r.keyGap(role, keys, threshold); gap.Blocking() {
+			continue
+		}
+		if data.IsDelegation(role) {
+			renewableDelegations = append(renewableDelegations, role)
+		} else {
+			renewable = append(renewable, role)
+		}
+	}
+	sort.Slice(renewableDelegations, func(i, j int) bool { return renewableDelegations[i] < renewableDelegations[j] })
+	return append(renewable, renewableDelegations...), nil
+}
+
+// rolesKeys picks the keys with the given IDs out of keys
+func rolesKeys(keys data.Keys, keyIDs []string) map[string]data.PublicKey {
+	picked := make(map[string]data.PublicKey, len(keyIDs))
+	for _, keyID := range keyIDs {
+		if key, ok := keys[keyID]; ok {
+			picked[keyID] = key
+		}
+	}
+	return picked
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// Roles are renewable once near expiry only if enough of their keys are held,
+// so the server's timestamp, and a delegation whose key has been removed, are
+// never reported
+func TestRenewableRoles(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	held := createKey(t, repo, "targets/held", false)
+	removed := createKey(t, repo, "targets/removed", false)
+	require.NoError(t, repo.AddDelegation("targets/held", []data.PublicKey{held}, []string{""}))
+	require.NoError(t, repo.AddDelegation("targets/removed", []data.PublicKey{removed}, []string{""}))
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt", "targets/held", "targets/removed")
+	require.NoError(t, repo.Publish())
+	removedID, err := utils.CanonicalKeyID(removed)
+	require.NoError(t, err)
+	require.NoError(t, repo.GetCryptoService().RemoveKey(removedID))
+
+	// nothing is due for renewal straight after publishing
+	renewable, err := repo.RenewableRoles()
+	require.NoError(t, err)
+	require.Empty(t, renewable)
+
+	// years ahead, all but the root have expired, and only the roles whose
+	// keys are held can be renewed
+	repo.now = func() time.Time { return time.Now().AddDate(4, 0, 0) }
+	renewable, err = repo.RenewableRoles()
+	require.NoError(t, err)
+	require.Equal(t, []data.RoleName{data.CanonicalSnapshotRole, data.CanonicalTargetsRole, "targets/held"}, renewable)
+	expired := make(map[data.RoleName]bool)
+	diagnoses, err := repo.DiagnoseExpiry()
+	require.NoError(t, err)
+	for _, d := range diagnoses {
+		expired[d.Role] = d.Expired
+	}
+	require.True(t, expired[data.CanonicalTimestampRole])
+	require.True(t, expired["targets/removed"])
+
+	// the root is renewable as it nears expiry
+	repo.now = func() time.Time { return time.Now().AddDate(9, 8, 0) }
+	renewable, err = repo.RenewableRoles()
+	require.NoError(t, err)
+	require.Equal(t, []data.RoleName{data.CanonicalRootRole, data.CanonicalSnapshotRole,
+		data.CanonicalTargetsRole, "targets/held"}, renewable)
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"encoding/json"
+	"sort"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// UnresolvableTarget is a target which a lookup by name would not find in the
+// role which lists it.  Name is empty for a delegation whose metadata was not
+// fetched and is not cached, since which targets it lists is not known.
+type UnresolvableTarget struct {
+	Name   string
+	Role   data.RoleName
+	Reason string
+}
+
+// ValidateTargetResolution checks, against the repository as last updated and
+// without updating it, that every target is reachable through a verified
+// delegation chain.  It reports targets listed by loaded metadata which a
+// lookup would not find in that role, such as those outside its delegated
+// paths or in a role no longer delegated to, and the targets of published
+// delegations whose metadata was not fetched, such as after a lazy or partial
+// update.  The targets of such a delegation are read from the cache, unverified,
+// where it is cached from an earlier update.
+func (r *repository) ValidateTargetResolution() ([]UnresolvableTarget, error) {
+	if r.tufRepo == nil || r.tufRepo.Targets[data.CanonicalTargetsRole] == nil {
+		return nil, tuf.ErrNotLoaded{Role: data.CanonicalTargetsRole}
+	}
+	readOnly := NewReadOnly(r.tufRepo)
+
+	unresolvable := []UnresolvableTarget{}
+	delegated := make(map[data.RoleName]bool)
+	for role, tgts := range r.tufRepo.Targets {
+		for name := range tgts.Signed.Targets {
+			if !foundInRole(readOnly, name, role) {
+				unresolvable = append(unresolvable, UnresolvableTarget{
+					Name: name, Role: role, Reason: "not reachable through a verified delegation chain",
+				})
+			}
+		}
+		for _, child := range tgts.Signed.Delegations.Roles {
+			delegated[child.Name] = true
+		}
+	}
+
+	for role := range delegated {
+		if _, loaded := r.tufRepo.Targets[role]; loaded || r.tufRepo.Snapshot == nil {
+			continue
+		}
+		if _, published := r.tufRepo.Snapshot.Signed.Meta[role.String()]; !published {
+			continue
+		}
+		const reason = "the delegation's metadata was not fetched"
+		names := r.cachedTargetNames(role)
+		if len(names) == 0 {
+			unresolvable = append(unresolvable, UnresolvableTarget{Role: role, Reason: reason})
+		}
+		for _, name := range names {
+			unresolvable = append(unresolvable, UnresolvableTarget{Name: name, Role: role, Reason: reason})
+		}
+	}
+
+	sort.Slice(unresolvable, func(i, j int) bool {
+		if unresolvable[i].Role != unresolvable[j].Role {
+			return unresolvable[i].Role < unresolvable[j].Role
+		}
+		return unresolvable[i].Name < unresolvable[j].Name
+	})
+	return unresolvable, nil
+}
+
+// foundInRole returns whether looking up the target by name finds it in role
+func foundInRole(readOnly ReadOnly, name string, role data.RoleName) bool {
+	found, err := readOnly.GetAllTargetMetadataByName(name)
+	if err != nil {
+		return false
+	}
+	for _, t := range found {
+		if t.Role.Name == role {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedTargetNames returns the names of the targets in the cached, unverified
+// metadata of a role, if any is cached
+func (r *repository) cachedTargetNames(role data.RoleName) []string {
+	raw, err := r.cache.GetSized(role.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil
+	}
+	tgts, err := data.TargetsFromSigned(s, role)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(tgts.Signed.Targets))
+	for name := range tgts.Signed.Targets {
+		names = append(names, name)
+	}
+	return names
+}
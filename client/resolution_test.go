@@ -0,0 +1,86 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A delegation which an update did not fetch leaves its targets unresolvable
+// until it is fetched
+func TestValidateTargetResolutionReportsUnfetchedDelegations(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.AddDelegation("targets/a",
+		[]data.PublicKey{createKey(t, repo, "targets/a", false)}, []string{""}))
+	addTarget(t, repo, "top", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "app", "../fixtures/root-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.ValidateTargetResolution()
+	require.IsType(t, tuf.ErrNotLoaded{}, err)
+
+	require.NoError(t, reader.updateTUF(false))
+	unresolvable, err := reader.ValidateTargetResolution()
+	require.NoError(t, err)
+	require.Empty(t, unresolvable)
+
+	// a partial update keeps targets/a's metadata only in the cache
+	reader.SetUpdateOptions(UpdateOptions{LazyDelegations: true})
+	require.NoError(t, reader.updateTUF(false))
+	require.NotContains(t, reader.tufRepo.Targets, data.RoleName("targets/a"))
+	unresolvable, err = reader.ValidateTargetResolution()
+	require.NoError(t, err)
+	require.Len(t, unresolvable, 1)
+	require.Equal(t, "app", unresolvable[0].Name)
+	require.Equal(t, data.RoleName("targets/a"), unresolvable[0].Role)
+
+	require.NoError(t, reader.ResolveDelegation("targets/a"))
+	unresolvable, err = reader.ValidateTargetResolution()
+	require.NoError(t, err)
+	require.Empty(t, unresolvable)
+
+	// with nothing cached, the delegation is reported without its targets
+	blank, _, blankDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(blankDir)
+	blank.SetUpdateOptions(UpdateOptions{LazyDelegations: true})
+	require.NoError(t, blank.updateTUF(false))
+	unresolvable, err = blank.ValidateTargetResolution()
+	require.NoError(t, err)
+	require.Len(t, unresolvable, 1)
+	require.Equal(t, UnresolvableTarget{Role: "targets/a", Reason: unresolvable[0].Reason}, unresolvable[0])
+}
+
+// A target outside the paths its role is delegated is reported
+func TestValidateTargetResolutionReportsTargetsOutsideDelegatedPaths(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.AddDelegation("targets/a",
+		[]data.PublicKey{createKey(t, repo, "targets/a", false)}, []string{""}))
+	addTarget(t, repo, "app", "../fixtures/root-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+
+	require.NoError(t, repo.RemoveDelegationPaths("targets/a", []string{""}))
+	require.NoError(t, repo.AddDelegationPaths("targets/a", []string{"other/"}))
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	require.NoError(t, reader.updateTUF(false))
+	unresolvable, err := reader.ValidateTargetResolution()
+	require.NoError(t, err)
+	require.Len(t, unresolvable, 1)
+	require.Equal(t, "app", unresolvable[0].Name)
+	require.Equal(t, data.RoleName("targets/a"), unresolvable[0].Role)
+}
@@ -0,0 +1,15 @@
+package client
+
+import "github.com/theupdateframework/notary/tuf/data"
+
+// AllowCustomRoleNamespaces sets whether delegation role names may use upper
+// case letters, dots, "@" and "+" in addition to the usual lower case letters,
+// digits, "-", "_" and "/", for interoperability with TUF repositories which
+// name their roles differently.  It applies to every repository in the
+// process, both when creating delegations and when verifying downloaded ones,
+// and a notary server must be configured likewise to accept such roles.
+// Delegation names must still start with "targets/", so that every delegation
+// chains from the targets role.
+func AllowCustomRoleNamespaces(allow bool) {
+	data.AllowCustomRoleNamespaces(allow)
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A delegation with a name outside the usual character set can only be created
+// once custom role namespaces are allowed, after which it is published and
+// verified like any other delegation
+func TestCustomRoleNamespaces(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	role := data.RoleName("targets/Releases/v1.2")
+	key := createKey(t, repo, role, false)
+
+	err := repo.AddDelegation(role, []data.PublicKey{key}, []string{""})
+	require.Error(t, err)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+
+	AllowCustomRoleNamespaces(true)
+	defer AllowCustomRoleNamespaces(false)
+
+	// delegations must still chain from the targets role
+	err = repo.AddDelegation("Releases/v1.2", []data.PublicKey{key}, []string{""})
+	require.Error(t, err)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+
+	require.NoError(t, repo.AddDelegation("targets/Releases", []data.PublicKey{key}, []string{""}))
+	require.NoError(t, repo.AddDelegation(role, []data.PublicKey{key}, []string{""}))
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt", role)
+	require.NoError(t, repo.Publish())
+
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+	require.NoError(t, repo2.VerifyFullTree())
+
+	target, err := repo2.GetTargetByName("current", role)
+	require.NoError(t, err)
+	require.Equal(t, role, target.Role)
+}
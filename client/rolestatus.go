@@ -0,0 +1,106 @@
+package client
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// RoleStatus is the verification status of a role's metadata against the
+// repository's trusted state, as computed when it was exported
+type RoleStatus struct {
+	Role data.RoleName `json:"role"`
+	// Verified is whether the metadata is signed by a threshold of the role's
+	// trusted keys and is unexpired, and Error is why not, if it isn't
+	Verified bool      `json:"verified"`
+	Error    string    `json:"error,omitempty"`
+	Version  int       `json:"version"`
+	Expires  time.Time `json:"expires"`
+	Expired  bool      `json:"expired"`
+	// SigningKeyIDs are the IDs of the role's trusted keys which have validly
+	// signed the metadata, sorted
+	SigningKeyIDs []string `json:"signing_key_ids"`
+}
+
+// RoleWithStatus is a role's signed metadata, exactly as served, together
+// with its verification status
+type RoleWithStatus struct {
+	Status   RoleStatus      `json:"status"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// ExportRoleWithStatus updates the repository, and then returns, as JSON, the
+// RoleWithStatus of the given role: its metadata wrapped with the result of
+// verifying it against the keys and threshold which the repository now trusts
+// for the role, its version and expiry, and the keys which signed it.  The
+// status is a snapshot for display, and is not itself signed.
+func (r *repository) ExportRoleWithStatus(role string) ([]byte, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	roleName := data.RoleName(role)
+	loaded := false
+	for _, name := range loadedRoles(r.tufRepo) {
+		if name == roleName {
+			loaded = true
+			break
+		}
+	}
+	if !loaded {
+		return nil, data.ErrInvalidRole{Role: roleName, Reason: "the role has no metadata to export"}
+	}
+
+	raw, err := r.cache.GetSized(roleName.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	status, err := r.roleStatus(roleName, raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(RoleWithStatus{Status: status, Metadata: raw})
+}
+
+// roleStatus verifies the raw metadata of a role against the keys which the
+// repository trusts for it
+func (r *repository) roleStatus(role data.RoleName, raw []byte) (RoleStatus, error) {
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return RoleStatus{}, err
+	}
+	common := data.SignedCommon{}
+	if err := json.Unmarshal(*s.Signed, &common); err != nil {
+		return RoleStatus{}, err
+	}
+	baseRole, err := r.signingRole(role)
+	if err != nil {
+		return RoleStatus{}, err
+	}
+
+	status := RoleStatus{
+		Role:          role,
+		Version:       common.Version,
+		Expires:       common.Expires,
+		Expired:       signed.IsExpired(common.Expires),
+		SigningKeyIDs: []string{},
+	}
+	verifyErr := signed.VerifySignatures(s, baseRole)
+	if verifyErr == nil {
+		verifyErr = signed.VerifyExpiry(&common, role)
+	}
+	status.Verified = verifyErr == nil
+	if verifyErr != nil {
+		status.Error = verifyErr.Error()
+	}
+	for _, sig := range s.Signatures {
+		if sig.IsValid {
+			status.SigningKeyIDs = append(status.SigningKeyIDs, sig.KeyID)
+		}
+	}
+	sort.Strings(status.SigningKeyIDs)
+	return status, nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// The status embedded with each role's metadata matches the trusted state:
+// the loaded version and expiry, and the role's keys which signed it
+func TestExportRoleWithStatus(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.AddDelegation("targets/releases",
+		[]data.PublicKey{createKey(t, repo, "targets/releases", false)}, []string{""}))
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt", "targets/releases")
+	require.NoError(t, repo.Publish())
+
+	for _, role := range append(data.BaseRoles, "targets/releases") {
+		out, err := repo.ExportRoleWithStatus(role.String())
+		require.NoError(t, err)
+		exported := RoleWithStatus{}
+		require.NoError(t, json.Unmarshal(out, &exported))
+
+		cached, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		require.Equal(t, json.RawMessage(cached), exported.Metadata)
+
+		var common data.SignedCommon
+		switch role {
+		case data.CanonicalRootRole:
+			common = repo.tufRepo.Root.Signed.SignedCommon
+		case data.CanonicalSnapshotRole:
+			common = repo.tufRepo.Snapshot.Signed.SignedCommon
+		case data.CanonicalTimestampRole:
+			common = repo.tufRepo.Timestamp.Signed.SignedCommon
+		default:
+			common = repo.tufRepo.Targets[role].Signed.SignedCommon
+		}
+		baseRole, err := repo.signingRole(role)
+		require.NoError(t, err)
+		keyIDs := baseRole.ListKeyIDs()
+		sort.Strings(keyIDs)
+
+		require.Equal(t, role, exported.Status.Role)
+		require.True(t, exported.Status.Verified, exported.Status.Error)
+		require.Empty(t, exported.Status.Error)
+		require.Equal(t, common.Version, exported.Status.Version)
+		require.True(t, common.Expires.Equal(exported.Status.Expires))
+		require.False(t, exported.Status.Expired)
+		require.Equal(t, keyIDs, exported.Status.SigningKeyIDs)
+	}
+
+	_, err := repo.ExportRoleWithStatus("targets/unknown")
+	require.IsType(t, data.ErrInvalidRole{}, err)
+}
+
+// Metadata which is not signed by the role's trusted keys is reported as
+// unverified, with no signing keys
+func TestRoleStatusUntrustedSigner(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	require.NoError(t, repo.updateTUF(false))
+
+	s, err := repo.tufRepo.Targets[data.CanonicalTargetsRole].ToSigned()
+	require.NoError(t, err)
+	otherService := cryptoservice.NewCryptoService(trustmanager.NewKeyMemoryStore(passphraseRetriever))
+	otherKey, err := otherService.Create(data.CanonicalTargetsRole, repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, signed.Sign(otherService, s, []data.PublicKey{otherKey}, 1, nil))
+	raw, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	status, err := repo.roleStatus(data.CanonicalTargetsRole, raw)
+	require.NoError(t, err)
+	require.False(t, status.Verified)
+	require.NotEmpty(t, status.Error)
+	require.Empty(t, status.SigningKeyIDs)
+}
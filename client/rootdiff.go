@@ -0,0 +1,121 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// ErrInvalidSuppliedRoot is returned when a root supplied for comparison can't
+// be parsed, or is not signed by a threshold of its own root role's keys
+type ErrInvalidSuppliedRoot struct {
+	Err error
+}
+
+func (err ErrInvalidSuppliedRoot) Error() string {
+	return fmt.Sprintf("the supplied root is not valid: %s", err.Err)
+}
+
+// RoleDiff describes how the keys and threshold of a base role differ between
+// two roots
+type RoleDiff struct {
+	Role              data.RoleName
+	LocalThreshold    int
+	SuppliedThreshold int
+	// OnlyLocal and OnlySupplied are the sorted IDs of the role's keys which
+	// only the local root, or only the supplied root, lists
+	OnlyLocal    []string
+	OnlySupplied []string
+}
+
+// RootDiff describes how a supplied root differs from the locally cached root
+type RootDiff struct {
+	LocalVersion    int
+	SuppliedVersion int
+	// Roles are the base roles whose keys or threshold differ, in the order of
+	// data.BaseRoles
+	Roles []RoleDiff
+}
+
+// Identical returns true if the keys and thresholds of every base role are the
+// same in both roots, regardless of their versions
+func (d RootDiff) Identical() bool {
+	return len(d.Roles) == 0
+}
+
+// RootDiffAgainst compares the locally cached root with a root supplied from
+// elsewhere, such as a backup or another machine, and reports the differences
+// in the keys and threshold of each base role.  The supplied root must be a
+// well formed root which is signed by a threshold of its own root role's keys,
+// or ErrInvalidSuppliedRoot is returned.  It is not required to be trusted, and
+// the repository is not updated.
+func (r *repository) RootDiffAgainst(rootBytes []byte) (RootDiff, error) {
+	supplied, err := selfConsistentRoot(rootBytes)
+	if err != nil {
+		return RootDiff{}, ErrInvalidSuppliedRoot{Err: err}
+	}
+
+	raw, err := r.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	if err != nil {
+		return RootDiff{}, err
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return RootDiff{}, err
+	}
+	local, err := data.RootFromSigned(s)
+	if err != nil {
+		return RootDiff{}, err
+	}
+
+	diff := RootDiff{LocalVersion: local.Signed.Version, SuppliedVersion: supplied.Signed.Version}
+	for _, role := range data.BaseRoles {
+		localRole, err := local.BuildBaseRole(role)
+		if err != nil {
+			return RootDiff{}, err
+		}
+		suppliedRole, err := supplied.BuildBaseRole(role)
+		if err != nil {
+			return RootDiff{}, ErrInvalidSuppliedRoot{Err: err}
+		}
+		localIDs, suppliedIDs := localRole.ListKeyIDs(), suppliedRole.ListKeyIDs()
+		sort.Strings(localIDs)
+		sort.Strings(suppliedIDs)
+		if localRole.Threshold == suppliedRole.Threshold && sameKeyIDs(localIDs, suppliedIDs) {
+			continue
+		}
+		diff.Roles = append(diff.Roles, RoleDiff{
+			Role:              role,
+			LocalThreshold:    localRole.Threshold,
+			SuppliedThreshold: suppliedRole.Threshold,
+			OnlyLocal:         keyIDDifference(localIDs, suppliedIDs),
+			OnlySupplied:      keyIDDifference(suppliedIDs, localIDs),
+		})
+	}
+	return diff, nil
+}
+
+// selfConsistentRoot parses a root, and verifies that it is signed by a
+// threshold of the keys of the root role it describes
+func selfConsistentRoot(rootBytes []byte) (*data.SignedRoot, error) {
+	s := &data.Signed{}
+	if err := json.Unmarshal(rootBytes, s); err != nil {
+		return nil, err
+	}
+	root, err := data.RootFromSigned(s)
+	if err != nil {
+		return nil, err
+	}
+	rootRole, err := root.BuildBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return nil, err
+	}
+	if err := signed.VerifySignatures(s, rootRole); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
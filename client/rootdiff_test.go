@@ -0,0 +1,83 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// Comparing the cached root with a copy of itself reports no differences, and
+// comparing it with a root from before a key rotation reports the rotated keys
+func TestRootDiffAgainst(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	oldRoot, err := repo.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	diff, err := repo.RootDiffAgainst(oldRoot)
+	require.NoError(t, err)
+	require.True(t, diff.Identical())
+	require.Equal(t, RootDiff{LocalVersion: 1, SuppliedVersion: 1}, diff)
+
+	oldTargets, err := repo.tufRepo.GetBaseRole(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.NoError(t, repo.RotateKey(data.CanonicalTargetsRole, false, nil))
+	require.NoError(t, repo.Publish())
+	require.NoError(t, repo.updateTUF(false))
+	newTargets, err := repo.tufRepo.GetBaseRole(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+
+	diff, err = repo.RootDiffAgainst(oldRoot)
+	require.NoError(t, err)
+	require.False(t, diff.Identical())
+	require.Equal(t, RootDiff{
+		LocalVersion:    2,
+		SuppliedVersion: 1,
+		Roles: []RoleDiff{{
+			Role:              data.CanonicalTargetsRole,
+			LocalThreshold:    1,
+			SuppliedThreshold: 1,
+			OnlyLocal:         newTargets.ListKeyIDs(),
+			OnlySupplied:      oldTargets.ListKeyIDs(),
+		}},
+	}, diff)
+}
+
+// A supplied root which is malformed, or not signed by its own root keys, is
+// rejected before being compared
+func TestRootDiffAgainstInvalidRoot(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	_, err := repo.RootDiffAgainst([]byte("not a root"))
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidSuppliedRoot{}, err)
+
+	// a root signed only by a key which is not one of its root keys
+	root := repo.tufRepo.Root
+	root.Signed.Version++
+	s, err := root.ToSigned()
+	require.NoError(t, err)
+	s.Signatures = nil
+	otherKey, err := repo.GetCryptoService().Create(data.CanonicalTargetsRole, repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, signed.Sign(repo.GetCryptoService(), s, []data.PublicKey{otherKey}, 1, nil))
+	forged, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	_, err = repo.RootDiffAgainst(forged)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidSuppliedRoot{}, err)
+}
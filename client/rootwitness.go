@@ -0,0 +1,150 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+const (
+	// RootWitnessesRole is the delegation whose direct child delegations are
+	// the witnesses who may countersign the root, one for each party
+	RootWitnessesRole data.RoleName = "targets/witnesses"
+	// RootWitnessTarget is the name of the target by which a witness
+	// countersigns the root, whose entry must match the root's entry in the
+	// snapshot
+	RootWitnessTarget = "root.json"
+)
+
+// ErrInsufficientRootWitnesses is returned by an update when fewer independent
+// witnesses have countersigned the root than the trust pinning requires
+type ErrInsufficientRootWitnesses struct {
+	GUN      data.GUN
+	Required int
+	Found    int
+}
+
+func (err ErrInsufficientRootWitnesses) Error() string {
+	return fmt.Sprintf("the root of %s is countersigned by %d independent witnesses, but %d are required",
+		err.GUN, err.Found, err.Required)
+}
+
+// checkRootWitnesses ensures that the root of an updated repo is countersigned
+// by at least the number of independent witnesses which the trust pinning
+// requires for its GUN.  A witness is a delegation directly under
+// RootWitnessesRole whose verified metadata lists RootWitnessTarget with the
+// length and hashes of the root in the snapshot, and which has a key pinned
+// for the GUN, since the owner of the repository could otherwise delegate to
+// as many witnesses as are required.  Witnesses are independent if they share
+// no keys, with each other or with the root or targets roles, so a witness
+// which shares a key with one already counted, or with the owner of the
+// repository, does not count.
+func checkRootWitnesses(repo *tuf.Repo, gun data.GUN, trustPin trustpinning.TrustPinConfig) error {
+	required, ok := trustPin.MinRootWitnesses(gun)
+	if !ok || required <= 0 {
+		return nil
+	}
+	pinned, _ := trustPin.PinnedRootWitnessKeys(gun)
+	found, err := countRootWitnesses(repo, pinned)
+	if err != nil {
+		return err
+	}
+	if found < required {
+		return ErrInsufficientRootWitnesses{GUN: gun, Required: required, Found: found}
+	}
+	return nil
+}
+
+// countRootWitnesses counts the independent witnesses which countersign the
+// root of the repo, in order of role name, and which have at least one key
+// whose ID or canonical ID is among the pinned key IDs
+func countRootWitnesses(repo *tuf.Repo, pinnedKeyIDs []string) (int, error) {
+	parent := repo.Targets[RootWitnessesRole]
+	if repo.Snapshot == nil || parent == nil || len(pinnedKeyIDs) == 0 {
+		return 0, nil
+	}
+	rootMeta, ok := repo.Snapshot.Signed.Meta[data.CanonicalRootRole.String()]
+	if !ok {
+		return 0, nil
+	}
+	pinned := make(map[string]bool, len(pinnedKeyIDs))
+	for _, keyID := range pinnedKeyIDs {
+		pinned[keyID] = true
+	}
+
+	used := make(map[string]bool)
+	for _, role := range []data.RoleName{data.CanonicalRootRole, data.CanonicalTargetsRole} {
+		baseRole, err := repo.GetBaseRole(role)
+		if err != nil {
+			return 0, err
+		}
+		for _, key := range baseRole.Keys {
+			if err := markCanonicalKeyID(used, key); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	witnesses := append([]*data.Role{}, parent.Signed.Delegations.Roles...)
+	sort.Slice(witnesses, func(i, j int) bool { return witnesses[i].Name < witnesses[j].Name })
+	found := 0
+	for _, witness := range witnesses {
+		if witness.Name.Parent() != RootWitnessesRole || !witnessCountersigns(repo, witness.Name, rootMeta) {
+			continue
+		}
+		independent, isPinned := true, false
+		keys := make([]data.PublicKey, 0, len(witness.KeyIDs))
+		for _, keyID := range witness.KeyIDs {
+			key, ok := parent.Signed.Delegations.Keys[keyID]
+			if !ok {
+				continue
+			}
+			canonicalID, err := utils.CanonicalKeyID(key)
+			if err != nil {
+				return 0, err
+			}
+			independent = independent && !used[canonicalID]
+			isPinned = isPinned || pinned[keyID] || pinned[canonicalID]
+			keys = append(keys, key)
+		}
+		if !independent || !isPinned {
+			continue
+		}
+		for _, key := range keys {
+			if err := markCanonicalKeyID(used, key); err != nil {
+				return 0, err
+			}
+		}
+		found++
+	}
+	return found, nil
+}
+
+// witnessCountersigns returns whether the verified metadata of a witness lists
+// the root, with its entry in the snapshot, within the witness's paths
+func witnessCountersigns(repo *tuf.Repo, witness data.RoleName, rootMeta data.FileMeta) bool {
+	tgts, ok := repo.Targets[witness]
+	if !ok {
+		return false
+	}
+	delgRole, err := repo.GetDelegationRole(witness)
+	if err != nil || !delgRole.CheckPaths(RootWitnessTarget) {
+		return false
+	}
+	meta, ok := tgts.Signed.Targets[RootWitnessTarget]
+	return ok && meta.Length == rootMeta.Length && data.CompareMultiHashes(meta.Hashes, rootMeta.Hashes) == nil
+}
+
+// markCanonicalKeyID records the canonical ID of a key as used
+func markCanonicalKeyID(used map[string]bool, key data.PublicKey) error {
+	canonicalID, err := utils.CanonicalKeyID(key)
+	if err != nil {
+		return err
+	}
+	used[canonicalID] = true
+	return nil
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// countersignRoot stages a witness's countersignature of the root, with the
+// given entry
+func countersignRoot(t *testing.T, repo *repository, witness data.RoleName, rootMeta data.FileMeta) {
+	target := &Target{Name: RootWitnessTarget, Hashes: rootMeta.Hashes, Length: rootMeta.Length}
+	require.NoError(t, repo.AddTarget(target, witness))
+}
+
+// witnessKeyIDs returns the IDs of the keys of the given witnesses
+func witnessKeyIDs(t *testing.T, repo *repository, witnesses ...data.RoleName) []string {
+	var keyIDs []string
+	for _, witness := range witnesses {
+		role, err := repo.tufRepo.GetDelegationRole(witness)
+		require.NoError(t, err)
+		keyIDs = append(keyIDs, role.ListKeyIDs()...)
+	}
+	return keyIDs
+}
+
+// witnessedRepo publishes a repository whose root is countersigned by alice
+// and bob, by carol with bob's key, and by dave with the wrong entry
+func witnessedRepo(t *testing.T, url string) (*repository, string) {
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", url, true)
+
+	require.NoError(t, repo.AddDelegation(RootWitnessesRole,
+		[]data.PublicKey{createKey(t, repo, RootWitnessesRole, false)}, []string{""}))
+	bobKey := createKey(t, repo, "targets/witnesses/bob", false)
+	for witness, key := range map[data.RoleName]data.PublicKey{
+		"targets/witnesses/alice": createKey(t, repo, "targets/witnesses/alice", false),
+		"targets/witnesses/bob":   bobKey,
+		"targets/witnesses/carol": bobKey,
+		"targets/witnesses/dave":  createKey(t, repo, "targets/witnesses/dave", false),
+	} {
+		require.NoError(t, repo.AddDelegation(witness, []data.PublicKey{key}, []string{""}))
+	}
+	addTarget(t, repo, "app", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	rootMeta := repo.tufRepo.Snapshot.Signed.Meta[data.CanonicalRootRole.String()]
+	for _, witness := range []data.RoleName{"targets/witnesses/alice", "targets/witnesses/bob", "targets/witnesses/carol"} {
+		countersignRoot(t, repo, witness, rootMeta)
+	}
+	wrongMeta := rootMeta
+	wrongMeta.Length++
+	countersignRoot(t, repo, "targets/witnesses/dave", wrongMeta)
+	require.NoError(t, repo.Publish())
+	require.Equal(t, rootMeta, repo.tufRepo.Snapshot.Signed.Meta[data.CanonicalRootRole.String()])
+	return repo, baseDir
+}
+
+// A root countersigned by enough independent witnesses is trusted, while one
+// countersigned by too few fails the update
+func TestRootWitnessMinimum(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	repo, baseDir := witnessedRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	pinned := witnessKeyIDs(t, repo, "targets/witnesses/alice", "targets/witnesses/bob",
+		"targets/witnesses/carol", "targets/witnesses/dave")
+	reader.trustPinning = trustpinning.TrustPinConfig{
+		RootWitnesses:   map[string]int{"docker.com/*": 2},
+		RootWitnessKeys: map[string][]string{"docker.com/notary": pinned},
+	}
+	target, err := reader.GetTargetByName("app")
+	require.NoError(t, err)
+	require.Equal(t, data.CanonicalTargetsRole, target.Role)
+
+	// carol shares bob's key and dave countersigned another root, so only
+	// alice and bob count
+	unmet, _, unmetDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(unmetDir)
+	unmet.trustPinning = trustpinning.TrustPinConfig{
+		RootWitnesses:   map[string]int{"docker.com/notary": 3},
+		RootWitnessKeys: map[string][]string{"docker.com/*": pinned},
+	}
+	_, err = unmet.GetTargetByName("app")
+	require.Equal(t, ErrInsufficientRootWitnesses{GUN: "docker.com/notary", Required: 3, Found: 2}, err)
+
+	// the owner can still publish, so that more witnesses can be added
+	unmet.cryptoService = repo.cryptoService
+	addTarget(t, unmet, "another", "../fixtures/root-ca.crt")
+	require.NoError(t, unmet.Publish())
+}
+
+// Witnesses signing with a key of the root or targets roles are not
+// independent of the repository's owner
+func TestRootWitnessMustNotShareOwnerKeys(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	targetsRole, err := repo.tufRepo.GetBaseRole(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddDelegation(RootWitnessesRole,
+		[]data.PublicKey{createKey(t, repo, RootWitnessesRole, false)}, []string{""}))
+	require.NoError(t, repo.AddDelegation("targets/witnesses/owner", targetsRole.ListKeys(), []string{""}))
+	require.NoError(t, repo.AddDelegation("targets/witnesses/other",
+		[]data.PublicKey{createKey(t, repo, "targets/witnesses/other", false)}, []string{""}))
+	require.NoError(t, repo.Publish())
+	rootMeta := repo.tufRepo.Snapshot.Signed.Meta[data.CanonicalRootRole.String()]
+	countersignRoot(t, repo, "targets/witnesses/owner", rootMeta)
+	countersignRoot(t, repo, "targets/witnesses/other", rootMeta)
+	require.NoError(t, repo.Publish())
+
+	pinned := append(witnessKeyIDs(t, repo, "targets/witnesses/other"), targetsRole.ListKeyIDs()...)
+	found, err := countRootWitnesses(repo.tufRepo, pinned)
+	require.NoError(t, err)
+	require.Equal(t, 1, found)
+}
+
+// Only witnesses with pinned keys count, since the owner of a repository can
+// delegate to as many witnesses as they like
+func TestRootWitnessMustBePinned(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	repo, baseDir := witnessedRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	for _, test := range []struct {
+		pinned []string
+		found  int
+	}{
+		{pinned: nil, found: 0},
+		{pinned: witnessKeyIDs(t, repo, "targets/witnesses/alice"), found: 1},
+		// a key of the witnesses' parent delegation pins none of them
+		{pinned: witnessKeyIDs(t, repo, RootWitnessesRole), found: 0},
+	} {
+		reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+		defer os.RemoveAll(readerDir)
+		reader.trustPinning = trustpinning.TrustPinConfig{
+			RootWitnesses:   map[string]int{"docker.com/notary": 2},
+			RootWitnessKeys: map[string][]string{"docker.com/notary": test.pinned},
+		}
+		_, err := reader.GetTargetByName("app")
+		require.Equal(t, ErrInsufficientRootWitnesses{GUN: "docker.com/notary", Required: 2, Found: test.found}, err)
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/client/changelist"
+)
+
+// PublishSelective publishes only the staged changes for which filter returns
+// true, in the order they were staged, and leaves the rest staged for a later
+// publish.  The published metadata, including the snapshot and timestamp, is
+// signed exactly as by Publish, but reflects only the selected changes.  The
+// filter should select any changes which selected changes depend on, such as
+// the creation of a delegation a selected target is added to.  If no change is
+// selected, nothing is published.  A publish interrupted before the server
+// acknowledges it can be resumed with ResumePublish, which then removes only
+// the selected changes from the changelist.
+func (r *repository) PublishSelective(filter func(changelist.Change) bool) error {
+	selected := changelist.NewMemChangelist()
+	var published []int
+	for i, c := range r.changelist.List() {
+		if !filter(c) {
+			continue
+		}
+		if err := selected.Add(c); err != nil {
+			return err
+		}
+		published = append(published, i)
+	}
+	if len(published) == 0 {
+		return nil
+	}
+
+	if err := r.publishJournaled(selected, &journalEntry{Published: published}); err != nil {
+		return err
+	}
+	if err := r.changelist.Remove(published); err != nil {
+		logrus.Warn("Unable to remove the published changes from the changelist. You may want to manually delete them from the folder ", r.changelist.Location())
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// selectPaths returns a filter selecting the changes to the given target paths
+func selectPaths(paths ...string) func(changelist.Change) bool {
+	return func(c changelist.Change) bool {
+		for _, path := range paths {
+			if c.Path() == path {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// changePaths returns the paths of the staged changes, in order
+func changePaths(t *testing.T, repo *repository) []string {
+	var paths []string
+	for _, c := range getChanges(t, repo) {
+		paths = append(paths, c.Path())
+	}
+	return paths
+}
+
+// Only the selected changes are published, consistently, and the rest stay
+// staged for a later publish
+func TestPublishSelective(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, true)
+	defer os.RemoveAll(baseDir)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		addTarget(t, repo, name, "../fixtures/intermediate-ca.crt")
+	}
+
+	require.NoError(t, repo.PublishSelective(selectPaths("a", "c")))
+	require.Equal(t, []string{"b", "d"}, changePaths(t, repo))
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	targets, err := reader.ListTargets()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a", "c"}, listedNames(targets))
+
+	// selecting nothing publishes nothing
+	require.NoError(t, repo.PublishSelective(selectPaths("missing")))
+	require.Equal(t, []string{"b", "d"}, changePaths(t, repo))
+
+	require.NoError(t, repo.Publish())
+	require.Empty(t, getChanges(t, repo))
+	targets, err = reader.ListTargets()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a", "b", "c", "d"}, listedNames(targets))
+}
+
+// Resuming an interrupted selective publish removes only the selected changes
+func TestResumePublishSelective(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	var failPosts int32
+	flaky := newFlakyProxy(t, ts.URL, &failPosts)
+	defer flaky.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", flaky.URL, true)
+	defer os.RemoveAll(baseDir)
+	for _, name := range []string{"a", "b", "c"} {
+		addTarget(t, repo, name, "../fixtures/intermediate-ca.crt")
+	}
+
+	atomic.StoreInt32(&failPosts, 1)
+	require.Error(t, repo.PublishSelective(selectPaths("b")))
+	require.Equal(t, []string{"a", "b", "c"}, changePaths(t, repo))
+
+	atomic.StoreInt32(&failPosts, 0)
+	resumer, _, _ := newRepoToTestRepo(t, repo, baseDir)
+	require.NoError(t, resumer.ResumePublish())
+	require.Equal(t, []string{"a", "c"}, changePaths(t, resumer))
+
+	targets, err := resumer.ListTargets()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, listedNames(targets))
+}
+
+// listedNames returns the names of the listed targets
+func listedNames(targets []*TargetWithRole) []string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
@@ -0,0 +1,334 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+)
+
+// semverCustomKey is the key in the custom data of a target which records its
+// semantic version
+const semverCustomKey = "version"
+
+// ErrInvalidSemverConstraint is returned when a version constraint can't be
+// parsed
+type ErrInvalidSemverConstraint struct {
+	Constraint string
+}
+
+func (err ErrInvalidSemverConstraint) Error() string {
+	return fmt.Sprintf("invalid semantic version constraint %q", err.Constraint)
+}
+
+// ErrNoMatchingVersion is returned when no target with the given name prefix
+// has a semantic version which satisfies the constraint
+type ErrNoMatchingVersion struct {
+	NamePrefix string
+	Constraint string
+}
+
+func (err ErrNoMatchingVersion) Error() string {
+	return fmt.Sprintf("no target starting with %q has a version matching %q", err.NamePrefix, err.Constraint)
+}
+
+// semver is a semantic version, as specified by semver.org
+type semver struct {
+	major, minor, patch uint64
+	prerelease          []string
+}
+
+// parseSemver parses a semantic version, optionally prefixed with a "v".  Build
+// metadata is accepted but ignored, as it has no bearing on precedence.
+func parseSemver(s string) (semver, bool) {
+	v, parts, ok := parsePartialSemver(s)
+	return v, ok && parts == 3
+}
+
+// parsePartialSemver parses a semantic version of which the minor and patch
+// versions may be missing, returning how many of the three were given
+func parsePartialSemver(s string) (semver, int, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	var v semver
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.prerelease = strings.Split(s[i+1:], ".")
+		for _, identifier := range v.prerelease {
+			if !isIdentifier(identifier) {
+				return semver{}, 0, false
+			}
+		}
+		s = s[:i]
+	}
+	numbers := strings.Split(s, ".")
+	if len(numbers) > 3 || len(numbers) < 3 && v.prerelease != nil {
+		return semver{}, 0, false
+	}
+	fields := []*uint64{&v.major, &v.minor, &v.patch}
+	for i, number := range numbers {
+		if !isNumeric(number) || len(number) > 1 && number[0] == '0' {
+			return semver{}, 0, false
+		}
+		n, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return semver{}, 0, false
+		}
+		*fields[i] = n
+	}
+	return v, len(numbers), true
+}
+
+func isNumeric(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// isIdentifier determines whether s is made up only of the alphanumerics and
+// hyphens allowed in a prerelease identifier
+func isIdentifier(s string) bool {
+	return s != "" && strings.Trim(s, "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") == ""
+}
+
+// compare returns -1, 0 or 1 if v has lower, equal or higher precedence than o
+func (v semver) compare(o semver) int {
+	for _, pair := range [][2]uint64{{v.major, o.major}, {v.minor, o.minor}, {v.patch, o.patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	// a prerelease has lower precedence than the release itself
+	switch {
+	case len(v.prerelease) == 0 && len(o.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(o.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(o.prerelease); i++ {
+		a, b := v.prerelease[i], o.prerelease[i]
+		if a == b {
+			continue
+		}
+		aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+		switch {
+		case aNumeric && bNumeric:
+			x, _ := strconv.ParseUint(a, 10, 64)
+			y, _ := strconv.ParseUint(b, 10, 64)
+			if x < y {
+				return -1
+			}
+			return 1
+		case aNumeric:
+			return -1
+		case bNumeric:
+			return 1
+		case a < b:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch {
+	case len(v.prerelease) < len(o.prerelease):
+		return -1
+	case len(v.prerelease) > len(o.prerelease):
+		return 1
+	}
+	return 0
+}
+
+// sameRelease returns true if v and o have the same major, minor and patch
+// versions
+func (v semver) sameRelease(o semver) bool {
+	return v.major == o.major && v.minor == o.minor && v.patch == o.patch
+}
+
+// comparator is a single comparison against a version, such as ">=1.2.0"
+type comparator struct {
+	op      string
+	version semver
+	// implied is set for the upper bounds which ranges such as ^1.2.0 imply
+	implied bool
+}
+
+func (c comparator) matches(v semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// semverConstraint is a union of ranges, each of which is an intersection of
+// comparators
+type semverConstraint [][]comparator
+
+// parseSemverConstraint parses a constraint in the style of npm: ranges are
+// separated by "||", and the comparators of a range by spaces or commas.  A
+// comparator is a version, optionally preceded by one of =, <, <=, > or >=, a
+// caret range such as ^1.2.3, which allows changes that do not modify the
+// leftmost non-zero version, a tilde range such as ~1.2.3, which allows patch
+// changes, or "*", which allows any version.  The minor and patch versions may
+// be left out, so that 1.2 allows any 1.2.x version, and >1.2 requires 1.3.0
+// or later.
+func parseSemverConstraint(s string) (semverConstraint, error) {
+	var constraint semverConstraint
+	for _, rawRange := range strings.Split(s, "||") {
+		raws := strings.FieldsFunc(rawRange, func(r rune) bool { return r == ' ' || r == ',' })
+		if len(raws) == 0 {
+			return nil, ErrInvalidSemverConstraint{Constraint: s}
+		}
+		var comparators []comparator
+		for _, raw := range raws {
+			parsed, ok := parseComparator(raw)
+			if !ok {
+				return nil, ErrInvalidSemverConstraint{Constraint: s}
+			}
+			comparators = append(comparators, parsed...)
+		}
+		constraint = append(constraint, comparators)
+	}
+	return constraint, nil
+}
+
+// parseComparator expands a single comparator into the plain comparisons it
+// stands for
+func parseComparator(raw string) ([]comparator, bool) {
+	if raw == "*" {
+		return nil, true
+	}
+	rest := strings.TrimLeft(raw, "<>=^~")
+	op := raw[:len(raw)-len(rest)]
+	v, parts, ok := parsePartialSemver(rest)
+	if !ok {
+		return nil, false
+	}
+
+	// the lowest version above those which the given parts of v allow
+	var upper semver
+	switch {
+	case op == "^" && (v.major > 0 || parts == 1):
+		upper = semver{major: v.major + 1}
+	case op == "^" && (v.minor > 0 || parts == 2):
+		upper = semver{minor: v.minor + 1}
+	case op == "^":
+		upper = semver{patch: v.patch + 1}
+	case parts == 1:
+		upper = semver{major: v.major + 1}
+	default:
+		upper = semver{major: v.major, minor: v.minor + 1}
+	}
+	// an exclusive upper bound excludes the prereleases of that version too
+	upper.prerelease = []string{"0"}
+	bounded := []comparator{{op: ">=", version: v}, {op: "<", version: upper, implied: true}}
+
+	switch {
+	case op == "^" || op == "~" || (op == "" || op == "=") && parts < 3:
+		return bounded, true
+	case op == "" || op == "=":
+		return []comparator{{op: "=", version: v}}, true
+	// >1.2 and <=1.2 compare against the whole of 1.2.x
+	case op == ">" && parts < 3:
+		return []comparator{{op: ">=", version: upper, implied: true}}, true
+	case op == "<=" && parts < 3:
+		return []comparator{{op: "<", version: upper, implied: true}}, true
+	case op == ">" || op == ">=" || op == "<" || op == "<=":
+		return []comparator{{op: op, version: v}}, true
+	}
+	return nil, false
+}
+
+// matches determines whether a version satisfies the constraint.  As with npm,
+// a prerelease only satisfies a range which compares against a prerelease of
+// the same major, minor and patch version, so that ^1.2.0 does not allow
+// 1.3.0-beta, but >=1.3.0-alpha <2.0.0 does.
+func (c semverConstraint) matches(v semver) bool {
+	for _, comparators := range c {
+		matched, prereleaseAllowed := true, len(v.prerelease) == 0
+		for _, comparison := range comparators {
+			if !comparison.matches(v) {
+				matched = false
+				break
+			}
+			if !comparison.implied && len(comparison.version.prerelease) > 0 && comparison.version.sameRelease(v) {
+				prereleaseAllowed = true
+			}
+		}
+		if matched && prereleaseAllowed {
+			return true
+		}
+	}
+	return false
+}
+
+// targetSemver reads the semantic version from a target's custom data
+func targetSemver(custom *canonicaljson.RawMessage) (semver, bool) {
+	if custom == nil {
+		return semver{}, false
+	}
+	var fields map[string]interface{}
+	if err := canonicaljson.Unmarshal(*custom, &fields); err != nil {
+		return semver{}, false
+	}
+	version, ok := fields[semverCustomKey].(string)
+	if !ok {
+		return semver{}, false
+	}
+	return parseSemver(version)
+}
+
+// GetTargetBySemver returns, of the targets whose names start with namePrefix,
+// the one with the highest semantic version satisfying the constraint, such
+// as "^1.2.0" or ">=1.0.0 <1.4.0 || ~2.1".  A target's version is read from
+// the "version" field of its custom data, and targets without one, or with one
+// which is not a valid semantic version, are ignored, as are yanked targets.
+// The targets are those ListTargets returns, so a target delegated to several
+// roles is considered only as the highest priority role has it.  Of targets
+// with the same version, the first by name is returned.
+func (r *repository) GetTargetBySemver(namePrefix, constraint string) (*TargetWithRole, error) {
+	parsed, err := parseSemverConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := r.ListTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	var best *TargetWithRole
+	var bestVersion semver
+	for _, target := range targets {
+		if !strings.HasPrefix(target.Name, namePrefix) || target.Yanked {
+			continue
+		}
+		version, ok := targetSemver(target.Custom)
+		if !ok || !parsed.matches(version) {
+			continue
+		}
+		if best != nil {
+			cmp := version.compare(bestVersion)
+			if cmp < 0 || cmp == 0 && target.Name > best.Name {
+				continue
+			}
+		}
+		best, bestVersion = target, version
+	}
+	if best == nil {
+		return nil, ErrNoMatchingVersion{NamePrefix: namePrefix, Constraint: constraint}
+	}
+	return best, nil
+}
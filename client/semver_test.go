@@ -0,0 +1,139 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func TestParseSemver(t *testing.T) {
+	for _, valid := range []string{"1.2.3", "v1.2.3", "0.0.0", "1.2.3-beta.1", "1.2.3+build.5", "1.2.3-rc-1+build"} {
+		_, ok := parseSemver(valid)
+		require.True(t, ok, valid)
+	}
+	for _, invalid := range []string{"", "1", "1.2", "1.2.3.4", "01.2.3", "1.2.x", "1.2.3-", "1.2.3-beta..1", "latest"} {
+		_, ok := parseSemver(invalid)
+		require.False(t, ok, invalid)
+	}
+
+	// in order of precedence, as given by semver.org
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		lower, _ := parseSemver(ordered[i-1])
+		higher, _ := parseSemver(ordered[i])
+		require.Equal(t, -1, lower.compare(higher), "%s < %s", ordered[i-1], ordered[i])
+		require.Equal(t, 1, higher.compare(lower), "%s > %s", ordered[i], ordered[i-1])
+	}
+	a, _ := parseSemver("1.0.0+a")
+	b, _ := parseSemver("1.0.0+b")
+	require.Equal(t, 0, a.compare(b))
+}
+
+func TestSemverConstraints(t *testing.T) {
+	cases := []struct {
+		constraint string
+		matching   []string
+		others     []string
+	}{
+		{"^1.2.0", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0", "2.0.0-alpha", "1.3.0-beta"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^0", []string{"0.0.0", "0.9.9"}, []string{"1.0.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"1.2", []string{"1.2.0", "1.2.5"}, []string{"1.3.0"}},
+		{"=1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<=1.2", []string{"1.2.9", "0.1.0"}, []string{"1.3.0"}},
+		{">=1.0.0 <1.4.0", []string{"1.0.0", "1.3.9"}, []string{"1.4.0", "0.9.0"}},
+		{">=1.0.0, <1.4.0", []string{"1.2.0"}, []string{"1.4.0"}},
+		{"<1.0.0 || >=2.0.0", []string{"0.5.0", "2.0.0"}, []string{"1.0.0", "1.5.0"}},
+		{"*", []string{"0.0.1", "3.0.0"}, []string{"3.0.0-beta"}},
+		{">=1.3.0-alpha <2.0.0", []string{"1.3.0-beta", "1.3.0", "1.5.0"}, []string{"1.4.0-beta", "1.3.0-0"}},
+	}
+	for _, c := range cases {
+		constraint, err := parseSemverConstraint(c.constraint)
+		require.NoError(t, err, c.constraint)
+		for _, version := range c.matching {
+			v, ok := parseSemver(version)
+			require.True(t, ok, version)
+			require.True(t, constraint.matches(v), "%s should match %s", version, c.constraint)
+		}
+		for _, version := range c.others {
+			v, ok := parseSemver(version)
+			require.True(t, ok, version)
+			require.False(t, constraint.matches(v), "%s should not match %s", version, c.constraint)
+		}
+	}
+
+	for _, invalid := range []string{"", "||", "^1.2.0 ||", "!1.2.0", "^^1.2.0", "=>1.0.0", "^1.2.x", "latest"} {
+		_, err := parseSemverConstraint(invalid)
+		require.Equal(t, ErrInvalidSemverConstraint{Constraint: invalid}, err, invalid)
+	}
+}
+
+func addVersionedTarget(t *testing.T, repo *repository, name, version string) {
+	var custom *canonicaljson.RawMessage
+	if version != "" {
+		raw, err := canonicaljson.MarshalCanonical(map[string]string{"version": version})
+		require.NoError(t, err)
+		rawCustom := canonicaljson.RawMessage(raw)
+		custom = &rawCustom
+	}
+	target, err := NewTarget(name, "../fixtures/intermediate-ca.crt", custom)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddTarget(target, data.CanonicalTargetsRole))
+}
+
+// The highest version matching the constraint is resolved among the targets
+// with the prefix, ignoring those without a valid version and yanked targets
+func TestGetTargetBySemver(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addVersionedTarget(t, repo, "app-1.2.0", "1.2.0")
+	addVersionedTarget(t, repo, "app-1.4.1", "1.4.1")
+	addVersionedTarget(t, repo, "app-1.4.2", "1.4.2")
+	addVersionedTarget(t, repo, "app-2.0.0", "v2.0.0")
+	addVersionedTarget(t, repo, "app-2.1.0-beta", "2.1.0-beta")
+	addVersionedTarget(t, repo, "app-latest", "latest")
+	addVersionedTarget(t, repo, "app-unversioned", "")
+	addVersionedTarget(t, repo, "other-1.9.0", "1.9.0")
+	require.NoError(t, repo.Publish())
+	require.NoError(t, repo.YankTarget("targets", "app-1.4.2"))
+	require.NoError(t, repo.Publish())
+
+	for constraint, expected := range map[string]string{
+		"^1.2.0":           "app-1.4.1",
+		"~1.2":             "app-1.2.0",
+		">=1.0.0 <1.4.0":   "app-1.2.0",
+		">=2.0.0":          "app-2.0.0",
+		">=2.1.0-alpha":    "app-2.1.0-beta",
+		"^1.0.0 || ^2.0.0": "app-2.0.0",
+		"*":                "app-2.0.0",
+		"<1.4.0 || =1.4.1": "app-1.4.1",
+		">=1.2.0, <=1.4.1": "app-1.4.1",
+		"1.4.2 || 1.2.0":   "app-1.2.0",
+	} {
+		target, err := repo.GetTargetBySemver("app-", constraint)
+		require.NoError(t, err, constraint)
+		require.Equal(t, expected, target.Name, constraint)
+		require.Equal(t, data.CanonicalTargetsRole, target.Role)
+	}
+
+	target, err := repo.GetTargetBySemver("", "^1.5.0")
+	require.NoError(t, err)
+	require.Equal(t, "other-1.9.0", target.Name)
+
+	_, err = repo.GetTargetBySemver("app-", "^3.0.0")
+	require.Equal(t, ErrNoMatchingVersion{NamePrefix: "app-", Constraint: "^3.0.0"}, err)
+	_, err = repo.GetTargetBySemver("app-", "latest")
+	require.IsType(t, ErrInvalidSemverConstraint{}, err)
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// publishedSignatureCount returns the number of signatures on the published
+// metadata for a role, as seen by a fresh client
+func publishedSignatureCount(t *testing.T, repo *repository, role data.RoleName) int {
+	repo2, _, dir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(dir)
+	roles, err := repo2.ListRoles()
+	require.NoError(t, err)
+	for _, r := range roles {
+		if r.Name == role {
+			return len(r.Signatures)
+		}
+	}
+	require.FailNow(t, "role not published", role.String())
+	return 0
+}
+
+// Without setting it, publishing signs with every key just as it always has
+func TestPublishSignsWithAllKeysByDefault(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	keys := []data.PublicKey{createKey(t, repo, "targets/a", false), createKey(t, repo, "targets/a", false)}
+	require.NoError(t, repo.AddDelegation("targets/a", keys, []string{""}))
+	addTarget(t, repo, "first", "../fixtures/intermediate-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+	require.Equal(t, 2, publishedSignatureCount(t, repo, "targets/a"))
+}
+
+// A role with two local keys is signed with both when signing with all keys,
+// and with only enough to meet its threshold otherwise
+func TestPublishSignWithAllKeys(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	keys := []data.PublicKey{createKey(t, repo, "targets/a", false), createKey(t, repo, "targets/a", false)}
+	require.NoError(t, repo.AddDelegation("targets/a", keys, []string{""}))
+	addTarget(t, repo, "first", "../fixtures/intermediate-ca.crt", "targets/a")
+
+	repo.SetSignWithAllKeys(true)
+	require.NoError(t, repo.Publish())
+	require.Equal(t, 2, publishedSignatureCount(t, repo, "targets/a"))
+
+	repo.SetSignWithAllKeys(false)
+	addTarget(t, repo, "second", "../fixtures/intermediate-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+	require.Equal(t, 1, publishedSignatureCount(t, repo, "targets/a"))
+
+	// the metadata signed with only enough keys is still valid
+	repo2, _, dir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(dir)
+	targets, err := repo2.ListTargets("targets/a")
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+
+	// signing with all keys again signs with both once more
+	repo.SetSignWithAllKeys(true)
+	addTarget(t, repo, "third", "../fixtures/intermediate-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+	require.Equal(t, 2, publishedSignatureCount(t, repo, "targets/a"))
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// ErrSignatureRejected is returned by ApplySignature when a signature can't be
+// attached to the metadata of a signing request
+type ErrSignatureRejected struct {
+	Role  data.RoleName
+	KeyID string
+	Err   error
+}
+
+func (err ErrSignatureRejected) Error() string {
+	return fmt.Sprintf("signature by key %s rejected for %s: %s", err.KeyID, err.Role, err.Err)
+}
+
+// SigningRequest describes metadata which needs to be signed by a key held
+// elsewhere, such as an offline root key.  It can be serialized to JSON to be
+// carried to and from the machine holding the key.
+type SigningRequest struct {
+	GUN     data.GUN      `json:"gun"`
+	Role    data.RoleName `json:"role"`
+	Version int           `json:"version"`
+	Expires time.Time     `json:"expires"`
+	// KeyIDs are the IDs of the keys which may sign the metadata, and
+	// Threshold is how many of them must
+	KeyIDs    []string `json:"keyids"`
+	Threshold int      `json:"threshold"`
+	// Bytes are the canonical bytes which a signature must be computed over
+	Bytes []byte `json:"bytes"`
+	// Metadata is the full metadata, including the signatures applied so far
+	Metadata []byte `json:"metadata"`
+}
+
+// signatureMethods maps key algorithms to the method of the signatures made
+// with them
+var signatureMethods = map[string]data.SigAlgorithm{
+	data.ECDSAKey:     data.ECDSASignature,
+	data.ECDSAx509Key: data.ECDSASignature,
+	data.RSAKey:       data.RSAPSSSignature,
+	data.RSAx509Key:   data.RSAPSSSignature,
+	data.ED25519Key:   data.EDDSASignature,
+}
+
+// GenerateSigningRequest updates the repository, and then produces a request
+// to sign the next version of the given role's metadata, which has the same
+// contents as the current version but a fresh expiry.  The request carries the
+// canonical bytes to sign and which keys may sign them, so that the signatures
+// can be produced offline, such as by an air-gapped root key holder, and then
+// attached with ApplySignature.
+func (r *repository) GenerateSigningRequest(role string) (*SigningRequest, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	roleName := data.RoleName(role)
+	baseRole, err := r.signingRole(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	expiryRole := roleName
+	if data.IsDelegation(roleName) {
+		expiryRole = data.CanonicalTargetsRole
+	}
+	expires := data.DefaultExpires(expiryRole)
+
+	var (
+		s       *data.Signed
+		version int
+	)
+	switch roleName {
+	case data.CanonicalRootRole:
+		root := *r.tufRepo.Root
+		root.Signatures = nil
+		root.Signed.Version++
+		root.Signed.Expires = expires
+		version = root.Signed.Version
+		s, err = root.ToSigned()
+	case data.CanonicalSnapshotRole:
+		snapshot := *r.tufRepo.Snapshot
+		snapshot.Signatures = nil
+		snapshot.Signed.Version++
+		snapshot.Signed.Expires = expires
+		version = snapshot.Signed.Version
+		s, err = snapshot.ToSigned()
+	case data.CanonicalTimestampRole:
+		timestamp := *r.tufRepo.Timestamp
+		timestamp.Signatures = nil
+		timestamp.Signed.Version++
+		timestamp.Signed.Expires = expires
+		version = timestamp.Signed.Version
+		s, err = timestamp.ToSigned()
+	default:
+		current, ok := r.tufRepo.Targets[roleName]
+		if !ok {
+			return nil, data.ErrInvalidRole{Role: roleName, Reason: "the role has no metadata to sign"}
+		}
+		targets := *current
+		targets.Signatures = nil
+		targets.Signed.Version++
+		targets.Signed.Expires = expires
+		version = targets.Signed.Version
+		s, err = targets.ToSigned()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signedBytes, err := data.SignedBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningRequest{
+		GUN:       r.gun,
+		Role:      roleName,
+		Version:   version,
+		Expires:   expires,
+		KeyIDs:    baseRole.ListKeyIDs(),
+		Threshold: baseRole.Threshold,
+		Bytes:     signedBytes,
+		Metadata:  metadata,
+	}, nil
+}
+
+// ApplySignature attaches a signature, produced offline by the given key over
+// the bytes of a signing request, to the request's metadata.  The signature is
+// only accepted if it verifies with a key which the repository trusts to sign
+// the role, regardless of which keys the request lists.  A later signature by
+// the same key replaces an earlier one.
+func (r *repository) ApplySignature(req *SigningRequest, keyID string, sig []byte) error {
+	if req.GUN != r.gun {
+		return ErrSignatureRejected{Role: req.Role, KeyID: keyID,
+			Err: fmt.Errorf("the request is for %s, not %s", req.GUN, r.gun)}
+	}
+	baseRole, err := r.signingRole(req.Role)
+	if err != nil {
+		return err
+	}
+	key, ok := baseRole.Keys[keyID]
+	if !ok {
+		return ErrSignatureRejected{Role: req.Role, KeyID: keyID,
+			Err: fmt.Errorf("the key is not trusted to sign the role")}
+	}
+	method, ok := signatureMethods[key.Algorithm()]
+	if !ok {
+		return ErrSignatureRejected{Role: req.Role, KeyID: keyID,
+			Err: fmt.Errorf("unsupported key algorithm %s", key.Algorithm())}
+	}
+
+	s := &data.Signed{}
+	if err := json.Unmarshal(req.Metadata, s); err != nil {
+		return err
+	}
+	// verify against the metadata itself, rather than the bytes carried
+	// alongside it, so that the two can't disagree
+	signedBytes, err := data.SignedBytes(s)
+	if err != nil {
+		return err
+	}
+	signature := data.Signature{KeyID: keyID, Method: method, Signature: sig}
+	if err := signed.VerifySignature(signedBytes, &signature, key); err != nil {
+		return ErrSignatureRejected{Role: req.Role, KeyID: keyID, Err: err}
+	}
+
+	signatures := make([]data.Signature, 0, len(s.Signatures)+1)
+	for _, existing := range s.Signatures {
+		if existing.KeyID != keyID {
+			signatures = append(signatures, existing)
+		}
+	}
+	s.Signatures = append(signatures, signature)
+	metadata, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	req.Metadata = metadata
+	return nil
+}
+
+// signingRole returns the keys and threshold which the repository trusts to
+// sign the given role
+func (r *repository) signingRole(role data.RoleName) (data.BaseRole, error) {
+	if r.tufRepo == nil {
+		if err := r.updateTUF(false); err != nil {
+			return data.BaseRole{}, err
+		}
+	}
+	if data.IsDelegation(role) {
+		delgRole, err := r.tufRepo.GetDelegationRole(role)
+		if err != nil {
+			return data.BaseRole{}, err
+		}
+		return delgRole.BaseRole, nil
+	}
+	return r.tufRepo.GetBaseRole(role)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// A signing request for the root can be signed by the root key outside of the
+// repository, and the signature applied to it, producing valid root metadata
+func TestSigningRequestRoundTrip(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, rootKeyID, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	req, err := repo.GenerateSigningRequest(data.CanonicalRootRole.String())
+	require.NoError(t, err)
+	require.Equal(t, data.CanonicalRootRole, req.Role)
+	require.Equal(t, repo.tufRepo.Root.Signed.Version+1, req.Version)
+	require.Equal(t, 1, req.Threshold)
+	require.Len(t, req.KeyIDs, 1)
+
+	// the request survives being carried elsewhere as JSON
+	serialized, err := json.Marshal(req)
+	require.NoError(t, err)
+	carried := &SigningRequest{}
+	require.NoError(t, json.Unmarshal(serialized, carried))
+
+	// sign the bytes of the request directly with the private key, as an
+	// offline key holder would
+	rootRole, err := repo.tufRepo.GetBaseRole(data.CanonicalRootRole)
+	require.NoError(t, err)
+	signingKeyID := req.KeyIDs[0]
+	privKey, _, err := repo.GetCryptoService().GetPrivateKey(rootKeyID)
+	require.NoError(t, err)
+	sig, err := privKey.Sign(rand.Reader, carried.Bytes, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.ApplySignature(carried, signingKeyID, sig))
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(carried.Metadata, s))
+	require.Len(t, s.Signatures, 1)
+	require.NoError(t, signed.VerifySignatures(s, rootRole))
+	root, err := data.RootFromSigned(s)
+	require.NoError(t, err)
+	require.Equal(t, req.Version, root.Signed.Version)
+
+	// applying a signature by the same key again replaces it
+	require.NoError(t, repo.ApplySignature(carried, signingKeyID, sig))
+	require.NoError(t, json.Unmarshal(carried.Metadata, s))
+	require.Len(t, s.Signatures, 1)
+}
+
+// Signatures which don't verify, or which are by keys not trusted for the
+// role, are rejected
+func TestApplySignatureRejectsInvalidSignatures(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, rootKeyID, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	req, err := repo.GenerateSigningRequest(data.CanonicalTargetsRole.String())
+	require.NoError(t, err)
+	original := req.Metadata
+	targetsKeyID := req.KeyIDs[0]
+
+	// a signature over other bytes does not verify
+	privKey, _, err := repo.GetCryptoService().GetPrivateKey(targetsKeyID)
+	require.NoError(t, err)
+	sig, err := privKey.Sign(rand.Reader, []byte("something else"), nil)
+	require.NoError(t, err)
+	err = repo.ApplySignature(req, targetsKeyID, sig)
+	require.Error(t, err)
+	require.IsType(t, ErrSignatureRejected{}, err)
+
+	// the root key is not trusted to sign the targets role
+	rootPrivKey, _, err := repo.GetCryptoService().GetPrivateKey(rootKeyID)
+	require.NoError(t, err)
+	sig, err = rootPrivKey.Sign(rand.Reader, req.Bytes, nil)
+	require.NoError(t, err)
+	err = repo.ApplySignature(req, rootKeyID, sig)
+	require.Error(t, err)
+	require.IsType(t, ErrSignatureRejected{}, err)
+
+	// rejected signatures are not attached
+	require.Equal(t, original, req.Metadata)
+
+	// a request for another GUN is rejected
+	sig, err = privKey.Sign(rand.Reader, req.Bytes, nil)
+	require.NoError(t, err)
+	req.GUN = "docker.com/other"
+	err = repo.ApplySignature(req, targetsKeyID, sig)
+	require.Error(t, err)
+	require.IsType(t, ErrSignatureRejected{}, err)
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrIncompleteSnapshot is returned by an update when the snapshot does not
+// list the root or targets metadata.  TUF requires that it lists both, and
+// leaving either out would let the server serve an old version of it.
+type ErrIncompleteSnapshot struct {
+	Missing []data.RoleName
+}
+
+func (err ErrIncompleteSnapshot) Error() string {
+	return fmt.Sprintf("snapshot is missing the entries for %v", err.Missing)
+}
+
+// checkSnapshotComplete returns ErrIncompleteSnapshot if snapshot metadata does
+// not list the root or targets metadata.  It returns nil for metadata which
+// can't be parsed, leaving it to be rejected when it is loaded.
+func checkSnapshotComplete(rawSnapshot []byte) error {
+	s := &data.Signed{}
+	if err := json.Unmarshal(rawSnapshot, s); err != nil || s.Signed == nil {
+		return nil
+	}
+	snapshot := &data.Snapshot{}
+	if err := json.Unmarshal(*s.Signed, snapshot); err != nil {
+		return nil
+	}
+	var missing []data.RoleName
+	for _, role := range []data.RoleName{data.CanonicalRootRole, data.CanonicalTargetsRole} {
+		if _, ok := snapshot.Meta[role.String()]; !ok {
+			missing = append(missing, role)
+		}
+	}
+	if len(missing) > 0 {
+		return ErrIncompleteSnapshot{Missing: missing}
+	}
+	return nil
+}
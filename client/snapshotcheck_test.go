@@ -0,0 +1,69 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// An update rejects a validly signed snapshot which leaves out the root or
+// targets entry
+func TestUpdateRejectsIncompleteSnapshot(t *testing.T) {
+	for _, missing := range [][]data.RoleName{
+		{data.CanonicalTargetsRole},
+		{data.CanonicalRootRole},
+		{data.CanonicalRootRole, data.CanonicalTargetsRole},
+	} {
+		_, serverSwizzler := newServerSwizzler(t)
+		ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+		repo, baseDir := newBlankRepo(t, ts.URL)
+
+		require.NoError(t, repo.updateTUF(false))
+
+		require.NoError(t, serverSwizzler.MutateSnapshot(func(sn *data.Snapshot) {
+			for _, role := range missing {
+				delete(sn.Meta, role.String())
+			}
+		}))
+		require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+		err := repo.updateTUF(false)
+		require.Error(t, err)
+		require.IsType(t, data.ErrInvalidMetadata{}, err)
+
+		ts.Close()
+		os.RemoveAll(baseDir)
+	}
+}
+
+// A snapshot which can be parsed, but which leaves out the root or targets
+// entry, is incomplete
+func TestCheckSnapshotComplete(t *testing.T) {
+	for _, missing := range [][]data.RoleName{
+		nil,
+		{data.CanonicalTargetsRole},
+		{data.CanonicalRootRole},
+		{data.CanonicalRootRole, data.CanonicalTargetsRole},
+	} {
+		_, serverSwizzler := newServerSwizzler(t)
+		require.NoError(t, serverSwizzler.MutateSnapshot(func(sn *data.Snapshot) {
+			for _, role := range missing {
+				delete(sn.Meta, role.String())
+			}
+		}))
+		raw, err := serverSwizzler.MetadataCache.GetSized(data.CanonicalSnapshotRole.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		if missing == nil {
+			require.NoError(t, checkSnapshotComplete(raw))
+		} else {
+			require.Equal(t, ErrIncompleteSnapshot{Missing: missing}, checkSnapshotComplete(raw))
+		}
+	}
+
+	// metadata which can't be parsed is left to the builder to reject
+	require.NoError(t, checkSnapshotComplete([]byte("not json")))
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// ErrSnapshotKeyNotPinned is returned when the snapshot of a repository whose
+// snapshot keys are pinned is not signed by a threshold of the pinned keys
+type ErrSnapshotKeyNotPinned struct {
+	GUN data.GUN
+	Err error
+}
+
+func (err ErrSnapshotKeyNotPinned) Error() string {
+	return fmt.Sprintf("the snapshot of %s is not signed by its pinned keys: %s", err.GUN, err.Err)
+}
+
+// checkSnapshotPin ensures that the snapshot of an updated repo is signed by a
+// threshold of the snapshot keys pinned for its GUN.  Only the pinned keys
+// which the root also authorizes count towards the threshold.
+func checkSnapshotPin(repo *tuf.Repo, verified map[data.RoleName][]byte, gun data.GUN, trustPin trustpinning.TrustPinConfig) error {
+	pinned, ok := trustPin.PinnedSnapshotKeys(gun)
+	if !ok || repo.Snapshot == nil {
+		return nil
+	}
+	if err := verifyPinnedKeys(repo, verified[data.CanonicalSnapshotRole], data.CanonicalSnapshotRole, pinned); err != nil {
+		return ErrSnapshotKeyNotPinned{GUN: gun, Err: err}
+	}
+	return nil
+}
+
+// verifyPinnedKeys verifies that the metadata of a base role, as the update
+// verified it, is signed by a threshold of the pinned keys which the root
+// authorizes for the role
+func verifyPinnedKeys(repo *tuf.Repo, raw []byte, roleName data.RoleName, pinned []string) error {
+	role, err := repo.GetBaseRole(roleName)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]data.PublicKey)
+	for _, keyID := range pinned {
+		if key, ok := role.Keys[keyID]; ok {
+			keys[keyID] = key
+		}
+	}
+	pinnedRole := data.BaseRole{Name: role.Name, Keys: keys, Threshold: role.Threshold}
+
+	if raw == nil {
+		return fmt.Errorf("the verified %s metadata is not available", roleName)
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return err
+	}
+	return signed.VerifySignatures(s, pinnedRole)
+}
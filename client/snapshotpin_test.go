@@ -0,0 +1,96 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A snapshot signed by a key which the root authorizes, but which is not
+// pinned, fails the update
+func TestSnapshotKeyPinRejectsUnpinnedKey(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	gun := "docker.com/notary"
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	pinnedKeyIDs := repo.tufRepo.Root.Signed.Roles[data.CanonicalSnapshotRole].KeyIDs
+	require.Len(t, pinnedKeyIDs, 1)
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	reader.trustPinning = trustpinning.TrustPinConfig{
+		SnapshotKeys: map[string][]string{gun: pinnedKeyIDs},
+	}
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+
+	// the root now authorizes a new snapshot key, which signs the snapshot
+	require.NoError(t, repo.RotateKey(data.CanonicalSnapshotRole, false, nil))
+
+	_, err = reader.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrSnapshotKeyNotPinned{}, err)
+
+	// without the pin the new key is trusted
+	reader.trustPinning = trustpinning.TrustPinConfig{}
+	_, err = reader.ListTargets()
+	require.NoError(t, err)
+}
+
+// Snapshot keys can be pinned for every GUN under a wildcard prefix
+func TestSnapshotKeyPinWildcard(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	reader.trustPinning = trustpinning.TrustPinConfig{
+		SnapshotKeys: map[string][]string{"docker.com/*": {"not-a-snapshot-key"}},
+	}
+	_, err := reader.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrSnapshotKeyNotPinned{}, err)
+}
+
+// The pin is checked against the snapshot the update verified, even if it
+// could not be cached, and none of the rejected metadata is cached
+func TestSnapshotKeyPinChecksVerifiedSnapshot(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	gun := "docker.com/notary"
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	reader.trustPinning = trustpinning.TrustPinConfig{
+		SnapshotKeys: map[string][]string{gun: repo.tufRepo.Root.Signed.Roles[data.CanonicalSnapshotRole].KeyIDs},
+	}
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+	cachedTimestamp, err := reader.cache.GetSized(data.CanonicalTimestampRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.RotateKey(data.CanonicalSnapshotRole, false, nil))
+
+	// the cache keeps the snapshot signed by the pinned key
+	reader.cache = &unwritableStore{MetadataStore: reader.cache, roleToNotWrite: data.CanonicalSnapshotRole}
+	_, err = reader.ListTargets()
+	require.IsType(t, ErrSnapshotKeyNotPinned{}, err)
+	stillCached, err := reader.cache.GetSized(data.CanonicalTimestampRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, cachedTimestamp, stillCached)
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"sync/atomic"
+)
+
+// UpdateStats are the totals, across every update of a repository since it was
+// created or its stats were last reset, of the metadata requested from the
+// server and of the metadata which was served from the local cache instead
+type UpdateStats struct {
+	// Requests is how many metadata files, or deltas of them, were requested
+	// from the server, whether or not the request succeeded
+	Requests int64
+	// BytesFetched is the size of the metadata downloaded from the server
+	BytesFetched int64
+	// BytesFromCache is the size of the cached metadata which was still valid
+	// and so did not need to be downloaded
+	BytesFromCache int64
+}
+
+// updateCounters accumulate UpdateStats, and are safe for concurrent use.  All
+// the methods are no-ops on nil updateCounters, so that callers do not need to
+// check whether stats are being kept.
+type updateCounters struct {
+	requests       int64
+	bytesFetched   int64
+	bytesFromCache int64
+}
+
+// recordFetch counts a request to the server, which downloaded raw if err is nil
+func (c *updateCounters) recordFetch(raw []byte, err error) {
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(&c.requests, 1)
+	if err == nil {
+		atomic.AddInt64(&c.bytesFetched, int64(len(raw)))
+	}
+}
+
+// recordCached counts cached metadata which was used instead of downloading it
+func (c *updateCounters) recordCached(raw []byte) {
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(&c.bytesFromCache, int64(len(raw)))
+}
+
+func (c *updateCounters) snapshot() UpdateStats {
+	if c == nil {
+		return UpdateStats{}
+	}
+	return UpdateStats{
+		Requests:       atomic.LoadInt64(&c.requests),
+		BytesFetched:   atomic.LoadInt64(&c.bytesFetched),
+		BytesFromCache: atomic.LoadInt64(&c.bytesFromCache),
+	}
+}
+
+func (c *updateCounters) reset() {
+	if c == nil {
+		return
+	}
+	atomic.StoreInt64(&c.requests, 0)
+	atomic.StoreInt64(&c.bytesFetched, 0)
+	atomic.StoreInt64(&c.bytesFromCache, 0)
+}
+
+// UpdateStats returns the totals of the metadata requested from the server and
+// served from the cache by every update since the repository was created, or
+// since ResetUpdateStats was last called.  This includes updates made by other
+// operations, polls of the timestamp, and auto refreshes.
+func (r *repository) UpdateStats() UpdateStats {
+	return r.stats.snapshot()
+}
+
+// ResetUpdateStats sets the totals reported by UpdateStats back to zero
+func (r *repository) ResetUpdateStats() {
+	r.stats.reset()
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func cachedSize(t *testing.T, repo *repository, role data.RoleName) int64 {
+	raw, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	return int64(len(raw))
+}
+
+// The stats count every download of a fresh update, and for an update with
+// nothing new, only the timestamp download with everything else from cache
+func TestUpdateStats(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	writer, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, writer, "first", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, writer.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, writer, "")
+	defer os.RemoveAll(readerDir)
+	require.Equal(t, UpdateStats{}, reader.UpdateStats())
+
+	require.NoError(t, reader.updateTUF(false))
+	fresh := reader.UpdateStats()
+	require.True(t, fresh.Requests >= 4, "root, timestamp, snapshot and targets should be requested")
+	var total int64
+	for _, role := range data.BaseRoles {
+		total += cachedSize(t, reader, role)
+	}
+	require.Equal(t, total, fresh.BytesFetched)
+
+	reader.ResetUpdateStats()
+	require.Equal(t, UpdateStats{}, reader.UpdateStats())
+
+	require.NoError(t, reader.updateTUF(false))
+	require.Equal(t, UpdateStats{
+		Requests:     1,
+		BytesFetched: cachedSize(t, reader, data.CanonicalTimestampRole),
+		BytesFromCache: cachedSize(t, reader, data.CanonicalRootRole) +
+			cachedSize(t, reader, data.CanonicalSnapshotRole) +
+			cachedSize(t, reader, data.CanonicalTargetsRole),
+	}, reader.UpdateStats())
+
+	// after a publish, the new snapshot and targets are fetched, and the root
+	// still comes from cache
+	addTarget(t, writer, "second", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, writer.Publish())
+	reader.ResetUpdateStats()
+	require.NoError(t, reader.updateTUF(false))
+	require.Equal(t, UpdateStats{
+		Requests: 3,
+		BytesFetched: cachedSize(t, reader, data.CanonicalTimestampRole) +
+			cachedSize(t, reader, data.CanonicalSnapshotRole) +
+			cachedSize(t, reader, data.CanonicalTargetsRole),
+		BytesFromCache: cachedSize(t, reader, data.CanonicalRootRole),
+	}, reader.UpdateStats())
+}
+
+// The stats can be read and reset while updates are running
+func TestUpdateStatsConcurrent(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := LoadTUFRepo(repo.tufLoadOptions(false))
+			require.NoError(t, err)
+			repo.UpdateStats()
+			repo.ResetUpdateStats()
+		}()
+	}
+	wg.Wait()
+	repo.ResetUpdateStats()
+	require.Equal(t, UpdateStats{}, repo.UpdateStats())
+}
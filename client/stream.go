@@ -0,0 +1,70 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// targetRecord is the newline delimited JSON record which StreamTargets writes
+// for each target
+type targetRecord struct {
+	Name   string            `json:"name"`
+	Role   data.RoleName     `json:"role"`
+	Length int64             `json:"length"`
+	Hashes map[string]string `json:"hashes"`
+	Custom *json.RawMessage  `json:"custom,omitempty"`
+	Yanked bool              `json:"yanked,omitempty"`
+}
+
+// StreamTargets updates the repository, and then writes the same targets that
+// ListTargets returns to w, one JSON object per line, as each role is visited,
+// rather than collecting them all first.  Hashes are hex encoded.  The targets
+// are written in the order the delegation tree is walked, and in order of name
+// within each role, so the output of an unchanged repository is always
+// identical.
+func (r *repository) StreamTargets(w io.Writer) error {
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	// only the names already written are kept, to apply the same priority as
+	// ListTargets
+	written := make(map[string]bool)
+	visitor := func(tgt *data.SignedTargets, validRole data.DelegationRole) interface{} {
+		names := make([]string, 0, len(tgt.Signed.Targets))
+		for name := range tgt.Signed.Targets {
+			if !written[name] && validRole.CheckPaths(name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			target := targetFromMeta(name, tgt.Signed.Targets[name])
+			record := targetRecord{
+				Name:   name,
+				Role:   validRole.Name,
+				Length: target.Length,
+				Hashes: make(map[string]string, len(target.Hashes)),
+				Yanked: target.Yanked,
+			}
+			for alg, checksum := range target.Hashes {
+				record.Hashes[alg] = hex.EncodeToString(checksum)
+			}
+			if target.Custom != nil {
+				custom := json.RawMessage(*target.Custom)
+				record.Custom = &custom
+			}
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+			written[name] = true
+		}
+		return nil
+	}
+	return r.tufRepo.WalkTargets("", data.CanonicalTargetsRole, visitor)
+}
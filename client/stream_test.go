@@ -0,0 +1,65 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// The streamed records describe exactly the targets that ListTargets returns,
+// and the same repository always streams identically
+func TestStreamTargets(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	addTarget(t, repo, "shadowed", "../fixtures/intermediate-ca.crt", data.CanonicalTargetsRole)
+	addTarget(t, repo, "shadowed", "../fixtures/root-ca.crt", "targets/a")
+	addTarget(t, repo, "top", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "delegated", "../fixtures/root-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+
+	var out bytes.Buffer
+	require.NoError(t, repo.StreamTargets(&out))
+	streamed := make(map[string]targetRecord)
+	var order []string
+	scanner := bufio.NewScanner(bytes.NewReader(out.Bytes()))
+	for scanner.Scan() {
+		var record targetRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		require.NotContains(t, streamed, record.Name)
+		streamed[record.Name] = record
+		order = append(order, record.Name)
+	}
+	require.NoError(t, scanner.Err())
+	// the top level targets come first, followed by the delegation's
+	require.Equal(t, []string{"shadowed", "top", "delegated"}, order)
+
+	listed, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, streamed, len(listed))
+	for _, target := range listed {
+		record, ok := streamed[target.Name]
+		require.True(t, ok, target.Name)
+		require.Equal(t, target.Role, record.Role)
+		require.Equal(t, target.Length, record.Length)
+		require.Len(t, record.Hashes, len(target.Hashes))
+		for alg, checksum := range target.Hashes {
+			require.Equal(t, hex.EncodeToString(checksum), record.Hashes[alg])
+		}
+	}
+
+	var again bytes.Buffer
+	require.NoError(t, repo.StreamTargets(&again))
+	require.Equal(t, out.String(), again.String())
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrDuplicateTarget is returned by StageFromTargetSet when the desired set has
+// more than one target with the same normalized name
+type ErrDuplicateTarget struct {
+	Name string
+}
+
+func (err ErrDuplicateTarget) Error() string {
+	return fmt.Sprintf("target %s is in the desired target set more than once", err.Name)
+}
+
+// StageFromTargetSet stages the fewest changes that make the targets signed
+// directly into role exactly the desired targets, once the changelist is
+// published.  The desired targets are compared, by normalized name, with the
+// role's published targets as updated from the server, together with any
+// changes to the role's targets already in the changelist: targets which are
+// not desired are removed, and desired targets which are missing, or whose
+// length, hashes or custom data differ, are added.  Targets which already
+// match are left alone, so staging the same set twice adds no further
+// changes.  The targets of delegations of role are not affected.
+func (r *repository) StageFromTargetSet(role string, desired []*Target) error {
+	roleName := data.RoleName(role)
+	if roleName != data.CanonicalTargetsRole && !data.IsDelegation(roleName) {
+		return data.ErrInvalidRole{Role: roleName, Reason: "cannot add targets to this role"}
+	}
+
+	current, err := r.stagedTargets(roleName)
+	if err != nil {
+		return err
+	}
+
+	// Stage everything in memory first so that no changes are added to the
+	// repository's changelist if any of them can't be created.
+	cl := changelist.NewMemChangelist()
+
+	wanted := make(map[string]bool, len(desired))
+	var adds []*changelist.TUFChange
+	for _, target := range desired {
+		template, err := newAddTargetChange(target)
+		if err != nil {
+			return err
+		}
+		name := template.Path()
+		if wanted[name] {
+			return ErrDuplicateTarget{Name: name}
+		}
+		wanted[name] = true
+		meta := data.FileMeta{Length: target.Length, Hashes: target.Hashes, Custom: target.Custom}
+		if existing, ok := current[name]; ok && existing.Equals(meta) {
+			continue
+		}
+		adds = append(adds, template)
+	}
+
+	var removes []string
+	for name := range current {
+		if !wanted[name] {
+			removes = append(removes, name)
+		}
+	}
+	sort.Strings(removes)
+	for _, name := range removes {
+		logrus.Debugf(`Removing target "%s" not in the desired set of %s`, name, roleName)
+		template := changelist.NewTUFChange(changelist.ActionDelete, "",
+			changelist.TypeTargetsTarget, name, nil)
+		if err := addChange(cl, template, roleName); err != nil {
+			return err
+		}
+	}
+	sort.Slice(adds, func(i, j int) bool { return adds[i].Path() < adds[j].Path() })
+	for _, template := range adds {
+		if err := addChange(cl, template, roleName); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range cl.List() {
+		if err := r.changelist.Add(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stagedTargets returns the targets signed directly into role as they will be
+// once the changelist is published: the role's published targets, with the
+// changes to them in the changelist applied in order
+func (r *repository) stagedTargets(role data.RoleName) (data.Files, error) {
+	targets := make(data.Files)
+	// update as publishing does, so that every delegation is downloaded
+	switch err := r.updateTUF(true); err.(type) {
+	case nil:
+		if tgts, ok := r.tufRepo.Targets[role]; ok {
+			for name, meta := range tgts.Signed.Targets {
+				targets[name] = meta
+			}
+		}
+	case ErrRepositoryNotExist:
+		// nothing has been published yet, so only the changelist has targets
+	default:
+		return nil, err
+	}
+
+	for _, c := range r.changelist.List() {
+		if c.Scope() != role || c.Type() != changelist.TypeTargetsTarget {
+			continue
+		}
+		switch c.Action() {
+		case changelist.ActionCreate:
+			meta := data.FileMeta{}
+			if err := json.Unmarshal(c.Content(), &meta); err != nil {
+				return nil, err
+			}
+			targets[c.Path()] = meta
+		case changelist.ActionDelete:
+			delete(targets, c.Path())
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// stagedChanges describes the changes in the changelist as action and path
+func stagedChanges(t *testing.T, repo *repository) []string {
+	var staged []string
+	for _, c := range getChanges(t, repo) {
+		require.Equal(t, changelist.TypeTargetsTarget, c.Type())
+		staged = append(staged, c.Action()+" "+c.Scope().String()+" "+c.Path())
+	}
+	return staged
+}
+
+func newTestTarget(t *testing.T, name, file string) *Target {
+	target, err := NewTarget(name, file, nil)
+	require.NoError(t, err)
+	return target
+}
+
+// Converging a published role on a desired set stages only the removals and
+// the additions of new or changed targets, and nothing once it has converged
+func TestStageFromTargetSet(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "kept", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "changed", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "removed", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	desired := []*Target{
+		newTestTarget(t, "kept", "../fixtures/intermediate-ca.crt"),
+		newTestTarget(t, "changed", "../fixtures/root-ca.crt"),
+		newTestTarget(t, "added", "../fixtures/root-ca.crt"),
+	}
+	require.NoError(t, repo.StageFromTargetSet("targets", desired))
+	require.Equal(t, []string{
+		"delete targets removed",
+		"create targets added",
+		"create targets changed",
+	}, stagedChanges(t, repo))
+
+	// staging the same set again adds nothing
+	require.NoError(t, repo.StageFromTargetSet("targets", desired))
+	require.Len(t, getChanges(t, repo), 3)
+
+	require.NoError(t, repo.Publish())
+	targets, err := repo.ListTargets()
+	require.NoError(t, err)
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"added", "changed", "kept"}, names)
+	changed, err := repo.GetTargetByName("changed")
+	require.NoError(t, err)
+	require.Equal(t, desired[1].Hashes, changed.Hashes)
+
+	require.NoError(t, repo.StageFromTargetSet("targets", desired))
+	require.Empty(t, getChanges(t, repo))
+}
+
+// Changes already in the changelist are taken into account, and an invalid
+// desired set stages nothing
+func TestStageFromTargetSetWithPendingChanges(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	// targets/a is published with no targets, so only the changelist has any
+	addTarget(t, repo, "pending", "../fixtures/intermediate-ca.crt", "targets/a")
+	addTarget(t, repo, "unwanted", "../fixtures/intermediate-ca.crt", "targets/a")
+	require.NoError(t, repo.RemoveTarget("unwanted", "targets/a"))
+	addTarget(t, repo, "elsewhere", "../fixtures/intermediate-ca.crt")
+
+	require.NoError(t, repo.StageFromTargetSet("targets/a", []*Target{
+		newTestTarget(t, "wanted", "../fixtures/root-ca.crt"),
+	}))
+	require.Equal(t, []string{
+		"create targets/a pending",
+		"create targets/a unwanted",
+		"delete targets/a unwanted",
+		"create targets elsewhere",
+		"delete targets/a pending",
+		"create targets/a wanted",
+	}, stagedChanges(t, repo))
+
+	err := repo.StageFromTargetSet("targets/a", []*Target{
+		newTestTarget(t, "twice", "../fixtures/root-ca.crt"),
+		newTestTarget(t, "/twice", "../fixtures/root-ca.crt"),
+	})
+	require.Equal(t, ErrDuplicateTarget{Name: "twice"}, err)
+	require.IsType(t, data.ErrInvalidRole{}, repo.StageFromTargetSet("root", nil))
+	require.Len(t, getChanges(t, repo), 6)
+}
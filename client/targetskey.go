@@ -0,0 +1,62 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrTargetsKeyUnauthorized is returned by an update when the targets metadata
+// is not signed by a threshold of the keys which the current root, after any
+// rotation, authorizes for the targets role, because it is still signed by a
+// targets key which the root has rotated out
+type ErrTargetsKeyUnauthorized struct {
+	// KeyIDs are the IDs of the keys which signed the targets metadata
+	KeyIDs []string
+}
+
+func (err ErrTargetsKeyUnauthorized) Error() string {
+	return fmt.Sprintf("the targets metadata is signed by keys which the current root does not authorize for targets: %s",
+		strings.Join(err.KeyIDs, ", "))
+}
+
+// unauthorizedTargetsKey returns ErrTargetsKeyUnauthorized for targets metadata
+// which failed to load with err because it is signed by a targets key which
+// the previously trusted root authorized, but which the root loaded by the
+// update has rotated out.  Otherwise err is returned, such as when the metadata
+// is signed by keys which neither root authorizes, or has too few signatures.
+func (c tufClient) unauthorizedTargetsKey(raw []byte, err error) error {
+	targetsRole, roleErr := c.newBuilder.GetBaseRole(data.CanonicalTargetsRole)
+	if roleErr != nil {
+		return err
+	}
+	trustedRole, roleErr := c.oldBuilder.GetBaseRole(data.CanonicalTargetsRole)
+	if roleErr != nil {
+		return err
+	}
+
+	s := &data.Signed{}
+	if json.Unmarshal(raw, s) != nil {
+		return err
+	}
+	for _, sig := range s.Signatures {
+		_, authorized := targetsRole.Keys[sig.KeyID]
+		if _, wasAuthorized := trustedRole.Keys[sig.KeyID]; wasAuthorized && !authorized {
+			return ErrTargetsKeyUnauthorized{KeyIDs: signatureKeyIDs(s)}
+		}
+	}
+	return err
+}
+
+// signatureKeyIDs returns the sorted IDs of the keys which signed s
+func signatureKeyIDs(s *data.Signed) []string {
+	keyIDs := make([]string, 0, len(s.Signatures))
+	for _, sig := range s.Signatures {
+		keyIDs = append(keyIDs, sig.KeyID)
+	}
+	sort.Strings(keyIDs)
+	return keyIDs
+}
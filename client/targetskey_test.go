@@ -0,0 +1,81 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// Once the root rotates the targets key, targets still signed only by the old
+// key are rejected, naming the key which signed them
+func TestUpdateRejectsTargetsSignedByRotatedKey(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.updateTUF(false))
+	oldKeyIDs := repo.tufRepo.Root.Signed.Roles[data.CanonicalTargetsRole].KeyIDs
+
+	newKey, err := signed.NewEd25519().Create(data.CanonicalTargetsRole, repo.gun, data.ED25519Key)
+	require.NoError(t, err)
+	require.NoError(t, serverSwizzler.OffsetMetadataVersion(data.CanonicalRootRole, 1))
+	require.NoError(t, serverSwizzler.RotateKey(data.CanonicalTargetsRole, newKey))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	require.Equal(t, ErrTargetsKeyUnauthorized{KeyIDs: oldKeyIDs}, repo.updateTUF(false))
+}
+
+// Targets signed by a key which no root has authorized fail with the builder's
+// error, since no targets key was rotated out
+func TestUpdateRejectsTargetsSignedByUnknownKey(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.updateTUF(false))
+
+	require.NoError(t, serverSwizzler.SignMetadataWithInvalidKey(data.CanonicalTargetsRole))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	err := repo.updateTUF(false)
+	require.Error(t, err)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+}
+
+// Targets signed by the rotated key as well as by the new one are accepted,
+// even if the cache keeps the targets signed only by the rotated key
+func TestUpdateChecksLoadedTargetsKey(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.updateTUF(false))
+
+	// the targets are re-signed with a new key as well as the rotated one
+	newKey, err := serverSwizzler.CryptoService.Create(data.CanonicalTargetsRole, repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, serverSwizzler.OffsetMetadataVersion(data.CanonicalRootRole, 1))
+	require.NoError(t, serverSwizzler.RotateKey(data.CanonicalTargetsRole, newKey))
+	require.NoError(t, serverSwizzler.MutateTargets(func(tgs *data.Targets) {
+		tgs.Targets["new"] = data.FileMeta{Length: 1, Hashes: data.Hashes{"sha256": make([]byte, 32)}}
+	}))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	// the cache keeps the targets signed only by the rotated key
+	repo.cache = &unwritableStore{MetadataStore: repo.cache, roleToNotWrite: data.CanonicalTargetsRole}
+	require.NoError(t, repo.updateTUF(false))
+	require.Contains(t, repo.tufRepo.Targets[data.CanonicalTargetsRole].Signed.Targets, "new")
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+const (
+	thresholdReasonSingleSignature = "has a threshold of 1 although several keys are authorized, so any one of them can sign alone"
+	thresholdReasonNoRedundancy    = "requires a signature from every authorized key, so losing any one of them leaves the role unsignable"
+)
+
+// ThresholdWarning describes a role whose threshold looks misconfigured
+// relative to the number of keys authorized to sign it
+type ThresholdWarning struct {
+	Role      data.RoleName
+	Threshold int
+	// Keys is how many keys are authorized to sign the role
+	Keys   int
+	Reason string
+}
+
+// ThresholdAudit updates the repository, and then reports the roles in the
+// trust data, including the delegations, whose threshold is 1 although
+// several keys are authorized, which suggests multiple signatures were
+// intended, or whose threshold equals the number of authorized keys, which
+// leaves no redundancy should a key be lost.  The base roles are reported
+// first, followed by the delegations in order of name.  The audit is advisory
+// only: if the update fails, the trust data already loaded, if any, is
+// audited instead.
+func (r *repository) ThresholdAudit() []ThresholdWarning {
+	if err := r.updateTUF(false); err != nil {
+		logrus.Warnf("auditing thresholds without updating %s: %s", r.gun, err)
+	}
+	if r.tufRepo == nil {
+		return nil
+	}
+
+	var warnings []ThresholdWarning
+	for _, role := range data.BaseRoles {
+		baseRole, err := r.tufRepo.GetBaseRole(role)
+		if err != nil {
+			continue
+		}
+		if warning, ok := thresholdWarning(role, baseRole.Threshold, len(baseRole.Keys)); ok {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	var delegationWarnings []ThresholdWarning
+	for _, tgts := range r.tufRepo.Targets {
+		for _, delegation := range tgts.Signed.Delegations.Roles {
+			keys := 0
+			for _, keyID := range delegation.KeyIDs {
+				if _, ok := tgts.Signed.Delegations.Keys[keyID]; ok {
+					keys++
+				}
+			}
+			if warning, ok := thresholdWarning(delegation.Name, delegation.Threshold, keys); ok {
+				delegationWarnings = append(delegationWarnings, warning)
+			}
+		}
+	}
+	sort.Slice(delegationWarnings, func(i, j int) bool { return delegationWarnings[i].Role < delegationWarnings[j].Role })
+	return append(warnings, delegationWarnings...)
+}
+
+// thresholdWarning determines whether a role's threshold is suspicious given
+// how many keys are authorized to sign it, returning false if it is not
+func thresholdWarning(role data.RoleName, threshold, keys int) (ThresholdWarning, bool) {
+	warning := ThresholdWarning{Role: role, Threshold: threshold, Keys: keys}
+	switch {
+	case threshold == 1 && keys > 1:
+		warning.Reason = thresholdReasonSingleSignature
+	case threshold == keys:
+		warning.Reason = thresholdReasonNoRedundancy
+	default:
+		return warning, false
+	}
+	return warning, true
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Thresholds are only suspicious at 1 with several keys, or when every key is
+// required
+func TestThresholdWarning(t *testing.T) {
+	cases := []struct {
+		threshold, keys int
+		reason          string
+	}{
+		{threshold: 1, keys: 1, reason: thresholdReasonNoRedundancy},
+		{threshold: 1, keys: 2, reason: thresholdReasonSingleSignature},
+		{threshold: 1, keys: 5, reason: thresholdReasonSingleSignature},
+		{threshold: 2, keys: 2, reason: thresholdReasonNoRedundancy},
+		{threshold: 2, keys: 3},
+		{threshold: 3, keys: 5},
+	}
+	for _, tc := range cases {
+		warning, ok := thresholdWarning("targets/a", tc.threshold, tc.keys)
+		require.Equal(t, tc.reason != "", ok, "%d of %d", tc.threshold, tc.keys)
+		if ok {
+			require.Equal(t, ThresholdWarning{Role: "targets/a", Threshold: tc.threshold, Keys: tc.keys, Reason: tc.reason}, warning)
+		}
+	}
+}
+
+// The audit covers the base roles, and then the delegations in order of name
+func TestThresholdAudit(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	keys := make([]data.PublicKey, 3)
+	for i := range keys {
+		keys[i] = createKey(t, repo, "targets/delegation", false)
+	}
+	// targets/b has three keys and a threshold of 1, while targets/c has a
+	// threshold of 2 of three keys, and so is not reported
+	require.NoError(t, repo.AddDelegation("targets/b", keys, []string{""}))
+	require.NoError(t, repo.AddDelegation("targets/c", keys, []string{""}))
+	require.NoError(t, repo.AddDelegation("targets/a", keys[:1], []string{""}))
+	tdJSON, err := json.Marshal(&changelist.TUFDelegation{NewThreshold: 2})
+	require.NoError(t, err)
+	require.NoError(t, addChange(repo.changelist, newUpdateDelegationChange("targets/c", tdJSON), "targets/c"))
+	require.NoError(t, repo.Publish())
+
+	warnings := repo.ThresholdAudit()
+	roles := make([]data.RoleName, 0, len(warnings))
+	for _, w := range warnings {
+		roles = append(roles, w.Role)
+	}
+	// the base roles each have a single key
+	require.Equal(t, []data.RoleName{
+		data.CanonicalRootRole, data.CanonicalTargetsRole, data.CanonicalSnapshotRole, data.CanonicalTimestampRole,
+		"targets/a", "targets/b",
+	}, roles)
+	require.Equal(t, ThresholdWarning{Role: "targets/a", Threshold: 1, Keys: 1, Reason: thresholdReasonNoRedundancy}, warnings[4])
+	require.Equal(t, ThresholdWarning{Role: "targets/b", Threshold: 1, Keys: 3, Reason: thresholdReasonSingleSignature}, warnings[5])
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// TimestampAdvisory describes a timestamp whose signatures depart from the
+// single authorized key which TUF recommends for the timestamp role.  An
+// update only fails if the timestamp is not signed by a threshold of its keys,
+// so the advisory is for information: signatures by keys the root has not
+// authorized for the timestamp may indicate that it has been tampered with.
+type TimestampAdvisory struct {
+	Threshold int
+	// AuthorizedKeys is how many keys the root authorizes for the timestamp
+	AuthorizedKeys int
+	// ValidSignatures is how many signatures by authorized keys verified
+	ValidSignatures int
+	// UnauthorizedKeyIDs are the sorted IDs of the keys, not authorized for
+	// the timestamp, which it carries signatures by
+	UnauthorizedKeyIDs []string
+}
+
+// checkTimestampSignatures compares the signatures of the updated timestamp
+// with the keys the root authorizes for it, returning an advisory, which is
+// also logged, if the timestamp does not have exactly one authorized key, has
+// fewer valid signatures than its threshold, or is signed by any other key
+func checkTimestampSignatures(repo *tuf.Repo) *TimestampAdvisory {
+	if repo == nil || repo.Timestamp == nil {
+		return nil
+	}
+	role, err := repo.GetBaseRole(data.CanonicalTimestampRole)
+	if err != nil {
+		return nil
+	}
+
+	advisory := &TimestampAdvisory{Threshold: role.Threshold, AuthorizedKeys: len(role.Keys)}
+	unauthorized := make(map[string]bool)
+	for _, sig := range repo.Timestamp.Signatures {
+		if _, ok := role.Keys[sig.KeyID]; !ok {
+			unauthorized[sig.KeyID] = true
+		} else if sig.IsValid {
+			advisory.ValidSignatures++
+		}
+	}
+	for keyID := range unauthorized {
+		advisory.UnauthorizedKeyIDs = append(advisory.UnauthorizedKeyIDs, keyID)
+	}
+	sort.Strings(advisory.UnauthorizedKeyIDs)
+
+	if advisory.AuthorizedKeys == 1 && advisory.ValidSignatures >= advisory.Threshold && len(unauthorized) == 0 {
+		return nil
+	}
+	logrus.Warnf("timestamp has %d authorized keys, %d valid signatures of a threshold of %d, and signatures by %d unauthorized keys",
+		advisory.AuthorizedKeys, advisory.ValidSignatures, advisory.Threshold, len(unauthorized))
+	return advisory
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A timestamp carrying an extra signature by a key the root does not authorize
+// still verifies, but is reported as an advisory
+func TestUpdateWithResultReportsUnauthorizedTimestampSignature(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, result, err := repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Nil(t, result.TimestampAdvisory)
+
+	cs := signed.NewEd25519()
+	extra, err := cs.Create(data.CanonicalTimestampRole, "docker.com/notary", data.ED25519Key)
+	require.NoError(t, err)
+	privKey, _, err := cs.GetPrivateKey(extra.ID())
+	require.NoError(t, err)
+	raw, err := serverSwizzler.MetadataCache.GetSized(data.CanonicalTimestampRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+	sig, err := privKey.Sign(rand.Reader, *s.Signed, nil)
+	require.NoError(t, err)
+	s.Signatures = append(s.Signatures, data.Signature{KeyID: extra.ID(), Method: privKey.SignatureAlgorithm(), Signature: sig})
+	raw, err = json.Marshal(s)
+	require.NoError(t, err)
+	require.NoError(t, serverSwizzler.MetadataCache.Set(data.CanonicalTimestampRole.String(), raw))
+
+	_, result, err = repo.UpdateWithResult(false)
+	require.NoError(t, err)
+	require.Equal(t, &TimestampAdvisory{
+		Threshold:          1,
+		AuthorizedKeys:     1,
+		ValidSignatures:    1,
+		UnauthorizedKeyIDs: []string{extra.ID()},
+	}, result.TimestampAdvisory)
+}
+
+// A timestamp role with more than the one recommended key, and too few valid
+// signatures for its threshold, is reported
+func TestCheckTimestampSignaturesMultipleKeys(t *testing.T) {
+	tufRepo, cs, err := testutils.EmptyRepo("docker.com/notary")
+	require.NoError(t, err)
+	require.Nil(t, checkTimestampSignatures(nil))
+
+	second, err := cs.Create(data.CanonicalTimestampRole, "docker.com/notary", data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, tufRepo.AddBaseKeys(data.CanonicalTimestampRole, second))
+	// nothing has been verified, so no signature is known to be valid
+	require.Equal(t, &TimestampAdvisory{Threshold: 1, AuthorizedKeys: 2},
+		checkTimestampSignatures(tufRepo))
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrTimestampKeyNotPinned is returned when the timestamp of a repository
+// whose timestamp keys are pinned is not signed by a threshold of the pinned
+// keys
+type ErrTimestampKeyNotPinned struct {
+	GUN data.GUN
+	Err error
+}
+
+func (err ErrTimestampKeyNotPinned) Error() string {
+	return fmt.Sprintf("the timestamp of %s is not signed by its pinned keys: %s", err.GUN, err.Err)
+}
+
+// checkTimestampPin ensures that the timestamp of an updated repo is signed by
+// a threshold of the timestamp keys pinned for its GUN.  Only the pinned keys
+// which the root also authorizes count towards the threshold.
+func checkTimestampPin(repo *tuf.Repo, verified map[data.RoleName][]byte, gun data.GUN, trustPin trustpinning.TrustPinConfig) error {
+	pinned, ok := trustPin.PinnedTimestampKeys(gun)
+	if !ok || repo.Timestamp == nil {
+		return nil
+	}
+	if err := verifyPinnedKeys(repo, verified[data.CanonicalTimestampRole], data.CanonicalTimestampRole, pinned); err != nil {
+		return ErrTimestampKeyNotPinned{GUN: gun, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A timestamp signed by a server key which the root authorizes, but which is
+// not pinned, fails the update
+func TestTimestampKeyPinRejectsUnpinnedKey(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	gun := "docker.com/notary"
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	pinnedKeyIDs := repo.tufRepo.Root.Signed.Roles[data.CanonicalTimestampRole].KeyIDs
+	require.Len(t, pinnedKeyIDs, 1)
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	reader.trustPinning = trustpinning.TrustPinConfig{
+		TimestampKeys: map[string][]string{gun: pinnedKeyIDs},
+	}
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+
+	// the server swaps in a new timestamp key, which the root authorizes
+	require.NoError(t, repo.RotateKey(data.CanonicalTimestampRole, true, nil))
+	require.NotEqual(t, pinnedKeyIDs, repo.tufRepo.Root.Signed.Roles[data.CanonicalTimestampRole].KeyIDs)
+
+	_, err = reader.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrTimestampKeyNotPinned{}, err)
+
+	// pinning the new key, or not pinning, trusts it
+	reader.trustPinning = trustpinning.TrustPinConfig{
+		TimestampKeys: map[string][]string{"docker.com/*": repo.tufRepo.Root.Signed.Roles[data.CanonicalTimestampRole].KeyIDs},
+	}
+	_, err = reader.ListTargets()
+	require.NoError(t, err)
+	reader.trustPinning = trustpinning.TrustPinConfig{}
+	_, err = reader.ListTargets()
+	require.NoError(t, err)
+}
+
+// The pin is checked against the timestamp the update verified, even if it
+// could not be cached
+func TestTimestampKeyPinChecksVerifiedTimestamp(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	gun := "docker.com/notary"
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+
+	// the cache keeps the timestamp signed by the old key, so only the
+	// downloaded timestamp is signed by the pinned key
+	require.NoError(t, repo.RotateKey(data.CanonicalTimestampRole, true, nil))
+	reader.cache = &unwritableStore{MetadataStore: reader.cache, roleToNotWrite: data.CanonicalTimestampRole}
+	reader.trustPinning = trustpinning.TrustPinConfig{
+		TimestampKeys: map[string][]string{gun: repo.tufRepo.Root.Signed.Roles[data.CanonicalTimestampRole].KeyIDs},
+	}
+	_, err = reader.ListTargets()
+	require.NoError(t, err)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"time"
+
+	"github.com/theupdateframework/notary/tuf"
+)
+
+// UpdateTiming breaks down the wall-clock time spent fetching and verifying
+// each kind of metadata during a single update. All durations are measured
+// with the monotonic clock.
+type UpdateTiming struct {
+	Root        time.Duration // bootstrapping and, if necessary, rotating the root
+	Timestamp   time.Duration // fetching and verifying the timestamp
+	Snapshot    time.Duration // fetching and verifying the snapshot
+	Targets     time.Duration // fetching and verifying the top level targets
+	Delegations time.Duration // fetching and verifying all delegated targets roles
+	Total       time.Duration // the entire update, including any overhead not listed above
+}
+
+type updatePhase int
+
+const (
+	phaseRoot updatePhase = iota
+	phaseTimestamp
+	phaseSnapshot
+	phaseTargets
+	phaseDelegations
+)
+
+// record adds the time elapsed since start to the given phase. It is a no-op
+// on a nil UpdateTiming so that callers do not need to check whether timing
+// was requested.
+func (t *UpdateTiming) record(phase updatePhase, start time.Time) {
+	if t == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	switch phase {
+	case phaseRoot:
+		t.Root += elapsed
+	case phaseTimestamp:
+		t.Timestamp += elapsed
+	case phaseSnapshot:
+		t.Snapshot += elapsed
+	case phaseTargets:
+		t.Targets += elapsed
+	case phaseDelegations:
+		t.Delegations += elapsed
+	}
+}
+
+// UpdateWithTiming updates the TUF metadata for the repository exactly as any
+// other read or write operation would, but also reports how long each stage of
+// the update took.
+func (r *repository) UpdateWithTiming(forWrite bool) (*tuf.Repo, UpdateTiming, error) {
+	timing := UpdateTiming{}
+	options := r.tufLoadOptions(forWrite)
+	options.Timing = &timing
+	repo, invalid, err := LoadTUFRepo(options)
+	if err != nil {
+		return nil, timing, err
+	}
+	r.tufRepo = repo
+	r.invalid = invalid
+	return repo, timing, nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// UpdateWithTiming populates every phase of the timing breakdown, and the
+// phases add up to no more than the total time spent updating.
+func TestUpdateWithTimingPopulatesAllPhases(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	tufRepo, timing, err := repo.UpdateWithTiming(false)
+	require.NoError(t, err)
+	require.NotNil(t, tufRepo)
+	require.Equal(t, tufRepo, repo.tufRepo)
+
+	require.True(t, timing.Root > 0, "root timing not populated")
+	require.True(t, timing.Timestamp > 0, "timestamp timing not populated")
+	require.True(t, timing.Snapshot > 0, "snapshot timing not populated")
+	require.True(t, timing.Targets > 0, "targets timing not populated")
+	require.True(t, timing.Delegations > 0, "delegations timing not populated")
+
+	sum := timing.Root + timing.Timestamp + timing.Snapshot + timing.Targets + timing.Delegations
+	require.True(t, sum <= timing.Total, "phases (%s) exceed the total (%s)", sum, timing.Total)
+	require.True(t, timing.Total-sum < timing.Total/2,
+		"phases (%s) do not account for most of the total (%s)", sum, timing.Total)
+
+	// a second update is served from cache, but is still timed
+	_, timing, err = repo.UpdateWithTiming(false)
+	require.NoError(t, err)
+	require.True(t, timing.Timestamp > 0)
+	require.True(t, timing.Total > 0)
+}
+
+// If the update fails, the time spent before the failure is still reported
+func TestUpdateWithTimingReportsOnFailure(t *testing.T) {
+	ts := errorTestServer(t, http.StatusNotFound)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	_, timing, err := repo.UpdateWithTiming(false)
+	require.Error(t, err)
+	require.IsType(t, ErrRepositoryNotExist{}, err)
+	require.True(t, timing.Root > 0)
+	require.True(t, timing.Total >= timing.Root)
+}
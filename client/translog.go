@@ -0,0 +1,79 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// LogVerifier verifies that metadata has been recorded in an append-only
+// transparency log, such as by obtaining an inclusion proof for it from the
+// log and checking it with translog.VerifyInclusion against a tree head the
+// log has signed.  Since a server can't show one client a root, or other
+// metadata, without it being visible to everyone who audits the log, this
+// detects split-view attacks.
+type LogVerifier interface {
+	// VerifyInclusion returns nil if the metadata of the role, exactly as
+	// downloaded, is included in the log, and an error if the log has no
+	// valid inclusion proof for it.  A log which only records some roles,
+	// such as the root, may accept the others without a proof.
+	VerifyInclusion(role data.RoleName, metadata []byte) error
+}
+
+// ErrNotInTransparencyLog is returned by an update when the transparency log
+// could not confirm that the metadata of a role is included in it
+type ErrNotInTransparencyLog struct {
+	Role data.RoleName
+	Err  error
+}
+
+func (err ErrNotInTransparencyLog) Error() string {
+	return fmt.Sprintf("%s metadata could not be verified to be in the transparency log: %s", err.Role, err.Err)
+}
+
+// SetTransparencyLog requires, during any subsequent update, every role's
+// metadata to be verified by verifier as included in a transparency log, and
+// fails the update with ErrNotInTransparencyLog otherwise.  A nil verifier
+// removes the requirement.
+func (r *repository) SetTransparencyLog(verifier LogVerifier) {
+	r.updateOptions.TransparencyLog = verifier
+}
+
+// checkTransparencyLog verifies the metadata of every role of an updated repo,
+// exactly as the update verified it, with the transparency log, starting with
+// the root, which all other trust derives from
+func checkTransparencyLog(repo *tuf.Repo, verified map[data.RoleName][]byte, verifier LogVerifier) error {
+	if verifier == nil {
+		return nil
+	}
+	for _, role := range loadedRoles(repo) {
+		raw, ok := verified[role]
+		if !ok {
+			return ErrNotInTransparencyLog{Role: role, Err: fmt.Errorf("the verified %s metadata is not available", role)}
+		}
+		if err := verifier.VerifyInclusion(role, raw); err != nil {
+			return ErrNotInTransparencyLog{Role: role, Err: err}
+		}
+	}
+	return nil
+}
+
+// loadedRoles returns the roles whose metadata is loaded in repo, starting with
+// the root and followed by the timestamp, snapshot, and targets roles in order
+func loadedRoles(repo *tuf.Repo) []data.RoleName {
+	roles := []data.RoleName{data.CanonicalRootRole}
+	if repo.Timestamp != nil {
+		roles = append(roles, data.CanonicalTimestampRole)
+	}
+	if repo.Snapshot != nil {
+		roles = append(roles, data.CanonicalSnapshotRole)
+	}
+	targets := make([]data.RoleName, 0, len(repo.Targets))
+	for role := range repo.Targets {
+		targets = append(targets, role)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+	return append(roles, targets...)
+}
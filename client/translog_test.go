@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/translog"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// testLog is a transparency log holding metadata in an in-memory tree
+type testLog struct {
+	tree    translog.Tree
+	indices map[string]int64
+	// tamper corrupts the inclusion proofs the log returns
+	tamper bool
+}
+
+func newTestLog() *testLog {
+	return &testLog{indices: make(map[string]int64)}
+}
+
+func (l *testLog) add(metadata map[data.RoleName][]byte, roles ...data.RoleName) {
+	for _, role := range roles {
+		l.indices[string(metadata[role])] = l.tree.Append(metadata[role])
+	}
+}
+
+func (l *testLog) VerifyInclusion(role data.RoleName, metadata []byte) error {
+	index, ok := l.indices[string(metadata)]
+	if !ok {
+		return errors.New("no inclusion proof")
+	}
+	proof, err := l.tree.Prove(index)
+	if err != nil {
+		return err
+	}
+	if l.tamper {
+		proof.Hashes[0] = translog.LeafHash([]byte("tampered"))
+	}
+	return translog.VerifyInclusion(metadata, proof, l.tree.RootHash())
+}
+
+// An update succeeds only while all of the metadata has valid inclusion proofs
+func TestSetTransparencyLog(t *testing.T) {
+	serverMeta, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	log := newTestLog()
+	// some unrelated entries, so that proofs are not trivial
+	log.tree.Append([]byte("some other repository's root"))
+	log.tree.Append([]byte("some other repository's timestamp"))
+	log.add(serverMeta, data.CanonicalRootRole, data.CanonicalTargetsRole, data.CanonicalSnapshotRole)
+	log.add(serverMeta, delegationsWithNonEmptyMetadata...)
+	repo.SetTransparencyLog(log)
+
+	// the timestamp is not yet in the log
+	_, err := repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrNotInTransparencyLog{}, err)
+	require.Equal(t, data.CanonicalTimestampRole, err.(ErrNotInTransparencyLog).Role)
+
+	log.add(serverMeta, data.CanonicalTimestampRole)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	// proofs which don't lead to the log's root hash are rejected
+	log.tamper = true
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrNotInTransparencyLog{}, err)
+	notLogged := err.(ErrNotInTransparencyLog)
+	require.Equal(t, data.CanonicalRootRole, notLogged.Role)
+	require.IsType(t, translog.ErrInvalidProof{}, notLogged.Err)
+
+	// without a log, nothing is checked
+	repo.SetTransparencyLog(nil)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// The metadata checked with the log is the metadata the update verified, even
+// if it could not be cached
+func TestTransparencyLogChecksVerifiedMetadata(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	gun := "docker.com/notary"
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	reader, _, readerDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(readerDir)
+	log := newTestLog()
+	logCached := func(cache store.MetadataStore, roles ...data.RoleName) {
+		for _, role := range roles {
+			raw, err := cache.GetSized(role.String(), store.NoSizeLimit)
+			require.NoError(t, err)
+			log.add(map[data.RoleName][]byte{role: raw}, role)
+		}
+	}
+	_, err := reader.ListTargets()
+	require.NoError(t, err)
+	logCached(reader.cache, data.BaseRoles...)
+	reader.SetTransparencyLog(log)
+
+	// everything but the new targets is logged
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	other, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+	_, err = other.ListTargets()
+	require.NoError(t, err)
+	logCached(other.cache, data.CanonicalTimestampRole, data.CanonicalSnapshotRole)
+
+	// the cache keeps the targets which were logged
+	reader.cache = &unwritableStore{MetadataStore: reader.cache, roleToNotWrite: data.CanonicalTargetsRole}
+	_, err = reader.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrNotInTransparencyLog{}, err)
+	require.Equal(t, data.CanonicalTargetsRole, err.(ErrNotInTransparencyLog).Role)
+}
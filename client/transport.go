@@ -0,0 +1,146 @@
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// TransportOptions tunes the connection pooling of a transport created by
+// NewTunedTransport.  Zero values select the same defaults as
+// http.DefaultTransport.
+type TransportOptions struct {
+	// MaxIdleConns limits the number of idle keep-alive connections kept
+	// across all hosts
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits the number of idle keep-alive connections
+	// kept to each host.  Updaters which update many GUNs from the same
+	// server benefit from raising it.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the number of connections, idle or in use, to
+	// each host.  Zero means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept before it is
+	// closed
+	IdleConnTimeout time.Duration
+	// TLSClientConfig configures TLS connections, if not nil
+	TLSClientConfig *tls.Config
+}
+
+const (
+	defaultTransportMaxIdleConns    = 100
+	defaultTransportIdleConnTimeout = 90 * time.Second
+)
+
+// NewTunedTransport returns an HTTP transport, for use as the RoundTripper of
+// repositories, which pools connections as configured by opts.  Apart from its
+// pooling, it behaves like http.DefaultTransport.
+func NewTunedTransport(opts TransportOptions) http.RoundTripper {
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = defaultTransportMaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost == 0 {
+		opts.MaxIdleConnsPerHost = http.DefaultMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout == 0 {
+		opts.IdleConnTimeout = defaultTransportIdleConnTimeout
+	}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       opts.TLSClientConfig,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+	}
+}
+
+// NewSOCKS5Transport returns an HTTP transport, for use as the RoundTripper of
+// repositories, which connects to notary servers through the SOCKS5 proxy at
+// addr, authenticating with auth if it is not nil.  Every request, whether for
+// updating or publishing, goes through the proxy, and so do the DNS lookups of
+// the servers' names.  Proxy settings from the environment are ignored.
+func NewSOCKS5Transport(addr string, auth *proxy.Auth) (http.RoundTripper, error) {
+	dialer, err := proxy.SOCKS5("tcp", addr, auth, &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Transport{
+		// the SOCKS5 dialer always supports contexts
+		DialContext:           dialer.(proxy.ContextDialer).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		MaxIdleConns:          defaultTransportMaxIdleConns,
+		IdleConnTimeout:       defaultTransportIdleConnTimeout,
+	}, nil
+}
+
+// rateLimitedTransport spaces the requests it sends evenly, so that no more
+// than one is sent per interval
+type rateLimitedTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+
+	mu sync.Mutex
+	// next is the earliest time at which the next request may be sent
+	next time.Time
+}
+
+// NewRateLimitedTransport returns an HTTP transport, for use as the
+// RoundTripper of repositories, which sends requests through base at no more
+// than rps requests per second.  Sharing it between the repositories of many
+// GUNs keeps an updater from overwhelming their server.  Requests beyond the
+// rate wait their turn, and give up with the context's error if their context
+// is done first.  A nil base selects http.DefaultTransport, and a rate of 0 or
+// less returns base unlimited.
+func NewRateLimitedTransport(base http.RoundTripper, rps float64) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if rps <= 0 {
+		return base
+	}
+	return &rateLimitedTransport{base: base, interval: time.Duration(float64(time.Second) / rps)}
+}
+
+// RoundTrip waits for the request's turn, and then sends it through the base
+// transport
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// reserve takes the next turn to send a request, returning how long to wait
+// for it
+func (t *rateLimitedTransport) reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	wait := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+	return wait
+}
@@ -0,0 +1,359 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+	"golang.org/x/net/proxy"
+)
+
+// connCountingServer is a test server which counts the connections opened to
+// and closed by it
+type connCountingServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	opened int
+	closed int
+}
+
+func newConnCountingServer(handler http.Handler) *connCountingServer {
+	s := &connCountingServer{}
+	s.Server = httptest.NewUnstartedServer(handler)
+	s.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch state {
+		case http.StateNew:
+			s.opened++
+		case http.StateClosed:
+			s.closed++
+		}
+	}
+	s.Start()
+	return s
+}
+
+func (s *connCountingServer) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.opened, s.closed
+}
+
+func getAndDrain(t *testing.T, client *http.Client, url string) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func TestNewTunedTransportDefaults(t *testing.T) {
+	tr, ok := NewTunedTransport(TransportOptions{}).(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, defaultTransportMaxIdleConns, tr.MaxIdleConns)
+	require.Equal(t, http.DefaultMaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+	require.Equal(t, 0, tr.MaxConnsPerHost)
+	require.Equal(t, defaultTransportIdleConnTimeout, tr.IdleConnTimeout)
+}
+
+// No more connections than allowed are opened to a host, even when more
+// requests than that are made at once, and they are reused afterwards
+func TestNewTunedTransportLimitsConnsPerHost(t *testing.T) {
+	release := make(chan struct{})
+	var inFlight sync.WaitGroup
+	s := newConnCountingServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Done()
+		<-release
+	}))
+	defer s.Close()
+
+	client := &http.Client{Transport: NewTunedTransport(TransportOptions{
+		MaxConnsPerHost:     2,
+		MaxIdleConnsPerHost: 2,
+	})}
+
+	var done sync.WaitGroup
+	errs := make(chan error, 6)
+	inFlight.Add(2)
+	for i := 0; i < 6; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			resp, err := client.Get(s.URL)
+			if err == nil {
+				io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			errs <- err
+		}()
+	}
+	// the first two requests reach the server, and the rest must wait for them
+	inFlight.Wait()
+	time.Sleep(50 * time.Millisecond)
+	opened, _ := s.counts()
+	require.Equal(t, 2, opened)
+
+	inFlight.Add(4)
+	close(release)
+	done.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	opened, _ = s.counts()
+	require.Equal(t, 2, opened)
+}
+
+// Idle connections are closed once they have been idle for the configured
+// timeout
+func TestNewTunedTransportIdleConnTimeout(t *testing.T) {
+	s := newConnCountingServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	client := &http.Client{Transport: NewTunedTransport(TransportOptions{
+		IdleConnTimeout: 50 * time.Millisecond,
+	})}
+
+	getAndDrain(t, client, s.URL)
+	getAndDrain(t, client, s.URL)
+	opened, closed := s.counts()
+	require.Equal(t, 1, opened, "the idle connection should have been reused")
+	require.Equal(t, 0, closed)
+
+	require.Eventually(t, func() bool {
+		_, closed := s.counts()
+		return closed == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	getAndDrain(t, client, s.URL)
+	opened, _ = s.counts()
+	require.Equal(t, 2, opened)
+}
+
+// socks5Server is a minimal in-process SOCKS5 proxy, supporting only the
+// CONNECT command, which requires a username and password if user is set
+type socks5Server struct {
+	listener net.Listener
+	user     string
+	password string
+	mu       sync.Mutex
+	connects []string
+}
+
+func newSOCKS5Server(t *testing.T, user, password string) *socks5Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &socks5Server{listener: l, user: user, password: password}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socks5Server) serve(conn net.Conn) {
+	defer conn.Close()
+	readN := func(n int) []byte {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil
+		}
+		return buf
+	}
+
+	// method negotiation
+	header := readN(2)
+	if header == nil || header[0] != 5 {
+		return
+	}
+	if readN(int(header[1])) == nil {
+		return
+	}
+	if s.user == "" {
+		conn.Write([]byte{5, 0})
+	} else {
+		conn.Write([]byte{5, 2})
+		// username and password authentication
+		version := readN(2)
+		if version == nil {
+			return
+		}
+		user := readN(int(version[1]))
+		passwordLen := readN(1)
+		if user == nil || passwordLen == nil {
+			return
+		}
+		password := readN(int(passwordLen[0]))
+		if string(user) != s.user || string(password) != s.password {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+	}
+
+	// the CONNECT request
+	request := readN(4)
+	if request == nil || request[1] != 1 {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		host = net.IP(readN(4)).String()
+	case 3:
+		host = string(readN(int(readN(1)[0])))
+	default:
+		return
+	}
+	port := readN(2)
+	address := net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1])))
+	target, err := net.Dial("tcp", address)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	s.mu.Lock()
+	s.connects = append(s.connects, address)
+	s.mu.Unlock()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+func (s *socks5Server) connectCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.connects)
+}
+
+// Updating and publishing both work through a SOCKS5 proxy
+func TestSOCKS5TransportUpdateAndPublish(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	socks := newSOCKS5Server(t, "notary", "secret")
+	defer socks.listener.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	rt, err := NewSOCKS5Transport(socks.listener.Addr().String(), &proxy.Auth{User: "notary", Password: "secret"})
+	require.NoError(t, err)
+	proxied, err := NewFileCachedRepository(baseDir, repo.gun, ts.URL, rt, passphraseRetriever, trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	addTarget(t, proxied.(*repository), "proxied", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, proxied.Publish())
+	published := socks.connectCount()
+	require.True(t, published > 0)
+
+	readerDir, err := ioutil.TempDir("", "notary-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(readerDir)
+	// a separate transport, so that the publisher's pooled connections aren't reused
+	readerRT, err := NewSOCKS5Transport(socks.listener.Addr().String(), &proxy.Auth{User: "notary", Password: "secret"})
+	require.NoError(t, err)
+	reader, err := NewFileCachedRepository(readerDir, repo.gun, ts.URL, readerRT, passphraseRetriever, trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	target, err := reader.GetTargetByName("proxied")
+	require.NoError(t, err)
+	require.Equal(t, "proxied", target.Name)
+	require.True(t, socks.connectCount() > published)
+
+	// with the wrong credentials, the proxy refuses to connect
+	badRT, err := NewSOCKS5Transport(socks.listener.Addr().String(), &proxy.Auth{User: "notary", Password: "wrong"})
+	require.NoError(t, err)
+	refusedDir, err := ioutil.TempDir("", "notary-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(refusedDir)
+	refused, err := NewFileCachedRepository(refusedDir, repo.gun, ts.URL, badRT, passphraseRetriever, trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	_, err = refused.ListTargets()
+	require.Error(t, err)
+}
+
+// Requests, whether sent one after another or all at once, are spaced so that
+// no more than the rate are sent per second
+func TestRateLimitedTransportSpacing(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		times = append(times, time.Now())
+	}))
+	defer ts.Close()
+
+	const interval = 50 * time.Millisecond
+	client := &http.Client{Transport: NewRateLimitedTransport(nil, float64(time.Second/interval))}
+	for i := 0; i < 4; i++ {
+		getAndDrain(t, client, ts.URL)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			getAndDrain(t, client, ts.URL)
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, times, 8)
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for i := 1; i < len(times); i++ {
+		// allow for requests taking different times to reach the server
+		require.True(t, times[i].Sub(times[i-1]) > interval/2,
+			"requests %d and %d were only %s apart", i-1, i, times[i].Sub(times[i-1]))
+	}
+	require.True(t, times[len(times)-1].Sub(times[0]) > 7*interval-interval/2)
+}
+
+// A request waiting for its turn gives up when its context is done
+func TestRateLimitedTransportContextCancellation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	transport := NewRateLimitedTransport(http.DefaultTransport, 0.5)
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the next turn is two seconds away
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = transport.RoundTrip(req.WithContext(ctx))
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.True(t, time.Since(start) < time.Second)
+}
+
+func TestNewRateLimitedTransportUnlimited(t *testing.T) {
+	require.Equal(t, http.DefaultTransport, NewRateLimitedTransport(nil, 0))
+	base := NewTunedTransport(TransportOptions{})
+	require.Equal(t, base, NewRateLimitedTransport(base, -1))
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrInvalidTrustChain is returned when an exported trust chain is malformed,
+// or its metadata does not reproduce the trust decision it records
+type ErrInvalidTrustChain struct {
+	Reason string
+}
+
+func (err ErrInvalidTrustChain) Error() string {
+	return fmt.Sprintf("invalid trust chain: %s", err.Reason)
+}
+
+// RoleMetadata is the signed metadata of a role, exactly as served
+type RoleMetadata struct {
+	Role     data.RoleName `json:"role"`
+	Metadata []byte        `json:"metadata"`
+}
+
+// TrustChain is an export of everything needed to verify a target offline:
+// the signed metadata from the root down to the role the target was found in,
+// and the target's entry in that role.  It can be serialized to JSON to serve
+// as a portable attestation of the target.
+type TrustChain struct {
+	GUN    data.GUN      `json:"gun"`
+	Target string        `json:"target"`
+	Role   data.RoleName `json:"role"`
+	Entry  data.FileMeta `json:"entry"`
+	// Root, Timestamp and Snapshot are the signed metadata of the base roles
+	Root      []byte `json:"root"`
+	Timestamp []byte `json:"timestamp"`
+	Snapshot  []byte `json:"snapshot"`
+	// Targets are the signed metadata of the targets role and of each
+	// delegation down to Role, in that order
+	Targets []RoleMetadata `json:"targets"`
+}
+
+// ExportTrustChain updates the repository, looks up the target by name, and
+// writes to w, as JSON, the TrustChain which establishes trust in it.  The
+// metadata is exported exactly as it was served, so that VerifyTrustChain can
+// re-verify its signatures and checksums without any network access.
+func (r *repository) ExportTrustChain(targetName string, w io.Writer) error {
+	target, err := r.GetTargetByName(targetName)
+	if err != nil {
+		return err
+	}
+
+	chain := TrustChain{
+		GUN:    r.gun,
+		Target: target.Name,
+		Role:   target.Role,
+		Entry:  data.FileMeta{Length: target.Length, Hashes: target.Hashes, Custom: target.Custom},
+	}
+	for role, raw := range map[data.RoleName]*[]byte{
+		data.CanonicalRootRole:      &chain.Root,
+		data.CanonicalTimestampRole: &chain.Timestamp,
+		data.CanonicalSnapshotRole:  &chain.Snapshot,
+	} {
+		if *raw, err = r.cache.GetSized(role.String(), store.NoSizeLimit); err != nil {
+			return err
+		}
+	}
+	for _, role := range delegationChain(target.Role) {
+		raw, err := r.cache.GetSized(role.String(), store.NoSizeLimit)
+		if err != nil {
+			return err
+		}
+		chain.Targets = append(chain.Targets, RoleMetadata{Role: role, Metadata: raw})
+	}
+	return json.NewEncoder(w).Encode(chain)
+}
+
+// VerifyTrustChain reads a TrustChain written by ExportTrustChain from rd, and
+// verifies it offline: the root must validate against the trust pinning
+// configuration, every role's metadata must be signed by its role's keys and
+// unexpired, the snapshot must hold the checksums of the targets metadata, and
+// looking the target up in the metadata must find the exported entry in the
+// exported role.  It returns the target as it would be found by
+// GetTargetByName.
+//
+// Since anyone can export a chain which is consistent with itself, trust in
+// its root must be anchored: either trustedRoot is the signed metadata of a
+// root already trusted for the GUN, which must have signed the exported root,
+// or the trust pinning pins the root of the GUN to certificates or a CA.
+// Without either, the chain is rejected rather than trusted on first use.
+func VerifyTrustChain(rd io.Reader, trustedRoot []byte, trustPinning trustpinning.TrustPinConfig) (*TargetWithRole, error) {
+	chain := &TrustChain{}
+	if err := json.NewDecoder(io.LimitReader(rd, 2*notary.MaxDownloadSize)).Decode(chain); err != nil {
+		return nil, ErrInvalidTrustChain{Reason: "could not parse the trust chain"}
+	}
+	expected := delegationChain(chain.Role)
+	if len(chain.Targets) != len(expected) {
+		return nil, ErrInvalidTrustChain{Reason: fmt.Sprintf("expected the metadata of %d targets roles down to %s", len(expected), chain.Role)}
+	}
+
+	builder := tuf.NewRepoBuilder(chain.GUN, cryptoservice.EmptyService, trustPinning)
+	minRootVersion := 1
+	if trustedRoot != nil {
+		if err := builder.Load(data.CanonicalRootRole, trustedRoot, 1, true); err != nil {
+			return nil, err
+		}
+		minRootVersion = builder.GetLoadedVersion(data.CanonicalRootRole)
+		builder = builder.BootstrapNewBuilder()
+	} else if !trustPinning.PinsRoot(chain.GUN) {
+		return nil, ErrInvalidTrustChain{Reason: fmt.Sprintf("no trusted root or pinned certificates anchor the root of %s", chain.GUN)}
+	}
+	if err := builder.Load(data.CanonicalRootRole, chain.Root, minRootVersion, false); err != nil {
+		return nil, err
+	}
+	for _, meta := range []RoleMetadata{
+		{Role: data.CanonicalTimestampRole, Metadata: chain.Timestamp},
+		{Role: data.CanonicalSnapshotRole, Metadata: chain.Snapshot},
+	} {
+		if err := builder.Load(meta.Role, meta.Metadata, 1, false); err != nil {
+			return nil, err
+		}
+	}
+	for i, meta := range chain.Targets {
+		if meta.Role != expected[i] {
+			return nil, ErrInvalidTrustChain{Reason: fmt.Sprintf("expected the metadata of %s, not %s", expected[i], meta.Role)}
+		}
+		if err := builder.Load(meta.Role, meta.Metadata, 1, false); err != nil {
+			return nil, err
+		}
+	}
+	repo, _, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := NewReadOnly(repo).GetTargetByName(chain.Target)
+	if err != nil {
+		return nil, err
+	}
+	if target.Role != chain.Role {
+		return nil, ErrInvalidTrustChain{Reason: fmt.Sprintf("%s is found in %s, not %s", chain.Target, target.Role, chain.Role)}
+	}
+	if target.Length != chain.Entry.Length || data.CompareMultiHashes(target.Hashes, chain.Entry.Hashes) != nil {
+		return nil, ErrInvalidTrustChain{Reason: fmt.Sprintf("the signed entry for %s does not match the exported entry", chain.Target)}
+	}
+	return target, nil
+}
+
+// delegationChain returns the targets role and each delegation down to role
+func delegationChain(role data.RoleName) []data.RoleName {
+	chain := []data.RoleName{role}
+	for data.IsDelegation(role) {
+		role = role.Parent()
+		chain = append([]data.RoleName{role}, chain...)
+	}
+	return chain
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// exportTrustChain exports the trust chain of a target, and parses it back
+func exportTrustChain(t *testing.T, repo *repository, name string) TrustChain {
+	var buf bytes.Buffer
+	require.NoError(t, repo.ExportTrustChain(name, &buf))
+	var chain TrustChain
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &chain))
+	return chain
+}
+
+// verifyExport serializes a trust chain and verifies it offline
+func verifyExport(t *testing.T, chain TrustChain, trustedRoot []byte, pinning trustpinning.TrustPinConfig) (*TargetWithRole, error) {
+	serialized, err := json.Marshal(chain)
+	require.NoError(t, err)
+	return VerifyTrustChain(bytes.NewReader(serialized), trustedRoot, pinning)
+}
+
+// cachedRoot returns the root which a repository has cached, and so trusts
+func cachedRoot(t *testing.T, repo *repository) []byte {
+	root, err := repo.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	return root
+}
+
+// An exported trust chain reproduces the lookup of the target offline, for a
+// target in the targets role and one delegated two levels down
+func TestExportTrustChainRoundTrip(t *testing.T) {
+	ts := fullTestServer(t)
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	require.NoError(t, repo.AddDelegation("targets/a",
+		[]data.PublicKey{createKey(t, repo, "targets/a", false)}, []string{""}))
+	require.NoError(t, repo.AddDelegation("targets/a/b",
+		[]data.PublicKey{createKey(t, repo, "targets/a/b", false)}, []string{"app/"}))
+	addTarget(t, repo, "top", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "app/latest", "../fixtures/root-ca.crt", "targets/a/b")
+	require.NoError(t, repo.Publish())
+
+	root := cachedRoot(t, repo)
+	expected, err := repo.GetTargetByName("app/latest")
+	require.NoError(t, err)
+	chain := exportTrustChain(t, repo, "app/latest")
+	require.Equal(t, data.RoleName("targets/a/b"), chain.Role)
+	require.Len(t, chain.Targets, 3)
+
+	// verification needs no network, so works after the server is gone
+	ts.Close()
+	target, err := verifyExport(t, chain, root, trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	require.Equal(t, expected, target)
+
+	chain = exportTrustChain(t, repo, "top")
+	require.Equal(t, []RoleMetadata{chain.Targets[0]}, chain.Targets)
+	target, err = verifyExport(t, chain, root, trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	require.Equal(t, data.CanonicalTargetsRole, target.Role)
+}
+
+// An export which has been tampered with, or which is missing metadata, does
+// not verify
+func TestVerifyTrustChainRejectsTampering(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	require.NoError(t, repo.AddDelegation("targets/a",
+		[]data.PublicKey{createKey(t, repo, "targets/a", false)}, []string{""}))
+	addTarget(t, repo, "app", "../fixtures/intermediate-ca.crt", "targets/a")
+	require.NoError(t, repo.Publish())
+	chain := exportTrustChain(t, repo, "app")
+	root := cachedRoot(t, repo)
+
+	// the recorded entry must be what is signed
+	tampered := chain
+	tampered.Entry = data.FileMeta{Length: chain.Entry.Length, Hashes: data.Hashes{"sha256": make([]byte, 32)}}
+	_, err := verifyExport(t, tampered, root, trustpinning.TrustPinConfig{})
+	require.IsType(t, ErrInvalidTrustChain{}, err)
+
+	// as must the recorded role, which the target is not found above
+	tampered = chain
+	tampered.Role = data.CanonicalTargetsRole
+	tampered.Targets = chain.Targets[:1]
+	_, err = verifyExport(t, tampered, root, trustpinning.TrustPinConfig{})
+	require.IsType(t, ErrNoSuchTarget(""), err)
+
+	// the delegation's metadata can't be left out
+	tampered = chain
+	tampered.Targets = chain.Targets[:1]
+	_, err = verifyExport(t, tampered, root, trustpinning.TrustPinConfig{})
+	require.IsType(t, ErrInvalidTrustChain{}, err)
+
+	// nor swapped for metadata which doesn't match the snapshot
+	tampered = chain
+	tampered.Targets = []RoleMetadata{chain.Targets[0], {Role: "targets/a", Metadata: append([]byte(" "), chain.Targets[1].Metadata...)}}
+	_, err = verifyExport(t, tampered, root, trustpinning.TrustPinConfig{})
+	require.Error(t, err)
+
+	// the root must satisfy the trust pinning
+	_, err = verifyExport(t, chain, nil, trustpinning.TrustPinConfig{Certs: map[string][]string{"docker.com/notary": {"abc"}}})
+	require.Error(t, err)
+
+	_, err = VerifyTrustChain(bytes.NewReader([]byte("not json")), root, trustpinning.TrustPinConfig{})
+	require.IsType(t, ErrInvalidTrustChain{}, err)
+}
+
+// A forged chain, which is consistent with itself but whose root was never
+// trusted for the GUN, does not verify, and neither does any chain without a
+// trusted root or pinned certificates to anchor it
+func TestVerifyTrustChainRequiresAnchor(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "app", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	genuine := exportTrustChain(t, repo, "app")
+
+	// a forger publishes the same GUN, with keys of their own, elsewhere
+	forgerServer := fullTestServer(t)
+	defer forgerServer.Close()
+	forger, _, forgerDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", forgerServer.URL, false)
+	defer os.RemoveAll(forgerDir)
+	addTarget(t, forger, "app", "../fixtures/root-ca.crt")
+	require.NoError(t, forger.Publish())
+	forged := exportTrustChain(t, forger, "app")
+
+	// trust on first use would accept either chain
+	for _, chain := range []TrustChain{genuine, forged} {
+		_, err := verifyExport(t, chain, nil, trustpinning.TrustPinConfig{})
+		require.IsType(t, ErrInvalidTrustChain{}, err)
+	}
+
+	// the root trusted for the GUN did not sign the forged root
+	_, err := verifyExport(t, genuine, cachedRoot(t, repo), trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	_, err = verifyExport(t, forged, cachedRoot(t, repo), trustpinning.TrustPinConfig{})
+	require.Error(t, err)
+
+	// nor do the forger's certificates match the pinned ones
+	pinning := trustpinning.TrustPinConfig{Certs: map[string][]string{
+		"docker.com/notary": repo.tufRepo.Root.Signed.Roles[data.CanonicalRootRole].KeyIDs,
+	}}
+	_, err = verifyExport(t, genuine, nil, pinning)
+	require.NoError(t, err)
+	_, err = verifyExport(t, forged, nil, pinning)
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tsa"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrNoCountersignature is returned when verifying the countersignature of a
+// signature which does not carry a timestamp token
+type ErrNoCountersignature struct {
+	KeyID string
+}
+
+func (err ErrNoCountersignature) Error() string {
+	return fmt.Sprintf("signature by key %s has no timestamp countersignature", err.KeyID)
+}
+
+// countersignature is the custom data of a signature which has been
+// countersigned by a timestamp authority
+type countersignature struct {
+	// Token is the DER encoded RFC 3161 timestamp token over the signature
+	Token []byte `json:"rfc3161_timestamp_token"`
+}
+
+// SetTSA configures publishing to countersign every signature of updated
+// targets metadata with a timestamp token from the RFC 3161 timestamp
+// authority at url, requested using rt.  The token is included in the custom
+// data of the signature, and proves that the signature existed at the time the
+// token asserts: see VerifyCountersignature.  An empty url disables
+// countersigning.
+func (r *repository) SetTSA(url string, rt http.RoundTripper) {
+	r.tsaURL = url
+	r.tsaRoundTrip = rt
+}
+
+// countersignTargets adds timestamp tokens to the signatures of the targets
+// roles being published, and re-serializes them.  It must happen before the
+// snapshot is signed, since the snapshot covers the signatures.
+func (r *repository) countersignTargets(updates map[data.RoleName][]byte) error {
+	if r.tsaURL == "" {
+		return nil
+	}
+	for roleName := range updates {
+		roleObj, ok := r.tufRepo.Targets[roleName]
+		if !ok {
+			continue
+		}
+		for i, sig := range roleObj.Signatures {
+			if err := countersign(&roleObj.Signatures[i], r.tsaURL, r.tsaRoundTrip); err != nil {
+				return fmt.Errorf("could not countersign the %s signature by key %s: %s", roleName, sig.KeyID, err)
+			}
+		}
+		s, err := roleObj.ToSigned()
+		if err != nil {
+			return err
+		}
+		targetsJSON, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		updates[roleName] = targetsJSON
+		logrus.Debugf("countersigned %d signatures of %s", len(roleObj.Signatures), roleName)
+	}
+	return nil
+}
+
+// countersign requests a timestamp token over the signature bytes, and
+// includes it in the custom data of the signature
+func countersign(sig *data.Signature, url string, rt http.RoundTripper) error {
+	token, err := tsa.RequestToken(url, rt, sig.Signature)
+	if err != nil {
+		return err
+	}
+	custom, err := canonicaljson.MarshalCanonical(countersignature{Token: token})
+	if err != nil {
+		return err
+	}
+	raw := canonicaljson.RawMessage(custom)
+	sig.Custom = &raw
+	return nil
+}
+
+// VerifyCountersignature verifies the timestamp token in the custom data of a
+// signature, as added when publishing with a timestamp authority configured,
+// and returns the time before which the token proves the signature was made.
+// The timestamp authority must chain to one of roots.  This does not verify
+// the signature itself, which a successful update already has.
+func VerifyCountersignature(sig data.Signature, roots *x509.CertPool) (time.Time, error) {
+	if sig.Custom == nil {
+		return time.Time{}, ErrNoCountersignature{KeyID: sig.KeyID}
+	}
+	var cs countersignature
+	if err := json.Unmarshal(*sig.Custom, &cs); err != nil || len(cs.Token) == 0 {
+		return time.Time{}, ErrNoCountersignature{KeyID: sig.KeyID}
+	}
+	if roots == nil {
+		roots = x509.NewCertPool()
+	}
+	return tsa.Verify(cs.Token, sig.Signature, roots)
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tsa"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// newTestTSA starts a timestamp authority with a self-signed timestamping
+// certificate, which asserts genTime in every token it issues
+func newTestTSA(t *testing.T, genTime time.Time) (*httptest.Server, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test timestamp authority"},
+		NotBefore:             genTime.Add(-time.Hour),
+		NotAfter:              genTime.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	authority := &tsa.Authority{
+		Certificate: cert,
+		Signer:      key,
+		Now:         func() time.Time { return genTime },
+	}
+	return httptest.NewServer(authority), roots
+}
+
+// requireTargetsCountersigned checks that every signature of the repo's
+// targets role carries a timestamp token asserting genTime
+func requireTargetsCountersigned(t *testing.T, repo *repository, roots *x509.CertPool, genTime time.Time) {
+	roles, err := repo.ListRoles()
+	require.NoError(t, err)
+	for _, role := range roles {
+		if role.Name != data.CanonicalTargetsRole {
+			continue
+		}
+		require.NotEmpty(t, role.Signatures)
+		for _, sig := range role.Signatures {
+			got, err := VerifyCountersignature(sig, roots)
+			require.NoError(t, err)
+			require.True(t, genTime.Equal(got), "expected %s, got %s", genTime, got)
+		}
+		return
+	}
+	t.Fatal("no targets role found")
+}
+
+// Publishing with a timestamp authority countersigns the targets signatures,
+// and the tokens round-trip through the server to another client
+func TestPublishWithTSA(t *testing.T) {
+	genTime := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	tsaServer, roots := newTestTSA(t, genTime)
+	defer tsaServer.Close()
+
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	repo.SetTSA(tsaServer.URL, http.DefaultTransport)
+
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	requireTargetsCountersigned(t, repo, roots, genTime)
+
+	// another client downloads and verifies the countersigned metadata
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+	targets, err := repo2.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	requireTargetsCountersigned(t, repo2, roots, genTime)
+
+	// the tokens are not trusted without the authority's certificate
+	roles, err := repo2.ListRoles()
+	require.NoError(t, err)
+	for _, role := range roles {
+		if role.Name == data.CanonicalTargetsRole {
+			_, err := VerifyCountersignature(role.Signatures[0], x509.NewCertPool())
+			require.Error(t, err)
+			require.IsType(t, tsa.ErrInvalidToken{}, err)
+		}
+	}
+}
+
+// Without a timestamp authority signatures are not countersigned, and if the
+// configured timestamp authority fails then publishing fails
+func TestPublishWithoutTSA(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	roles, err := repo.ListRoles()
+	require.NoError(t, err)
+	for _, role := range roles {
+		for _, sig := range role.Signatures {
+			require.Nil(t, sig.Custom)
+			_, err := VerifyCountersignature(sig, nil)
+			require.Error(t, err)
+			require.IsType(t, ErrNoCountersignature{}, err)
+		}
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	repo.SetTSA(failing.URL, nil)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.Error(t, repo.Publish())
+	require.Len(t, getChanges(t, repo), 1, "the changes should not have been published")
+
+	// disabling the timestamp authority allows publishing again
+	repo.SetTSA("", nil)
+	require.NoError(t, repo.Publish())
+}
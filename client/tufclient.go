@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary"
@@ -21,6 +22,14 @@ type tufClient struct {
 	cache      store.MetadataStore
 	oldBuilder tuf.RepoBuilder
 	newBuilder tuf.RepoBuilder
+	timing     *UpdateTiming
+	options    UpdateOptions
+	stats      *updateCounters
+	// verified holds the metadata of each role exactly as the builder
+	// verified it, and downloaded the roles of it which were downloaded,
+	// which are only cached once the update as a whole has been checked
+	verified   map[data.RoleName][]byte
+	downloaded map[data.RoleName]bool
 }
 
 // Update performs an update to the TUF repo as defined by the TUF spec
@@ -41,7 +50,10 @@ func (c *tufClient) Update() (*tuf.Repo, *tuf.Repo, error) {
 
 		c.newBuilder = c.newBuilder.BootstrapNewBuilder()
 
-		if err := c.updateRoot(); err != nil {
+		start := time.Now()
+		err := c.updateRoot()
+		c.timing.record(phaseRoot, start)
+		if err != nil {
 			logrus.Debug("Client Update (Root): ", err)
 			return nil, nil, err
 		}
@@ -56,11 +68,17 @@ func (c *tufClient) Update() (*tuf.Repo, *tuf.Repo, error) {
 }
 
 func (c *tufClient) update() error {
-	if err := c.downloadTimestamp(); err != nil {
+	start := time.Now()
+	err := c.downloadTimestamp()
+	c.timing.record(phaseTimestamp, start)
+	if err != nil {
 		logrus.Debugf("Client Update (Timestamp): %s", err.Error())
 		return err
 	}
-	if err := c.downloadSnapshot(); err != nil {
+	start = time.Now()
+	err = c.downloadSnapshot()
+	c.timing.record(phaseSnapshot, start)
+	if err != nil {
 		logrus.Debugf("Client Update (Snapshot): %s", err.Error())
 		return err
 	}
@@ -96,7 +114,7 @@ func (c *tufClient) updateRoot() error {
 	}
 
 	// Load current version into newBuilder
-	currentRaw, err := c.cache.GetSized(data.CanonicalRootRole.String(), -1)
+	currentRaw, err := c.latest(data.CanonicalRootRole)
 	if err != nil {
 		logrus.Debugf("error loading %d.%s: %s", currentVersion, data.CanonicalRootRole, err)
 		return err
@@ -128,11 +146,7 @@ func (c *tufClient) updateRoot() error {
 		return err
 	}
 	logrus.Debugf("successfully verified downloaded %d.%s", newestVersion, data.CanonicalRootRole)
-
-	// Write newest to cache
-	if err := c.cache.Set(data.CanonicalRootRole.String(), raw); err != nil {
-		logrus.Debugf("unable to write %d.%s to cache: %s", newestVersion, data.CanonicalRootRole, err)
-	}
+	c.recordVerified(data.CanonicalRootRole, raw, true)
 	logrus.Debugf("finished updating root files")
 	return nil
 }
@@ -146,6 +160,7 @@ func (c *tufClient) updateRootVersions(fromVersion, toVersion int) error {
 		versionedRole := fmt.Sprintf("%d.%s", v, data.CanonicalRootRole)
 
 		raw, err := c.remote.GetSized(versionedRole, -1)
+		c.stats.recordFetch(raw, err)
 		if err != nil {
 			logrus.Debugf("error downloading %s: %s", versionedRole, err)
 			return err
@@ -192,6 +207,8 @@ func (c *tufClient) downloadTimestamp() error {
 	err := c.newBuilder.Load(role, cachedTS, 1, false)
 	if err == nil {
 		logrus.Debug("successfully verified cached timestamp")
+		c.stats.recordCached(cachedTS)
+		c.recordVerified(role, cachedTS, false)
 	}
 	return err
 
@@ -203,8 +220,13 @@ func (c *tufClient) downloadSnapshot() error {
 	role := data.CanonicalSnapshotRole
 	consistentInfo := c.newBuilder.GetConsistentInfo(role)
 
-	_, err := c.tryLoadCacheThenRemote(consistentInfo)
-	return err
+	raw, err := c.tryLoadCacheThenRemote(consistentInfo)
+	if err != nil {
+		return err
+	}
+	// the builder accepted the snapshot, but TUF requires that it also lists
+	// the root and targets metadata
+	return checkSnapshotComplete(raw)
 }
 
 // downloadTargets downloads all targets and delegated targets for the repository.
@@ -226,7 +248,13 @@ func (c *tufClient) downloadTargets() error {
 			continue
 		}
 
+		start := time.Now()
 		children, err := c.getTargetsFile(role, consistentInfo)
+		if role.Name == data.CanonicalTargetsRole {
+			c.timing.record(phaseTargets, start)
+		} else {
+			c.timing.record(phaseDelegations, start)
+		}
 		switch err.(type) {
 		case signed.ErrExpired, signed.ErrRoleThreshold:
 			if role.Name == data.CanonicalTargetsRole {
@@ -235,7 +263,7 @@ func (c *tufClient) downloadTargets() error {
 			logrus.Warnf("Error getting %s: %s", role.Name, err)
 			break
 		case nil:
-			toDownload = append(children, toDownload...)
+			toDownload = append(c.options.delegationsToDownload(children), toDownload...)
 		default:
 			return err
 		}
@@ -243,15 +271,17 @@ func (c *tufClient) downloadTargets() error {
 	return nil
 }
 
-func (c tufClient) getTargetsFile(role data.DelegationRole, ci tuf.ConsistentInfo) ([]data.DelegationRole, error) {
+func (c *tufClient) getTargetsFile(role data.DelegationRole, ci tuf.ConsistentInfo) ([]data.DelegationRole, error) {
 	logrus.Debugf("Loading %s...", role.Name)
 	tgs := &data.SignedTargets{}
 
 	raw, err := c.tryLoadCacheThenRemote(ci)
 	if err != nil {
+		if _, ok := err.(signed.ErrRoleThreshold); ok && role.Name == data.CanonicalTargetsRole {
+			return nil, c.unauthorizedTargetsKey(raw, err)
+		}
 		return nil, err
 	}
-
 	// we know it unmarshals because if `tryLoadCacheThenRemote` didn't fail, then
 	// the raw has already been loaded into the builder
 	json.Unmarshal(raw, tgs)
@@ -269,7 +299,7 @@ func (c *tufClient) downloadRoot() ([]byte, error) {
 		logrus.Debugf("Loading root with no expected checksum")
 
 		// get the cached root, if it exists, just for version checking
-		cachedRoot, _ := c.cache.GetSized(role.String(), -1)
+		cachedRoot, _ := c.latest(role)
 		// prefer to download a new root
 		return c.tryLoadRemote(consistentInfo, cachedRoot)
 	}
@@ -280,11 +310,17 @@ func (c *tufClient) tryLoadCacheThenRemote(consistentInfo tuf.ConsistentInfo) ([
 	cachedTS, err := c.cache.GetSized(consistentInfo.RoleName.String(), consistentInfo.Length())
 	if err != nil {
 		logrus.Debugf("no %s in cache, must download", consistentInfo.RoleName)
-		return c.tryLoadRemote(consistentInfo, nil)
+		return c.tryLoadRemote(consistentInfo, c.diffBase(consistentInfo))
 	}
 
+	if err = checkMetadataPolicy(consistentInfo.RoleName, cachedTS, c.options); err != nil {
+		logrus.Debugf("cached %s is invalid (must download): %s", consistentInfo.RoleName, err)
+		return c.tryLoadRemote(consistentInfo, cachedTS)
+	}
 	if err = c.newBuilder.Load(consistentInfo.RoleName, cachedTS, 1, false); err == nil {
 		logrus.Debugf("successfully verified cached %s", consistentInfo.RoleName)
+		c.stats.recordCached(cachedTS)
+		c.recordVerified(consistentInfo.RoleName, cachedTS, false)
 		return cachedTS, nil
 	}
 
@@ -294,11 +330,42 @@ func (c *tufClient) tryLoadCacheThenRemote(consistentInfo tuf.ConsistentInfo) ([
 
 func (c *tufClient) tryLoadRemote(consistentInfo tuf.ConsistentInfo, old []byte) ([]byte, error) {
 	consistentName := consistentInfo.ConsistentName()
+	if c.options.AllowDiffs && old != nil && isTargetsRole(consistentInfo.RoleName) {
+		raw, err := c.tryLoadRemoteDiff(consistentInfo, old)
+		if err == nil {
+			return raw, nil
+		}
+		logrus.Debugf("unable to update %s from a delta, downloading in full: %s", consistentName, err)
+	}
 	raw, err := c.remote.GetSized(consistentName, consistentInfo.Length())
+	c.stats.recordFetch(raw, err)
 	if err != nil {
 		logrus.Debugf("error downloading %s: %s", consistentName, err)
 		return old, err
 	}
+	return c.loadDownloaded(consistentInfo, raw, old)
+}
+
+// loadDownloaded verifies metadata which has been obtained from the remote
+// store, and records it to be cached if it is valid
+func (c *tufClient) loadDownloaded(consistentInfo tuf.ConsistentInfo, raw, old []byte) ([]byte, error) {
+	consistentName := consistentInfo.ConsistentName()
+	// only metadata which matches the snapshot's checksum can be blamed on
+	// the snapshot referencing it, rather than on its transfer
+	if isTargetsRole(consistentInfo.RoleName) && consistentInfo.ChecksumKnown() {
+		if err := consistentInfo.CheckHashes(raw); err != nil {
+			logrus.Debugf("downloaded %s is invalid: %s", consistentName, err)
+			return raw, err
+		}
+		if err := checkReferencedRole(consistentInfo.RoleName, raw); err != nil {
+			logrus.Debugf("downloaded %s is invalid: %s", consistentName, err)
+			return raw, err
+		}
+	}
+	if err := checkMetadataPolicy(consistentInfo.RoleName, raw, c.options); err != nil {
+		logrus.Debugf("downloaded %s is invalid: %s", consistentName, err)
+		return raw, err
+	}
 
 	// try to load the old data into the old builder - only use it to validate
 	// versions if it loads successfully.  If it errors, then the loaded version
@@ -309,13 +376,50 @@ func (c *tufClient) tryLoadRemote(consistentInfo tuf.ConsistentInfo, old []byte)
 		logrus.Debugf("downloaded %s is invalid: %s", consistentName, err)
 		return raw, err
 	}
-	logrus.Debugf("successfully verified downloaded %s", consistentName)
-	if err := c.cache.Set(consistentInfo.RoleName.String(), raw); err != nil {
-		logrus.Debugf("Unable to write %s to cache: %s", consistentInfo.RoleName, err)
+	served := c.newBuilder.GetLoadedVersion(consistentInfo.RoleName)
+	if err := c.checkVersionJump(consistentInfo.RoleName, minVersion, served); err != nil {
+		logrus.Debugf("downloaded %s is invalid: %s", consistentName, err)
+		return raw, err
 	}
+	logrus.Debugf("successfully verified downloaded %s", consistentName)
+	c.recordVerified(consistentInfo.RoleName, raw, true)
 	return raw, nil
 }
 
+// recordVerified records the metadata of a role which the builder has loaded,
+// and whether it was downloaded, and so is to be cached
+func (c *tufClient) recordVerified(role data.RoleName, raw []byte, downloaded bool) {
+	if c.verified == nil {
+		c.verified = make(map[data.RoleName][]byte)
+		c.downloaded = make(map[data.RoleName]bool)
+	}
+	c.verified[role] = raw
+	c.downloaded[role] = downloaded
+}
+
+// latest returns the metadata of a role which was last downloaded and verified
+// by this client, if it has not yet been cached, or otherwise the cached copy
+func (c *tufClient) latest(role data.RoleName) ([]byte, error) {
+	if c.downloaded[role] {
+		return c.verified[role], nil
+	}
+	return c.cache.GetSized(role.String(), store.NoSizeLimit)
+}
+
+// cacheDownloaded writes the verified metadata which was downloaded to the
+// cache, root first and then in the order of the update.  A failure to write
+// is only logged, as the update itself succeeded.
+func (c *tufClient) cacheDownloaded(repo *tuf.Repo) {
+	for _, role := range loadedRoles(repo) {
+		if !c.downloaded[role] {
+			continue
+		}
+		if err := c.cache.Set(role.String(), c.verified[role]); err != nil {
+			logrus.Debugf("Unable to write %s to cache: %s", role, err)
+		}
+	}
+}
+
 // TUFLoadOptions are provided to LoadTUFRepo, which loads a TUF repo from cache,
 // from a remote store, or both
 type TUFLoadOptions struct {
@@ -325,6 +429,20 @@ type TUFLoadOptions struct {
 	Cache                  store.MetadataStore
 	RemoteStore            store.RemoteStore
 	AlwaysCheckInitialized bool
+	// Timing, if not nil, is populated with a breakdown of the time spent
+	// in each phase of the update
+	Timing *UpdateTiming
+	// UpdateOptions configures optional update behaviours
+	UpdateOptions UpdateOptions
+	// stats, if not nil, accumulate the metadata fetched and served from cache
+	stats *updateCounters
+	// VerificationCache, if not nil, holds the delegations whose signatures
+	// were verified by previous updates sharing it, which need not be
+	// verified again while the snapshot is unchanged
+	VerificationCache *tuf.VerificationCache
+	// PathMatcher, if not nil, replaces the prefix matching of target paths
+	// against delegation paths in the loaded repo
+	PathMatcher data.PathMatcher
 }
 
 // bootstrapClient attempts to bootstrap a root.json to be used as the trust
@@ -350,15 +468,20 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 	minVersion := 1
 	// the old root on disk should not be validated against any trust pinning configuration
 	// because if we have an old root, it itself is the thing that pins trust
-	oldBuilder := tuf.NewRepoBuilder(l.GUN, l.CryptoService, trustpinning.TrustPinConfig{})
+	// whether expired root certificates are accepted is still honoured, as it is
+	// not part of pinning trust
+	unpinned := trustpinning.TrustPinConfig{IgnoreRootCertExpiry: l.TrustPinning.IgnoreRootCertExpiry}
+	oldBuilder := tuf.NewRepoBuilderWithVerificationCache(l.GUN, l.CryptoService, unpinned, l.VerificationCache)
 
 	// by default, we want to use the trust pinning configuration on any new root that we download
-	newBuilder := tuf.NewRepoBuilder(l.GUN, l.CryptoService, l.TrustPinning)
+	newBuilder := tuf.NewRepoBuilderWithVerificationCache(l.GUN, l.CryptoService, l.TrustPinning, l.VerificationCache)
 
 	// Try to read root from cache first. We will trust this root until we detect a problem
 	// during update which will cause us to download a new root and perform a rotation.
 	// If we have an old root, and it's valid, then we overwrite the newBuilder to be one
 	// preloaded with the old root or one which uses the old root for trust bootstrapping.
+	var loadedRoot []byte
+	rootDownloaded := false
 	if rootJSON, err := l.Cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit); err == nil {
 		// if we can't load the cached root, fail hard because that is how we pin trust
 		if err := oldBuilder.Load(data.CanonicalRootRole, rootJSON, minVersion, true); err != nil {
@@ -367,9 +490,12 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 
 		// again, the root on disk is the source of trust pinning, so use an empty trust
 		// pinning configuration
-		newBuilder = tuf.NewRepoBuilder(l.GUN, l.CryptoService, trustpinning.TrustPinConfig{})
+		newBuilder = tuf.NewRepoBuilderWithVerificationCache(l.GUN, l.CryptoService, unpinned, l.VerificationCache)
 
-		if err := newBuilder.Load(data.CanonicalRootRole, rootJSON, minVersion, false); err != nil {
+		if err := newBuilder.Load(data.CanonicalRootRole, rootJSON, minVersion, false); err == nil {
+			l.stats.recordCached(rootJSON)
+			loadedRoot = rootJSON
+		} else {
 			// Ok, the old root is expired - we want to download a new one.  But we want to use the
 			// old root to verify the new root, so bootstrap a new builder with the old builder
 			// but use the trustpinning to validate the new root
@@ -385,6 +511,7 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 		// if remote store successfully set up, try and get root from remote
 		// We don't have any local data to determine the size of root, so try the maximum (though it is restricted at 100MB)
 		tmpJSON, err := l.RemoteStore.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+		l.stats.recordFetch(tmpJSON, err)
 		if err != nil {
 			// we didn't have a root in cache and were unable to load one from
 			// the server. Nothing we can do but error.
@@ -396,12 +523,8 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 			if err := newBuilder.Load(data.CanonicalRootRole, tmpJSON, minVersion, false); err != nil {
 				return nil, err
 			}
-
-			err = l.Cache.Set(data.CanonicalRootRole.String(), tmpJSON)
-			if err != nil {
-				// if we can't write cache we should still continue, just log error
-				logrus.Errorf("could not save root to cache: %s", err.Error())
-			}
+			// it is cached along with the rest of the update
+			loadedRoot, rootDownloaded = tmpJSON, true
 		}
 	}
 
@@ -411,18 +534,28 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 		return nil, ErrRepoNotInitialized{}
 	}
 
-	return &tufClient{
+	c := &tufClient{
 		oldBuilder: oldBuilder,
 		newBuilder: newBuilder,
 		remote:     l.RemoteStore,
 		cache:      l.Cache,
-	}, nil
+		timing:     l.Timing,
+		options:    l.UpdateOptions,
+		stats:      l.stats,
+	}
+	c.recordVerified(data.CanonicalRootRole, loadedRoot, rootDownloaded)
+	return c, nil
 }
 
 // LoadTUFRepo bootstraps a trust anchor (root.json) from cache (if provided) before updating
 // all the metadata for the repo from the remote (if provided). It loads a TUF repo from cache,
 // from a remote store, or both.
 func LoadTUFRepo(options TUFLoadOptions) (*tuf.Repo, *tuf.Repo, error) {
+	if options.Timing != nil {
+		start := time.Now()
+		defer func() { options.Timing.Total = time.Since(start) }()
+	}
+
 	// set some sane defaults, so nothing has to be provided necessarily
 	if options.RemoteStore == nil {
 		options.RemoteStore = store.OfflineStore{}
@@ -434,7 +567,9 @@ func LoadTUFRepo(options TUFLoadOptions) (*tuf.Repo, *tuf.Repo, error) {
 		options.CryptoService = cryptoservice.EmptyService
 	}
 
+	start := time.Now()
 	c, err := bootstrapClient(options)
+	options.Timing.record(phaseRoot, start)
 	if err != nil {
 		if _, ok := err.(store.ErrMetaNotFound); ok {
 			return nil, nil, ErrRepositoryNotExist{
@@ -458,6 +593,39 @@ func LoadTUFRepo(options TUFLoadOptions) (*tuf.Repo, *tuf.Repo, error) {
 		}
 		return nil, nil, err
 	}
+	if err := checkFreshness(repo, options.UpdateOptions.FreshnessWindows); err != nil {
+		return nil, nil, err
+	}
+	if err := checkTimestampExpiry(repo, options.UpdateOptions.CheckTimestampExpiry); err != nil {
+		return nil, nil, err
+	}
+	if err := checkDelegationExpiry(repo, options.UpdateOptions.CheckDelegationExpiry,
+		options.UpdateOptions.DelegationExpiryMargin); err != nil {
+		return nil, nil, err
+	}
+	if policy := options.UpdateOptions.KeyPolicy; policy != nil {
+		if err := policy.enforce(repo); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := checkSnapshotPin(repo, c.verified, options.GUN, options.TrustPinning); err != nil {
+		return nil, nil, err
+	}
+	if err := checkTimestampPin(repo, c.verified, options.GUN, options.TrustPinning); err != nil {
+		return nil, nil, err
+	}
+	if err := checkTransparencyLog(repo, c.verified, options.UpdateOptions.TransparencyLog); err != nil {
+		return nil, nil, err
+	}
+	// publishing is left to the owner and witnesses, who must be able to
+	// publish the countersignatures in the first place
+	if !options.AlwaysCheckInitialized {
+		if err := checkRootWitnesses(repo, options.GUN, options.TrustPinning); err != nil {
+			return nil, nil, err
+		}
+	}
+	c.cacheDownloaded(repo)
+	repo.SetPathMatcher(options.PathMatcher)
 	warnRolesNearExpiry(repo)
 	return repo, invalid, nil
 }
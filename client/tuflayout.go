@@ -0,0 +1,139 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// tufLayoutMetadataDir is the directory under which a TUF repository serves
+// its metadata
+const tufLayoutMetadataDir = "metadata"
+
+// ExportTUFLayout updates the repository, and then writes its verified
+// metadata, exactly as served, under dir in the directory layout of a TUF
+// repository, such as python-tuf serves: a metadata directory holding a JSON
+// file per role.  Role names are URL encoded, so that a delegation such as
+// targets/releases is written to targets%2Freleases.json rather than to a
+// subdirectory.  The root is written as root.json, and every version of it as
+// <version>.root.json, and the timestamp always as timestamp.json.  If the root
+// enables consistent snapshots, the snapshot and targets roles are written only
+// under their version, as <version>.<role>.json, and otherwise as <role>.json.
+//
+// Only the layout follows the TUF specification, not the metadata, which is
+// written as notary signs it: its _type fields are capitalized, the snapshot
+// and timestamp list roles without a .json suffix, there is no spec_version,
+// and keys have no signature scheme.  Converting it would mean re-signing
+// every role, including those whose keys the server holds, so clients which
+// implement the specification, such as python-tuf's, cannot load the export.
+// It is of use for mirroring and archiving the metadata in a familiar layout.
+//
+// The versions of the root before the current one are downloaded, and must
+// each be signed by the version before it, with the last signing the current
+// root.
+func (r *repository) ExportTUFLayout(dir string) error {
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	metaDir := filepath.Join(dir, tufLayoutMetadataDir)
+	if err := os.MkdirAll(metaDir, notary.PrivExecPerms); err != nil {
+		return err
+	}
+	consistent := r.tufRepo.Root.Signed.ConsistentSnapshot
+
+	for _, role := range loadedRoles(r.tufRepo) {
+		raw, err := r.cache.GetSized(role.String(), store.NoSizeLimit)
+		if err != nil {
+			return err
+		}
+		files := map[string][]byte{}
+		switch role {
+		case data.CanonicalRootRole:
+			roots, err := r.rootVersions(raw)
+			if err != nil {
+				return err
+			}
+			for i, root := range roots {
+				files[tufLayoutFileName(role, i+1)] = root
+			}
+			files[tufLayoutFileName(role, 0)] = raw
+		case data.CanonicalTimestampRole:
+			files[tufLayoutFileName(role, 0)] = raw
+		default:
+			version := 0
+			if consistent {
+				version = loadedVersion(r.tufRepo, role)
+			}
+			files[tufLayoutFileName(role, version)] = raw
+		}
+		for name, content := range files {
+			if err := ioutil.WriteFile(filepath.Join(metaDir, name), content, notary.PrivNoExecPerms); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rootVersions returns every version of the root, in order, ending with the
+// current, trusted one.  The versions before it are downloaded, and verified
+// from the first onwards as when rotating the root, so that each must be
+// signed by the keys of the version before it, and the last must have signed
+// the current root.
+func (r *repository) rootVersions(current []byte) ([][]byte, error) {
+	currentVersion := r.tufRepo.Root.Signed.Version
+	// the chain is anchored by the current root, which has already been
+	// validated against the trust pinning, rather than by the first
+	builder := tuf.NewRepoBuilder(r.gun, cryptoservice.EmptyService, trustpinning.TrustPinConfig{})
+	roots := make([][]byte, 0, currentVersion)
+	for v := 1; v < currentVersion; v++ {
+		versionedRole := fmt.Sprintf("%d.%s", v, data.CanonicalRootRole)
+		raw, err := r.remoteStore.GetSized(versionedRole, notary.MaxDownloadSize)
+		if err != nil {
+			logrus.Debugf("error downloading %s: %s", versionedRole, err)
+			return nil, err
+		}
+		if err := builder.LoadRootForUpdate(raw, v, false); err != nil {
+			logrus.Debugf("downloaded %s is invalid: %s", versionedRole, err)
+			return nil, err
+		}
+		if loaded := builder.GetLoadedVersion(data.CanonicalRootRole); loaded != v {
+			logrus.Debugf("downloaded %s is version %d of the root", versionedRole, loaded)
+			return nil, store.ErrMaliciousServer{}
+		}
+		roots = append(roots, raw)
+	}
+	if err := builder.LoadRootForUpdate(current, currentVersion, true); err != nil {
+		return nil, err
+	}
+	return append(roots, current), nil
+}
+
+// tufLayoutFileName returns the name a TUF repository gives the metadata file
+// of a role, prefixed with its version unless the version is 0
+func tufLayoutFileName(role data.RoleName, version int) string {
+	name := url.PathEscape(role.String()) + ".json"
+	if version == 0 {
+		return name
+	}
+	return fmt.Sprintf("%d.%s", version, name)
+}
+
+// loadedVersion returns the version of the loaded metadata of a snapshot or
+// targets role
+func loadedVersion(repo *tuf.Repo, role data.RoleName) int {
+	if role == data.CanonicalSnapshotRole {
+		return repo.Snapshot.Signed.Version
+	}
+	return repo.Targets[role].Signed.Version
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// exportedTUFLayout exports the repository in the layout of a TUF repository,
+// and returns the names of the files in its metadata directory
+func exportedTUFLayout(t *testing.T, repo *repository) (string, []string) {
+	dir, err := ioutil.TempDir("", "notary-test-tuf-layout-")
+	require.NoError(t, err)
+	require.NoError(t, repo.ExportTUFLayout(dir))
+
+	infos, err := ioutil.ReadDir(filepath.Join(dir, "metadata"))
+	require.NoError(t, err)
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		require.False(t, info.IsDir())
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return dir, names
+}
+
+// requireExported checks that each exported file holds the given metadata
+func requireExported(t *testing.T, dir string, expected map[string][]byte) {
+	for file, content := range expected {
+		exported, err := ioutil.ReadFile(filepath.Join(dir, "metadata", file))
+		require.NoError(t, err)
+		require.Equal(t, content, exported, file)
+	}
+}
+
+// Without consistent snapshots, every role but the root is written under its
+// name alone, with delegation names URL encoded, and as served, while every
+// version of the root is written under its version
+func TestExportTUFLayout(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.AddDelegation("targets/releases",
+		[]data.PublicKey{createKey(t, repo, "targets/releases", false)}, []string{""}))
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt", "targets/releases")
+	require.NoError(t, repo.Publish())
+	firstRoot, err := repo.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	require.NoError(t, repo.RotateKey(data.CanonicalRootRole, false, nil))
+
+	dir, names := exportedTUFLayout(t, repo)
+	defer os.RemoveAll(dir)
+	require.False(t, repo.tufRepo.Root.Signed.ConsistentSnapshot)
+	require.Equal(t, 2, repo.tufRepo.Root.Signed.Version)
+	require.Equal(t, []string{
+		"1.root.json",
+		"2.root.json",
+		"root.json",
+		"snapshot.json",
+		"targets%2Freleases.json",
+		"targets.json",
+		"timestamp.json",
+	}, names)
+
+	expected := map[string][]byte{"1.root.json": firstRoot}
+	for file, role := range map[string]data.RoleName{
+		"root.json":               data.CanonicalRootRole,
+		"2.root.json":             data.CanonicalRootRole,
+		"snapshot.json":           data.CanonicalSnapshotRole,
+		"targets.json":            data.CanonicalTargetsRole,
+		"targets%2Freleases.json": "targets/releases",
+		"timestamp.json":          data.CanonicalTimestampRole,
+	} {
+		cached, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		expected[file] = cached
+	}
+	requireExported(t, dir, expected)
+}
+
+// The metadata is exported as notary signs it, rather than converted to the
+// TUF specification, which would mean re-signing it
+func TestExportTUFLayoutIsNotConverted(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	dir, _ := exportedTUFLayout(t, repo)
+	defer os.RemoveAll(dir)
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "metadata", "snapshot.json"))
+	require.NoError(t, err)
+	snapshot := &data.SignedSnapshot{}
+	require.NoError(t, json.Unmarshal(raw, snapshot))
+	require.Equal(t, data.TUFTypes[data.CanonicalSnapshotRole], snapshot.Signed.Type)
+	require.Contains(t, snapshot.Signed.Meta, data.CanonicalTargetsRole.String())
+	require.NotContains(t, snapshot.Signed.Meta, "targets.json")
+}
+
+// With consistent snapshots, the snapshot and targets roles are written only
+// under their versions, while the timestamp is never versioned
+func TestExportTUFLayoutConsistentSnapshot(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	require.NoError(t, serverSwizzler.MutateRoot(func(r *data.Root) { r.ConsistentSnapshot = true }))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	dir, names := exportedTUFLayout(t, repo)
+	defer os.RemoveAll(dir)
+	require.True(t, repo.tufRepo.Root.Signed.ConsistentSnapshot)
+
+	expectedNames := []string{
+		"root.json",
+		"timestamp.json",
+		fmt.Sprintf("%d.root.json", repo.tufRepo.Root.Signed.Version),
+		fmt.Sprintf("%d.snapshot.json", repo.tufRepo.Snapshot.Signed.Version),
+	}
+	expected := map[string][]byte{}
+	for role, tgts := range repo.tufRepo.Targets {
+		name := fmt.Sprintf("%d.%s", tgts.Signed.Version, tufLayoutFileName(role, 0))
+		expectedNames = append(expectedNames, name)
+		served, err := serverSwizzler.MetadataCache.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		expected[name] = served
+	}
+	sort.Strings(expectedNames)
+	require.Equal(t, expectedNames, names)
+	require.Contains(t, names, fmt.Sprintf("%d.targets%%2Fa%%2Fb.json", repo.tufRepo.Targets["targets/a/b"].Signed.Version))
+	requireExported(t, dir, expected)
+}
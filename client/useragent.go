@@ -0,0 +1,66 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/theupdateframework/notary/version"
+)
+
+// defaultUserAgent identifies the requests of repositories whose user agent
+// has not been set
+func defaultUserAgent() string {
+	if version.NotaryVersion == "" {
+		return "notary-client"
+	}
+	return "notary-client/" + version.NotaryVersion
+}
+
+// userAgentTransport sets the User-Agent header of every request it sends
+// through base
+type userAgentTransport struct {
+	base http.RoundTripper
+
+	mu        sync.RWMutex
+	userAgent string
+}
+
+// newUserAgentTransport wraps a repository's RoundTripper so that its requests
+// carry the default user agent until one is set.  A nil RoundTripper, which
+// makes a repository offline, is returned as it is.
+func newUserAgentTransport(base http.RoundTripper) *userAgentTransport {
+	if base == nil {
+		return nil
+	}
+	return &userAgentTransport{base: base, userAgent: defaultUserAgent()}
+}
+
+// RoundTrip sends a copy of the request with the User-Agent header set
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	userAgent := t.userAgent
+	t.mu.RUnlock()
+
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// SetUserAgent sets the User-Agent header sent with every request to the
+// notary server, its mirrors and trust bundle URLs, so that server operators
+// can identify the client or tool in their logs.  An empty ua restores the
+// default, which identifies the notary client and its version.  It has no
+// effect on a repository created without a RoundTripper, which is offline, nor
+// on requests to a timestamp authority, which are sent with the RoundTripper
+// given to SetTSA.
+func (r *repository) SetUserAgent(ua string) {
+	if r.userAgent == nil {
+		return
+	}
+	if ua == "" {
+		ua = defaultUserAgent()
+	}
+	r.userAgent.mu.Lock()
+	r.userAgent.userAgent = ua
+	r.userAgent.mu.Unlock()
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// userAgentRecorder records the User-Agent header of each request by method
+type userAgentRecorder struct {
+	mu         sync.Mutex
+	userAgents map[string][]string
+}
+
+func (rec *userAgentRecorder) wrap(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rec.mu.Lock()
+		rec.userAgents[req.Method] = append(rec.userAgents[req.Method], req.UserAgent())
+		rec.mu.Unlock()
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// take returns the User-Agent headers recorded so far, and forgets them
+func (rec *userAgentRecorder) take() map[string][]string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	userAgents := rec.userAgents
+	rec.userAgents = make(map[string][]string)
+	return userAgents
+}
+
+// requireUserAgent asserts that requests of the given method were made, all
+// with the user agent
+func requireUserAgent(t *testing.T, userAgents map[string][]string, method, expected string) {
+	require.NotEmpty(t, userAgents[method])
+	for _, ua := range userAgents[method] {
+		require.Equal(t, expected, ua)
+	}
+}
+
+// The user agent, default or set, is sent with update and publish requests
+func TestSetUserAgent(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	rec := &userAgentRecorder{userAgents: make(map[string][]string)}
+	recording := httptest.NewServer(rec.wrap(ts.Config.Handler))
+	defer recording.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", recording.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	requireUserAgent(t, rec.take(), http.MethodPost, defaultUserAgent())
+
+	repo.SetUserAgent("release-bot/1.2")
+	addTarget(t, repo, "app", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	userAgents := rec.take()
+	requireUserAgent(t, userAgents, http.MethodGet, "release-bot/1.2")
+	requireUserAgent(t, userAgents, http.MethodPost, "release-bot/1.2")
+
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+	requireUserAgent(t, rec.take(), http.MethodGet, "release-bot/1.2")
+
+	repo.SetUserAgent("")
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	requireUserAgent(t, rec.take(), http.MethodGet, defaultUserAgent())
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrVerifierReadOnly is returned when a verifier, which has no signing
+// capability, is asked to publish
+type ErrVerifierReadOnly struct{}
+
+func (err ErrVerifierReadOnly) Error() string {
+	return "verifier is read-only, and cannot sign or publish"
+}
+
+// Verifier is a handle to a repository which can update and read its trust
+// data, and verify content against it, but which has no signing capability
+type Verifier interface {
+	ReadOnlyRepository
+
+	// Publish always fails with ErrVerifierReadOnly
+	Publish() error
+}
+
+// verifier is a read only handle to a repository with no keys
+type verifier struct {
+	readOnlyRepository
+}
+
+// NewVerifier returns a verifier for the repository of the given GUN on the
+// notary server at url, caching its trust data under baseDir like
+// NewFileCachedRepository.  A verifier has no key stores and so never prompts
+// for passphrases.  Trust in the root is established on first use.  In case of
+// a nil RoundTripper, the verifier works offline from the cached trust data.
+func NewVerifier(baseDir, gun, url string, rt http.RoundTripper) (Verifier, error) {
+	layout, err := TrustDirLayout(baseDir, gun)
+	if err != nil {
+		return nil, err
+	}
+	if err := recoverInterruptedCacheSwap(layout.Metadata); err != nil {
+		return nil, err
+	}
+	cache, err := store.NewFileStore(layout.Metadata, "json")
+	if err != nil {
+		return nil, err
+	}
+	userAgent := newUserAgentTransport(rt)
+	if userAgent != nil {
+		rt = userAgent
+	}
+	remoteStore, err := getRemoteStore(url, data.GUN(gun), rt)
+	if err != nil {
+		// url is syntactically invalid
+		return nil, err
+	}
+
+	repo, err := NewRepository(data.GUN(gun), url, remoteStore, cache, trustpinning.TrustPinConfig{},
+		cryptoservice.NewCryptoService(), changelist.NewMemChangelist())
+	if err != nil {
+		return nil, err
+	}
+	r := repo.(*repository)
+	r.roundTrip = rt
+	r.userAgent = userAgent
+	r.verifyOnly = true
+	return verifier{readOnlyRepository{ReadOnly: r, repo: r}}, nil
+}
+
+func (v verifier) Publish() error {
+	return ErrVerifierReadOnly{}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A verifier can update, list and verify the targets of a repository, but
+// can't publish, even through its underlying repository
+func TestVerifier(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	gun := "docker.com/notary"
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	verifierDir, err := ioutil.TempDir("", "notary-verifier-")
+	require.NoError(t, err)
+	defer os.RemoveAll(verifierDir)
+	v, err := NewVerifier(verifierDir, gun, ts.URL, http.DefaultTransport)
+	require.NoError(t, err)
+
+	var handle interface{} = v
+	_, ok := handle.(Repository)
+	require.False(t, ok, "verifier exposes the full repository interface")
+
+	require.NoError(t, v.Update())
+	targets, err := v.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	target, err := v.GetTargetByName("current")
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	results, err := v.VerifyAllTargets(func(name string) (io.Reader, error) {
+		require.Equal(t, target.Name, name)
+		return bytes.NewReader(content), nil
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.True(t, results[0].Verified())
+
+	err = v.Publish()
+	require.Error(t, err)
+	require.IsType(t, ErrVerifierReadOnly{}, err)
+
+	// the underlying repository has no keys, and refuses to sign
+	underlying := v.(verifier).repo
+	keys := underlying.GetCryptoService().ListAllKeys()
+	require.Empty(t, keys)
+	require.NoError(t, underlying.AddTarget(&target.Target, data.CanonicalTargetsRole))
+	err = underlying.Publish()
+	require.Error(t, err)
+	require.IsType(t, ErrVerifierReadOnly{}, err)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// verifyDirectoryWorkers bounds how many files VerifyDirectory reads at once
+const verifyDirectoryWorkers = 8
+
+// VerifyDirectory verifies the files under the local directory root, such as
+// an unpacked set of artifacts, against the TUF metadata of the repository.
+// Each file is mapped to the target named by its slash separated path relative
+// to root, and is verified against that target in every role it is signed
+// into.  Files are read concurrently by a bounded number of workers.
+//
+// As with VerifyAllTargets, a result is returned for each target in each role,
+// and targets with no file under root are reported as missing.  Files which
+// are not targets are reported as untracked, with no role.  Results are
+// ordered by name, and then by role.
+func (r *repository) VerifyDirectory(root string) ([]TargetVerificationResult, error) {
+	targets, err := r.GetAllTargetMetadataByName("")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = path
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TargetVerificationResult, len(targets))
+	preferred := make([]Target, len(targets))
+	tracked := make(map[string]bool)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < verifyDirectoryWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j].Err = verifyTargetFile(preferred[j], files[targets[j].Target.Name])
+			}
+		}()
+	}
+	for i, t := range targets {
+		results[i] = TargetVerificationResult{Name: t.Target.Name, Role: t.Role.Name}
+		tracked[t.Target.Name] = true
+		if _, ok := files[t.Target.Name]; !ok {
+			results[i].Missing = true
+			results[i].Err = &os.PathError{Op: "open", Path: filepath.Join(root, filepath.FromSlash(t.Target.Name)), Err: os.ErrNotExist}
+			continue
+		}
+		if preferred[i], err = r.preferredHashes(t.Target); err != nil {
+			results[i].Err = err
+			continue
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for name := range files {
+		if !tracked[name] {
+			results = append(results, TargetVerificationResult{Name: name, Untracked: true, Err: ErrNoSuchTarget(name)})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].Role < results[j].Role
+	})
+	return results, nil
+}
+
+// verifyTargetFile verifies the content of the file at path against the target
+func verifyTargetFile(target Target, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return verifyTargetContent(target, f)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Files under the directory are verified against the targets named by their
+// relative paths, with missing targets and extra files each flagged
+func TestVerifyDirectory(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	addTarget(t, repo, "good", "../fixtures/intermediate-ca.crt", data.CanonicalTargetsRole, "targets/a")
+	addTarget(t, repo, "nested/good", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "changed", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "missing", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	good, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	changed := append([]byte{}, good...)
+	changed[0] ^= 0xff
+
+	dir, err := ioutil.TempDir("", "notary-verify-dir-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for name, content := range map[string][]byte{
+		"good":        good,
+		"nested/good": good,
+		"changed":     changed,
+		"extra":       good,
+		"nested/more": good,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, ioutil.WriteFile(path, content, 0600))
+	}
+
+	results, err := repo.VerifyDirectory(dir)
+	require.NoError(t, err)
+	require.Len(t, results, 7)
+
+	expected := []struct {
+		name      string
+		role      data.RoleName
+		verified  bool
+		missing   bool
+		untracked bool
+	}{
+		{name: "changed", role: data.CanonicalTargetsRole},
+		{name: "extra", untracked: true},
+		{name: "good", role: data.CanonicalTargetsRole, verified: true},
+		{name: "good", role: "targets/a", verified: true},
+		{name: "missing", role: data.CanonicalTargetsRole, missing: true},
+		{name: "nested/good", role: data.CanonicalTargetsRole, verified: true},
+		{name: "nested/more", untracked: true},
+	}
+	for i, e := range expected {
+		result := results[i]
+		require.Equal(t, e.name, result.Name)
+		require.Equal(t, e.role, result.Role)
+		require.Equal(t, e.verified, result.Verified(), "%s in %s", e.name, e.role)
+		require.Equal(t, e.missing, result.Missing, "%s in %s", e.name, e.role)
+		require.Equal(t, e.untracked, result.Untracked, "%s in %s", e.name, e.role)
+	}
+	require.IsType(t, data.ErrMismatchedChecksum{}, results[0].Err)
+	require.IsType(t, ErrNoSuchTarget(""), results[1].Err)
+	require.True(t, os.IsNotExist(results[4].Err))
+
+	_, err = repo.VerifyDirectory(filepath.Join(dir, "does-not-exist"))
+	require.Error(t, err)
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// VerifyMetadataWithKeys verifies that the signed metadata of a role carries
+// valid signatures by at least threshold of the given keys, independent of any
+// repository and its trust data.  The signatures are verified over the
+// canonical JSON of the metadata's signed portion, as when updating.  Only the
+// signatures are verified: the metadata's expiry, version and contents are not
+// checked.
+func VerifyMetadataWithKeys(role string, metadata []byte, keys []data.PublicKey, threshold int) error {
+	roleName := data.RoleName(role)
+	if !data.ValidRole(roleName) {
+		return data.ErrInvalidRole{Role: roleName, Reason: "not a valid role name"}
+	}
+	s := &data.Signed{}
+	if err := json.Unmarshal(metadata, s); err != nil {
+		return err
+	}
+	return signed.VerifySignatures(s, data.NewBaseRole(roleName, threshold, keys...))
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/cryptoservice"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// signedTargetsWithKeys returns targets metadata signed by the given keys, of
+// the given number of keys created
+func signedTargetsWithKeys(t *testing.T, created, signing int) ([]byte, []data.PublicKey) {
+	cs := cryptoservice.NewCryptoService(trustmanager.NewKeyMemoryStore(passphraseRetriever))
+	keys := make([]data.PublicKey, 0, created)
+	for i := 0; i < created; i++ {
+		key, err := cs.Create(data.CanonicalTargetsRole, "docker.com/notary", data.ECDSAKey)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	s, err := data.NewTargets().ToSigned()
+	require.NoError(t, err)
+	require.NoError(t, signed.Sign(cs, s, keys[:signing], signing, nil))
+	metadata, err := json.Marshal(s)
+	require.NoError(t, err)
+	return metadata, keys
+}
+
+// Metadata verifies when enough of the supplied keys have signed it
+func TestVerifyMetadataWithKeysSufficientSignatures(t *testing.T) {
+	metadata, keys := signedTargetsWithKeys(t, 3, 2)
+	require.NoError(t, VerifyMetadataWithKeys("targets", metadata, keys, 2))
+	require.NoError(t, VerifyMetadataWithKeys("targets", metadata, keys, 1))
+	require.NoError(t, VerifyMetadataWithKeys("targets", metadata, keys[:2], 2))
+
+	// the signatures are over the canonical JSON, not the bytes as given
+	var indented map[string]interface{}
+	require.NoError(t, json.Unmarshal(metadata, &indented))
+	reformatted, err := json.MarshalIndent(indented, "", "    ")
+	require.NoError(t, err)
+	require.NoError(t, VerifyMetadataWithKeys("targets", reformatted, keys, 2))
+}
+
+// Metadata does not verify without enough valid signatures by the supplied
+// keys
+func TestVerifyMetadataWithKeysInsufficientSignatures(t *testing.T) {
+	metadata, keys := signedTargetsWithKeys(t, 3, 2)
+
+	err := VerifyMetadataWithKeys("targets", metadata, keys, 3)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+	err = VerifyMetadataWithKeys("targets", metadata, keys[1:], 2)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+	_, otherKeys := signedTargetsWithKeys(t, 2, 0)
+	err = VerifyMetadataWithKeys("targets", metadata, otherKeys, 1)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+	err = VerifyMetadataWithKeys("targets", metadata, keys, 0)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+
+	// altering the signed portion invalidates the signatures
+	require.Contains(t, string(metadata), `"version":0`)
+	altered := strings.Replace(string(metadata), `"version":0`, `"version":1`, 1)
+	err = VerifyMetadataWithKeys("targets", []byte(altered), keys, 1)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+
+	unsigned, _ := signedTargetsWithKeys(t, 1, 0)
+	require.Equal(t, signed.ErrNoSignatures, VerifyMetadataWithKeys("targets", unsigned, keys, 1))
+	require.IsType(t, data.ErrInvalidRole{}, VerifyMetadataWithKeys("invalid", metadata, keys, 1))
+	require.Error(t, VerifyMetadataWithKeys("targets", []byte("not json"), keys, 1))
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrTargetLengthMismatch is returned when the content of a target does not
+// have the length recorded in its TUF metadata
+type ErrTargetLengthMismatch struct {
+	Name     string
+	Expected int64
+	Actual   int64
+}
+
+func (err ErrTargetLengthMismatch) Error() string {
+	return fmt.Sprintf("target %s should be %d bytes long, but %d bytes were read", err.Name, err.Expected, err.Actual)
+}
+
+// TargetVerificationResult is the outcome of verifying the content of a single
+// target, as signed into a single role, against its TUF metadata
+type TargetVerificationResult struct {
+	Name string
+	Role data.RoleName
+	// Missing is true if the content of the target could not be obtained
+	Missing bool
+	// Untracked is true if the content was found, but is not signed into any
+	// role as a target
+	Untracked bool
+	// Err is nil if the content matched the length and hashes of the target,
+	// and otherwise describes why verification failed
+	Err error
+}
+
+// Verified returns true if the target's content matched its metadata
+func (t TargetVerificationResult) Verified() bool {
+	return t.Err == nil
+}
+
+// VerifyAllTargets obtains the content of every target signed into any role of
+// the repository from source, and verifies it against the length and hashes in
+// the TUF metadata.  A result is returned for each target in each role it is
+// signed into.  Content that source cannot provide is reported as missing
+// rather than causing VerifyAllTargets to fail.  Content is hashed as it is
+// read, and if the reader source returns is also an io.Closer, it is closed
+// once read.  A repository with no targets has no results.
+func (r *repository) VerifyAllTargets(source func(name string) (io.Reader, error)) ([]TargetVerificationResult, error) {
+	targets, err := r.GetAllTargetMetadataByName("")
+	if _, ok := err.(ErrNoSuchTarget); ok {
+		return []TargetVerificationResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TargetVerificationResult, 0, len(targets))
+	for _, t := range targets {
+		result := TargetVerificationResult{Name: t.Target.Name, Role: t.Role.Name}
+		target, err := r.preferredHashes(t.Target)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		content, err := source(t.Target.Name)
+		if err != nil {
+			result.Missing = true
+			result.Err = err
+		} else {
+			result.Err = verifyTargetContent(target, content)
+			if closer, ok := content.(io.Closer); ok {
+				if err := closer.Close(); err != nil && result.Err == nil {
+					result.Err = err
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// verifyTargetContent hashes at most one byte more than the target's length as
+// it is read, so that oversized content is detected without reading all of it,
+// and no more than a buffer of it is held in memory
+func verifyTargetContent(target Target, content io.Reader) error {
+	var algorithms []string
+	for _, algorithm := range []string{notary.SHA256, notary.SHA512} {
+		if _, ok := target.Hashes[algorithm]; ok {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+	meta, err := data.NewFileMeta(io.LimitReader(content, target.Length+1), algorithms...)
+	if err != nil {
+		return err
+	}
+	if meta.Length != target.Length {
+		return ErrTargetLengthMismatch{Name: target.Name, Expected: target.Length, Actual: meta.Length}
+	}
+	return data.CheckComputedHashes(meta.Hashes, target.Name, target.Hashes)
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Every target in every role is verified, with matching, mismatching and
+// missing content each reported in the results
+func TestVerifyAllTargets(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	key := createKey(t, repo, "targets/a", false)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{key}, []string{""}))
+	addTarget(t, repo, "good", "../fixtures/intermediate-ca.crt", data.CanonicalTargetsRole, "targets/a")
+	addTarget(t, repo, "changed", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "truncated", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "missing", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	good, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	changed := append([]byte{}, good...)
+	changed[0] ^= 0xff
+
+	content := map[string][]byte{
+		"good":      good,
+		"changed":   changed,
+		"truncated": good[:len(good)-1],
+	}
+	var readers []*closeRecorder
+	results, err := repo.VerifyAllTargets(func(name string) (io.Reader, error) {
+		c, ok := content[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		reader := &closeRecorder{Reader: bytes.NewReader(c)}
+		readers = append(readers, reader)
+		return reader, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	// every reader which was returned is closed
+	require.Len(t, readers, 4)
+	for _, reader := range readers {
+		require.True(t, reader.closed)
+	}
+
+	seen := make(map[string]map[data.RoleName]TargetVerificationResult)
+	for _, result := range results {
+		if seen[result.Name] == nil {
+			seen[result.Name] = make(map[data.RoleName]TargetVerificationResult)
+		}
+		seen[result.Name][result.Role] = result
+	}
+
+	for _, role := range []data.RoleName{data.CanonicalTargetsRole, "targets/a"} {
+		result := seen["good"][role]
+		require.True(t, result.Verified(), "good target in %s did not verify: %v", role, result.Err)
+		require.False(t, result.Missing)
+	}
+
+	result := seen["changed"][data.CanonicalTargetsRole]
+	require.False(t, result.Verified())
+	require.False(t, result.Missing)
+	require.IsType(t, data.ErrMismatchedChecksum{}, result.Err)
+
+	result = seen["truncated"][data.CanonicalTargetsRole]
+	require.False(t, result.Verified())
+	require.False(t, result.Missing)
+	require.Equal(t, ErrTargetLengthMismatch{
+		Name: "truncated", Expected: int64(len(good)), Actual: int64(len(good) - 1),
+	}, result.Err)
+
+	result = seen["missing"][data.CanonicalTargetsRole]
+	require.False(t, result.Verified())
+	require.True(t, result.Missing)
+	require.Equal(t, os.ErrNotExist, result.Err)
+}
+
+// Content longer than the target's recorded length fails verification
+func TestVerifyTargetContentTooLong(t *testing.T) {
+	good, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	meta, err := data.NewFileMeta(bytes.NewReader(good), data.NotaryDefaultHashes...)
+	require.NoError(t, err)
+	target := Target{Name: "long", Length: meta.Length, Hashes: meta.Hashes}
+
+	require.NoError(t, verifyTargetContent(target, bytes.NewReader(good)))
+
+	err = verifyTargetContent(target, bytes.NewReader(append(good, 'x', 'y')))
+	require.IsType(t, ErrTargetLengthMismatch{}, err)
+}
+
+// A repository with no targets has nothing to verify
+func TestVerifyAllTargetsWithoutTargets(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	results, err := repo.VerifyAllTargets(func(name string) (io.Reader, error) {
+		return nil, os.ErrNotExist
+	})
+	require.NoError(t, err)
+	require.Empty(t, results)
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// DefaultMaxSnapshotVersionDelta is how far ahead of the cached snapshot the
+// version of a downloaded snapshot may be, unless configured otherwise.  It
+// allows for far more publishes between updates than any repository sees.
+const DefaultMaxSnapshotVersionDelta = 1 << 20
+
+// ErrImplausibleVersion is returned by an update when the server serves a
+// snapshot whose version is implausibly far ahead of the cached one.  A
+// malicious server could serve such a version to make every later, legitimate
+// snapshot look like a rollback, freezing clients at the metadata it chooses.
+type ErrImplausibleVersion struct {
+	Role     data.RoleName
+	Cached   int
+	Served   int
+	MaxDelta int
+}
+
+func (err ErrImplausibleVersion) Error() string {
+	return fmt.Sprintf("%s version %d is implausibly far ahead of the cached version %d (at most %d ahead is accepted)",
+		err.Role, err.Served, err.Cached, err.MaxDelta)
+}
+
+// maxSnapshotVersionDelta returns how far ahead of the cached snapshot a
+// downloaded one may be, or 0 if the jump is not bounded
+func (o UpdateOptions) maxSnapshotVersionDelta() int {
+	switch {
+	case o.MaxSnapshotVersionDelta < 0:
+		return 0
+	case o.MaxSnapshotVersionDelta == 0:
+		return DefaultMaxSnapshotVersionDelta
+	default:
+		return o.MaxSnapshotVersionDelta
+	}
+}
+
+// checkVersionJump returns ErrImplausibleVersion if the version of a
+// downloaded snapshot is more than the maximum delta ahead of the cached one.
+// Other roles, and snapshots with no valid cached version, are not checked.
+func (c *tufClient) checkVersionJump(role data.RoleName, cached, served int) error {
+	maxDelta := c.options.maxSnapshotVersionDelta()
+	if role != data.CanonicalSnapshotRole || maxDelta == 0 || !c.oldBuilder.IsLoaded(role) {
+		return nil
+	}
+	if served-cached > maxDelta {
+		return ErrImplausibleVersion{Role: role, Cached: cached, Served: served, MaxDelta: maxDelta}
+	}
+	return nil
+}
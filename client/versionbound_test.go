@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// bumpSnapshotVersion offsets the version of the snapshot on the server
+func bumpSnapshotVersion(t *testing.T, s *testutils.MetadataSwizzler, offset int) {
+	require.NoError(t, s.OffsetMetadataVersion(data.CanonicalSnapshotRole, offset))
+	require.NoError(t, s.UpdateTimestampHash())
+}
+
+// A snapshot a normal number of versions ahead is accepted, while an
+// implausible jump is rejected without replacing the cached snapshot
+func TestUpdateRejectsImplausibleSnapshotVersion(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.updateTUF(false))
+	cachedVersion := repo.tufRepo.Snapshot.Signed.Version
+
+	bumpSnapshotVersion(t, serverSwizzler, 1)
+	require.NoError(t, repo.updateTUF(false))
+	require.Equal(t, cachedVersion+1, repo.tufRepo.Snapshot.Signed.Version)
+
+	bumpSnapshotVersion(t, serverSwizzler, DefaultMaxSnapshotVersionDelta+1)
+	err := repo.updateTUF(false)
+	require.Equal(t, ErrImplausibleVersion{
+		Role:     data.CanonicalSnapshotRole,
+		Cached:   cachedVersion + 1,
+		Served:   cachedVersion + 2 + DefaultMaxSnapshotVersionDelta,
+		MaxDelta: DefaultMaxSnapshotVersionDelta,
+	}, err)
+	cached, err := repo.cache.GetSized(data.CanonicalSnapshotRole.String(), -1)
+	require.NoError(t, err)
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(cached, s))
+	cachedSnapshot, err := data.SnapshotFromSigned(s)
+	require.NoError(t, err)
+	require.Equal(t, cachedVersion+1, cachedSnapshot.Signed.Version)
+
+	// the bound can be configured, or removed
+	repo.SetUpdateOptions(UpdateOptions{MaxSnapshotVersionDelta: 2 * DefaultMaxSnapshotVersionDelta})
+	require.NoError(t, repo.updateTUF(false))
+	bumpSnapshotVersion(t, serverSwizzler, 10)
+	repo.SetUpdateOptions(UpdateOptions{MaxSnapshotVersionDelta: 5})
+	require.IsType(t, ErrImplausibleVersion{}, repo.updateTUF(false))
+	repo.SetUpdateOptions(UpdateOptions{MaxSnapshotVersionDelta: -1})
+	require.NoError(t, repo.updateTUF(false))
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// yankedCustomKey is the key in the custom data of a target which records
+// whether it has been yanked
+const yankedCustomKey = "yanked"
+
+// ErrCannotYank is returned when a target's existing custom data can't be
+// extended to record that it has been yanked
+type ErrCannotYank struct {
+	Name string
+}
+
+func (err ErrCannotYank) Error() string {
+	return fmt.Sprintf("cannot yank %s, as its custom data is not a JSON object", err.Name)
+}
+
+// targetFromMeta returns the named target described by the file meta
+func targetFromMeta(name string, meta data.FileMeta) Target {
+	return Target{
+		Name:   name,
+		Hashes: meta.Hashes,
+		Length: meta.Length,
+		Custom: meta.Custom,
+		Yanked: isYanked(meta.Custom),
+	}
+}
+
+// isYanked determines whether custom data records a target as yanked
+func isYanked(custom *canonicaljson.RawMessage) bool {
+	if custom == nil {
+		return false
+	}
+	var fields map[string]interface{}
+	if err := canonicaljson.Unmarshal(*custom, &fields); err != nil {
+		return false
+	}
+	yanked, _ := fields[yankedCustomKey].(bool)
+	return yanked
+}
+
+// YankTarget creates a changelist entry to mark the named target in the given
+// role as yanked when the changelist gets applied at publish time.  A yanked
+// target is not removed, so that existing references to it still resolve, but
+// it is reported with Yanked set so that consumers can warn about it.  The
+// target's other custom data is kept, and so must be a JSON object if present.
+func (r *repository) YankTarget(role, name string) error {
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	roleName := data.RoleName(role)
+	tgts, ok := r.tufRepo.Targets[roleName]
+	if !ok {
+		return ErrNoSuchTarget(name)
+	}
+	meta, ok := tgts.Signed.Targets[name]
+	if !ok {
+		return ErrNoSuchTarget(name)
+	}
+
+	fields := make(map[string]*canonicaljson.RawMessage)
+	if meta.Custom != nil {
+		if err := canonicaljson.Unmarshal(*meta.Custom, &fields); err != nil || fields == nil {
+			return ErrCannotYank{Name: name}
+		}
+	}
+	yanked := canonicaljson.RawMessage("true")
+	fields[yankedCustomKey] = &yanked
+	custom, err := canonicaljson.MarshalCanonical(fields)
+	if err != nil {
+		return err
+	}
+	rawCustom := canonicaljson.RawMessage(custom)
+
+	logrus.Debugf("Yanking target \"%s\" in %s", name, role)
+	target := targetFromMeta(name, meta)
+	target.Custom = &rawCustom
+	return r.AddTarget(&target, roleName)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A yanked target still resolves, with its hashes and other custom data
+// unchanged, but is reported as yanked
+func TestYankTarget(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	custom := canonicaljson.RawMessage(`{"owner":"releases"}`)
+	withCustom := addTargetWithCustom(t, repo, "v1", "../fixtures/intermediate-ca.crt", &custom)
+	plain := addTarget(t, repo, "v2", "../fixtures/intermediate-ca.crt")
+	addTarget(t, repo, "v3", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	target, err := repo.GetTargetByName("v1")
+	require.NoError(t, err)
+	require.False(t, target.Yanked)
+
+	require.NoError(t, repo.YankTarget(data.CanonicalTargetsRole.String(), "v1"))
+	require.NoError(t, repo.YankTarget(data.CanonicalTargetsRole.String(), "v2"))
+	require.Len(t, getChanges(t, repo), 2)
+	require.NoError(t, repo.Publish())
+
+	// the flag surfaces on another client too
+	repo2, _, dir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(dir)
+	for _, expected := range []*Target{withCustom, plain} {
+		target, err := repo2.GetTargetByName(expected.Name)
+		require.NoError(t, err)
+		require.True(t, target.Yanked)
+		require.Equal(t, expected.Hashes, target.Hashes)
+		require.Equal(t, expected.Length, target.Length)
+	}
+	target, err = repo2.GetTargetByName("v1")
+	require.NoError(t, err)
+	require.Equal(t, `{"owner":"releases","yanked":true}`, string(*target.Custom))
+
+	targets, err := repo2.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, targets, 3)
+	for _, target := range targets {
+		require.Equal(t, target.Name != "v3", target.Yanked, target.Name)
+	}
+}
+
+func TestYankTargetErrors(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	custom := canonicaljson.RawMessage(`"not an object"`)
+	addTargetWithCustom(t, repo, "scalar", "../fixtures/intermediate-ca.crt", &custom)
+	require.NoError(t, repo.Publish())
+
+	err := repo.YankTarget(data.CanonicalTargetsRole.String(), "scalar")
+	require.IsType(t, ErrCannotYank{}, err)
+
+	err = repo.YankTarget(data.CanonicalTargetsRole.String(), "missing")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+
+	err = repo.YankTarget("targets/missing", "scalar")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+	require.Empty(t, getChanges(t, repo))
+}
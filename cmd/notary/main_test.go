@@ -446,13 +446,15 @@ func TestConfigFileTrustPinning(t *testing.T) {
 	trustPin, err := getTrustPinning(config)
 	require.NoError(t, err)
 	require.Equal(t, false, trustPin.DisableTOFU)
+	require.Equal(t, false, trustPin.IgnoreRootCertExpiry)
 
 	tempDir = tempDirWithConfig(t, `{
 		"remote_server": {
 			"url": "%s"
 		},
 		"trust_pinning": {
-		    "disable_tofu": true
+		    "disable_tofu": true,
+		    "ignore_root_cert_expiry": true
 		}
 	}`)
 	defer os.RemoveAll(tempDir)
@@ -468,6 +470,7 @@ func TestConfigFileTrustPinning(t *testing.T) {
 	trustPin, err = getTrustPinning(config)
 	require.NoError(t, err)
 	require.Equal(t, true, trustPin.DisableTOFU)
+	require.Equal(t, true, trustPin.IgnoreRootCertExpiry)
 
 	tempDir = tempDirWithConfig(t, fmt.Sprintf(`{
 		"trust_pinning": {
@@ -527,6 +530,85 @@ func TestConfigFileTrustPinning(t *testing.T) {
 	trustPin, err = getTrustPinning(config)
 	require.NoError(t, err)
 	require.Equal(t, "root-ca.crt", trustPin.CA["repo4"])
+
+	tempDir = tempDirWithConfig(t, fmt.Sprintf(`{
+		"trust_pinning": {
+		    "snapshot_keys": {
+		        "repo5": ["%s"]
+		    }
+		 }
+	}`, strings.Repeat("y", notary.SHA256HexSize)))
+	defer os.RemoveAll(tempDir)
+	commander = &notaryCommander{
+		getRetriever: func() notary.PassRetriever { return passphrase.ConstantRetriever("pass") },
+		configFile:   filepath.Join(tempDir, "config.json"),
+	}
+
+	config, err = commander.parseConfig()
+	require.NoError(t, err)
+	trustPin, err = getTrustPinning(config)
+	require.NoError(t, err)
+	require.Equal(t, []string{strings.Repeat("y", notary.SHA256HexSize)}, trustPin.SnapshotKeys["repo5"])
+
+	tempDir = tempDirWithConfig(t, fmt.Sprintf(`{
+		"trust_pinning": {
+		    "timestamp_keys": {
+		        "repo6": ["%s"]
+		    }
+		 }
+	}`, strings.Repeat("z", notary.SHA256HexSize)))
+	defer os.RemoveAll(tempDir)
+	commander = &notaryCommander{
+		getRetriever: func() notary.PassRetriever { return passphrase.ConstantRetriever("pass") },
+		configFile:   filepath.Join(tempDir, "config.json"),
+	}
+
+	config, err = commander.parseConfig()
+	require.NoError(t, err)
+	trustPin, err = getTrustPinning(config)
+	require.NoError(t, err)
+	require.Equal(t, []string{strings.Repeat("z", notary.SHA256HexSize)}, trustPin.TimestampKeys["repo6"])
+
+	tempDir = tempDirWithConfig(t, fmt.Sprintf(`{
+		"trust_pinning": {
+		    "root_witnesses": {
+		        "repo7": 2
+		    },
+		    "root_witness_keys": {
+		        "repo7": ["%s"]
+		    }
+		 }
+	}`, strings.Repeat("w", notary.SHA256HexSize)))
+	defer os.RemoveAll(tempDir)
+	commander = &notaryCommander{
+		getRetriever: func() notary.PassRetriever { return passphrase.ConstantRetriever("pass") },
+		configFile:   filepath.Join(tempDir, "config.json"),
+	}
+
+	config, err = commander.parseConfig()
+	require.NoError(t, err)
+	trustPin, err = getTrustPinning(config)
+	require.NoError(t, err)
+	require.Equal(t, 2, trustPin.RootWitnesses["repo7"])
+	require.Equal(t, []string{strings.Repeat("w", notary.SHA256HexSize)}, trustPin.RootWitnessKeys["repo7"])
+
+	tempDir = tempDirWithConfig(t, `{
+		"trust_pinning": {
+		    "root_witnesses": {
+		        "repo8": 1.5
+		    }
+		 }
+	}`)
+	defer os.RemoveAll(tempDir)
+	commander = &notaryCommander{
+		getRetriever: func() notary.PassRetriever { return passphrase.ConstantRetriever("pass") },
+		configFile:   filepath.Join(tempDir, "config.json"),
+	}
+
+	config, err = commander.parseConfig()
+	require.NoError(t, err)
+	_, err = getTrustPinning(config)
+	require.Error(t, err)
 }
 
 // sets the env vars to empty, and returns a function to reset them at the end
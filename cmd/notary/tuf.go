@@ -983,30 +983,81 @@ func getRemoteTrustServer(config *viper.Viper) string {
 }
 
 func getTrustPinning(config *viper.Viper) (trustpinning.TrustPinConfig, error) {
+	// Need to parse out the sections of config which map GUNs to lists or numbers
+	resultCertMap, err := getKeyIDsByGUN(config, "trust_pinning.certs")
+	if err != nil {
+		return trustpinning.TrustPinConfig{}, err
+	}
+	resultSnapshotKeyMap, err := getKeyIDsByGUN(config, "trust_pinning.snapshot_keys")
+	if err != nil {
+		return trustpinning.TrustPinConfig{}, err
+	}
+	resultTimestampKeyMap, err := getKeyIDsByGUN(config, "trust_pinning.timestamp_keys")
+	if err != nil {
+		return trustpinning.TrustPinConfig{}, err
+	}
+	resultRootWitnessMap, err := getMinimumsByGUN(config, "trust_pinning.root_witnesses")
+	if err != nil {
+		return trustpinning.TrustPinConfig{}, err
+	}
+	resultRootWitnessKeyMap, err := getKeyIDsByGUN(config, "trust_pinning.root_witness_keys")
+	if err != nil {
+		return trustpinning.TrustPinConfig{}, err
+	}
+	return trustpinning.TrustPinConfig{
+		DisableTOFU:          config.GetBool("trust_pinning.disable_tofu"),
+		CA:                   config.GetStringMapString("trust_pinning.ca"),
+		Certs:                resultCertMap,
+		IgnoreRootCertExpiry: config.GetBool("trust_pinning.ignore_root_cert_expiry"),
+		SnapshotKeys:         resultSnapshotKeyMap,
+		TimestampKeys:        resultTimestampKeyMap,
+		RootWitnesses:        resultRootWitnessMap,
+		RootWitnessKeys:      resultRootWitnessKeyMap,
+	}, nil
+}
+
+// getMinimumsByGUN parses a config section which maps GUNs to whole numbers
+func getMinimumsByGUN(config *viper.Viper, section string) (map[string]int, error) {
+	resultMap := make(map[string]int)
+	for gun, value := range config.GetStringMap(section) {
+		switch number := value.(type) {
+		case int:
+			resultMap[gun] = number
+		case int64:
+			resultMap[gun] = int(number)
+		case float64:
+			if number != float64(int(number)) {
+				return nil, fmt.Errorf("invalid format for %s", section)
+			}
+			resultMap[gun] = int(number)
+		default:
+			return nil, fmt.Errorf("invalid format for %s", section)
+		}
+	}
+	return resultMap, nil
+}
+
+// getKeyIDsByGUN parses a config section which maps GUNs to lists of key IDs
+func getKeyIDsByGUN(config *viper.Viper, section string) (map[string][]string, error) {
 	var ok bool
-	// Need to parse out Certs section from config
-	certMap := config.GetStringMap("trust_pinning.certs")
-	resultCertMap := make(map[string][]string)
-	for gun, certSlice := range certMap {
-		var castedCertSlice []interface{}
-		if castedCertSlice, ok = certSlice.([]interface{}); !ok {
-			return trustpinning.TrustPinConfig{}, fmt.Errorf("invalid format for trust_pinning.certs")
+	idMap := config.GetStringMap(section)
+	resultIDMap := make(map[string][]string)
+	for gun, idSlice := range idMap {
+		var castedIDSlice []interface{}
+		if castedIDSlice, ok = idSlice.([]interface{}); !ok {
+			return nil, fmt.Errorf("invalid format for %s", section)
 		}
-		certsForGun := make([]string, len(castedCertSlice))
-		for idx, certIDInterface := range castedCertSlice {
-			if certID, ok := certIDInterface.(string); ok {
-				certsForGun[idx] = certID
+		idsForGun := make([]string, len(castedIDSlice))
+		for idx, idInterface := range castedIDSlice {
+			if id, ok := idInterface.(string); ok {
+				idsForGun[idx] = id
 			} else {
-				return trustpinning.TrustPinConfig{}, fmt.Errorf("invalid format for trust_pinning.certs")
+				return nil, fmt.Errorf("invalid format for %s", section)
 			}
 		}
-		resultCertMap[gun] = certsForGun
+		resultIDMap[gun] = idsForGun
 	}
-	return trustpinning.TrustPinConfig{
-		DisableTOFU: config.GetBool("trust_pinning.disable_tofu"),
-		CA:          config.GetStringMapString("trust_pinning.ca"),
-		Certs:       resultCertMap,
-	}, nil
+	return resultIDMap, nil
 }
 
 // authRoundTripper tries to authenticate the requests via multiple HTTP transactions (until first succeed)
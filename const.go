@@ -75,6 +75,21 @@ const (
 
 	// DefaultPageSize is the default number of records to return from the changefeed
 	DefaultPageSize = 100
+
+	// DiffFromQueryParam is the query parameter a client uses to ask the server
+	// for a metadata file as a delta against the version with the given checksum
+	DiffFromQueryParam = "diff_from"
+
+	// NotaryServerAPIVersionHeader is the header in which a notary server
+	// advertises the version of its API, in response to a request for "/v2/"
+	NotaryServerAPIVersionHeader = "Notary-Server-Api-Version"
+	// NotaryServerAPIVersion is the version of the notary server API implemented
+	// by this server and spoken by this client
+	NotaryServerAPIVersion = "2"
+
+	// IdempotencyKeyHeader is the header in which a client sends the key of a
+	// logical publish, which is the same for every retry of that publish
+	IdempotencyKeyHeader = "Idempotency-Key"
 )
 
 // enum to use for setting and retrieving values from contexts
@@ -69,6 +69,35 @@ func (cs *CryptoService) GetPrivateKey(keyID string) (k data.PrivateKey, role da
 	return // returns whatever the final values were
 }
 
+// roleScopedKeyStore is implemented by key stores which can ask for the
+// passphrase of a key with the alias of the role it is retrieved to sign
+type roleScopedKeyStore interface {
+	GetKeyForRole(keyID string, signingRole data.RoleName) (data.PrivateKey, data.RoleName, error)
+}
+
+// GetPrivateKeyForRole is like GetPrivateKey, but a key store which supports it
+// asks for the passphrase of a key imported without a role with the alias of
+// signingRole, rather than the generic alias of imported keys
+func (cs *CryptoService) GetPrivateKeyForRole(keyID string, signingRole data.RoleName) (k data.PrivateKey, role data.RoleName, err error) {
+	for _, ks := range cs.keyStores {
+		if scoped, ok := ks.(roleScopedKeyStore); ok {
+			k, role, err = scoped.GetKeyForRole(keyID, signingRole)
+		} else {
+			k, role, err = ks.GetKey(keyID)
+		}
+		if err == nil {
+			return
+		}
+		switch err.(type) {
+		case trustmanager.ErrPasswordInvalid, trustmanager.ErrAttemptsExceeded:
+			return
+		default:
+			continue
+		}
+	}
+	return // returns whatever the final values were
+}
+
 // GetKey returns a key by ID
 func (cs *CryptoService) GetKey(keyID string) data.PublicKey {
 	privKey, _, err := cs.GetPrivateKey(keyID)
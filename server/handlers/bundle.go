@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	ctxu "github.com/docker/distribution/context"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/server/errors"
+	"github.com/theupdateframework/notary/server/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// GetTrustBundleHandler returns a trust bundle holding the current root of a
+// GUN, which clients can use to bootstrap trust in the GUN against the
+// certificates they have pinned for it
+func GetTrustBundleHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+	vars := mux.Vars(r)
+	return getTrustBundleHandler(ctx, w, r, vars)
+}
+
+func getTrustBundleHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	gun := data.GUN(vars["gun"])
+	logger := ctxu.GetLoggerWithField(ctx, gun, "gun")
+
+	store, ok := ctx.Value(notary.CtxKeyMetaStore).(storage.MetaStore)
+	if !ok {
+		logger.Error("500 GET trust bundle: no storage exists")
+		return errors.ErrNoStorage.WithDetail(nil)
+	}
+	_, rootJSON, err := getRole(ctx, store, gun, data.CanonicalRootRole, "", "")
+	if err != nil {
+		logger.Info("404 GET trust bundle")
+		return err
+	}
+
+	signed := &data.Signed{}
+	if err := json.Unmarshal(rootJSON, signed); err != nil {
+		logger.Errorf("500 GET trust bundle: could not parse the root: %s", err)
+		return errors.ErrUnknown.WithDetail(err)
+	}
+	root, err := data.RootFromSigned(signed)
+	if err != nil {
+		logger.Errorf("500 GET trust bundle: invalid root: %s", err)
+		return errors.ErrUnknown.WithDetail(err)
+	}
+	rootRole, ok := root.Signed.Roles[data.CanonicalRootRole]
+	if !ok {
+		logger.Error("500 GET trust bundle: root has no root role")
+		return errors.ErrUnknown.WithDetail(nil)
+	}
+
+	out, err := json.Marshal(data.TrustBundle{GUN: gun, Root: rootJSON, CertIDs: rootRole.KeyIDs})
+	if err != nil {
+		logger.Errorf("500 GET trust bundle: could not marshal the bundle: %s", err)
+		return errors.ErrUnknown.WithDetail(err)
+	}
+	w.Write(out)
+	return nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/server/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+func TestGetTrustBundleHandler(t *testing.T) {
+	metaStore := storage.NewMemStorage()
+	repo, _, err := testutils.EmptyRepo("gun")
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), notary.CtxKeyMetaStore, metaStore)
+	req := &http.Request{Body: ioutil.NopCloser(bytes.NewBuffer(nil))}
+	vars := map[string]string{"gun": "gun"}
+
+	// there is no root yet
+	err = getTrustBundleHandler(ctx, httptest.NewRecorder(), req, vars)
+	require.Error(t, err)
+
+	root, err := repo.SignRoot(data.DefaultExpires("root"), nil)
+	require.NoError(t, err)
+	rootJSON, err := json.Marshal(root)
+	require.NoError(t, err)
+	require.NoError(t, metaStore.UpdateCurrent("gun", storage.MetaUpdate{Role: "root", Version: 1, Data: rootJSON}))
+
+	rw := httptest.NewRecorder()
+	require.NoError(t, getTrustBundleHandler(ctx, rw, req, vars))
+
+	var bundle data.TrustBundle
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &bundle))
+	require.Equal(t, data.GUN("gun"), bundle.GUN)
+	require.Equal(t, rootJSON, bundle.Root)
+	require.Equal(t, repo.Root.Signed.Roles[data.CanonicalRootRole].KeyIDs, bundle.CertIDs)
+}
+
+func TestGetTrustBundleHandlerNoStorage(t *testing.T) {
+	req := &http.Request{Body: ioutil.NopCloser(bytes.NewBuffer(nil))}
+	err := GetTrustBundleHandler(context.Background(), nil, req)
+	require.Error(t, err)
+}
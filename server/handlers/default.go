@@ -32,6 +32,7 @@ func MainHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) er
 		return errors.ErrGenericNotFound.WithDetail(nil)
 	}
 
+	w.Header().Set(notary.NotaryServerAPIVersionHeader, notary.NotaryServerAPIVersion)
 	if _, err := w.Write([]byte("{}")); err != nil {
 		return errors.ErrUnknown.WithDetail(err)
 	}
@@ -167,6 +168,15 @@ func getHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, var
 		logger.Infof("404 GET %s role", tufRole)
 		return err
 	}
+	if r.URL != nil {
+		if base := r.URL.Query().Get(notary.DiffFromQueryParam); base != "" {
+			output, err = getRoleDelta(store, gun, data.RoleName(tufRole), base, output)
+			if err != nil {
+				logger.Infof("404 GET %s role delta from %s", tufRole, base)
+				return err
+			}
+		}
+	}
 	if lastModified != nil {
 		// This shouldn't always be true, but in case it is nil, and the last modified headers
 		// are not set, the cache control handler should set the last modified date to the beginning
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,6 +26,7 @@ import (
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/signed"
 	"github.com/theupdateframework/notary/tuf/testutils"
+	tufutils "github.com/theupdateframework/notary/tuf/utils"
 	"github.com/theupdateframework/notary/tuf/validation"
 	"github.com/theupdateframework/notary/utils"
 )
@@ -55,10 +60,11 @@ func TestMainHandlerGet(t *testing.T) {
 	ts := httptest.NewServer(handler)
 	defer ts.Close()
 
-	_, err := http.Get(ts.URL)
+	res, err := http.Get(ts.URL)
 	if err != nil {
 		t.Fatalf("Received error on GET /: %s", err.Error())
 	}
+	require.Equal(t, notary.NotaryServerAPIVersion, res.Header.Get(notary.NotaryServerAPIVersionHeader))
 }
 
 func TestMainHandlerNotGet(t *testing.T) {
@@ -322,6 +328,62 @@ func TestGetHandlerSnapshot(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestGetHandlerDelta(t *testing.T) {
+	metaStore := storage.NewMemStorage()
+	repo, crypto, err := testutils.EmptyRepo("gun")
+	require.NoError(t, err)
+
+	ctx := getContext(handlerState{store: metaStore, crypto: crypto})
+
+	var versions [][]byte
+	for i := 1; i <= 2; i++ {
+		_, err := repo.AddTargets(data.CanonicalTargetsRole,
+			data.Files{fmt.Sprintf("target%d", i): data.FileMeta{Length: 1, Hashes: data.Hashes{"sha256": []byte("abc")}}})
+		require.NoError(t, err)
+		tgs, err := repo.SignTargets(data.CanonicalTargetsRole, data.DefaultExpires("targets"))
+		require.NoError(t, err)
+		tgsJSON, err := json.Marshal(tgs)
+		require.NoError(t, err)
+		require.NoError(t, metaStore.UpdateCurrent(
+			"gun", storage.MetaUpdate{Role: data.CanonicalTargetsRole, Version: i, Data: tgsJSON}))
+		versions = append(versions, tgsJSON)
+	}
+	baseChecksum := sha256.Sum256(versions[0])
+
+	vars := map[string]string{
+		"gun":     "gun",
+		"tufRole": "targets",
+	}
+	getDelta := func(role string, base string) (*httptest.ResponseRecorder, error) {
+		vars["tufRole"] = role
+		req := &http.Request{
+			Body: ioutil.NopCloser(bytes.NewBuffer(nil)),
+			URL:  &url.URL{RawQuery: url.Values{notary.DiffFromQueryParam: []string{base}}.Encode()},
+		}
+		rw := httptest.NewRecorder()
+		return rw, getHandler(ctx, rw, req, vars)
+	}
+
+	rw, err := getDelta("targets", hex.EncodeToString(baseChecksum[:]))
+	require.NoError(t, err)
+	delta := &tufutils.Delta{}
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), delta))
+	reconstructed, err := tufutils.ApplyDelta(versions[0], delta)
+	require.NoError(t, err)
+	require.Equal(t, versions[1], reconstructed)
+
+	// unknown base
+	_, err = getDelta("targets", strings.Repeat("0", notary.SHA256HexSize))
+	require.Error(t, err)
+	errorCode, ok := err.(errcode.Error)
+	require.True(t, ok)
+	require.Equal(t, errors.ErrMetadataNotFound, errorCode.Code)
+
+	// deltas are not served for non-targets roles
+	_, err = getDelta("root", hex.EncodeToString(baseChecksum[:]))
+	require.Error(t, err)
+}
+
 func TestGetHandler404(t *testing.T) {
 	metaStore := storage.NewMemStorage()
 
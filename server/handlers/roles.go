@@ -16,6 +16,7 @@ import (
 	"github.com/theupdateframework/notary/server/timestamp"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/utils"
 )
 
 func getRole(ctx context.Context, store storage.MetaStore, gun data.GUN, role data.RoleName, checksum, version string) (*time.Time, []byte, error) {
@@ -56,6 +57,29 @@ func getRole(ctx context.Context, store storage.MetaStore, gun data.GUN, role da
 	return lastModified, out, nil
 }
 
+// getRoleDelta returns a serialized delta which transforms the version of the
+// role with the given base checksum into the provided metadata.  Deltas are
+// only served for targets and delegation roles, since they are the only roles
+// which may grow large enough for deltas to be worthwhile.
+func getRoleDelta(store storage.MetaStore, gun data.GUN, role data.RoleName, baseChecksum string, current []byte) ([]byte, error) {
+	if role != data.CanonicalTargetsRole && !data.IsDelegation(role) {
+		return nil, errors.ErrMetadataNotFound.WithDetail(
+			fmt.Sprintf("deltas are not served for %s", role))
+	}
+	_, base, err := store.GetChecksum(gun, role, baseChecksum)
+	if err != nil {
+		if _, ok := err.(storage.ErrNotFound); ok {
+			return nil, errors.ErrMetadataNotFound.WithDetail(err)
+		}
+		return nil, errors.ErrUnknown.WithDetail(err)
+	}
+	out, err := json.Marshal(utils.ComputeDelta(base, current))
+	if err != nil {
+		return nil, errors.ErrUnknown.WithDetail(err)
+	}
+	return out, nil
+}
+
 // getMaybeServerSigned writes the current snapshot or timestamp (based on the
 // role passed) to the provided writer or returns an error. In retrieving
 // the timestamp and snapshot, based on the keys held by the server, a new one
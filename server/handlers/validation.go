@@ -92,6 +92,22 @@ func validateUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.Met
 
 	// generate a timestamp immediately
 	update, err := generateTimestamp(gun, builder, store)
+	if timestampUpdate, ok := roles[data.CanonicalTimestampRole]; ok && err != nil {
+		if _, noKeys := err.(validation.ErrBadRoot); noKeys {
+			// the server does not hold the timestamp key, so the repository
+			// is one whose owner signs its own timestamps, each of which must
+			// be newer than the one already stored
+			currentVersion, err := currentTimestampVersion(gun, store)
+			if err != nil {
+				return nil, err
+			}
+			if err := builder.Load(data.CanonicalTimestampRole, timestampUpdate.Data, currentVersion+1, false); err != nil {
+				return nil, validation.ErrBadTimestamp{Msg: err.Error()}
+			}
+			logrus.Debug("Successfully validated timestamp")
+			return append(updatesToApply, timestampUpdate), nil
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -223,6 +239,25 @@ func generateTimestamp(gun data.GUN, builder tuf.RepoBuilder, store storage.Meta
 	}
 }
 
+// currentTimestampVersion returns the version of the timestamp in the store,
+// or 0 if there is none yet
+func currentTimestampVersion(gun data.GUN, store storage.MetaStore) (int, error) {
+	_, currentJSON, err := store.GetCurrent(gun, data.CanonicalTimestampRole)
+	switch err.(type) {
+	case nil:
+	case storage.ErrNotFound:
+		return 0, nil
+	default:
+		return 0, err
+	}
+	var current data.SignedMeta
+	if err := json.Unmarshal(currentJSON, &current); err != nil {
+		logrus.Error("Failed to unmarshal existing timestamp for GUN ", gun)
+		return 0, err
+	}
+	return current.Signed.Version, nil
+}
+
 func loadFromStore(gun data.GUN, roleName data.RoleName, builder tuf.RepoBuilder, store storage.MetaStore) error {
 	_, metaJSON, err := store.GetCurrent(gun, roleName)
 	if err != nil {
@@ -115,6 +115,56 @@ func TestValidateEmptyNew(t *testing.T) {
 	require.Len(t, founds, 4)
 }
 
+// If the server does not hold the timestamp key, the timestamp in the update,
+// signed by the repository's owner, is used
+func TestValidateClientSignedTimestamp(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	repo, _, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	store := storage.NewMemStorage()
+
+	r, tg, sn, ts, err := testutils.Sign(repo)
+	require.NoError(t, err)
+	root, targets, snapshot, timestamp, err := getUpdates(r, tg, sn, ts)
+	require.NoError(t, err)
+
+	updates := []storage.MetaUpdate{root, targets, snapshot, timestamp}
+	updates, err = validateUpdate(signed.NewEd25519(), gun, updates, store)
+	require.NoError(t, err)
+	require.Len(t, updates, 4)
+	for _, update := range updates {
+		if update.Role == data.CanonicalTimestampRole {
+			require.True(t, bytes.Equal(update.Data, timestamp.Data))
+		}
+	}
+
+	// once stored, a timestamp with the same version is rejected as a replay
+	require.NoError(t, store.UpdateMany(gun, updates))
+	updates = []storage.MetaUpdate{targets, snapshot, timestamp}
+	_, err = validateUpdate(signed.NewEd25519(), gun, updates, store)
+	require.Error(t, err)
+	require.IsType(t, validation.ErrBadTimestamp{}, err)
+
+	// but one with a higher version is accepted
+	repo.Timestamp.Signed.Version++
+	r, tg, sn, ts, err = testutils.Sign(repo)
+	require.NoError(t, err)
+	newRoot, newTargets, newSnapshot, newTimestamp, err := getUpdates(r, tg, sn, ts)
+	require.NoError(t, err)
+	updates = []storage.MetaUpdate{newRoot, newTargets, newSnapshot, newTimestamp}
+	updates, err = validateUpdate(signed.NewEd25519(), gun, updates, store)
+	require.NoError(t, err)
+	require.Len(t, updates, 4)
+
+	// a corrupt timestamp is rejected
+	newTimestamp.Data = append([]byte{}, newTimestamp.Data...)
+	newTimestamp.Data[0] = newTimestamp.Data[0] ^ 0xff
+	updates = []storage.MetaUpdate{newRoot, newTargets, newSnapshot, newTimestamp}
+	_, err = validateUpdate(signed.NewEd25519(), gun, updates, storage.NewMemStorage())
+	require.Error(t, err)
+	require.IsType(t, validation.ErrBadTimestamp{}, err)
+}
+
 func TestValidateRootCanContainOnlyx509KeysWithRightGun(t *testing.T) {
 	var gun data.GUN = "docker.com/notary"
 	repo, cs, err := testutils.EmptyRepo("wrong/gun")
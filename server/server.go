@@ -179,6 +179,16 @@ func RootHandler(ctx context.Context, ac auth.AccessController, trust signed.Cry
 		authWrapper,
 		repoPrefixes,
 	))
+	r.Methods("GET").Path("/v2/{gun:[^*]+}/_trust/bundle.json").Handler(CreateHandler(
+		"GetTrustBundle",
+		handlers.GetTrustBundleHandler,
+		notFoundError,
+		true,
+		current,
+		[]string{"pull"},
+		authWrapper,
+		repoPrefixes,
+	))
 	r.Methods("GET").Path(
 		"/v2/{gun:[^*]+}/_trust/tuf/{tufRole:snapshot|timestamp}.key").Handler(CreateHandler(
 		"GetKey",
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// ErrMetadataDecryption is returned when encrypted metadata cannot be
+// decrypted, either because it was encrypted with a different key, or because
+// it has been modified
+type ErrMetadataDecryption struct {
+	Resource string
+}
+
+func (err ErrMetadataDecryption) Error() string {
+	return fmt.Sprintf("could not decrypt %s: wrong key or corrupted data", err.Resource)
+}
+
+// EncryptedStore is a MetadataStore which encrypts metadata with AES-GCM before
+// writing it to an underlying MetadataStore, and decrypts it when reading, so
+// that the metadata is encrypted at rest.  Each write uses a new random nonce,
+// which is stored ahead of the ciphertext.  The name of the metadata is
+// authenticated along with it, so that encrypted metadata cannot be swapped
+// between names undetected.
+type EncryptedStore struct {
+	inner MetadataStore
+	aead  cipher.AEAD
+}
+
+var _ MetadataStore = &EncryptedStore{}
+
+// NewEncryptedStore returns an EncryptedStore which stores its metadata in
+// inner, encrypted with key.  The key must be 16, 24 or 32 bytes long, to use
+// AES-128, AES-192 or AES-256 respectively.
+func NewEncryptedStore(inner MetadataStore, key []byte) (*EncryptedStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{inner: inner, aead: aead}, nil
+}
+
+// overhead is how many more bytes encrypted metadata takes than its plaintext
+func (s *EncryptedStore) overhead() int64 {
+	return int64(s.aead.NonceSize() + s.aead.Overhead())
+}
+
+func (s *EncryptedStore) encrypt(name string, blob []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(blob)+s.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, blob, []byte(name)), nil
+}
+
+func (s *EncryptedStore) decrypt(name string, encrypted []byte) ([]byte, error) {
+	if len(encrypted) < s.aead.NonceSize() {
+		return nil, ErrMetadataDecryption{Resource: name}
+	}
+	nonce, ciphertext := encrypted[:s.aead.NonceSize()], encrypted[s.aead.NonceSize():]
+	blob, err := s.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, ErrMetadataDecryption{Resource: name}
+	}
+	return blob, nil
+}
+
+// GetSized reads and decrypts the named metadata.  The size applies to the
+// decrypted metadata, and if it is exceeded an error is returned.
+func (s *EncryptedStore) GetSized(name string, size int64) ([]byte, error) {
+	innerSize := size
+	if size != NoSizeLimit {
+		innerSize = size + s.overhead()
+	}
+	encrypted, err := s.inner.GetSized(name, innerSize)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := s.decrypt(name, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if size != NoSizeLimit && int64(len(blob)) > size {
+		return nil, ErrMaliciousServer{}
+	}
+	return blob, nil
+}
+
+// Set encrypts and stores the named metadata
+func (s *EncryptedStore) Set(name string, blob []byte) error {
+	encrypted, err := s.encrypt(name, blob)
+	if err != nil {
+		return err
+	}
+	return s.inner.Set(name, encrypted)
+}
+
+// SetMulti encrypts and stores all the provided metadata
+func (s *EncryptedStore) SetMulti(metas map[string][]byte) error {
+	encrypted := make(map[string][]byte, len(metas))
+	for name, blob := range metas {
+		var err error
+		if encrypted[name], err = s.encrypt(name, blob); err != nil {
+			return err
+		}
+	}
+	return s.inner.SetMulti(encrypted)
+}
+
+// Remove deletes the named metadata
+func (s *EncryptedStore) Remove(name string) error {
+	return s.inner.Remove(name)
+}
+
+// RemoveAll deletes all the metadata
+func (s *EncryptedStore) RemoveAll() error {
+	return s.inner.RemoveAll()
+}
+
+// ListFiles returns the names of the stored metadata, if the underlying store
+// is able to list them
+func (s *EncryptedStore) ListFiles() []string {
+	if lister, ok := s.inner.(interface {
+		ListFiles() []string
+	}); ok {
+		return lister.ListFiles()
+	}
+	return nil
+}
+
+// Location returns a human readable name for the storage location
+func (s *EncryptedStore) Location() string {
+	return fmt.Sprintf("encrypted %s", s.inner.Location())
+}
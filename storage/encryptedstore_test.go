@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testEncryptionKey = bytes.Repeat([]byte{0x42}, 32)
+
+func TestEncryptedStoreRoundTrip(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "notary-encrypted-store")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	fileStore, err := NewFileStore(tempDir, "json")
+	require.NoError(t, err)
+
+	for _, inner := range []MetadataStore{NewMemoryStore(nil), fileStore} {
+		s, err := NewEncryptedStore(inner, testEncryptionKey)
+		require.NoError(t, err)
+
+		content := []byte(`{"signed": {"_type": "Targets"}}`)
+		require.NoError(t, s.Set("targets", content))
+		require.NoError(t, s.SetMulti(map[string][]byte{"root": content, "snapshot": content}))
+
+		for _, name := range []string{"targets", "root", "snapshot"} {
+			got, err := s.GetSized(name, int64(len(content)))
+			require.NoError(t, err)
+			require.Equal(t, content, got)
+
+			got, err = s.GetSized(name, NoSizeLimit)
+			require.NoError(t, err)
+			require.Equal(t, content, got)
+
+			// the size limit applies to the decrypted metadata
+			_, err = s.GetSized(name, int64(len(content))-1)
+			require.Error(t, err)
+
+			// the underlying store only has the encrypted metadata
+			encrypted, err := inner.GetSized(name, NoSizeLimit)
+			require.NoError(t, err)
+			require.False(t, bytes.Contains(encrypted, content))
+		}
+
+		require.NoError(t, s.Remove("targets"))
+		_, err = s.GetSized("targets", NoSizeLimit)
+		require.IsType(t, ErrMetaNotFound{}, err)
+		require.NoError(t, s.RemoveAll())
+		_, err = s.GetSized("root", NoSizeLimit)
+		require.IsType(t, ErrMetaNotFound{}, err)
+	}
+}
+
+// Each write uses a fresh nonce, so the same metadata never encrypts to the
+// same bytes
+func TestEncryptedStoreUsesNewNonces(t *testing.T) {
+	inner := NewMemoryStore(nil)
+	s, err := NewEncryptedStore(inner, testEncryptionKey)
+	require.NoError(t, err)
+
+	content := []byte("content")
+	require.NoError(t, s.Set("first", content))
+	require.NoError(t, s.Set("second", content))
+	first, err := inner.GetSized("first", NoSizeLimit)
+	require.NoError(t, err)
+	second, err := inner.GetSized("second", NoSizeLimit)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+}
+
+func TestEncryptedStoreRejectsWrongKeyAndTampering(t *testing.T) {
+	inner := NewMemoryStore(nil)
+	s, err := NewEncryptedStore(inner, testEncryptionKey)
+	require.NoError(t, err)
+	require.NoError(t, s.Set("root", []byte("content")))
+
+	wrongKey, err := NewEncryptedStore(inner, bytes.Repeat([]byte{0x24}, 32))
+	require.NoError(t, err)
+	_, err = wrongKey.GetSized("root", NoSizeLimit)
+	require.Error(t, err)
+	require.IsType(t, ErrMetadataDecryption{}, err)
+
+	encrypted, err := inner.GetSized("root", NoSizeLimit)
+	require.NoError(t, err)
+
+	// metadata moved to another name does not decrypt
+	require.NoError(t, inner.Set("targets", encrypted))
+	_, err = s.GetSized("targets", NoSizeLimit)
+	require.IsType(t, ErrMetadataDecryption{}, err)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 0x01
+	require.NoError(t, inner.Set("root", tampered))
+	_, err = s.GetSized("root", NoSizeLimit)
+	require.IsType(t, ErrMetadataDecryption{}, err)
+
+	require.NoError(t, inner.Set("root", encrypted[:4]))
+	_, err = s.GetSized("root", NoSizeLimit)
+	require.IsType(t, ErrMetadataDecryption{}, err)
+}
+
+func TestNewEncryptedStoreInvalidKey(t *testing.T) {
+	_, err := NewEncryptedStore(NewMemoryStore(nil), []byte("too short"))
+	require.Error(t, err)
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	google_protobuf "github.com/golang/protobuf/ptypes/empty"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/storage/rpcstore"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// DefaultGRPCTimeout is the time a request to a GRPCStore will block waiting
+// for a response from the server
+const DefaultGRPCTimeout = time.Second * 30
+
+// GRPCStore is a MetadataStore which reads and writes the TUF metadata of a
+// single GUN by calling a notary metadata gRPC service
+type GRPCStore struct {
+	client  rpcstore.MetadataStoreClient
+	gun     data.GUN
+	timeout time.Duration
+}
+
+var _ MetadataStore = &GRPCStore{}
+
+// NewGRPCStore returns a GRPCStore for the given GUN which makes its requests
+// over the provided connection
+func NewGRPCStore(conn *grpc.ClientConn, gun data.GUN) *GRPCStore {
+	return &GRPCStore{
+		client:  rpcstore.NewMetadataStoreClient(conn),
+		gun:     gun,
+		timeout: DefaultGRPCTimeout,
+	}
+}
+
+func (s *GRPCStore) getContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.timeout)
+}
+
+// translateGRPCError converts the status of a failed gRPC request into the
+// equivalent storage error
+func translateGRPCError(err error, resource string) error {
+	switch grpc.Code(err) {
+	case codes.NotFound:
+		return ErrMetaNotFound{Resource: resource}
+	case codes.InvalidArgument:
+		return ErrInvalidOperation{msg: grpc.ErrorDesc(err)}
+	default:
+		return NetworkError{Wrapped: err}
+	}
+}
+
+// GetSized retrieves the named metadata from the server.  If size is
+// "NoSizeLimit", this corresponds to "infinite," but we cut off at a
+// predefined threshold "notary.MaxDownloadSize".
+func (s *GRPCStore) GetSized(name string, size int64) ([]byte, error) {
+	if size == NoSizeLimit {
+		size = notary.MaxDownloadSize
+	}
+	ctx, cancel := s.getContext()
+	defer cancel()
+	msg, err := s.client.Get(ctx, &rpcstore.GetMsg{GUN: s.gun.String(), Name: name, Size: size})
+	if err != nil {
+		return nil, translateGRPCError(err, name)
+	}
+	if int64(len(msg.Data)) > size {
+		return nil, ErrMaliciousServer{}
+	}
+	return msg.Data, nil
+}
+
+// Set stores the named metadata on the server
+func (s *GRPCStore) Set(name string, blob []byte) error {
+	ctx, cancel := s.getContext()
+	defer cancel()
+	_, err := s.client.Set(ctx, &rpcstore.SetMsg{GUN: s.gun.String(), Name: name, Data: blob})
+	if err != nil {
+		return translateGRPCError(err, name)
+	}
+	return nil
+}
+
+// SetMulti stores all the provided metadata on the server in a single request
+func (s *GRPCStore) SetMulti(metas map[string][]byte) error {
+	msg := &rpcstore.SetMultiMsg{}
+	for name, blob := range metas {
+		msg.Metas = append(msg.Metas, &rpcstore.SetMsg{GUN: s.gun.String(), Name: name, Data: blob})
+	}
+	ctx, cancel := s.getContext()
+	defer cancel()
+	if _, err := s.client.SetMulti(ctx, msg); err != nil {
+		return translateGRPCError(err, s.gun.String())
+	}
+	return nil
+}
+
+// Remove deletes the named metadata from the server
+func (s *GRPCStore) Remove(name string) error {
+	ctx, cancel := s.getContext()
+	defer cancel()
+	if _, err := s.client.Remove(ctx, &rpcstore.NameMsg{GUN: s.gun.String(), Name: name}); err != nil {
+		return translateGRPCError(err, name)
+	}
+	return nil
+}
+
+// RemoveAll deletes all the metadata for the GUN from the server
+func (s *GRPCStore) RemoveAll() error {
+	ctx, cancel := s.getContext()
+	defer cancel()
+	if _, err := s.client.RemoveAll(ctx, &rpcstore.GUNMsg{GUN: s.gun.String()}); err != nil {
+		return translateGRPCError(err, s.gun.String())
+	}
+	return nil
+}
+
+// Location returns a human readable name for the storage location
+func (s *GRPCStore) Location() string {
+	return fmt.Sprintf("gRPC metadata store for %s", s.gun)
+}
+
+// GRPCMetadataServer is an implementer of the notary metadata gRPC service.
+// It passes through the requested operations to an underlying MetadataStore
+// holding the metadata of a single GUN, translating between the Go and gRPC
+// interfaces.
+type GRPCMetadataServer struct {
+	gun     data.GUN
+	backend MetadataStore
+}
+
+var _ rpcstore.MetadataStoreServer = &GRPCMetadataServer{}
+
+// NewGRPCMetadataServer instantiates a new gRPC metadata server serving the
+// metadata of the given GUN from the provided backend
+func NewGRPCMetadataServer(gun data.GUN, backend MetadataStore) *GRPCMetadataServer {
+	return &GRPCMetadataServer{gun: gun, backend: backend}
+}
+
+// checkGUN rejects requests for any GUN other than the one being served, as
+// though the metadata does not exist
+func (s *GRPCMetadataServer) checkGUN(gun string) error {
+	if data.GUN(gun) != s.gun {
+		return grpc.Errorf(codes.NotFound, "no trust data for %s", gun)
+	}
+	return nil
+}
+
+// translateToGRPCError converts a storage error into a gRPC status
+func translateToGRPCError(err error) error {
+	switch err.(type) {
+	case ErrMetaNotFound:
+		return grpc.Errorf(codes.NotFound, "%s", err.Error())
+	default:
+		return grpc.Errorf(codes.Internal, "%s", err.Error())
+	}
+}
+
+// Get returns the requested metadata
+func (s *GRPCMetadataServer) Get(ctx context.Context, msg *rpcstore.GetMsg) (*rpcstore.MetadataMsg, error) {
+	if err := s.checkGUN(msg.GUN); err != nil {
+		return nil, err
+	}
+	meta, err := s.backend.GetSized(msg.Name, msg.Size)
+	if err != nil {
+		return nil, translateToGRPCError(err)
+	}
+	return &rpcstore.MetadataMsg{Data: meta}, nil
+}
+
+// Set stores the provided metadata
+func (s *GRPCMetadataServer) Set(ctx context.Context, msg *rpcstore.SetMsg) (*google_protobuf.Empty, error) {
+	if err := s.checkGUN(msg.GUN); err != nil {
+		return nil, err
+	}
+	logrus.Debugf("storing: %s", msg.Name)
+	if err := s.backend.Set(msg.Name, msg.Data); err != nil {
+		return nil, translateToGRPCError(err)
+	}
+	return &google_protobuf.Empty{}, nil
+}
+
+// SetMulti stores all the provided metadata
+func (s *GRPCMetadataServer) SetMulti(ctx context.Context, msg *rpcstore.SetMultiMsg) (*google_protobuf.Empty, error) {
+	metas := make(map[string][]byte, len(msg.Metas))
+	for _, meta := range msg.Metas {
+		if err := s.checkGUN(meta.GUN); err != nil {
+			return nil, err
+		}
+		metas[meta.Name] = meta.Data
+	}
+	if err := s.backend.SetMulti(metas); err != nil {
+		return nil, translateToGRPCError(err)
+	}
+	return &google_protobuf.Empty{}, nil
+}
+
+// Remove deletes the named metadata
+func (s *GRPCMetadataServer) Remove(ctx context.Context, msg *rpcstore.NameMsg) (*google_protobuf.Empty, error) {
+	if err := s.checkGUN(msg.GUN); err != nil {
+		return nil, err
+	}
+	if err := s.backend.Remove(msg.Name); err != nil {
+		return nil, translateToGRPCError(err)
+	}
+	return &google_protobuf.Empty{}, nil
+}
+
+// RemoveAll deletes all the metadata of the GUN
+func (s *GRPCMetadataServer) RemoveAll(ctx context.Context, msg *rpcstore.GUNMsg) (*google_protobuf.Empty, error) {
+	if err := s.checkGUN(msg.GUN); err != nil {
+		return nil, err
+	}
+	if err := s.backend.RemoveAll(); err != nil {
+		return nil, translateToGRPCError(err)
+	}
+	return &google_protobuf.Empty{}, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/theupdateframework/notary/storage/rpcstore"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// setupGRPCStore starts an in-process gRPC server serving the metadata of
+// "docker.com/notary" from backend, and returns a GRPCStore for the given GUN
+// connected to it
+func setupGRPCStore(t *testing.T, backend MetadataStore, gun data.GUN) (*GRPCStore, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	rpcstore.RegisterMetadataStoreServer(s, NewGRPCMetadataServer("docker.com/notary", backend))
+	go s.Serve(l)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	return NewGRPCStore(conn, gun), func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func TestGRPCStoreGetSized(t *testing.T) {
+	backend := NewMemoryStore(map[data.RoleName][]byte{"root": []byte("root data")})
+	store, cleanup := setupGRPCStore(t, backend, "docker.com/notary")
+	defer cleanup()
+
+	meta, err := store.GetSized("root", NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, []byte("root data"), meta)
+
+	meta, err = store.GetSized("root", 4)
+	require.NoError(t, err)
+	require.Equal(t, []byte("root"), meta)
+
+	_, err = store.GetSized("targets", NoSizeLimit)
+	require.Error(t, err)
+	require.Equal(t, ErrMetaNotFound{Resource: "targets"}, err)
+}
+
+// Metadata for any other GUN appears not to exist
+func TestGRPCStoreOtherGUN(t *testing.T) {
+	backend := NewMemoryStore(map[data.RoleName][]byte{"root": []byte("root data")})
+	store, cleanup := setupGRPCStore(t, backend, "docker.com/other")
+	defer cleanup()
+
+	_, err := store.GetSized("root", NoSizeLimit)
+	require.IsType(t, ErrMetaNotFound{}, err)
+	require.IsType(t, ErrMetaNotFound{}, store.Set("root", []byte("other root")))
+
+	meta, err := backend.GetSized("root", NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, []byte("root data"), meta)
+}
+
+func TestGRPCStoreSetAndRemove(t *testing.T) {
+	backend := NewMemoryStore(nil)
+	store, cleanup := setupGRPCStore(t, backend, "docker.com/notary")
+	defer cleanup()
+
+	require.NoError(t, store.Set("root", []byte("root data")))
+	require.NoError(t, store.SetMulti(map[string][]byte{
+		"targets":  []byte("targets data"),
+		"snapshot": []byte("snapshot data"),
+	}))
+	for name, expected := range map[string]string{"root": "root data", "targets": "targets data", "snapshot": "snapshot data"} {
+		meta, err := backend.GetSized(name, NoSizeLimit)
+		require.NoError(t, err)
+		require.Equal(t, []byte(expected), meta)
+
+		meta, err = store.GetSized(name, NoSizeLimit)
+		require.NoError(t, err)
+		require.Equal(t, []byte(expected), meta)
+	}
+
+	require.NoError(t, store.Remove("root"))
+	_, err := store.GetSized("root", NoSizeLimit)
+	require.IsType(t, ErrMetaNotFound{}, err)
+
+	require.NoError(t, store.RemoveAll())
+	_, err = store.GetSized("targets", NoSizeLimit)
+	require.IsType(t, ErrMetaNotFound{}, err)
+}
+
+// Errors other than missing metadata are reported as network errors
+func TestGRPCStoreServerUnavailable(t *testing.T) {
+	store, cleanup := setupGRPCStore(t, NewMemoryStore(nil), "docker.com/notary")
+	cleanup()
+
+	_, err := store.GetSized("root", NoSizeLimit)
+	require.Error(t, err)
+	require.IsType(t, NetworkError{}, err)
+}
@@ -109,18 +109,39 @@ type HTTPStore struct {
 	metaExtension string
 	keyExtension  string
 	roundTrip     http.RoundTripper
+
+	// only set for stores against a notary server
+	serverURL  string
+	gun        data.GUN
+	apiVersion string
+}
+
+// notaryServerAPIRoot is requested to discover the API version of a notary
+// server.  It is the same for every version, so that it can always be found.
+const notaryServerAPIRoot = "/v2/"
+
+// notaryServerAPIPaths maps each notary server API version known to this
+// client to the path under which that version serves the trust data of a GUN
+var notaryServerAPIPaths = map[string]string{
+	"2": "/v2/%s/_trust/tuf/",
 }
 
 // NewNotaryServerStore returns a new HTTPStore against a URL which should represent a notary
 // server
 func NewNotaryServerStore(serverURL string, gun data.GUN, roundTrip http.RoundTripper) (RemoteStore, error) {
-	return NewHTTPStore(
-		serverURL+"/v2/"+gun.String()+"/_trust/tuf/",
+	s, err := NewHTTPStore(
+		serverURL+fmt.Sprintf(notaryServerAPIPaths[notary.NotaryServerAPIVersion], gun.String()),
 		"",
 		"json",
 		"key",
 		roundTrip,
 	)
+	if httpStore, ok := s.(*HTTPStore); ok {
+		httpStore.serverURL = serverURL
+		httpStore.gun = gun
+		httpStore.apiVersion = notary.NotaryServerAPIVersion
+	}
+	return s, err
 }
 
 // NewHTTPStore initializes a new store against a URL and a number of configuration options.
@@ -193,6 +214,24 @@ func (s HTTPStore) GetSized(name string, size int64) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	return s.getSized(url, name, size)
+}
+
+// GetDiffSized downloads the named meta file as a delta against the version of
+// it with the given SHA256 checksum. The delta is subject to the same size
+// limits as the full file would be.
+func (s HTTPStore) GetDiffSized(name, baseChecksum string, size int64) ([]byte, error) {
+	url, err := s.buildMetaURL(name)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Query()
+	q.Set(notary.DiffFromQueryParam, baseChecksum)
+	url.RawQuery = q.Encode()
+	return s.getSized(url, name, size)
+}
+
+func (s HTTPStore) getSized(url *url.URL, name string, size int64) ([]byte, error) {
 	req, err := http.NewRequest("GET", url.String(), nil)
 	if err != nil {
 		return nil, err
@@ -221,6 +260,53 @@ func (s HTTPStore) GetSized(name string, size int64) ([]byte, error) {
 	return body, nil
 }
 
+// ServerAPIVersion asks the notary server for the version of its API, and
+// makes all subsequent requests as that version expects.  Servers which
+// predate advertising their version are assumed to serve the current version.
+// If the server advertises a version this client does not know, a warning is
+// logged and requests continue to be made as before.
+//
+// Unlike the other methods, this has a pointer receiver since it updates the
+// store.
+func (s *HTTPStore) ServerAPIVersion() (string, error) {
+	if s.serverURL == "" {
+		return "", ErrInvalidOperation{msg: "API versions are only served by notary servers"}
+	}
+	req, err := http.NewRequest("GET", s.serverURL+notaryServerAPIRoot, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.roundTrip.RoundTrip(req)
+	if err != nil {
+		return "", NetworkError{Wrapped: err}
+	}
+	defer resp.Body.Close()
+	if err := translateStatusToError(resp, notaryServerAPIRoot); err != nil {
+		return "", err
+	}
+
+	version := resp.Header.Get(notary.NotaryServerAPIVersionHeader)
+	if version == "" {
+		logrus.Debugf("%s does not advertise its API version, assuming version %s", s.serverURL, notary.NotaryServerAPIVersion)
+		version = notary.NotaryServerAPIVersion
+	}
+	apiPath, ok := notaryServerAPIPaths[version]
+	if !ok {
+		logrus.Warnf("%s serves unknown API version %s, continuing to use API version %s", s.serverURL, version, s.apiVersion)
+		return version, nil
+	}
+	if version != s.apiVersion {
+		base, err := url.Parse(s.serverURL + fmt.Sprintf(apiPath, s.gun.String()))
+		if err != nil {
+			return "", err
+		}
+		logrus.Debugf("switching from API version %s to %s for %s", s.apiVersion, version, s.serverURL)
+		s.baseURL = *base
+		s.apiVersion = version
+	}
+	return version, nil
+}
+
 // Set sends a single piece of metadata to the TUF server
 func (s HTTPStore) Set(name string, blob []byte) error {
 	return s.SetMulti(map[string][]byte{name: blob})
@@ -263,6 +349,13 @@ func NewMultiPartMetaRequest(url string, metas map[string][]byte) (*http.Request
 // This should be preferred for updating a remote server as it enable the server
 // to remain consistent, either accepting or rejecting the complete update.
 func (s HTTPStore) SetMulti(metas map[string][]byte) error {
+	return s.SetMultiWithIdempotencyKey(metas, "")
+}
+
+// SetMultiWithIdempotencyKey does the same batch upload as SetMulti, sending
+// the key in the idempotency key header so that the server can recognize and
+// dedupe a repeated upload.  No header is sent if the key is empty.
+func (s HTTPStore) SetMultiWithIdempotencyKey(metas map[string][]byte, key string) error {
 	url, err := s.buildMetaURL("")
 	if err != nil {
 		return err
@@ -271,6 +364,9 @@ func (s HTTPStore) SetMulti(metas map[string][]byte) error {
 	if err != nil {
 		return err
 	}
+	if key != "" {
+		req.Header.Set(notary.IdempotencyKeyHeader, key)
+	}
 	resp, err := s.roundTrip.RoundTrip(req)
 	if err != nil {
 		return NetworkError{Wrapped: err}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/docker/go/canonical/json"
 	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/validation"
 )
@@ -153,6 +154,26 @@ func TestSetSingleAndSetMultiMeta(t *testing.T) {
 	require.Equal(t, "FAIL", err.Error())
 }
 
+// An idempotency key is sent in its header with a batch upload, and no header is
+// sent without one
+func TestSetMultiWithIdempotencyKey(t *testing.T) {
+	var keys []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(notary.IdempotencyKeyHeader))
+	}
+	server := httptest.NewServer(http.HandlerFunc(handler))
+	defer server.Close()
+	store, err := NewHTTPStore(server.URL, "metadata", "json", "key", http.DefaultTransport)
+	require.NoError(t, err)
+
+	metas := map[string][]byte{data.CanonicalRootRole.String(): []byte("root data")}
+	idempotent, ok := store.(IdempotentStore)
+	require.True(t, ok)
+	require.NoError(t, idempotent.SetMultiWithIdempotencyKey(metas, "publish-1"))
+	require.NoError(t, store.SetMulti(metas))
+	require.Equal(t, []string{"publish-1", ""}, keys)
+}
+
 func testErrorCode(t *testing.T, errorCode int, errType error) {
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(errorCode)
@@ -427,3 +448,80 @@ func TestLocation(t *testing.T) {
 	require.NotNil(t, s)
 	require.Equal(t, s.Location(), "store.me")
 }
+
+// apiVersionServer returns a mock notary server which advertises the given API
+// version, or none if it is empty, and records the paths of metadata requests
+func apiVersionServer(version string, requested *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == notaryServerAPIRoot {
+			if version != "" {
+				w.Header().Set(notary.NotaryServerAPIVersionHeader, version)
+			}
+			w.Write([]byte("{}"))
+			return
+		}
+		*requested = append(*requested, r.URL.Path)
+		w.Write([]byte(testRoot))
+	}))
+}
+
+func TestHTTPStoreServerAPIVersion(t *testing.T) {
+	// a version this client doesn't know about, which serves a different path
+	notaryServerAPIPaths["3"] = "/v3/%s/_trust/tuf/"
+	defer delete(notaryServerAPIPaths, "3")
+
+	testCases := []struct {
+		advertised   string
+		expected     string
+		expectedPath string
+	}{
+		// the current version, and a server predating the version header
+		{advertised: notary.NotaryServerAPIVersion, expected: notary.NotaryServerAPIVersion, expectedPath: "/v2/docker.com/notary/_trust/tuf/root.json"},
+		{advertised: "", expected: notary.NotaryServerAPIVersion, expectedPath: "/v2/docker.com/notary/_trust/tuf/root.json"},
+		// a known version with different paths
+		{advertised: "3", expected: "3", expectedPath: "/v3/docker.com/notary/_trust/tuf/root.json"},
+		// an unknown version falls back to the current paths
+		{advertised: "99", expected: "99", expectedPath: "/v2/docker.com/notary/_trust/tuf/root.json"},
+	}
+	for _, tc := range testCases {
+		var requested []string
+		server := apiVersionServer(tc.advertised, &requested)
+
+		s, err := NewNotaryServerStore(server.URL, "docker.com/notary", &TestRoundTripper{})
+		require.NoError(t, err)
+		version, err := s.(APIVersionStore).ServerAPIVersion()
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, version)
+
+		_, err = s.GetSized(data.CanonicalRootRole.String(), NoSizeLimit)
+		require.NoError(t, err)
+		require.Equal(t, []string{tc.expectedPath}, requested)
+		server.Close()
+	}
+}
+
+func TestHTTPStoreServerAPIVersionErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	s, err := NewNotaryServerStore(server.URL, "docker.com/notary", &TestRoundTripper{})
+	require.NoError(t, err)
+	_, err = s.(APIVersionStore).ServerAPIVersion()
+	require.IsType(t, ErrServerUnavailable{}, err)
+
+	s, err = NewNotaryServerStore(server.URL, "docker.com/notary", failRoundTripper{})
+	require.NoError(t, err)
+	_, err = s.(APIVersionStore).ServerAPIVersion()
+	require.IsType(t, NetworkError{}, err)
+
+	// a plain HTTP store isn't known to be a notary server
+	s, err = NewHTTPStore(server.URL, "", "json", "key", &TestRoundTripper{})
+	require.NoError(t, err)
+	_, err = s.(APIVersionStore).ServerAPIVersion()
+	require.IsType(t, ErrInvalidOperation{}, err)
+
+	_, err = OfflineStore{}.ServerAPIVersion()
+	require.IsType(t, ErrOffline{}, err)
+}
@@ -31,6 +31,26 @@ type RemoteStore interface {
 	PublicKeyStore
 }
 
+// APIVersionStore is implemented by remote stores which are able to ask the
+// server which version of its API it serves, and adjust their requests to it
+type APIVersionStore interface {
+	ServerAPIVersion() (string, error)
+}
+
+// DiffStore is implemented by remote stores which are able to serve a
+// metadata file as a delta against an older version of it identified by its
+// SHA256 checksum, rather than serving the full file
+type DiffStore interface {
+	GetDiffSized(name, baseChecksum string, size int64) ([]byte, error)
+}
+
+// IdempotentStore is implemented by remote stores which are able to send a
+// batch upload with an idempotency key, so that a server which receives the
+// same upload more than once, such as when it is retried, applies it only once
+type IdempotentStore interface {
+	SetMultiWithIdempotencyKey(metas map[string][]byte, key string) error
+}
+
 // Bootstrapper is a thing that can set itself up
 type Bootstrapper interface {
 	// Bootstrap instructs a configured Bootstrapper to perform
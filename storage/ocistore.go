@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+const (
+	// OCITUFArtifactType is the artifact type of the OCI artifacts which
+	// carry the TUF metadata of the artifact they refer to
+	OCITUFArtifactType = "application/vnd.notary.tuf.metadata.v1"
+	// OCITUFRoleMediaType is the media type of the layers of such an artifact,
+	// each of which is the metadata of one role, titled with the file name
+	// the metadata has on a notary server, such as root.json or
+	// targets/releases.json
+	OCITUFRoleMediaType = "application/vnd.notary.tuf.role.v1+json"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexMediaType    = "application/vnd.oci.image.index.v1+json"
+	ociTitleAnnotation   = "org.opencontainers.image.title"
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+	ociDefaultTag        = "latest"
+)
+
+// consistentSuffix matches the SHA256 checksum which consistent names of
+// metadata end with
+var consistentSuffix = regexp.MustCompile(`\.[0-9a-f]{64}$`)
+
+// ErrOCIReadOnly is returned when an OCI store is asked to change the metadata
+// in the registry, or for the server's keys, which it can't do
+type ErrOCIReadOnly struct{}
+
+func (err ErrOCIReadOnly) Error() string {
+	return "trust data stored in an OCI registry is read only"
+}
+
+// OCIReference identifies an artifact in an OCI registry, by tag or by digest
+type OCIReference struct {
+	// Registry is the host, and optionally the port, of the registry
+	Registry string
+	// Repository is the name of the repository within the registry
+	Repository string
+	// Tag is the tag of the artifact, if it is not identified by digest
+	Tag string
+	// Digest is the digest of the artifact's manifest, such as sha256:<hex>
+	Digest string
+}
+
+// ParseOCIReference parses a reference to an artifact in an OCI registry, of
+// the form <registry>/<repository>[:<tag>|@<digest>].  A reference with
+// neither a tag nor a digest refers to the latest tag.
+func ParseOCIReference(ref string) (OCIReference, error) {
+	var parsed OCIReference
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref, parsed.Digest = ref[:i], ref[i+1:]
+		if !strings.Contains(parsed.Digest, ":") {
+			return OCIReference{}, fmt.Errorf("invalid digest in OCI reference %s", ref)
+		}
+	}
+	slash := strings.Index(ref, "/")
+	if slash <= 0 || slash == len(ref)-1 {
+		return OCIReference{}, fmt.Errorf("OCI reference %s has no registry or repository", ref)
+	}
+	parsed.Registry, parsed.Repository = ref[:slash], ref[slash+1:]
+	if i := strings.LastIndex(parsed.Repository, ":"); i >= 0 {
+		if parsed.Digest != "" {
+			return OCIReference{}, fmt.Errorf("OCI reference %s has both a tag and a digest", ref)
+		}
+		parsed.Repository, parsed.Tag = parsed.Repository[:i], parsed.Repository[i+1:]
+	}
+	if parsed.Tag == "" && parsed.Digest == "" {
+		parsed.Tag = ociDefaultTag
+	}
+	if parsed.Repository == "" || parsed.Repository != strings.ToLower(parsed.Repository) {
+		return OCIReference{}, fmt.Errorf("invalid repository in OCI reference %s", ref)
+	}
+	return parsed, nil
+}
+
+// String returns the reference in the form ParseOCIReference parses
+func (r OCIReference) String() string {
+	if r.Digest != "" {
+		return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, r.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// ociDescriptor describes content in an OCI registry
+type ociDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIStore is a read only remote store of the TUF metadata of an artifact in
+// an OCI registry, which the registry serves as the layers of an OCI artifact
+// referring to that artifact, listed by the registry's referrers API, rather
+// than as files under a notary server's paths.  If several such artifacts
+// refer to it, the one most recently created, according to its annotations,
+// is used.  Every manifest and layer is verified against the digest it was
+// requested by, and the metadata itself is then verified by the client
+// exactly as metadata from a notary server is.  The registry is accessed
+// over HTTPS, and any authentication it requires is left to the round
+// tripper.
+type OCIStore struct {
+	ref       OCIReference
+	roundTrip http.RoundTripper
+
+	mu sync.Mutex
+	// layers are the metadata layers of the artifact last resolved, by the
+	// name of the role's metadata
+	layers map[string]ociDescriptor
+}
+
+// NewOCIStore returns a store of the TUF metadata of the artifact ref in an
+// OCI registry.  In case of a nil roundTrip, an offline store is returned
+// instead.
+func NewOCIStore(ref string, roundTrip http.RoundTripper) (RemoteStore, error) {
+	parsed, err := ParseOCIReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	if roundTrip == nil {
+		return &OfflineStore{}, nil
+	}
+	return &OCIStore{ref: parsed, roundTrip: roundTrip}, nil
+}
+
+// GetSized downloads the metadata of the named role from the artifact, which
+// is resolved again whenever the root or timestamp is requested, since every
+// update starts with those.  Consistent names are served the role's metadata,
+// which the client verifies against the checksum.
+func (s *OCIStore) GetSized(name string, size int64) ([]byte, error) {
+	refresh := name == data.CanonicalRootRole.String() || name == data.CanonicalTimestampRole.String()
+	layers, err := s.metadataLayers(name, refresh)
+	if err != nil {
+		return nil, err
+	}
+	desc, ok := layers[name]
+	if !ok {
+		desc, ok = layers[consistentSuffix.ReplaceAllString(name, "")]
+	}
+	if !ok {
+		return nil, ErrMetaNotFound{Resource: name}
+	}
+	if size == NoSizeLimit {
+		size = notary.MaxDownloadSize
+	}
+	if desc.Size > size {
+		return nil, ErrMaliciousServer{}
+	}
+	return s.fetch("blobs", desc.Digest, name, desc.Size, "")
+}
+
+// metadataLayers returns the metadata layers of the most recently created
+// artifact holding TUF metadata which refers to the artifact of the store,
+// resolving it if it has not been, or if refresh is set
+func (s *OCIStore) metadataLayers(name string, refresh bool) (map[string]ociDescriptor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.layers != nil && !refresh {
+		return s.layers, nil
+	}
+
+	subject := s.ref.Digest
+	if subject == "" {
+		manifest, err := s.fetch("manifests", s.ref.Tag, name, notary.MaxDownloadSize,
+			strings.Join([]string{ociManifestMediaType, ociIndexMediaType}, ", "))
+		if err != nil {
+			return nil, err
+		}
+		subject = fmt.Sprintf("%s:%x", notary.SHA256, sha256.Sum256(manifest))
+	}
+
+	raw, err := s.fetch("referrers", subject+"?artifactType="+url.QueryEscape(OCITUFArtifactType), name,
+		notary.MaxDownloadSize, ociIndexMediaType)
+	if err != nil {
+		return nil, err
+	}
+	var index struct {
+		Manifests []ociDescriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return nil, err
+	}
+	var latest *ociDescriptor
+	var latestCreated time.Time
+	for i, desc := range index.Manifests {
+		// registries are not required to filter by artifact type
+		if desc.ArtifactType != OCITUFArtifactType {
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339, desc.Annotations[ociCreatedAnnotation])
+		if latest == nil || !created.Before(latestCreated) {
+			latest, latestCreated = &index.Manifests[i], created
+		}
+	}
+	if latest == nil {
+		logrus.Debugf("no TUF metadata refers to %s", s.ref)
+		return nil, ErrMetaNotFound{Resource: name}
+	}
+
+	raw, err = s.fetch("manifests", latest.Digest, name, notary.MaxDownloadSize, ociManifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Layers []ociDescriptor `json:"layers"`
+	}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, err
+	}
+	layers := make(map[string]ociDescriptor, len(manifest.Layers))
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ociTitleAnnotation]
+		if layer.MediaType != OCITUFRoleMediaType || !strings.HasSuffix(title, ".json") {
+			continue
+		}
+		layers[strings.TrimSuffix(title, ".json")] = layer
+	}
+	s.layers = layers
+	return layers, nil
+}
+
+// fetch downloads a manifest, blob or list of referrers of the store's
+// repository, of at most size bytes.  Content requested by digest is verified
+// against the digest.
+func (s *OCIStore) fetch(kind, reference, name string, size int64, accept string) ([]byte, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s/%s", s.ref.Registry, s.ref.Repository, kind, reference)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := s.roundTrip.RoundTrip(req)
+	if err != nil {
+		return nil, NetworkError{Wrapped: err}
+	}
+	defer resp.Body.Close()
+	if err := translateStatusToError(resp, name); err != nil {
+		logrus.Debugf("received HTTP status %d when requesting %s %s for %s.", resp.StatusCode, kind, reference, name)
+		return nil, err
+	}
+	if resp.ContentLength > size {
+		return nil, ErrMaliciousServer{}
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > size {
+		return nil, ErrMaliciousServer{}
+	}
+	if kind != "referrers" && strings.Contains(reference, ":") {
+		if err := verifyOCIDigest(reference, body); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// verifyOCIDigest checks that content has the digest it was requested by
+func verifyOCIDigest(digest string, content []byte) error {
+	var h hash.Hash
+	algorithm := strings.SplitN(digest, ":", 2)[0]
+	switch algorithm {
+	case notary.SHA256:
+		h = sha256.New()
+	case notary.SHA512:
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported digest algorithm %s", algorithm)
+	}
+	h.Write(content)
+	if algorithm+":"+hex.EncodeToString(h.Sum(nil)) != digest {
+		logrus.Debugf("content requested by digest %s does not match it", digest)
+		return ErrMaliciousServer{}
+	}
+	return nil
+}
+
+// Set returns ErrOCIReadOnly
+func (s *OCIStore) Set(name string, blob []byte) error {
+	return ErrOCIReadOnly{}
+}
+
+// SetMulti returns ErrOCIReadOnly
+func (s *OCIStore) SetMulti(map[string][]byte) error {
+	return ErrOCIReadOnly{}
+}
+
+// Remove returns ErrOCIReadOnly
+func (s *OCIStore) Remove(name string) error {
+	return ErrOCIReadOnly{}
+}
+
+// RemoveAll returns ErrOCIReadOnly
+func (s *OCIStore) RemoveAll() error {
+	return ErrOCIReadOnly{}
+}
+
+// GetKey returns ErrOCIReadOnly, since a registry holds no keys
+func (s *OCIStore) GetKey(role data.RoleName) ([]byte, error) {
+	return nil, ErrOCIReadOnly{}
+}
+
+// RotateKey returns ErrOCIReadOnly, since a registry holds no keys
+func (s *OCIStore) RotateKey(role data.RoleName) ([]byte, error) {
+	return nil, ErrOCIReadOnly{}
+}
+
+// Location returns a human readable name for the storage location
+func (s *OCIStore) Location() string {
+	return s.ref.String()
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	valid := map[string]OCIReference{
+		"registry.example.com/org/app":          {Registry: "registry.example.com", Repository: "org/app", Tag: "latest"},
+		"localhost:5000/app:v1.2":               {Registry: "localhost:5000", Repository: "app", Tag: "v1.2"},
+		"localhost:5000/org/app@" + digest:      {Registry: "localhost:5000", Repository: "org/app", Digest: digest},
+		"registry.example.com/a/b/c:stable-tag": {Registry: "registry.example.com", Repository: "a/b/c", Tag: "stable-tag"},
+	}
+	for ref, expected := range valid {
+		parsed, err := ParseOCIReference(ref)
+		require.NoError(t, err, ref)
+		require.Equal(t, expected, parsed, ref)
+	}
+	require.Equal(t, "localhost:5000/app:v1.2", valid["localhost:5000/app:v1.2"].String())
+	require.Equal(t, "localhost:5000/org/app@"+digest, valid["localhost:5000/org/app@"+digest].String())
+
+	for _, ref := range []string{
+		"app",
+		"/org/app",
+		"registry.example.com/",
+		"registry.example.com/Org/App",
+		"registry.example.com/org/app@notadigest",
+		"registry.example.com/org/app:v1@" + digest,
+	} {
+		_, err := ParseOCIReference(ref)
+		require.Error(t, err, ref)
+	}
+}
+
+func TestOCIStoreIsReadOnly(t *testing.T) {
+	s, err := NewOCIStore("registry.example.com/org/app", http.DefaultTransport)
+	require.NoError(t, err)
+	require.Equal(t, "registry.example.com/org/app:latest", s.Location())
+	require.IsType(t, ErrOCIReadOnly{}, s.Set("root", nil))
+	require.IsType(t, ErrOCIReadOnly{}, s.SetMulti(nil))
+	require.IsType(t, ErrOCIReadOnly{}, s.Remove("root"))
+	require.IsType(t, ErrOCIReadOnly{}, s.RemoveAll())
+	_, err = s.GetKey(data.CanonicalSnapshotRole)
+	require.IsType(t, ErrOCIReadOnly{}, err)
+	_, err = s.RotateKey(data.CanonicalSnapshotRole)
+	require.IsType(t, ErrOCIReadOnly{}, err)
+
+	s, err = NewOCIStore("registry.example.com/org/app", nil)
+	require.NoError(t, err)
+	require.IsType(t, &OfflineStore{}, s)
+}
@@ -22,6 +22,11 @@ func (es OfflineStore) GetSized(name string, size int64) ([]byte, error) {
 	return nil, err
 }
 
+// ServerAPIVersion returns ErrOffline
+func (es OfflineStore) ServerAPIVersion() (string, error) {
+	return "", err
+}
+
 // Set returns ErrOffline
 func (es OfflineStore) Set(name string, blob []byte) error {
 	return err
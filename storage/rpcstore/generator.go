@@ -0,0 +1,5 @@
+package rpcstore
+
+// this file exists solely to allow us to use `go generate` to build our
+// compiled GRPC interface for Go.
+//go:generate protoc -I ./ ./metadata.proto --go_out=plugins=grpc:.
@@ -0,0 +1,417 @@
+// Code generated by protoc-gen-go.
+// source: metadata.proto
+// DO NOT EDIT!
+
+/*
+Package rpcstore is a generated protocol buffer package.
+
+It is generated from these files:
+
+	metadata.proto
+
+It has these top-level messages:
+
+	GetMsg
+	MetadataMsg
+	SetMsg
+	SetMultiMsg
+	NameMsg
+	GUNMsg
+*/
+package rpcstore
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import google_protobuf "github.com/golang/protobuf/ptypes/empty"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type GetMsg struct {
+	GUN  string `protobuf:"bytes,1,opt,name=GUN" json:"GUN,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+	Size int64  `protobuf:"varint,3,opt,name=Size" json:"Size,omitempty"`
+}
+
+func (m *GetMsg) Reset()                    { *m = GetMsg{} }
+func (m *GetMsg) String() string            { return proto.CompactTextString(m) }
+func (*GetMsg) ProtoMessage()               {}
+func (*GetMsg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *GetMsg) GetGUN() string {
+	if m != nil {
+		return m.GUN
+	}
+	return ""
+}
+
+func (m *GetMsg) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GetMsg) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type MetadataMsg struct {
+	Data []byte `protobuf:"bytes,1,opt,name=Data,proto3" json:"Data,omitempty"`
+}
+
+func (m *MetadataMsg) Reset()                    { *m = MetadataMsg{} }
+func (m *MetadataMsg) String() string            { return proto.CompactTextString(m) }
+func (*MetadataMsg) ProtoMessage()               {}
+func (*MetadataMsg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *MetadataMsg) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type SetMsg struct {
+	GUN  string `protobuf:"bytes,1,opt,name=GUN" json:"GUN,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+	Data []byte `protobuf:"bytes,3,opt,name=Data,proto3" json:"Data,omitempty"`
+}
+
+func (m *SetMsg) Reset()                    { *m = SetMsg{} }
+func (m *SetMsg) String() string            { return proto.CompactTextString(m) }
+func (*SetMsg) ProtoMessage()               {}
+func (*SetMsg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *SetMsg) GetGUN() string {
+	if m != nil {
+		return m.GUN
+	}
+	return ""
+}
+
+func (m *SetMsg) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SetMsg) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type SetMultiMsg struct {
+	Metas []*SetMsg `protobuf:"bytes,1,rep,name=Metas" json:"Metas,omitempty"`
+}
+
+func (m *SetMultiMsg) Reset()                    { *m = SetMultiMsg{} }
+func (m *SetMultiMsg) String() string            { return proto.CompactTextString(m) }
+func (*SetMultiMsg) ProtoMessage()               {}
+func (*SetMultiMsg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *SetMultiMsg) GetMetas() []*SetMsg {
+	if m != nil {
+		return m.Metas
+	}
+	return nil
+}
+
+type NameMsg struct {
+	GUN  string `protobuf:"bytes,1,opt,name=GUN" json:"GUN,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+}
+
+func (m *NameMsg) Reset()                    { *m = NameMsg{} }
+func (m *NameMsg) String() string            { return proto.CompactTextString(m) }
+func (*NameMsg) ProtoMessage()               {}
+func (*NameMsg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *NameMsg) GetGUN() string {
+	if m != nil {
+		return m.GUN
+	}
+	return ""
+}
+
+func (m *NameMsg) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GUNMsg struct {
+	GUN string `protobuf:"bytes,1,opt,name=GUN" json:"GUN,omitempty"`
+}
+
+func (m *GUNMsg) Reset()                    { *m = GUNMsg{} }
+func (m *GUNMsg) String() string            { return proto.CompactTextString(m) }
+func (*GUNMsg) ProtoMessage()               {}
+func (*GUNMsg) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *GUNMsg) GetGUN() string {
+	if m != nil {
+		return m.GUN
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*GetMsg)(nil), "rpcstore.GetMsg")
+	proto.RegisterType((*MetadataMsg)(nil), "rpcstore.MetadataMsg")
+	proto.RegisterType((*SetMsg)(nil), "rpcstore.SetMsg")
+	proto.RegisterType((*SetMultiMsg)(nil), "rpcstore.SetMultiMsg")
+	proto.RegisterType((*NameMsg)(nil), "rpcstore.NameMsg")
+	proto.RegisterType((*GUNMsg)(nil), "rpcstore.GUNMsg")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for MetadataStore service
+
+type MetadataStoreClient interface {
+	Get(ctx context.Context, in *GetMsg, opts ...grpc.CallOption) (*MetadataMsg, error)
+	Set(ctx context.Context, in *SetMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	SetMulti(ctx context.Context, in *SetMultiMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	Remove(ctx context.Context, in *NameMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	RemoveAll(ctx context.Context, in *GUNMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+}
+
+type metadataStoreClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMetadataStoreClient(cc *grpc.ClientConn) MetadataStoreClient {
+	return &metadataStoreClient{cc}
+}
+
+func (c *metadataStoreClient) Get(ctx context.Context, in *GetMsg, opts ...grpc.CallOption) (*MetadataMsg, error) {
+	out := new(MetadataMsg)
+	err := grpc.Invoke(ctx, "/rpcstore.MetadataStore/Get", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataStoreClient) Set(ctx context.Context, in *SetMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/rpcstore.MetadataStore/Set", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataStoreClient) SetMulti(ctx context.Context, in *SetMultiMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/rpcstore.MetadataStore/SetMulti", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataStoreClient) Remove(ctx context.Context, in *NameMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/rpcstore.MetadataStore/Remove", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metadataStoreClient) RemoveAll(ctx context.Context, in *GUNMsg, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/rpcstore.MetadataStore/RemoveAll", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for MetadataStore service
+
+type MetadataStoreServer interface {
+	Get(context.Context, *GetMsg) (*MetadataMsg, error)
+	Set(context.Context, *SetMsg) (*google_protobuf.Empty, error)
+	SetMulti(context.Context, *SetMultiMsg) (*google_protobuf.Empty, error)
+	Remove(context.Context, *NameMsg) (*google_protobuf.Empty, error)
+	RemoveAll(context.Context, *GUNMsg) (*google_protobuf.Empty, error)
+}
+
+func RegisterMetadataStoreServer(s *grpc.Server, srv MetadataStoreServer) {
+	s.RegisterService(&_MetadataStore_serviceDesc, srv)
+}
+
+func _MetadataStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataStoreServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcstore.MetadataStore/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataStoreServer).Get(ctx, req.(*GetMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataStore_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataStoreServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcstore.MetadataStore/Set",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataStoreServer).Set(ctx, req.(*SetMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataStore_SetMulti_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMultiMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataStoreServer).SetMulti(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcstore.MetadataStore/SetMulti",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataStoreServer).SetMulti(ctx, req.(*SetMultiMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataStore_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NameMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataStoreServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcstore.MetadataStore/Remove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataStoreServer).Remove(ctx, req.(*NameMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetadataStore_RemoveAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GUNMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataStoreServer).RemoveAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpcstore.MetadataStore/RemoveAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataStoreServer).RemoveAll(ctx, req.(*GUNMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _MetadataStore_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "rpcstore.MetadataStore",
+	HandlerType: (*MetadataStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _MetadataStore_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _MetadataStore_Set_Handler,
+		},
+		{
+			MethodName: "SetMulti",
+			Handler:    _MetadataStore_SetMulti_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _MetadataStore_Remove_Handler,
+		},
+		{
+			MethodName: "RemoveAll",
+			Handler:    _MetadataStore_RemoveAll_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "metadata.proto",
+}
+
+func init() { proto.RegisterFile("metadata.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 308 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0xc1, 0x4f, 0x83, 0x30,
+	0x14, 0xc6, 0xc7, 0x3a, 0x71, 0x7b, 0x53, 0x33, 0x9b, 0xcc, 0x10, 0xbc, 0x60, 0x0f, 0x86, 0x53,
+	0xd1, 0x99, 0xc5, 0x83, 0x27, 0x8d, 0x86, 0xd3, 0x38, 0x40, 0xf8, 0x03, 0x3a, 0xad, 0x64, 0x09,
+	0xa4, 0x04, 0x3a, 0x13, 0xfd, 0x6f, 0xfc, 0x4f, 0x4d, 0x5b, 0xc8, 0x20, 0x31, 0x44, 0x6f, 0x2f,
+	0x1f, 0xfd, 0xbe, 0x1f, 0xef, 0x7b, 0x70, 0x56, 0x70, 0xc9, 0xde, 0x98, 0x64, 0xb4, 0xac, 0x84,
+	0x14, 0x78, 0x5a, 0x95, 0xaf, 0xb5, 0x14, 0x15, 0x77, 0x2f, 0x33, 0x21, 0xb2, 0x9c, 0x07, 0x5a,
+	0xdf, 0xee, 0xdf, 0x03, 0x5e, 0x94, 0xf2, 0xd3, 0x3c, 0x23, 0x4f, 0x60, 0x87, 0x5c, 0x6e, 0xea,
+	0x0c, 0x2f, 0x00, 0x85, 0x69, 0xe4, 0x58, 0x9e, 0xe5, 0xcf, 0x62, 0x35, 0x62, 0x0c, 0x93, 0x88,
+	0x15, 0xdc, 0x19, 0x6b, 0x49, 0xcf, 0x4a, 0x4b, 0x76, 0x5f, 0xdc, 0x41, 0x9e, 0xe5, 0xa3, 0x58,
+	0xcf, 0xe4, 0x0a, 0xe6, 0x9b, 0x06, 0xae, 0x82, 0x30, 0x4c, 0x9e, 0x99, 0x64, 0x3a, 0xe9, 0x24,
+	0xd6, 0xb3, 0xc2, 0x24, 0xff, 0xc4, 0xe8, 0x0c, 0xd4, 0xc9, 0x58, 0xc3, 0x5c, 0x65, 0xec, 0x73,
+	0xb9, 0x53, 0x41, 0xd7, 0x70, 0xa4, 0xa8, 0xb5, 0x63, 0x79, 0xc8, 0x9f, 0xaf, 0x16, 0xb4, 0x5d,
+	0x98, 0x1a, 0x52, 0x6c, 0x3e, 0x93, 0x00, 0x8e, 0x55, 0xe4, 0x9f, 0xd9, 0xc4, 0x05, 0x3b, 0x4c,
+	0xa3, 0x5f, 0xdf, 0xaf, 0xbe, 0xc7, 0x70, 0xda, 0xee, 0x9a, 0x28, 0x18, 0xbe, 0x01, 0x14, 0x72,
+	0x89, 0x3b, 0x78, 0xd3, 0xa7, 0xbb, 0x3c, 0x28, 0x9d, 0x76, 0xc8, 0x08, 0xdf, 0x02, 0x4a, 0xfa,
+	0x0e, 0xf3, 0xc3, 0xee, 0x05, 0x35, 0x97, 0xa2, 0xed, 0xa5, 0xe8, 0x8b, 0xba, 0x14, 0x19, 0xe1,
+	0x07, 0x98, 0xb6, 0xab, 0xe3, 0x65, 0xdf, 0xd7, 0xd4, 0x31, 0x60, 0x5e, 0x83, 0x1d, 0xf3, 0x42,
+	0x7c, 0x70, 0x7c, 0x7e, 0xb0, 0x36, 0x95, 0x0c, 0xd8, 0xee, 0x61, 0x66, 0x6c, 0x8f, 0x79, 0xde,
+	0x5b, 0x2f, 0x8d, 0x06, 0x8d, 0x5b, 0x5b, 0x2b, 0x77, 0x3f, 0x03, 0x00, 0xca, 0x81, 0x48, 0x86,
+	0x92, 0x02, 0x00, 0x00,
+}
@@ -0,0 +1,96 @@
+// Package translog implements the Merkle tree hashing of RFC 6962 transparency
+// logs, so that a log's inclusion proofs can be verified.
+package translog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Domain separation prefixes for hashing leaves and interior nodes, so that a
+// leaf can never be mistaken for a node
+const (
+	leafPrefix = 0
+	nodePrefix = 1
+)
+
+// ErrInvalidProof is returned when an inclusion proof does not prove that an
+// entry is included in a log
+type ErrInvalidProof struct {
+	Reason string
+}
+
+func (err ErrInvalidProof) Error() string {
+	return fmt.Sprintf("invalid inclusion proof: %s", err.Reason)
+}
+
+// InclusionProof proves that the entry at LeafIndex is included in the tree of
+// the first TreeSize entries of a log.  Hashes is the audit path of the entry,
+// from the leaf up.
+type InclusionProof struct {
+	LeafIndex int64    `json:"leaf_index"`
+	TreeSize  int64    `json:"tree_size"`
+	Hashes    [][]byte `json:"hashes"`
+}
+
+// LeafHash returns the hash of a log entry, as a leaf of the tree
+func LeafHash(entry []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(entry)
+	return h.Sum(nil)
+}
+
+// nodeHash returns the hash of an interior node of the tree
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// VerifyInclusion verifies that proof proves entry to be included in the tree
+// with the given root hash.  The root hash must come from a tree head the log
+// has signed, and whose signature the caller has verified.
+func VerifyInclusion(entry []byte, proof InclusionProof, rootHash []byte) error {
+	computed, err := rootFromProof(LeafHash(entry), proof)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(computed, rootHash) {
+		return ErrInvalidProof{Reason: "proof does not lead to the root hash"}
+	}
+	return nil
+}
+
+// rootFromProof computes the root hash of a tree from a leaf hash and its
+// audit path, as described in RFC 9162, section 2.1.3.2
+func rootFromProof(leafHash []byte, proof InclusionProof) ([]byte, error) {
+	if proof.LeafIndex < 0 || proof.LeafIndex >= proof.TreeSize {
+		return nil, ErrInvalidProof{Reason: fmt.Sprintf("leaf %d is not in a tree of size %d", proof.LeafIndex, proof.TreeSize)}
+	}
+	fn, sn := proof.LeafIndex, proof.TreeSize-1
+	r := leafHash
+	for _, p := range proof.Hashes {
+		if sn == 0 {
+			return nil, ErrInvalidProof{Reason: "audit path is too long"}
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return nil, ErrInvalidProof{Reason: "audit path is too short"}
+	}
+	return r, nil
+}
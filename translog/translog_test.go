@@ -0,0 +1,73 @@
+package translog
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Every entry of trees of many shapes can be proven to be included
+func TestVerifyInclusion(t *testing.T) {
+	tree := &Tree{}
+	for size := 1; size <= 33; size++ {
+		tree.Append([]byte(fmt.Sprintf("entry %d", size-1)))
+		root := tree.RootHash()
+		for i := int64(0); i < tree.Size(); i++ {
+			proof, err := tree.Prove(i)
+			require.NoError(t, err)
+			require.NoError(t, VerifyInclusion([]byte(fmt.Sprintf("entry %d", i)), proof, root),
+				"entry %d of a tree of size %d", i, size)
+		}
+	}
+}
+
+// A proof is rejected if it is for another entry, another tree, or has been
+// tampered with
+func TestVerifyInclusionInvalid(t *testing.T) {
+	tree := &Tree{}
+	for i := 0; i < 7; i++ {
+		tree.Append([]byte(fmt.Sprintf("entry %d", i)))
+	}
+	root := tree.RootHash()
+	entry := []byte("entry 2")
+	proof, err := tree.Prove(2)
+	require.NoError(t, err)
+	require.NoError(t, VerifyInclusion(entry, proof, root))
+
+	requireInvalid := func(entry []byte, proof InclusionProof, root []byte) {
+		err := VerifyInclusion(entry, proof, root)
+		require.Error(t, err)
+		require.IsType(t, ErrInvalidProof{}, err)
+	}
+
+	requireInvalid([]byte("entry 3"), proof, root)
+	requireInvalid([]byte("not an entry"), proof, root)
+
+	tree.Append([]byte("entry 7"))
+	requireInvalid(entry, proof, tree.RootHash())
+
+	tampered := proof
+	tampered.Hashes = append([][]byte{}, proof.Hashes...)
+	tampered.Hashes[1] = LeafHash([]byte("tampered"))
+	requireInvalid(entry, tampered, root)
+
+	wrongIndex := proof
+	wrongIndex.LeafIndex = 3
+	requireInvalid(entry, wrongIndex, root)
+
+	outOfRange := proof
+	outOfRange.LeafIndex = proof.TreeSize
+	requireInvalid(entry, outOfRange, root)
+
+	tooShort := proof
+	tooShort.Hashes = proof.Hashes[:len(proof.Hashes)-1]
+	requireInvalid(entry, tooShort, root)
+
+	tooLong := proof
+	tooLong.Hashes = append(append([][]byte{}, proof.Hashes...), root)
+	requireInvalid(entry, tooLong, root)
+
+	_, err = tree.Prove(tree.Size())
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package translog
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// Tree is an in-memory, append-only Merkle tree of log entries, which can
+// produce the root hash and inclusion proofs a transparency log would serve.
+// It is not safe for concurrent use.
+type Tree struct {
+	leaves [][]byte
+}
+
+// Append adds an entry to the tree, and returns its index
+func (t *Tree) Append(entry []byte) int64 {
+	t.leaves = append(t.leaves, LeafHash(entry))
+	return int64(len(t.leaves) - 1)
+}
+
+// Size returns the number of entries in the tree
+func (t *Tree) Size() int64 {
+	return int64(len(t.leaves))
+}
+
+// RootHash returns the root hash of the tree
+func (t *Tree) RootHash() []byte {
+	return subtreeHash(t.leaves)
+}
+
+// Prove returns a proof that the entry at index is included in the tree as
+// it currently is
+func (t *Tree) Prove(index int64) (InclusionProof, error) {
+	if index < 0 || index >= t.Size() {
+		return InclusionProof{}, fmt.Errorf("there is no entry %d in a tree of size %d", index, t.Size())
+	}
+	return InclusionProof{
+		LeafIndex: index,
+		TreeSize:  t.Size(),
+		Hashes:    auditPath(index, t.leaves),
+	}, nil
+}
+
+// splitPoint returns the largest power of two smaller than n, where n > 1
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// subtreeHash computes the Merkle tree hash of a list of leaf hashes
+func subtreeHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		empty := sha256.Sum256(nil)
+		return empty[:]
+	case 1:
+		return leaves[0]
+	}
+	k := splitPoint(len(leaves))
+	return nodeHash(subtreeHash(leaves[:k]), subtreeHash(leaves[k:]))
+}
+
+// auditPath computes the audit path of the leaf at index, from the leaf up
+func auditPath(index int64, leaves [][]byte) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := splitPoint(len(leaves))
+	if index < int64(k) {
+		return append(auditPath(index, leaves[:k]), subtreeHash(leaves[k:]))
+	}
+	return append(auditPath(index-int64(k), leaves[k:]), subtreeHash(leaves[:k]))
+}
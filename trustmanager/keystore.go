@@ -130,6 +130,17 @@ func (s *GenericKeyStore) AddKey(keyInfo KeyInfo, privKey data.PrivateKey) error
 
 // GetKey returns the PrivateKey given a KeyID
 func (s *GenericKeyStore) GetKey(keyID string) (data.PrivateKey, data.RoleName, error) {
+	return s.getKey(keyID, "")
+}
+
+// GetKeyForRole is like GetKey, but if the key was imported without a role, so
+// is stored under the generic notary.DefaultImportRole, the passphrase is asked
+// for with the alias of signingRole, the role it is being retrieved to sign.
+func (s *GenericKeyStore) GetKeyForRole(keyID string, signingRole data.RoleName) (data.PrivateKey, data.RoleName, error) {
+	return s.getKey(keyID, signingRole)
+}
+
+func (s *GenericKeyStore) getKey(keyID string, signingRole data.RoleName) (data.PrivateKey, data.RoleName, error) {
 	s.Lock()
 	defer s.Unlock()
 
@@ -151,7 +162,11 @@ func (s *GenericKeyStore) GetKey(keyID string) (data.PrivateKey, data.RoleName,
 	// See if the key is encrypted. If its encrypted we'll fail to parse the private key
 	privKey, err := utils.ParsePEMPrivateKey(keyBytes, "")
 	if err != nil {
-		privKey, _, err = GetPasswdDecryptBytes(s.PassRetriever, keyBytes, keyID, string(role))
+		alias := role
+		if role == notary.DefaultImportRole && signingRole != "" {
+			alias = signingRole
+		}
+		privKey, _, err = GetPasswdDecryptBytes(s.PassRetriever, keyBytes, keyID, alias.String())
 		if err != nil {
 			return nil, "", err
 		}
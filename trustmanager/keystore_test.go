@@ -650,3 +650,39 @@ func TestKeysAreCached(t *testing.T) {
 	}
 	require.Equal(t, 2, numTimesCalled, "numTimesCalled should be 2 -- no additional call to passphraseRetriever")
 }
+
+// A key imported without a role is asked for with the alias of the role it
+// is retrieved to sign, while a key with a role keeps its own alias
+func TestGetKeyForRoleAlias(t *testing.T) {
+	tempBaseDir, err := ioutil.TempDir("", "notary-test-")
+	require.NoError(t, err, "failed to create a temporary directory")
+	defer os.RemoveAll(tempBaseDir)
+
+	var aliases []string
+	recordingRetriever := func(keyID, alias string, createNew bool, attempts int) (string, bool, error) {
+		if !createNew {
+			aliases = append(aliases, alias)
+		}
+		return cannedPassphrase, false, nil
+	}
+	store, err := NewKeyFileStore(tempBaseDir, recordingRetriever)
+	require.NoError(t, err, "failed to create new key filestore")
+
+	importedKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err, "could not generate private key")
+	require.NoError(t, store.AddKey(KeyInfo{Role: notary.DefaultImportRole}, importedKey))
+	targetsKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err, "could not generate private key")
+	require.NoError(t, store.AddKey(KeyInfo{Role: data.CanonicalTargetsRole, Gun: "docker.com/notary"}, targetsKey))
+
+	// a new store, so that the keys are not cached
+	store, err = NewKeyFileStore(tempBaseDir, recordingRetriever)
+	require.NoError(t, err, "failed to create new key filestore")
+
+	_, role, err := store.GetKeyForRole(importedKey.ID(), "targets/releases")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName(notary.DefaultImportRole), role)
+	_, _, err = store.GetKeyForRole(targetsKey.ID(), "targets/releases")
+	require.NoError(t, err)
+	require.Equal(t, []string{"targets/releases", data.CanonicalTargetsRole.String()}, aliases)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary/tuf/data"
@@ -38,6 +39,19 @@ func (err ErrRootRotationFail) Error() string {
 	return fmt.Sprintf("could not rotate trust to a new trusted root: %s", err.Reason)
 }
 
+// ErrRootCertExpired is returned by ValidateRoot, in place of ErrValidationFail,
+// when the only certificates for the GUN in the root keys have expired.  It is
+// returned by value, unlike ErrValidationFail.
+type ErrRootCertExpired struct {
+	KeyID   string
+	CN      string
+	Expired time.Time
+}
+
+func (err ErrRootCertExpired) Error() string {
+	return fmt.Sprintf("certificate with CN %s for root key %s expired at %s", err.CN, err.KeyID, err.Expired)
+}
+
 func prettyFormatCertIDs(certs map[string]*x509.Certificate) string {
 	ids := make([]string, 0, len(certs))
 	for id := range certs {
@@ -82,7 +96,10 @@ we are using the current public PKI to validate the first download of the certif
 adding an extra layer of security over the normal (SSH style) trust model.
 We shall call this: TOFUS.
 
-Validation failure at any step will result in an ErrValidationFailed error.
+Validation failure at any step will result in an ErrValidationFailed error,
+except that a root whose certificates for this GUN have all expired is rejected
+with an ErrRootCertExpired value, unless IgnoreRootCertExpiry is set, so that
+callers can tell an expired root apart from an untrusted one.
 */
 func ValidateRoot(prevRoot *data.SignedRoot, root *data.Signed, gun data.GUN, trustPinning TrustPinConfig) (*data.SignedRoot, error) {
 	logrus.Debugf("entered ValidateRoot with dns: %s", gun)
@@ -98,9 +115,13 @@ func ValidateRoot(prevRoot *data.SignedRoot, root *data.Signed, gun data.GUN, tr
 
 	// Retrieve all the leaf and intermediate certificates in root for which the CN matches the GUN
 	allLeafCerts, allIntCerts := parseAllCerts(signedRoot)
-	certsFromRoot, err := validRootLeafCerts(allLeafCerts, gun, true)
+	certsFromRoot, err := validRootLeafCerts(allLeafCerts, gun, !trustPinning.IgnoreRootCertExpiry)
 	validIntCerts := validRootIntCerts(allIntCerts)
 
+	if expiredErr, ok := err.(ErrRootCertExpired); ok {
+		logrus.Debugf("all leaf certificates for %s have expired: %v", gun, err)
+		return nil, expiredErr
+	}
 	if err != nil {
 		logrus.Debugf("error retrieving valid leaf certificates for: %s, %v", gun, err)
 		return nil, &ErrValidationFail{Reason: "unable to retrieve valid leaf certificates"}
@@ -193,6 +214,7 @@ func MatchCNToGun(commonName string, gun data.GUN) bool {
 // "validity" alone does not imply any measure of trust.
 func validRootLeafCerts(allLeafCerts map[string]*x509.Certificate, gun data.GUN, checkExpiry bool) (map[string]*x509.Certificate, error) {
 	validLeafCerts := make(map[string]*x509.Certificate)
+	var expired *ErrRootCertExpired
 
 	// Go through every leaf certificate and check that the CN matches the gun
 	for id, cert := range allLeafCerts {
@@ -206,6 +228,9 @@ func validRootLeafCerts(allLeafCerts map[string]*x509.Certificate, gun data.GUN,
 		// and warn if it hasn't expired yet but is within 6 months of expiry
 		if err := utils.ValidateCertificate(cert, checkExpiry); err != nil {
 			logrus.Debugf("%s is invalid: %s", id, err.Error())
+			if _, ok := err.(data.ErrCertExpired); ok {
+				expired = &ErrRootCertExpired{KeyID: id, CN: cert.Subject.CommonName, Expired: cert.NotAfter}
+			}
 			continue
 		}
 
@@ -214,6 +239,9 @@ func validRootLeafCerts(allLeafCerts map[string]*x509.Certificate, gun data.GUN,
 
 	if len(validLeafCerts) < 1 {
 		logrus.Debugf("didn't find any valid leaf certificates for %s", gun)
+		if expired != nil {
+			return nil, *expired
+		}
 		return nil, errors.New("no valid leaf certificates found in any of the root keys")
 	}
 
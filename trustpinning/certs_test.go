@@ -25,6 +25,7 @@ import (
 	"github.com/theupdateframework/notary/cryptoservice"
 	"github.com/theupdateframework/notary/trustmanager"
 	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/signed"
 	"github.com/theupdateframework/notary/tuf/testutils"
@@ -1020,6 +1021,76 @@ func TestValidateRootRotationTrustPinningInvalidCA(t *testing.T) {
 	require.Error(t, err)
 }
 
+// signedRootWithCert returns a root whose only key, used for every base role,
+// is the provided certificate, signed with the corresponding private key
+func signedRootWithCert(t *testing.T, cs signed.CryptoService, cert *x509.Certificate) *data.Signed {
+	pubKey := utils.CertToKey(cert)
+	rootRole, err := data.NewRole(data.CanonicalRootRole, 1, []string{pubKey.ID()}, nil)
+	require.NoError(t, err)
+	testRoot, err := data.NewRoot(
+		map[string]data.PublicKey{pubKey.ID(): pubKey},
+		map[data.RoleName]*data.RootRole{
+			data.CanonicalRootRole:      &rootRole.RootRole,
+			data.CanonicalTimestampRole: &rootRole.RootRole,
+			data.CanonicalTargetsRole:   &rootRole.RootRole,
+			data.CanonicalSnapshotRole:  &rootRole.RootRole},
+		false,
+	)
+	require.NoError(t, err, "Failed to create new root")
+	testRoot.Signed.Version = 1
+
+	signedTestRoot, err := testRoot.ToSigned()
+	require.NoError(t, err)
+	require.NoError(t, signed.Sign(cs, signedTestRoot, []data.PublicKey{pubKey}, 1, nil))
+	return signedTestRoot
+}
+
+// A root whose certificate has expired is rejected with ErrRootCertExpired,
+// unless expired root certificates are explicitly ignored
+func TestValidateRootCertExpiry(t *testing.T) {
+	var gun data.GUN = "notary"
+	memStore := trustmanager.NewKeyMemoryStore(passphraseRetriever)
+	cs := cryptoservice.NewCryptoService(memStore)
+	testPubKey, err := cs.Create(data.CanonicalRootRole, gun, data.ECDSAKey)
+	require.NoError(t, err)
+	testPrivKey, _, err := memStore.GetKey(testPubKey.ID())
+	require.NoError(t, err)
+
+	validCert, err := generateTestingCertificate(testPrivKey, gun, notary.Year)
+	require.NoError(t, err)
+	expiredCert, err := generateExpiredTestingCertificate(testPrivKey, gun)
+	require.NoError(t, err)
+
+	// a valid certificate is accepted whether or not expiry is checked
+	validRoot := signedRootWithCert(t, cs, validCert)
+	for _, ignore := range []bool{false, true} {
+		_, err = trustpinning.ValidateRoot(nil, validRoot, gun, trustpinning.TrustPinConfig{IgnoreRootCertExpiry: ignore})
+		require.NoError(t, err)
+	}
+
+	expiredRoot := signedRootWithCert(t, cs, expiredCert)
+	_, err = trustpinning.ValidateRoot(nil, expiredRoot, gun, trustpinning.TrustPinConfig{})
+	require.Error(t, err)
+	require.IsType(t, trustpinning.ErrRootCertExpired{}, err)
+	_, isValidationFail := err.(*trustpinning.ErrValidationFail)
+	require.False(t, isValidationFail)
+	expiredErr := err.(trustpinning.ErrRootCertExpired)
+	require.Equal(t, utils.CertToKey(expiredCert).ID(), expiredErr.KeyID)
+	require.Equal(t, gun.String(), expiredErr.CN)
+	require.True(t, expiredErr.Expired.Equal(expiredCert.NotAfter))
+
+	validated, err := trustpinning.ValidateRoot(nil, expiredRoot, gun, trustpinning.TrustPinConfig{IgnoreRootCertExpiry: true})
+	require.NoError(t, err)
+	require.NotNil(t, validated)
+
+	// the error reaches callers loading the root through a builder unchanged
+	expiredJSON, err := json.Marshal(expiredRoot)
+	require.NoError(t, err)
+	builder := tuf.NewRepoBuilder(gun, cs, trustpinning.TrustPinConfig{})
+	err = builder.Load(data.CanonicalRootRole, expiredJSON, 1, false)
+	require.Equal(t, expiredErr, err)
+}
+
 func generateTestingCertificate(rootKey data.PrivateKey, gun data.GUN, timeToExpire time.Duration) (*x509.Certificate, error) {
 	startTime := time.Now()
 	return cryptoservice.GenerateCertificate(rootKey, gun, startTime, startTime.Add(timeToExpire))
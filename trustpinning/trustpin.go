@@ -31,6 +31,94 @@ type TrustPinConfig struct {
 	// DisableTOFU, when true, disables "Trust On First Use" of new key data
 	// This is false by default, which means new key data will always be trusted the first time it is seen.
 	DisableTOFU bool
+	// IgnoreRootCertExpiry, when true, accepts root keys whose certificates
+	// have expired.  This is false by default, which means a root is rejected
+	// with ErrRootCertExpired if all of its certificates for the GUN have expired.
+	IgnoreRootCertExpiry bool
+	// SnapshotKeys maps a GUN, or a GUN prefix ending in a wildcard, to the IDs
+	// of the keys which may sign its snapshot.  A snapshot which a threshold of
+	// those keys has not signed is rejected, even if the root authorizes the
+	// keys which did sign it.
+	SnapshotKeys map[string][]string
+	// TimestampKeys maps a GUN, or a GUN prefix ending in a wildcard, to the
+	// IDs of the keys which may sign its timestamp, like SnapshotKeys.  Since
+	// the server usually manages the timestamp key, this detects it being
+	// swapped for another, even one which the root authorizes.
+	TimestampKeys map[string][]string
+	// RootWitnesses maps a GUN, or a GUN prefix ending in a wildcard, to the
+	// minimum number of independent witnesses which must countersign its root
+	// before its targets are trusted.  A witness countersigns the root by
+	// listing it in the metadata of a delegation under targets/witnesses, and
+	// only witnesses signing with a key pinned in RootWitnessKeys count.
+	RootWitnesses map[string]int
+	// RootWitnessKeys maps a GUN, or a GUN prefix ending in a wildcard, to the
+	// IDs of the keys, or of the certificates, of the witnesses who may
+	// countersign its root.  Since the owner of a repository can delegate to
+	// any number of witnesses, one whose keys are not pinned is not counted
+	// towards RootWitnesses.
+	RootWitnessKeys map[string][]string
+}
+
+// PinnedSnapshotKeys returns the IDs of the keys pinned to sign the snapshot of
+// a GUN, preferring an exact match of the GUN to the most specific wildcard.
+// It returns false if the snapshot keys of the GUN are not pinned.
+func (t TrustPinConfig) PinnedSnapshotKeys(gun data.GUN) ([]string, bool) {
+	if keyIDs, ok := t.SnapshotKeys[gun.String()]; ok {
+		return keyIDs, true
+	}
+	return wildcardMatch(gun, t.SnapshotKeys)
+}
+
+// MinRootWitnesses returns the minimum number of independent witnesses which
+// must countersign the root of a GUN, preferring an exact match of the GUN to
+// the most specific wildcard.  It returns false if no minimum is configured.
+func (t TrustPinConfig) MinRootWitnesses(gun data.GUN) (int, bool) {
+	if minimum, ok := t.RootWitnesses[gun.String()]; ok {
+		return minimum, true
+	}
+	gunPrefixes := make([]string, 0, len(t.RootWitnesses))
+	for gunPrefix := range t.RootWitnesses {
+		gunPrefixes = append(gunPrefixes, gunPrefix)
+	}
+	longest, ok := longestWildcardPrefix(gun, gunPrefixes)
+	if !ok {
+		return 0, false
+	}
+	return t.RootWitnesses[longest], true
+}
+
+// PinnedRootWitnessKeys returns the IDs of the keys pinned to the witnesses
+// who may countersign the root of a GUN, preferring an exact match of the GUN
+// to the most specific wildcard.  It returns false if no witness keys of the
+// GUN are pinned.
+func (t TrustPinConfig) PinnedRootWitnessKeys(gun data.GUN) ([]string, bool) {
+	if keyIDs, ok := t.RootWitnessKeys[gun.String()]; ok {
+		return keyIDs, true
+	}
+	return wildcardMatch(gun, t.RootWitnessKeys)
+}
+
+// PinnedTimestampKeys returns the IDs of the keys pinned to sign the timestamp
+// of a GUN, preferring an exact match of the GUN to the most specific
+// wildcard.  It returns false if the timestamp keys of the GUN are not pinned.
+func (t TrustPinConfig) PinnedTimestampKeys(gun data.GUN) ([]string, bool) {
+	if keyIDs, ok := t.TimestampKeys[gun.String()]; ok {
+		return keyIDs, true
+	}
+	return wildcardMatch(gun, t.TimestampKeys)
+}
+
+// PinsRoot returns whether the root of a GUN is pinned to certificate IDs or to
+// a CA, rather than being trusted on first use
+func (t TrustPinConfig) PinsRoot(gun data.GUN) bool {
+	if _, ok := t.Certs[gun.String()]; ok {
+		return true
+	}
+	if _, ok := wildcardMatch(gun, t.Certs); ok {
+		return true
+	}
+	_, err := getPinnedCAFilepathByPrefix(gun, t)
+	return err == nil
 }
 
 type trustPinChecker struct {
@@ -147,17 +235,28 @@ func getPinnedCAFilepathByPrefix(gun data.GUN, t TrustPinConfig) (string, error)
 // it is impossible to have two different prefixes of equal length.
 // This logic also solves the issue of Go's randomization of map iteration.
 func wildcardMatch(gun data.GUN, certs map[string][]string) ([]string, bool) {
-	var (
-		longest = ""
-		ids     []string
-	)
-	for gunPrefix, keyIDs := range certs {
+	gunPrefixes := make([]string, 0, len(certs))
+	for gunPrefix := range certs {
+		gunPrefixes = append(gunPrefixes, gunPrefix)
+	}
+	longest, ok := longestWildcardPrefix(gun, gunPrefixes)
+	if !ok {
+		return nil, false
+	}
+	ids := certs[longest]
+	return ids, ids != nil
+}
+
+// longestWildcardPrefix returns the most specific (longest) of the wildcarded
+// GUN prefixes which matches the GUN, or false if none does
+func longestWildcardPrefix(gun data.GUN, gunPrefixes []string) (string, bool) {
+	longest := ""
+	for _, gunPrefix := range gunPrefixes {
 		if strings.HasSuffix(gunPrefix, "*") {
 			if strings.HasPrefix(gun.String(), gunPrefix[:len(gunPrefix)-1]) && len(gunPrefix) > len(longest) {
 				longest = gunPrefix
-				ids = keyIDs
 			}
 		}
 	}
-	return ids, ids != nil
+	return longest, longest != ""
 }
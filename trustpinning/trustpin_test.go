@@ -33,3 +33,115 @@ func TestWildcardMatch(t *testing.T) {
 	require.Equal(t, "def", res[0])
 	require.True(t, ok)
 }
+
+func TestPinnedSnapshotKeys(t *testing.T) {
+	config := TrustPinConfig{
+		SnapshotKeys: map[string][]string{
+			"docker.io/library/ubuntu": {"abc"},
+			"docker.io/library/*":      {"def"},
+		},
+	}
+
+	// an exact match is preferred to a wildcard
+	res, ok := config.PinnedSnapshotKeys("docker.io/library/ubuntu")
+	require.True(t, ok)
+	require.Equal(t, []string{"abc"}, res)
+
+	res, ok = config.PinnedSnapshotKeys("docker.io/library/debian")
+	require.True(t, ok)
+	require.Equal(t, []string{"def"}, res)
+
+	// GUNs which match nothing are not pinned
+	res, ok = config.PinnedSnapshotKeys("docker.io/endophage/foo")
+	require.False(t, ok)
+	require.Nil(t, res)
+
+	_, ok = TrustPinConfig{}.PinnedSnapshotKeys("docker.io/library/ubuntu")
+	require.False(t, ok)
+}
+
+func TestPinnedTimestampKeys(t *testing.T) {
+	config := TrustPinConfig{
+		TimestampKeys: map[string][]string{
+			"docker.io/library/ubuntu": {"abc"},
+			"docker.io/library/*":      {"def"},
+		},
+		SnapshotKeys: map[string][]string{"docker.io/*": {"ghi"}},
+	}
+
+	res, ok := config.PinnedTimestampKeys("docker.io/library/ubuntu")
+	require.True(t, ok)
+	require.Equal(t, []string{"abc"}, res)
+
+	res, ok = config.PinnedTimestampKeys("docker.io/library/debian")
+	require.True(t, ok)
+	require.Equal(t, []string{"def"}, res)
+
+	// snapshot pins do not pin the timestamp
+	res, ok = config.PinnedTimestampKeys("docker.io/endophage/foo")
+	require.False(t, ok)
+	require.Nil(t, res)
+}
+
+func TestMinRootWitnesses(t *testing.T) {
+	config := TrustPinConfig{
+		RootWitnesses: map[string]int{
+			"docker.io/library/ubuntu": 3,
+			"docker.io/library/*":      2,
+			"docker.io/*":              1,
+		},
+	}
+
+	// an exact match is preferred to the most specific wildcard
+	minimum, ok := config.MinRootWitnesses("docker.io/library/ubuntu")
+	require.True(t, ok)
+	require.Equal(t, 3, minimum)
+
+	minimum, ok = config.MinRootWitnesses("docker.io/library/debian")
+	require.True(t, ok)
+	require.Equal(t, 2, minimum)
+
+	minimum, ok = config.MinRootWitnesses("docker.io/endophage/foo")
+	require.True(t, ok)
+	require.Equal(t, 1, minimum)
+
+	_, ok = config.MinRootWitnesses("quay.io/foo")
+	require.False(t, ok)
+	_, ok = TrustPinConfig{}.MinRootWitnesses("docker.io/library/ubuntu")
+	require.False(t, ok)
+}
+
+func TestPinnedRootWitnessKeys(t *testing.T) {
+	config := TrustPinConfig{
+		RootWitnessKeys: map[string][]string{
+			"docker.io/library/ubuntu": {"abc"},
+			"docker.io/library/*":      {"def"},
+		},
+	}
+
+	res, ok := config.PinnedRootWitnessKeys("docker.io/library/ubuntu")
+	require.True(t, ok)
+	require.Equal(t, []string{"abc"}, res)
+
+	res, ok = config.PinnedRootWitnessKeys("docker.io/library/debian")
+	require.True(t, ok)
+	require.Equal(t, []string{"def"}, res)
+
+	_, ok = config.PinnedRootWitnessKeys("docker.io/endophage/foo")
+	require.False(t, ok)
+}
+
+func TestPinsRoot(t *testing.T) {
+	config := TrustPinConfig{
+		Certs: map[string][]string{
+			"docker.io/library/ubuntu": {"abc"},
+			"docker.io/endophage/*":    {"def"},
+		},
+		CA: map[string]string{"quay.io/": "ca.crt"},
+	}
+	require.True(t, config.PinsRoot("docker.io/library/ubuntu"))
+	require.True(t, config.PinsRoot("docker.io/endophage/foo"))
+	require.True(t, config.PinsRoot("quay.io/foo"))
+	require.False(t, config.PinsRoot("docker.io/library/debian"))
+	require.False(t, TrustPinConfig{DisableTOFU: true}.PinsRoot("docker.io/library/ubuntu"))
+}
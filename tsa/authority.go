@@ -0,0 +1,171 @@
+package tsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultPolicy is the timestamp policy an Authority issues tokens under, if
+// it is not configured with another
+var DefaultPolicy = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 4146, 2, 3}
+
+// Authority is a minimal RFC 3161 timestamp authority, which grants every
+// well formed request for SHA256 message imprints
+type Authority struct {
+	// Certificate identifies the authority, and must be valid for
+	// timestamping
+	Certificate *x509.Certificate
+	// Signer holds the private key of the certificate
+	Signer crypto.Signer
+	// Policy is the timestamp policy tokens are issued under
+	Policy asn1.ObjectIdentifier
+	// Now returns the time to assert in issued tokens, and defaults to the
+	// current time
+	Now func() time.Time
+}
+
+// ServeHTTP responds to timestamp requests
+func (a *Authority) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || r.Header.Get("Content-Type") != RequestContentType {
+		http.Error(w, "expected a timestamp request", http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxResponseSize))
+	if err != nil {
+		http.Error(w, "could not read the request", http.StatusBadRequest)
+		return
+	}
+	resp, err := a.Respond(body)
+	if err != nil {
+		logrus.Errorf("could not issue a timestamp token: %s", err)
+		http.Error(w, "could not issue a timestamp token", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ResponseContentType)
+	w.Write(resp)
+}
+
+// Respond returns the DER encoded timestamp response to a DER encoded
+// timestamp request
+func (a *Authority) Respond(request []byte) ([]byte, error) {
+	var req timeStampReq
+	if rest, err := asn1.Unmarshal(request, &req); err != nil || len(rest) != 0 ||
+		!req.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) ||
+		len(req.MessageImprint.HashedMessage) != crypto.SHA256.Size() {
+		// 2 is rejection, and 1 << 5 is badDataFormat
+		return asn1.Marshal(timeStampResp{Status: pkiStatusInfo{
+			Status:       2,
+			StatusString: []string{"malformed timestamp request"},
+			FailInfo:     asn1.BitString{Bytes: []byte{1 << 5}, BitLength: 3},
+		}})
+	}
+	token, err := a.issue(req)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(timeStampResp{
+		Status:         pkiStatusInfo{Status: 0},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
+
+// issue creates the signed timestamp token for a request
+func (a *Authority) issue(req timeStampReq) ([]byte, error) {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	policy := a.Policy
+	if policy == nil {
+		policy = DefaultPolicy
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	content, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         policy,
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   serial,
+		GenTime:        now().UTC().Truncate(time.Second),
+		Nonce:          req.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contentDigest := crypto.SHA256.New()
+	contentDigest.Write(content)
+	signedAttrs, err := marshalAttributes(oidTSTInfo, contentDigest.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := crypto.SHA256.New()
+	attrsDigest.Write(signedAttrs)
+	sig, err := a.Signer.Sign(rand.Reader, attrsDigest.Sum(nil), crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	sha256Alg := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	var certs asn1.RawValue
+	if req.CertReq {
+		certs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.Certificate.Raw}
+	}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Alg},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: content},
+		Certificates:     certs,
+		SignerInfos: []signerInfo{{
+			Version:         1,
+			SID:             issuerAndSerial{Issuer: asn1.RawValue{FullBytes: a.Certificate.RawIssuer}, Serial: a.Certificate.SerialNumber},
+			DigestAlgorithm: sha256Alg,
+			// included with an implicit tag rather than as a SET
+			SignedAttrs:        asn1.RawValue{FullBytes: append([]byte{0xA0}, signedAttrs[1:]...)},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: publicKeyOID(a.Certificate.PublicKeyAlgorithm)},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+// marshalAttributes DER encodes the signed attributes of a token as a SET
+func marshalAttributes(contentType asn1.ObjectIdentifier, messageDigest []byte) ([]byte, error) {
+	encodedType, err := asn1.Marshal(contentType)
+	if err != nil {
+		return nil, err
+	}
+	encodedDigest, err := asn1.Marshal(messageDigest)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.MarshalWithParams([]attribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: encodedType}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: encodedDigest}}},
+	}, "set")
+}
+
+func publicKeyOID(alg x509.PublicKeyAlgorithm) asn1.ObjectIdentifier {
+	if alg == x509.ECDSA {
+		return asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	}
+	return asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+}
@@ -0,0 +1,415 @@
+// Package tsa implements the parts of the RFC 3161 Time-Stamp Protocol needed
+// to obtain timestamp tokens from a timestamp authority, and to verify them.
+package tsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+const (
+	// RequestContentType is the content type of a timestamp request
+	RequestContentType = "application/timestamp-query"
+	// ResponseContentType is the content type of a timestamp response
+	ResponseContentType = "application/timestamp-reply"
+
+	// maxResponseSize limits how much of a response from a timestamp
+	// authority is read
+	maxResponseSize = 1 << 20
+)
+
+var (
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+// ErrInvalidToken is returned when a timestamp token cannot be parsed, or
+// does not verify
+type ErrInvalidToken struct {
+	Reason string
+}
+
+func (err ErrInvalidToken) Error() string {
+	return fmt.Sprintf("invalid timestamp token: %s", err.Reason)
+}
+
+// ErrRequestRejected is returned when a timestamp authority does not grant a
+// timestamp request
+type ErrRequestRejected struct {
+	Status       int
+	StatusString string
+}
+
+func (err ErrRequestRejected) Error() string {
+	return fmt.Sprintf("timestamp request rejected with status %d: %s", err.Status, err.StatusString)
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerial
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// Token is a parsed timestamp token whose signature has been verified
+type Token struct {
+	// GenTime is the time at which the timestamp authority asserts the
+	// message existed
+	GenTime time.Time
+	// HashAlgorithm and HashedMessage identify the timestamped message
+	HashAlgorithm crypto.Hash
+	HashedMessage []byte
+	// Nonce is the nonce of the request the token was issued for, if any
+	Nonce *big.Int
+	// Signer is the certificate of the timestamp authority which signed the
+	// token
+	Signer *x509.Certificate
+	// Certificates are all the certificates included in the token
+	Certificates []*x509.Certificate
+}
+
+// RequestToken asks the timestamp authority at url for a timestamp token over
+// the SHA256 digest of message, and returns the DER encoded token once its
+// signature and contents have been checked against the request.
+func RequestToken(url string, roundTrip http.RoundTripper, message []byte) ([]byte, error) {
+	if roundTrip == nil {
+		roundTrip = http.DefaultTransport
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	digest := crypto.SHA256.New()
+	digest.Write(message)
+	reqBytes, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			HashedMessage: digest.Sum(nil),
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", RequestContentType)
+	resp, err := roundTrip.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("timestamp authority responded with HTTP status %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var tsResp timeStampResp
+	if rest, err := asn1.Unmarshal(body, &tsResp); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("could not parse the timestamp response")
+	}
+	// 0 is granted, and 1 is granted with modifications
+	if tsResp.Status.Status > 1 {
+		var statusString string
+		if len(tsResp.Status.StatusString) > 0 {
+			statusString = tsResp.Status.StatusString[0]
+		}
+		return nil, ErrRequestRejected{Status: tsResp.Status.Status, StatusString: statusString}
+	}
+
+	token := tsResp.TimeStampToken.FullBytes
+	parsed, err := Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.checkMessage(message); err != nil {
+		return nil, err
+	}
+	if parsed.Nonce == nil || parsed.Nonce.Cmp(nonce) != 0 {
+		return nil, ErrInvalidToken{Reason: "nonce does not match the request"}
+	}
+	return token, nil
+}
+
+// Verify parses the timestamp token and checks that it timestamps message.
+// If roots is not nil, the certificate of the timestamp authority must chain
+// to one of them, and must be valid for timestamping at the time asserted by
+// the token.  It returns the time asserted by the token.
+func Verify(token, message []byte, roots *x509.CertPool) (time.Time, error) {
+	parsed, err := Parse(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := parsed.checkMessage(message); err != nil {
+		return time.Time{}, err
+	}
+	if roots != nil {
+		intermediates := x509.NewCertPool()
+		for _, cert := range parsed.Certificates {
+			intermediates.AddCert(cert)
+		}
+		_, err := parsed.Signer.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   parsed.GenTime,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		})
+		if err != nil {
+			return time.Time{}, ErrInvalidToken{Reason: fmt.Sprintf("untrusted timestamp authority: %s", err)}
+		}
+	}
+	return parsed.GenTime, nil
+}
+
+func (t *Token) checkMessage(message []byte) error {
+	if !t.HashAlgorithm.Available() {
+		return ErrInvalidToken{Reason: "unsupported message hash algorithm"}
+	}
+	digest := t.HashAlgorithm.New()
+	digest.Write(message)
+	if !bytes.Equal(digest.Sum(nil), t.HashedMessage) {
+		return ErrInvalidToken{Reason: "token does not timestamp the message"}
+	}
+	return nil
+}
+
+// Parse parses a DER encoded timestamp token and verifies that it was signed
+// by the certificate it includes for its signer.  It does not establish
+// whether that certificate is trusted: use Verify for that.
+func Parse(token []byte) (*Token, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(token, &ci); err != nil || len(rest) != 0 {
+		return nil, ErrInvalidToken{Reason: "not a CMS content info"}
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, ErrInvalidToken{Reason: "not CMS signed data"}
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, ErrInvalidToken{Reason: "could not parse CMS signed data"}
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, ErrInvalidToken{Reason: "signed content is not timestamp info"}
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, ErrInvalidToken{Reason: "must have exactly one signer"}
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, ErrInvalidToken{Reason: "could not parse certificates"}
+	}
+
+	si := sd.SignerInfos[0]
+	var signer *x509.Certificate
+	for _, cert := range certs {
+		if bytes.Equal(cert.RawIssuer, si.SID.Issuer.FullBytes) && cert.SerialNumber.Cmp(si.SID.Serial) == 0 {
+			signer = cert
+			break
+		}
+	}
+	if signer == nil {
+		return nil, ErrInvalidToken{Reason: "signer certificate not included"}
+	}
+	if err := verifySignerInfo(si, signer, sd.EncapContentInfo.EContent); err != nil {
+		return nil, err
+	}
+
+	var info tstInfo
+	if rest, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil || len(rest) != 0 {
+		return nil, ErrInvalidToken{Reason: "could not parse timestamp info"}
+	}
+	hash, err := hashFromOID(info.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{
+		GenTime:       info.GenTime,
+		HashAlgorithm: hash,
+		HashedMessage: info.MessageImprint.HashedMessage,
+		Nonce:         info.Nonce,
+		Signer:        signer,
+		Certificates:  certs,
+	}, nil
+}
+
+// verifySignerInfo checks that the signed attributes of the signer include the
+// digest of the content, and are signed by the signer's certificate
+func verifySignerInfo(si signerInfo, signer *x509.Certificate, content []byte) error {
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return ErrInvalidToken{Reason: "no signed attributes"}
+	}
+	hash, err := hashFromOID(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	// the signature is over the DER encoding of the attributes as a SET,
+	// rather than with the implicit tag they are included with
+	signedAttrs := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(signedAttrs, &attrs, "set"); err != nil {
+		return ErrInvalidToken{Reason: "could not parse signed attributes"}
+	}
+	var contentType asn1.ObjectIdentifier
+	var messageDigest []byte
+	for _, attr := range attrs {
+		if len(attr.Values) != 1 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			asn1.Unmarshal(attr.Values[0].FullBytes, &contentType)
+		case attr.Type.Equal(oidMessageDigest):
+			asn1.Unmarshal(attr.Values[0].FullBytes, &messageDigest)
+		}
+	}
+	if !contentType.Equal(oidTSTInfo) {
+		return ErrInvalidToken{Reason: "signed content type is not timestamp info"}
+	}
+	digest := hash.New()
+	digest.Write(content)
+	if !bytes.Equal(digest.Sum(nil), messageDigest) {
+		return ErrInvalidToken{Reason: "content does not match the signed digest"}
+	}
+
+	sigAlg, err := signatureAlgorithm(signer.PublicKeyAlgorithm, hash)
+	if err != nil {
+		return err
+	}
+	if err := signer.CheckSignature(sigAlg, signedAttrs, si.Signature); err != nil {
+		return ErrInvalidToken{Reason: "signature does not verify"}
+	}
+	return nil
+}
+
+func hashFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, ErrInvalidToken{Reason: fmt.Sprintf("unsupported hash algorithm %s", oid)}
+}
+
+// signatureAlgorithm determines the signature algorithm from the signer's key
+// and the digest algorithm, since CMS signers commonly identify only the key
+// type as the signature algorithm
+func signatureAlgorithm(keyAlgorithm x509.PublicKeyAlgorithm, hash crypto.Hash) (x509.SignatureAlgorithm, error) {
+	algorithms := map[x509.PublicKeyAlgorithm]map[crypto.Hash]x509.SignatureAlgorithm{
+		x509.RSA: {
+			crypto.SHA256: x509.SHA256WithRSA,
+			crypto.SHA384: x509.SHA384WithRSA,
+			crypto.SHA512: x509.SHA512WithRSA,
+		},
+		x509.ECDSA: {
+			crypto.SHA256: x509.ECDSAWithSHA256,
+			crypto.SHA384: x509.ECDSAWithSHA384,
+			crypto.SHA512: x509.ECDSAWithSHA512,
+		},
+	}
+	if alg, ok := algorithms[keyAlgorithm][hash]; ok {
+		return alg, nil
+	}
+	return x509.UnknownSignatureAlgorithm, ErrInvalidToken{Reason: "unsupported signature algorithm"}
+}
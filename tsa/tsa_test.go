@@ -0,0 +1,158 @@
+package tsa
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAuthority returns an authority with a self-signed timestamping
+// certificate, and a pool trusting it
+func newTestAuthority(t *testing.T, usages ...x509.ExtKeyUsage) (*Authority, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping}
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test timestamp authority"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           usages,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &Authority{Certificate: cert, Signer: key}, roots
+}
+
+func TestRequestAndVerifyToken(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	genTime := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	authority.Now = func() time.Time { return genTime }
+	ts := httptest.NewServer(authority)
+	defer ts.Close()
+
+	message := []byte("message to timestamp")
+	token, err := RequestToken(ts.URL, nil, message)
+	require.NoError(t, err)
+
+	got, err := Verify(token, message, roots)
+	require.NoError(t, err)
+	require.True(t, genTime.Equal(got))
+
+	parsed, err := Parse(token)
+	require.NoError(t, err)
+	require.Equal(t, authority.Certificate.Raw, parsed.Signer.Raw)
+
+	// a nil pool only checks that the token is self-consistent
+	_, err = Verify(token, message, nil)
+	require.NoError(t, err)
+}
+
+func TestVerifyTokenRejectsOtherMessages(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	ts := httptest.NewServer(authority)
+	defer ts.Close()
+
+	token, err := RequestToken(ts.URL, nil, []byte("message"))
+	require.NoError(t, err)
+	_, err = Verify(token, []byte("another message"), roots)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidToken{}, err)
+}
+
+func TestVerifyTokenRejectsUntrustedAuthority(t *testing.T) {
+	authority, _ := newTestAuthority(t)
+	_, otherRoots := newTestAuthority(t)
+	ts := httptest.NewServer(authority)
+	defer ts.Close()
+
+	message := []byte("message")
+	token, err := RequestToken(ts.URL, nil, message)
+	require.NoError(t, err)
+	_, err = Verify(token, message, otherRoots)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidToken{}, err)
+
+	// the authority's certificate must be valid for timestamping
+	notTSA, roots := newTestAuthority(t, x509.ExtKeyUsageCodeSigning)
+	ts2 := httptest.NewServer(notTSA)
+	defer ts2.Close()
+	token, err = RequestToken(ts2.URL, nil, message)
+	require.NoError(t, err)
+	_, err = Verify(token, message, roots)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidToken{}, err)
+}
+
+func TestVerifyTokenRejectsTampering(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	genTime := time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+	authority.Now = func() time.Time { return genTime }
+	ts := httptest.NewServer(authority)
+	defer ts.Close()
+
+	message := []byte("message")
+	token, err := RequestToken(ts.URL, nil, message)
+	require.NoError(t, err)
+
+	// change the asserted time, which is covered by the signed digest
+	timeIndex := bytes.Index(token, []byte("20170101000000Z"))
+	require.True(t, timeIndex > 0)
+	tampered := append([]byte{}, token...)
+	tampered[timeIndex+3] = '8'
+	_, err = Verify(tampered, message, roots)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidToken{}, err)
+
+	// change the signature, which is at the end of the token
+	tampered = append([]byte{}, token...)
+	tampered[len(tampered)-1] ^= 0x01
+	_, err = Verify(tampered, message, roots)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidToken{}, err)
+
+	_, err = Verify(append(token, 0), message, roots)
+	require.Error(t, err)
+	require.IsType(t, ErrInvalidToken{}, err)
+}
+
+func TestRequestTokenRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authority, _ := newTestAuthority(t)
+		resp, err := authority.Respond([]byte("not a timestamp request"))
+		require.NoError(t, err)
+		w.Write(resp)
+	}))
+	defer ts.Close()
+
+	_, err := RequestToken(ts.URL, nil, []byte("message"))
+	require.Error(t, err)
+	require.IsType(t, ErrRequestRejected{}, err)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	_, err = RequestToken(failing.URL, nil, []byte("message"))
+	require.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package tuf
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/docker/go/canonical/json"
@@ -54,6 +56,12 @@ func (c ConsistentInfo) Length() int64 {
 	return -1
 }
 
+// CheckHashes checks content against the checksums of the role in this
+// consistent information, failing if no checksum is known
+func (c ConsistentInfo) CheckHashes(content []byte) error {
+	return data.CheckHashes(content, c.RoleName.String(), c.fileMeta.Hashes)
+}
+
 // RepoBuilder is an interface for an object which builds a tuf.Repo
 type RepoBuilder interface {
 	Load(roleName data.RoleName, content []byte, minVersion int, allowExpired bool) error
@@ -68,6 +76,7 @@ type RepoBuilder interface {
 	IsLoaded(roleName data.RoleName) bool
 	GetLoadedVersion(roleName data.RoleName) int
 	GetConsistentInfo(roleName data.RoleName) ConsistentInfo
+	GetBaseRole(roleName data.RoleName) (data.BaseRole, error)
 }
 
 // finishedBuilder refuses any more input or output
@@ -95,12 +104,27 @@ func (f finishedBuilder) GetLoadedVersion(roleName data.RoleName) int { return 0
 func (f finishedBuilder) GetConsistentInfo(roleName data.RoleName) ConsistentInfo {
 	return ConsistentInfo{RoleName: roleName}
 }
+func (f finishedBuilder) GetBaseRole(roleName data.RoleName) (data.BaseRole, error) {
+	return data.BaseRole{}, ErrBuildDone
+}
 
 // NewRepoBuilder is the only way to get a pre-built RepoBuilder
 func NewRepoBuilder(gun data.GUN, cs signed.CryptoService, trustpin trustpinning.TrustPinConfig) RepoBuilder {
 	return NewBuilderFromRepo(gun, NewRepo(cs), trustpin)
 }
 
+// NewRepoBuilderWithVerificationCache returns a RepoBuilder which records the
+// delegations it verifies in the cache, and skips verifying the signatures of
+// delegations the cache holds a verification of.  Builders bootstrapped from it
+// share the cache.
+func NewRepoBuilderWithVerificationCache(gun data.GUN, cs signed.CryptoService, trustpin trustpinning.TrustPinConfig,
+	cache *VerificationCache) RepoBuilder {
+
+	builder := NewRepoBuilder(gun, cs, trustpin)
+	builder.(*repoBuilderWrapper).RepoBuilder.(*repoBuilder).verificationCache = cache
+	return builder
+}
+
 // NewBuilderFromRepo allows us to bootstrap a builder given existing repo data.
 // YOU PROBABLY SHOULDN'T BE USING THIS OUTSIDE OF TESTING CODE!!!
 func NewBuilderFromRepo(gun data.GUN, repo *Repo, trustpin trustpinning.TrustPinConfig) RepoBuilder {
@@ -152,6 +176,11 @@ type repoBuilder struct {
 
 	// for bootstrapping the next builder
 	nextRootChecksum *data.FileMeta
+
+	// delegations whose signatures have already been verified, and the
+	// checksum of the snapshot loaded, which the cached entries are valid for
+	verificationCache *VerificationCache
+	snapshotChecksum  string
 }
 
 func (rb *repoBuilder) Finish() (*Repo, *Repo, error) {
@@ -168,6 +197,7 @@ func (rb *repoBuilder) BootstrapNewBuilder() RepoBuilder {
 
 		prevRoot:                 rb.repo.Root,
 		bootstrappedRootChecksum: rb.nextRootChecksum,
+		verificationCache:        rb.verificationCache,
 	}}
 }
 
@@ -180,6 +210,7 @@ func (rb *repoBuilder) BootstrapNewBuilderWithNewTrustpin(trustpin trustpinning.
 
 		prevRoot:                 rb.repo.Root,
 		bootstrappedRootChecksum: rb.nextRootChecksum,
+		verificationCache:        rb.verificationCache,
 	}}
 }
 
@@ -216,6 +247,15 @@ func (rb *repoBuilder) GetLoadedVersion(roleName data.RoleName) int {
 	return 1
 }
 
+// GetBaseRole returns a base role, with its keys and threshold, as the loaded
+// root defines it
+func (rb *repoBuilder) GetBaseRole(roleName data.RoleName) (data.BaseRole, error) {
+	if err := rb.checkPrereqsLoaded([]data.RoleName{data.CanonicalRootRole}); err != nil {
+		return data.BaseRole{}, err
+	}
+	return rb.repo.GetBaseRole(roleName)
+}
+
 // GetConsistentInfo returns the consistent name and size of a role, if it is known,
 // otherwise just the rolename and a -1 for size (both of which are inside a
 // ConsistentInfo object)
@@ -539,6 +579,11 @@ func (rb *repoBuilder) loadSnapshot(content []byte, minVersion int, allowExpired
 	}
 
 	rb.repo.Snapshot = signedSnapshot
+	if rb.verificationCache != nil {
+		checksum := sha256.Sum256(content)
+		rb.snapshotChecksum = hex.EncodeToString(checksum[:])
+		rb.verificationCache.useSnapshot(rb.snapshotChecksum)
+	}
 	return nil
 }
 
@@ -598,7 +643,7 @@ func (rb *repoBuilder) loadDelegation(roleName data.RoleName, content []byte, mi
 	}
 
 	// verify signature
-	if err := signed.VerifySignatures(signedObj, delegationRole.BaseRole); err != nil {
+	if err := rb.verifyDelegationSignatures(signedObj, delegationRole.BaseRole, content); err != nil {
 		rb.invalidRoles.Targets[roleName] = signedTargets
 		return err
 	}
@@ -615,6 +660,32 @@ func (rb *repoBuilder) loadDelegation(roleName data.RoleName, content []byte, mi
 	return nil
 }
 
+// verifyDelegationSignatures verifies the signatures of delegation metadata,
+// unless the verification cache holds a verification of the same content
+// against the same keys under the loaded snapshot, in which case the validity
+// of each signature is restored from the cache instead
+func (rb *repoBuilder) verifyDelegationSignatures(signedObj *data.Signed, role data.BaseRole, content []byte) error {
+	if rb.verificationCache == nil || rb.snapshotChecksum == "" {
+		return signed.VerifySignatures(signedObj, role)
+	}
+	key := newVerificationKey(role, content)
+	if valid, ok := rb.verificationCache.lookup(rb.snapshotChecksum, key); ok && len(valid) == len(signedObj.Signatures) {
+		for i := range signedObj.Signatures {
+			signedObj.Signatures[i].IsValid = valid[i]
+		}
+		return nil
+	}
+	if err := signed.VerifySignatures(signedObj, role); err != nil {
+		return err
+	}
+	valid := make([]bool, len(signedObj.Signatures))
+	for i, sig := range signedObj.Signatures {
+		valid[i] = sig.IsValid
+	}
+	rb.verificationCache.store(rb.snapshotChecksum, key, valid)
+	return nil
+}
+
 func (rb *repoBuilder) validateChecksumsFromTimestamp(ts *data.SignedTimestamp) error {
 	sn, ok := rb.loadedNotChecksummed[data.CanonicalSnapshotRole]
 	if ok {
@@ -289,6 +289,8 @@ func TestBuilderStopsAcceptingOrProducingDataOnceDone(t *testing.T) {
 			// checksums are all empty, versions are all zero
 			require.Equal(t, 0, b.GetLoadedVersion(roleName))
 			require.Equal(t, tuf.ConsistentInfo{RoleName: roleName}, b.GetConsistentInfo(roleName))
+			_, err = b.GetBaseRole(roleName)
+			require.Equal(t, tuf.ErrBuildDone, err)
 		}
 
 	}
@@ -481,6 +483,24 @@ func TestGenerateTimestampInvalidOperations(t *testing.T) {
 	require.False(t, builder.IsLoaded(data.CanonicalTimestampRole))
 }
 
+// The base roles are those of the loaded root
+func TestBuilderGetBaseRole(t *testing.T) {
+	meta, gun := getSampleMeta(t)
+	builder := tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{})
+	_, err := builder.GetBaseRole(data.CanonicalTargetsRole)
+	require.IsType(t, tuf.ErrInvalidBuilderInput{}, err)
+
+	require.NoError(t, builder.Load(data.CanonicalRootRole, meta[data.CanonicalRootRole], 1, false))
+	role, err := builder.GetBaseRole(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	root := &data.SignedRoot{}
+	require.NoError(t, json.Unmarshal(meta[data.CanonicalRootRole], root))
+	expected, err := root.BuildBaseRole(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.ElementsMatch(t, expected.ListKeyIDs(), role.ListKeyIDs())
+	require.Equal(t, expected.Threshold, role.Threshold)
+}
+
 func TestGetConsistentInfo(t *testing.T) {
 	var gun data.GUN = "docker.com/notary"
 	repo, _, err := testutils.EmptyRepo(gun)
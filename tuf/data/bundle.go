@@ -0,0 +1,24 @@
+package data
+
+// TrustBundle is served to bootstrap trust in a GUN on first use.  It holds
+// the current root of the GUN, along with the IDs of the root's certificates
+// so that they can be compared with the certificates pinned for the GUN.
+type TrustBundle struct {
+	GUN GUN `json:"gun"`
+	// Root is the signed root metadata, exactly as served
+	Root []byte `json:"root"`
+	// CertIDs are the IDs of the certificates of the root role's keys
+	CertIDs []string `json:"cert_ids"`
+}
+
+// RootAnchor is a compact anchor of trust in a GUN, small enough to embed in a
+// client so that its first update needn't trust the server's root on first
+// use.  Rather than a whole signed root, it holds just the keys and threshold
+// of the root role, as of a given version of the root.
+type RootAnchor struct {
+	GUN GUN `json:"gun"`
+	// Version is the version of the root whose root role is described
+	Version   int     `json:"version"`
+	Threshold int     `json:"threshold"`
+	Keys      KeyList `json:"keys"`
+}
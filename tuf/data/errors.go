@@ -51,3 +51,37 @@ type ErrCertExpired struct {
 func (e ErrCertExpired) Error() string {
 	return fmt.Sprintf("certificate with CN %s is expired", e.CN)
 }
+
+// ErrUndeclaredDelegationKey is the error to be returned when a delegation
+// lists a signing key ID that is not declared in the delegating role's keys
+type ErrUndeclaredDelegationKey struct {
+	Role       RoleName
+	Delegation RoleName
+	KeyID      string
+}
+
+func (e ErrUndeclaredDelegationKey) Error() string {
+	return fmt.Sprintf("delegation %s in %s lists key ID %s, which %s does not declare",
+		e.Delegation, e.Role, e.KeyID, e.Role)
+}
+
+// ErrInvalidTargetName is the error to be returned when a target name
+// normalizes to an empty name, or to one outside the repository's root
+type ErrInvalidTargetName struct {
+	Name string
+}
+
+func (e ErrInvalidTargetName) Error() string {
+	return fmt.Sprintf("invalid target name %q: it must name a file under the repository's root", e.Name)
+}
+
+// ErrInvalidVersion is the error to be returned when a number in metadata,
+// such as a version or length, is not an integer which fits in 64 bits
+type ErrInvalidVersion struct {
+	Field string
+	Value string
+}
+
+func (e ErrInvalidVersion) Error() string {
+	return fmt.Sprintf("invalid %s %s: it must be an integer which fits in 64 bits", e.Field, e.Value)
+}
@@ -202,6 +202,9 @@ type TUFKey struct {
 	id    string
 	Type  string  `json:"keytype"`
 	Value KeyPair `json:"keyval"`
+	// Attributes describe the key, such as "team": "release".  They are part
+	// of the signed metadata which lists the key, but not of the key's ID.
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 // Algorithm returns the algorithm of the key
@@ -234,6 +237,36 @@ func (k TUFKey) Public() []byte {
 	return k.Value.Public
 }
 
+func (k TUFKey) attributes() map[string]string {
+	return k.Attributes
+}
+
+// KeyAttributes returns the attributes of a public key, or nil if it has none
+func KeyAttributes(k PublicKey) map[string]string {
+	if attributed, ok := k.(interface{ attributes() map[string]string }); ok {
+		return attributed.attributes()
+	}
+	return nil
+}
+
+// WithKeyAttributes returns a copy of a public key which carries the given
+// attributes in place of any it had.  The copy has the same ID as the key.
+func WithKeyAttributes(k PublicKey, attributes map[string]string) PublicKey {
+	tk := TUFKey{
+		Type: k.Algorithm(),
+		Value: KeyPair{
+			Public: k.Public(),
+		},
+	}
+	if len(attributes) > 0 {
+		tk.Attributes = make(map[string]string, len(attributes))
+		for name, value := range attributes {
+			tk.Attributes[name] = value
+		}
+	}
+	return typedPublicKey(tk)
+}
+
 // Public key types
 
 // ECDSAPublicKey represents an ECDSA key using a raw serialization
@@ -0,0 +1,32 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+)
+
+// Key attributes survive serialization, and do not change the key's ID
+func TestKeyAttributes(t *testing.T) {
+	key := NewPublicKey(ECDSAKey, []byte("public"))
+	require.Nil(t, KeyAttributes(key))
+
+	attributed := WithKeyAttributes(key, map[string]string{"team": "release"})
+	require.Equal(t, key.ID(), attributed.ID())
+	require.Equal(t, map[string]string{"team": "release"}, KeyAttributes(attributed))
+
+	serialized, err := json.MarshalCanonical(attributed)
+	require.NoError(t, err)
+	parsed, err := UnmarshalPublicKey(serialized)
+	require.NoError(t, err)
+	require.Equal(t, key.ID(), parsed.ID())
+	require.Equal(t, map[string]string{"team": "release"}, KeyAttributes(parsed))
+
+	// a key without attributes serializes exactly as it always has
+	serialized, err = json.MarshalCanonical(key)
+	require.NoError(t, err)
+	require.NotContains(t, string(serialized), "attributes")
+
+	require.Nil(t, KeyAttributes(WithKeyAttributes(attributed, nil)))
+}
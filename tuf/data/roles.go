@@ -5,6 +5,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 )
@@ -29,6 +30,27 @@ var BaseRoles = []RoleName{
 // Regex for validating delegation names
 var delegationRegexp = regexp.MustCompile("^[-a-z0-9_/]+$")
 
+// Regex for validating delegation names when custom role namespaces are
+// allowed, which also admits upper case letters, dots, "@" and "+"
+var customDelegationRegexp = regexp.MustCompile("^[-A-Za-z0-9_.@+/]+$")
+
+// customRoleNamespaces is non-zero if custom role namespaces are allowed
+var customRoleNamespaces int32
+
+// AllowCustomRoleNamespaces sets whether delegation role names may use the
+// wider character set of customDelegationRegexp, for interoperability with TUF
+// repositories which name their roles differently.  It affects every role name
+// validation in the process, both when creating delegations and when verifying
+// them.  Delegations must still be under the targets role, since that is how
+// a delegation's chain of trust is found from its name.
+func AllowCustomRoleNamespaces(allow bool) {
+	var value int32
+	if allow {
+		value = 1
+	}
+	atomic.StoreInt32(&customRoleNamespaces, value)
+}
+
 // ErrNoSuchRole indicates the roles doesn't exist
 type ErrNoSuchRole struct {
 	Role RoleName
@@ -74,7 +96,11 @@ func IsDelegation(role RoleName) bool {
 	strRole := role.String()
 	targetsBase := CanonicalTargetsRole + "/"
 
-	whitelistedChars := delegationRegexp.MatchString(strRole)
+	allowedChars := delegationRegexp
+	if atomic.LoadInt32(&customRoleNamespaces) != 0 {
+		allowedChars = customDelegationRegexp
+	}
+	whitelistedChars := allowedChars.MatchString(strRole)
 
 	// Limit size of full role string to 255 chars for db column size limit
 	correctLength := len(role) < 256
@@ -162,6 +188,33 @@ func (b BaseRole) Equals(o BaseRole) bool {
 type DelegationRole struct {
 	BaseRole
 	Paths []string
+	// ExcludePaths are path prefixes which the role is not trusted for, even
+	// where they fall under its Paths
+	ExcludePaths []string
+	// Matcher, if set, replaces prefix matching of target paths against
+	// Paths and ExcludePaths
+	Matcher PathMatcher
+	// ancestorPaths are the Paths of each delegation above this one, which a
+	// target path must also match when a Matcher is set, since the patterns of
+	// a custom matcher can't be narrowed to their parent's like prefixes can
+	ancestorPaths [][]string
+}
+
+// PathMatcher decides whether a target path matches a path pattern of a
+// delegation.  Implementations must be deterministic, always giving the same
+// answer for the same path and pattern, since every client has to agree on
+// which roles are trusted for a target.
+type PathMatcher interface {
+	MatchPath(targetPath, pattern string) bool
+}
+
+// PrefixPathMatcher is the default PathMatcher, which matches target paths
+// beginning with the pattern
+type PrefixPathMatcher struct{}
+
+// MatchPath returns whether targetPath has the prefix pattern
+func (PrefixPathMatcher) MatchPath(targetPath, pattern string) bool {
+	return strings.HasPrefix(targetPath, pattern)
 }
 
 func listKeys(keyMap map[string]PublicKey) KeyList {
@@ -186,14 +239,25 @@ func (d DelegationRole) Restrict(child DelegationRole) (DelegationRole, error) {
 	if !d.IsParentOf(child) {
 		return DelegationRole{}, fmt.Errorf("%s is not a parent of %s", d.Name, child.Name)
 	}
-	return DelegationRole{
+	restricted := DelegationRole{
 		BaseRole: BaseRole{
 			Keys:      child.Keys,
 			Name:      child.Name,
 			Threshold: child.Threshold,
 		},
 		Paths: RestrictDelegationPathPrefixes(d.Paths, child.Paths),
-	}, nil
+		// a child is never trusted for paths its parent is excluded from
+		ExcludePaths: mergeStrSlices(d.ExcludePaths, child.ExcludePaths),
+		Matcher:      d.Matcher,
+	}
+	if d.Matcher != nil {
+		restricted.Paths = child.Paths
+		restricted.ancestorPaths = append([][]string{}, d.ancestorPaths...)
+		if IsDelegation(d.Name) {
+			restricted.ancestorPaths = append(restricted.ancestorPaths, d.Paths)
+		}
+	}
+	return restricted, nil
 }
 
 // IsParentOf returns whether the passed in delegation role is the direct child of this role,
@@ -205,11 +269,37 @@ func (d DelegationRole) IsParentOf(child DelegationRole) bool {
 
 // CheckPaths checks if a given path is valid for the role
 func (d DelegationRole) CheckPaths(path string) bool {
-	return checkPaths(path, d.Paths)
+	if d.Matcher == nil {
+		return checkPaths(path, d.Paths) && !checkPaths(path, d.ExcludePaths)
+	}
+	// the top level targets role is trusted for every path
+	if d.Name == CanonicalTargetsRole {
+		return true
+	}
+	if !matchPaths(d.Matcher, path, d.Paths) || matchPaths(d.Matcher, path, d.ExcludePaths) {
+		return false
+	}
+	for _, paths := range d.ancestorPaths {
+		if !matchPaths(d.Matcher, path, paths) {
+			return false
+		}
+	}
+	return true
 }
 
-func checkPaths(path string, permitted []string) bool {
-	for _, p := range permitted {
+// matchPaths returns true if path matches any of the given patterns
+func matchPaths(matcher PathMatcher, path string, patterns []string) bool {
+	for _, p := range patterns {
+		if matcher.MatchPath(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPaths returns true if path has any of the given prefixes
+func checkPaths(path string, prefixes []string) bool {
+	for _, p := range prefixes {
 		if strings.HasPrefix(path, p) {
 			return true
 		}
@@ -254,6 +344,9 @@ type Role struct {
 	RootRole
 	Name  RoleName `json:"name"`
 	Paths []string `json:"paths,omitempty"`
+	// ExcludePaths are path prefixes under Paths which the role is not
+	// trusted for
+	ExcludePaths []string `json:"exclude_paths,omitempty"`
 }
 
 // NewRole creates a new Role object from the given parameters
@@ -282,7 +375,7 @@ func NewRole(name RoleName, threshold int, keyIDs, paths []string) (*Role, error
 
 // CheckPaths checks if a given path is valid for the role
 func (r Role) CheckPaths(path string) bool {
-	return checkPaths(path, r.Paths)
+	return checkPaths(path, r.Paths) && !checkPaths(path, r.ExcludePaths)
 }
 
 // AddKeys merges the ids into the current list of role key ids
@@ -309,6 +402,19 @@ func (r *Role) RemovePaths(paths []string) {
 	r.Paths = subtractStrSlices(r.Paths, paths)
 }
 
+// AddExcludePaths merges the paths into the current list of excluded paths
+func (r *Role) AddExcludePaths(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	r.ExcludePaths = mergeStrSlices(r.ExcludePaths, paths)
+}
+
+// RemoveExcludePaths removes the paths from the current list of excluded paths
+func (r *Role) RemoveExcludePaths(paths []string) {
+	r.ExcludePaths = subtractStrSlices(r.ExcludePaths, paths)
+}
+
 func mergeStrSlices(orig, new []string) []string {
 	have := make(map[string]bool)
 	for _, e := range orig {
@@ -88,6 +88,82 @@ func TestAddRemovePaths(t *testing.T) {
 	require.Equal(t, []string{"456"}, role.Paths)
 }
 
+func TestAddRemoveExcludePaths(t *testing.T) {
+	role, err := NewRole("targets/a", 1, []string{"abc"}, []string{"prod/"})
+	require.NoError(t, err)
+	role.AddExcludePaths([]string{"prod/secret/"})
+	role.AddExcludePaths([]string{"prod/secret/", "prod/keys/"})
+	require.Equal(t, []string{"prod/secret/", "prod/keys/"}, role.ExcludePaths)
+	require.True(t, role.CheckPaths("prod/app"))
+	require.False(t, role.CheckPaths("prod/secret/app"))
+	role.RemoveExcludePaths([]string{"prod/secret/"})
+	require.Equal(t, []string{"prod/keys/"}, role.ExcludePaths)
+	require.True(t, role.CheckPaths("prod/secret/app"))
+}
+
+func TestDelegationRoleExcludePaths(t *testing.T) {
+	parent := DelegationRole{
+		BaseRole:     BaseRole{Name: "targets/a"},
+		Paths:        []string{"prod/"},
+		ExcludePaths: []string{"prod/secret/"},
+	}
+	require.True(t, parent.CheckPaths("prod/app"))
+	require.False(t, parent.CheckPaths("prod/secret/app"))
+	require.False(t, parent.CheckPaths("dev/app"))
+
+	// a child inherits its parent's exclusions, as well as having its own
+	child, err := parent.Restrict(DelegationRole{
+		BaseRole:     BaseRole{Name: "targets/a/b"},
+		Paths:        []string{"prod/"},
+		ExcludePaths: []string{"prod/keys/"},
+	})
+	require.NoError(t, err)
+	require.True(t, child.CheckPaths("prod/app"))
+	require.False(t, child.CheckPaths("prod/secret/app"))
+	require.False(t, child.CheckPaths("prod/keys/app"))
+}
+
+// wholeMatcher matches a pattern against the whole of a target path
+type wholeMatcher struct{}
+
+func (wholeMatcher) MatchPath(targetPath, pattern string) bool {
+	return targetPath == pattern || strings.HasSuffix(pattern, "*") &&
+		strings.HasPrefix(targetPath, strings.TrimSuffix(pattern, "*"))
+}
+
+func TestDelegationRoleCustomMatcher(t *testing.T) {
+	targets := DelegationRole{BaseRole: BaseRole{Name: CanonicalTargetsRole}, Paths: []string{""}, Matcher: wholeMatcher{}}
+	require.True(t, targets.CheckPaths("anything"))
+
+	parent, err := targets.Restrict(DelegationRole{
+		BaseRole:     BaseRole{Name: "targets/a"},
+		Paths:        []string{"prod/*", "README"},
+		ExcludePaths: []string{"prod/secret"},
+	})
+	require.NoError(t, err)
+	require.True(t, parent.CheckPaths("README"))
+	require.False(t, parent.CheckPaths("README.md"))
+	require.True(t, parent.CheckPaths("prod/app"))
+	require.False(t, parent.CheckPaths("prod/secret"))
+
+	// a child's paths are kept as they are, but a target must also match the
+	// paths of each ancestor
+	child, err := parent.Restrict(DelegationRole{
+		BaseRole: BaseRole{Name: "targets/a/b"},
+		Paths:    []string{"*"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"*"}, child.Paths)
+	require.True(t, child.CheckPaths("prod/app"))
+	require.True(t, child.CheckPaths("README"))
+	require.False(t, child.CheckPaths("dev/app"))
+	require.False(t, child.CheckPaths("prod/secret"))
+
+	// without a matcher, the prefix matching is unchanged
+	require.True(t, DelegationRole{Paths: []string{"README"}}.CheckPaths("README.md"))
+	require.True(t, PrefixPathMatcher{}.MatchPath("README.md", "README"))
+}
+
 func TestAddPathNil(t *testing.T) {
 	role, err := NewRole("targets", 1, []string{"abc"}, nil)
 	require.NoError(t, err)
@@ -138,6 +214,34 @@ func TestIsDelegation(t *testing.T) {
 
 }
 
+func TestIsDelegationCustomRoleNamespaces(t *testing.T) {
+	AllowCustomRoleNamespaces(true)
+	defer AllowCustomRoleNamespaces(false)
+
+	for _, val := range []string{
+		path.Join(CanonicalTargetsRole.String(), "UpperCase"),
+		path.Join(CanonicalTargetsRole.String(), "releases", "v1.2"),
+		path.Join(CanonicalTargetsRole.String(), "team@example.com+ci"),
+	} {
+		require.True(t, IsDelegation(RoleName(val)), val)
+	}
+
+	// names must still be clean, and under the targets role
+	for _, val := range []string{
+		"Releases/v1.2",
+		path.Join(CanonicalRootRole.String(), "UpperCase"),
+		CanonicalTargetsRole.String() + "/../traversal",
+		CanonicalTargetsRole.String() + "/./dot",
+		path.Join(CanonicalTargetsRole.String(), "white space"),
+		path.Join(CanonicalTargetsRole.String(), "*"),
+	} {
+		require.False(t, IsDelegation(RoleName(val)), val)
+	}
+
+	AllowCustomRoleNamespaces(false)
+	require.False(t, IsDelegation(RoleName(path.Join(CanonicalTargetsRole.String(), "UpperCase"))))
+}
+
 func TestIsWildDelegation(t *testing.T) {
 	f := require.False
 	tr := require.True
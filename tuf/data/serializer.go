@@ -1,6 +1,11 @@
 package data
 
-import "github.com/docker/go/canonical/json"
+import (
+	"reflect"
+	"strings"
+
+	"github.com/docker/go/canonical/json"
+)
 
 // Serializer is an interface that can marshal and unmarshal TUF data.  This
 // is expected to be a canonical JSON marshaller
@@ -23,9 +28,20 @@ func (c canonicalJSON) Marshal(from interface{}) ([]byte, error) {
 	return json.Marshal(from)
 }
 
-// Unmarshal unmarshals some JSON bytes
+// Unmarshal unmarshals some JSON bytes.  Integer fields are decoded straight
+// from the number as written, without passing through a float, and a number
+// which is not an integer, or which overflows its field, is rejected with
+// ErrInvalidVersion.
 func (c canonicalJSON) Unmarshal(from []byte, to interface{}) error {
-	return json.Unmarshal(from, to)
+	err := json.Unmarshal(from, to)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && strings.HasPrefix(typeErr.Value, "number ") {
+		switch typeErr.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return ErrInvalidVersion{Field: "integer", Value: strings.TrimPrefix(typeErr.Value, "number ")}
+		}
+	}
+	return err
 }
 
 // defaultSerializer is a canonical JSON serializer
@@ -1,9 +1,13 @@
 package data
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"path"
+	"sort"
+	"strings"
 
 	"github.com/docker/go/canonical/json"
 )
@@ -47,6 +51,11 @@ func isValidTargetsStructure(t Targets, roleName RoleName) error {
 			return ErrInvalidMetadata{
 				role: roleName, msg: fmt.Sprintf("delegation role %s invalid", roleObj.Name)}
 		}
+		for _, keyID := range roleObj.KeyIDs {
+			if _, ok := t.Delegations.Keys[keyID]; !ok {
+				return ErrUndeclaredDelegationKey{Role: roleName, Delegation: roleObj.Name, KeyID: keyID}
+			}
+		}
 		if err := isValidRootRoleStructure(roleName, roleObj.Name, roleObj.RootRole, t.Delegations.Keys); err != nil {
 			return err
 		}
@@ -54,6 +63,23 @@ func isValidTargetsStructure(t Targets, roleName RoleName) error {
 	return nil
 }
 
+// NormalizeTargetName returns the canonical form of a target name, so that
+// the same logical artifact always has the same name: backslashes become
+// forward slashes, leading slashes are removed, and "." and ".." elements and
+// repeated or trailing slashes are resolved as in path.Clean.  It returns ""
+// for a name which normalizes to nothing, or which escapes the root with "..".
+func NormalizeTargetName(name string) string {
+	name = strings.TrimLeft(strings.Replace(name, "\\", "/", -1), "/")
+	if name == "" {
+		return ""
+	}
+	name = path.Clean(name)
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return ""
+	}
+	return name
+}
+
 // NewTargets initializes a new empty SignedTargets object
 func NewTargets() *SignedTargets {
 	return &SignedTargets{
@@ -120,7 +146,8 @@ func (t *SignedTargets) BuildDelegationRole(roleName RoleName) (DelegationRole,
 					Keys:      pubKeys,
 					Threshold: role.Threshold,
 				},
-				Paths: role.Paths,
+				Paths:        role.Paths,
+				ExcludePaths: role.ExcludePaths,
 			}, nil
 		}
 	}
@@ -173,6 +200,85 @@ func (t *SignedTargets) ToSigned() (*Signed, error) {
 	}, nil
 }
 
+// ToSignedStreamed partially serializes a SignedTargets for further signing,
+// exactly as ToSigned does, but writes the signed portion onto the end of buf,
+// one target at a time, rather than serializing all the targets at once and
+// then copying them.  The signed portion of the result is the bytes written
+// to buf, so the whole of it is still held in memory.
+func (t *SignedTargets) ToSignedStreamed(buf *bytes.Buffer) (*Signed, error) {
+	start := buf.Len()
+	if err := t.Signed.WriteCanonical(buf); err != nil {
+		return nil, err
+	}
+	signed := json.RawMessage(buf.Bytes()[start:])
+	sigs := make([]Signature, len(t.Signatures))
+	copy(sigs, t.Signatures)
+	return &Signed{
+		Signatures: sigs,
+		Signed:     &signed,
+	}, nil
+}
+
+// WriteCanonical writes the canonical JSON form of t to w, identical to what
+// MarshalCanonical returns for it, but serializing the targets one at a time,
+// in order of name, so that they are never all held serialized at once
+func (t Targets) WriteCanonical(w io.Writer) error {
+	if t.Targets == nil {
+		b, err := defaultSerializer.MarshalCanonical(t)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+
+	// serialize everything but the targets, and then write the targets in
+	// where the empty targets are.  Since the targets are the last key which
+	// holds an object, the last empty targets object is theirs.
+	skeleton := t
+	skeleton.Targets = Files{}
+	b, err := defaultSerializer.MarshalCanonical(skeleton)
+	if err != nil {
+		return err
+	}
+	emptyTargets := []byte(`"targets":{}`)
+	i := bytes.LastIndex(b, emptyTargets)
+	if i < 0 {
+		return fmt.Errorf("targets missing from serialized targets metadata")
+	}
+	split := i + len(emptyTargets) - 1
+	if _, err := w.Write(b[:split]); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(t.Targets))
+	for name := range t.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	enc := json.NewEncoder(w)
+	enc.Canonical()
+	for n, name := range names {
+		if n > 0 {
+			if _, err := w.Write([]byte{','}); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(name); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{':'}); err != nil {
+			return err
+		}
+		if err := enc.Encode(t.Targets[name]); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Write(b[split:])
+	return err
+}
+
 // MarshalJSON returns the serialized form of SignedTargets as bytes
 func (t *SignedTargets) MarshalJSON() ([]byte, error) {
 	signed, err := t.ToSigned()
@@ -3,6 +3,7 @@ package data
 import (
 	"bytes"
 	rjson "encoding/json"
+	"fmt"
 	"path"
 	"reflect"
 	"testing"
@@ -47,6 +48,40 @@ func TestTargetsToSignedMarshalsSignedPortionWithCanonicalJSON(t *testing.T) {
 		"expected %v == %v", signedCanonical.Signed, castedCanonical)
 }
 
+// Writing the targets one at a time produces the same canonical JSON as
+// serializing them all at once, whatever the targets and delegations contain
+func TestTargetsWriteCanonicalMatchesMarshalCanonical(t *testing.T) {
+	custom := cjson.RawMessage(`{"b": 1, "a": "<&>"}`)
+	withTargets := validTargetsTemplate()
+	withTargets.Signed.Delegations.Roles = []*Role{{
+		RootRole: RootRole{KeyIDs: []string{"key1"}, Threshold: 1},
+		Name:     "targets/a",
+		Paths:    []string{`"targets":{}`, "<&>"},
+	}}
+	for _, name := range []string{"b", "a", `"targets":{}`, "<html>&", "\u2028\n", "日本"} {
+		withTargets.Signed.Targets[name] = FileMeta{Length: 1, Hashes: Hashes{"sha256": []byte(name)}}
+	}
+	withTargets.Signed.Targets["custom"] = FileMeta{Length: 2, Hashes: Hashes{"sha512": []byte("x")}, Custom: &custom}
+
+	for _, targets := range []Targets{
+		withTargets.Signed,
+		validTargetsTemplate().Signed,
+		{SignedCommon: SignedCommon{Type: "Targets", Version: 1, Expires: time.Now()}},
+	} {
+		expected, err := cjson.MarshalCanonical(targets)
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, targets.WriteCanonical(buf))
+		require.Equal(t, string(expected), buf.String())
+	}
+
+	streamed, err := withTargets.ToSignedStreamed(&bytes.Buffer{})
+	require.NoError(t, err)
+	inMemory, err := withTargets.ToSigned()
+	require.NoError(t, err)
+	require.Equal(t, inMemory, streamed)
+}
+
 func TestTargetsToSignCopiesSignatures(t *testing.T) {
 	tg := SignedTargets{
 		Signed: Targets{SignedCommon: SignedCommon{Type: "Targets", Version: 2, Expires: time.Now()}},
@@ -119,6 +154,29 @@ func TestTargetsFromSignedUnmarshallingErrorsPropagated(t *testing.T) {
 	require.EqualError(t, err, "bad")
 }
 
+// Versions and lengths are parsed exactly up to the limits of an int64, and any
+// other number in them is rejected with ErrInvalidVersion, while numbers in
+// the custom data of targets are left alone
+func TestTargetsFromSignedIntegers(t *testing.T) {
+	fromJSON := func(signedJSON string) (*SignedTargets, error) {
+		raw := cjson.RawMessage(signedJSON)
+		return TargetsFromSigned(&Signed{Signed: &raw}, CanonicalTargetsRole)
+	}
+	const template = `{"_type":"Targets","delegations":{"keys":{},"roles":[]},"expires":"2030-01-01T00:00:00Z",` +
+		`"targets":{"a":{"custom":{"score":1.5},"hashes":{"sha256":"AAAA"},"length":%s}},"version":%s}`
+
+	parsed, err := fromJSON(fmt.Sprintf(template, "9223372036854775807", "9007199254740993"))
+	require.NoError(t, err)
+	require.Equal(t, 9007199254740993, parsed.Signed.Version)
+	require.Equal(t, int64(9223372036854775807), parsed.Signed.Targets["a"].Length)
+	require.Equal(t, `{"score":1.5}`, string(*parsed.Signed.Targets["a"].Custom))
+
+	for _, invalid := range [][2]string{{"1", "2.5"}, {"1", "1e3"}, {"1.5", "1"}, {"9223372036854775808", "1"}} {
+		_, err := fromJSON(fmt.Sprintf(template, invalid[0], invalid[1]))
+		require.IsType(t, ErrInvalidVersion{}, err, invalid)
+	}
+}
+
 // TargetsFromSigned succeeds if the targets is valid, and copies the signatures
 // rather than assigns them
 func TestTargetsFromSignedCopiesSignatures(t *testing.T) {
@@ -161,7 +219,7 @@ func TestTargetsFromSignedValidatesDelegations(t *testing.T) {
 		require.NoError(t, err)
 		_, err = TargetsFromSigned(s, roleName)
 		require.Error(t, err)
-		require.IsType(t, ErrInvalidMetadata{}, err)
+		require.Equal(t, ErrUndeclaredDelegationKey{Role: roleName, Delegation: delgRole.Name, KeyID: "keys11"}, err)
 
 		delgRole.KeyIDs = []string{"keys1"}
 
@@ -249,3 +307,30 @@ func TestTargetsFromSignedValidatesVersion(t *testing.T) {
 	_, err = TargetsFromSigned(s, "targets/a")
 	require.NoError(t, err)
 }
+
+func TestNormalizeTargetName(t *testing.T) {
+	for messy, canonical := range map[string]string{
+		"app/latest":             "app/latest",
+		"/app/latest":            "app/latest",
+		"//app/latest":           "app/latest",
+		"./app/latest":           "app/latest",
+		"app\\latest":            "app/latest",
+		"\\app\\.\\latest":       "app/latest",
+		"app//latest/":           "app/latest",
+		"app/v1/../latest":       "app/latest",
+		"/../app":                "",
+		"":                       "",
+		"/":                      "",
+		".":                      "",
+		"./.":                    "",
+		"..":                     "",
+		"../app":                 "",
+		"app/../..":              "",
+		"app/../../outside/file": "",
+		"..\\outside":            "",
+		"..app":                  "..app",
+		"app/..hidden":           "app/..hidden",
+	} {
+		require.Equal(t, canonical, NormalizeTargetName(messy), "normalizing %q", messy)
+	}
+}
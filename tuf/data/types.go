@@ -11,6 +11,7 @@ import (
 	"io"
 	"io/ioutil"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -142,6 +143,64 @@ type Signed struct {
 	Signatures []Signature      `json:"signatures"`
 }
 
+// SignedBytes returns the bytes over which the signatures of s are computed
+// and verified, which are the canonical JSON form of its signed portion.  The
+// signed portion is canonicalized again, so the bytes are the same even if it
+// was not in canonical form as received.  Numbers are decoded exactly rather
+// than as floats, so that large versions and lengths are not altered, and a
+// version or length which is not an integer fitting in 64 bits is rejected
+// with ErrInvalidVersion.  Other numbers, such as those in the custom data of
+// targets, are kept as they are.
+func SignedBytes(s *Signed) ([]byte, error) {
+	if s == nil || s.Signed == nil {
+		return nil, fmt.Errorf("there is no signed portion to get the bytes of")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(*s.Signed))
+	decoder.UseNumber()
+	var decoded map[string]interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	if err := checkIntegers("signed", decoded); err != nil {
+		return nil, err
+	}
+	return json.MarshalCanonical(decoded)
+}
+
+// integerFields are the fields of metadata which must hold integers
+var integerFields = map[string]bool{"version": true, "length": true}
+
+// checkIntegers checks that every version and length in a decoded value is an
+// integer which converts to an int64, naming the field of one which is not.
+// The free-form custom data of targets is not checked.
+func checkIntegers(field string, decoded interface{}) error {
+	switch v := decoded.(type) {
+	case json.Number:
+		if !integerFields[field] {
+			return nil
+		}
+		if _, err := strconv.ParseInt(string(v), 10, 64); err != nil {
+			return ErrInvalidVersion{Field: field, Value: string(v)}
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			if key == "custom" {
+				continue
+			}
+			if err := checkIntegers(key, child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := checkIntegers(field, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // SignedCommon contains the fields common to the Signed component of all
 // TUF metadata files
 type SignedCommon struct {
@@ -157,12 +216,14 @@ type SignedMeta struct {
 	Signatures []Signature  `json:"signatures"`
 }
 
-// Signature is a signature on a piece of metadata
+// Signature is a signature on a piece of metadata.  Custom data, which is not
+// covered by any signature, can be optionally added.
 type Signature struct {
-	KeyID     string       `json:"keyid"`
-	Method    SigAlgorithm `json:"method"`
-	Signature []byte       `json:"sig"`
-	IsValid   bool         `json:"-"`
+	KeyID     string           `json:"keyid"`
+	Method    SigAlgorithm     `json:"method"`
+	Signature []byte           `json:"sig"`
+	Custom    *json.RawMessage `json:"custom,omitempty"`
+	IsValid   bool             `json:"-"`
 }
 
 // Files is the map of paths to file meta container in targets and delegations
@@ -214,21 +275,32 @@ func (f FileMeta) Equals(o FileMeta) bool {
 
 // CheckHashes verifies all the checksums specified by the "hashes" of the payload.
 func CheckHashes(payload []byte, name string, hashes Hashes) error {
-	cnt := 0
-
-	// k, v indicate the hash algorithm and the corresponding value
-	for k, v := range hashes {
+	computed := make(Hashes, len(hashes))
+	for k := range hashes {
 		switch k {
 		case notary.SHA256:
 			checksum := sha256.Sum256(payload)
-			if subtle.ConstantTimeCompare(checksum[:], v) == 0 {
-				return ErrMismatchedChecksum{alg: notary.SHA256, name: name, expected: hex.EncodeToString(v)}
-			}
-			cnt++
+			computed[k] = checksum[:]
 		case notary.SHA512:
 			checksum := sha512.Sum512(payload)
-			if subtle.ConstantTimeCompare(checksum[:], v) == 0 {
-				return ErrMismatchedChecksum{alg: notary.SHA512, name: name, expected: hex.EncodeToString(v)}
+			computed[k] = checksum[:]
+		}
+	}
+	return CheckComputedHashes(computed, name, hashes)
+}
+
+// CheckComputedHashes verifies all the checksums specified by the "hashes"
+// against those computed of a payload, such as by NewFileMeta, exactly as
+// CheckHashes verifies them against the payload itself.
+func CheckComputedHashes(computed Hashes, name string, hashes Hashes) error {
+	cnt := 0
+
+	// k, v indicate the hash algorithm and the corresponding value
+	for k, v := range hashes {
+		switch k {
+		case notary.SHA256, notary.SHA512:
+			if subtle.ConstantTimeCompare(computed[k], v) == 0 {
+				return ErrMismatchedChecksum{alg: k, name: name, expected: hex.EncodeToString(v)}
 			}
 			cnt++
 		}
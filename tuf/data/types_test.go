@@ -178,6 +178,21 @@ func TestCheckValidHashStructures(t *testing.T) {
 	require.IsType(t, ErrInvalidChecksum{}, err)
 }
 
+// Hashes computed as content is read are checked just as the content would be
+func TestCheckComputedHashes(t *testing.T) {
+	raw := []byte("Bumblebee")
+	meta, err := NewFileMeta(bytes.NewReader(raw), notary.SHA256, notary.SHA512)
+	require.NoError(t, err)
+	expected, err := NewFileMeta(bytes.NewReader(raw), notary.SHA256)
+	require.NoError(t, err)
+	require.NoError(t, CheckComputedHashes(meta.Hashes, "meta", expected.Hashes))
+
+	malicious := Hashes{notary.SHA512: []byte("malicious data")}
+	require.Equal(t, CheckHashes(raw, "meta", malicious), CheckComputedHashes(meta.Hashes, "meta", malicious))
+	require.IsType(t, ErrMismatchedChecksum{}, CheckComputedHashes(meta.Hashes, "meta", malicious))
+	require.Equal(t, ErrMissingMeta{Role: "meta"}, CheckComputedHashes(meta.Hashes, "meta", Hashes{"Arthas": []byte("x")}))
+}
+
 func TestCompareMultiHashes(t *testing.T) {
 	var err error
 	hashes1 := make(Hashes)
@@ -299,3 +314,50 @@ func TestFileMetaEquals(t *testing.T) {
 	}
 	require.False(t, FileMeta{Length: 1}.Equals(f1[0]))
 }
+
+func TestSignedBytes(t *testing.T) {
+	_, err := SignedBytes(&Signed{})
+	require.Error(t, err)
+
+	raw := json.RawMessage(`{ "version": 2,  "_type": "Targets" }`)
+	b, err := SignedBytes(&Signed{Signed: &raw})
+	require.NoError(t, err)
+	require.Equal(t, `{"_type":"Targets","version":2}`, string(b))
+
+	garbage := json.RawMessage(`not json`)
+	_, err = SignedBytes(&Signed{Signed: &garbage})
+	require.Error(t, err)
+}
+
+// Integers are canonicalized exactly up to the limits of an int64, and any
+// other number is rejected
+func TestSignedBytesIntegers(t *testing.T) {
+	for _, exact := range []string{
+		`{"version":9007199254740993}`,
+		`{"version":9223372036854775807}`,
+		`{"version":-9223372036854775808}`,
+		`{"meta":{"snapshot":{"length":9223372036854775807}}}`,
+		// other numbers, and the custom data of targets, are not restricted
+		`{"roles":[{"thresh":1e3}]}`,
+		`{"targets":{"a":{"custom":{"length":0.25,"score":1.5},"length":1}}}`,
+	} {
+		raw := json.RawMessage(exact)
+		b, err := SignedBytes(&Signed{Signed: &raw})
+		require.NoError(t, err, exact)
+		require.Equal(t, exact, string(b))
+	}
+
+	for field, invalid := range map[string]string{
+		"version": `{"version":9223372036854775808}`,
+		"length":  `{"targets":{"a":{"length":1.5}}}`,
+	} {
+		raw := json.RawMessage(invalid)
+		_, err := SignedBytes(&Signed{Signed: &raw})
+		require.IsType(t, ErrInvalidVersion{}, err, invalid)
+		require.Equal(t, field, err.(ErrInvalidVersion).Field)
+	}
+
+	raw := json.RawMessage(`{"_type":"Targets","version":2.5}`)
+	_, err := SignedBytes(&Signed{Signed: &raw})
+	require.Equal(t, ErrInvalidVersion{Field: "version", Value: "2.5"}, err)
+}
@@ -39,6 +39,13 @@ type CryptoService interface {
 	KeyService
 }
 
+// RoleScopedKeyService is implemented by key services which can retrieve a
+// private key to sign a particular role, so that, for instance, a passphrase
+// prompt can name that role
+type RoleScopedKeyService interface {
+	GetPrivateKeyForRole(keyID string, signingRole data.RoleName) (data.PrivateKey, data.RoleName, error)
+}
+
 // Verifier defines an interface for verifying signatures. An implementer
 // of this interface should verify signatures for one and only one
 // signing scheme.
@@ -112,3 +112,24 @@ func Sign(service CryptoService, s *data.Signed, signingKeys []data.PublicKey,
 	s.Signatures = signatures
 	return nil
 }
+
+// roleScopedService is a CryptoService whose private keys are all retrieved to
+// sign one role
+type roleScopedService struct {
+	CryptoService
+	scoped RoleScopedKeyService
+	role   data.RoleName
+}
+
+func (s roleScopedService) GetPrivateKey(keyID string) (data.PrivateKey, data.RoleName, error) {
+	return s.scoped.GetPrivateKeyForRole(keyID, s.role)
+}
+
+// ForRole returns a CryptoService which retrieves private keys from service to
+// sign role, if service is a RoleScopedKeyService, or else service itself
+func ForRole(service CryptoService, role data.RoleName) CryptoService {
+	if scoped, ok := service.(RoleScopedKeyService); ok {
+		return roleScopedService{CryptoService: service, scoped: scoped, role: role}
+	}
+	return service
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/pem"
 	"fmt"
 	"math/big"
@@ -242,19 +243,24 @@ func (v ECDSAVerifier) Verify(key data.PublicKey, sig []byte, msg []byte) error
 		return ErrInvalid
 	}
 
-	sigLength := len(sig)
+	digest := sha256.Sum256(msg)
+
+	// notary produces raw r||s signatures, but other TUF implementations may
+	// produce ASN.1 DER encoded ones.  A signature of exactly the length of a
+	// raw signature for the key's curve is most likely raw, but is also
+	// attempted as DER in case it happens to be a short DER signature.
 	expectedOctetLength := 2 * ((ecdsaPubKey.Params().BitSize + 7) >> 3)
-	if sigLength != expectedOctetLength {
-		logrus.Debugf("signature had an unexpected length")
+	if len(sig) == expectedOctetLength {
+		r, s := parseRawECDSASignature(sig)
+		if ecdsa.Verify(ecdsaPubKey, digest[:], r, s) {
+			return nil
+		}
+	}
+	r, s, err := parseDERECDSASignature(sig)
+	if err != nil {
+		logrus.Debugf("signature is neither a raw nor a DER encoded ECDSA signature")
 		return ErrInvalid
 	}
-
-	rBytes, sBytes := sig[:sigLength/2], sig[sigLength/2:]
-	r := new(big.Int).SetBytes(rBytes)
-	s := new(big.Int).SetBytes(sBytes)
-
-	digest := sha256.Sum256(msg)
-
 	if !ecdsa.Verify(ecdsaPubKey, digest[:], r, s) {
 		logrus.Debugf("failed ECDSA signature validation")
 		return ErrInvalid
@@ -262,3 +268,32 @@ func (v ECDSAVerifier) Verify(key data.PublicKey, sig []byte, msg []byte) error
 
 	return nil
 }
+
+// parseRawECDSASignature splits a signature consisting of the big-endian
+// values r and s, each padded to half the length of the signature
+func parseRawECDSASignature(sig []byte) (*big.Int, *big.Int) {
+	rBytes, sBytes := sig[:len(sig)/2], sig[len(sig)/2:]
+	return new(big.Int).SetBytes(rBytes), new(big.Int).SetBytes(sBytes)
+}
+
+// ecdsaDERSignature is the ASN.1 structure of a DER encoded ECDSA signature
+type ecdsaDERSignature struct {
+	R, S *big.Int
+}
+
+// parseDERECDSASignature decodes an ASN.1 DER encoded ECDSA signature, rejecting
+// any trailing data
+func parseDERECDSASignature(sig []byte) (*big.Int, *big.Int, error) {
+	var parsed ecdsaDERSignature
+	rest, err := asn1.Unmarshal(sig, &parsed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) != 0 {
+		return nil, nil, fmt.Errorf("trailing data after ECDSA signature")
+	}
+	if parsed.R == nil || parsed.S == nil || parsed.R.Sign() <= 0 || parsed.S.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("invalid ECDSA signature values")
+	}
+	return parsed.R, parsed.S, nil
+}
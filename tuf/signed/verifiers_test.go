@@ -9,8 +9,10 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"testing"
 	"text/template"
 
@@ -329,6 +331,74 @@ func TestECDSAVerifierOtherCurves(t *testing.T) {
 	}
 }
 
+// Signatures over the same content verify whether they are raw r||s or ASN.1
+// DER encoded, for every supported curve
+func TestECDSAVerifierRawAndDEREncodings(t *testing.T) {
+	curves := []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()}
+	message := []byte("test data for signing")
+	hashed := sha256.Sum256(message)
+
+	for _, curve := range curves {
+		ecdsaPrivKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		ecdsaPubBytes, err := x509.MarshalPKIXPublicKey(&ecdsaPrivKey.PublicKey)
+		require.NoError(t, err)
+		pubKey := data.NewECDSAPublicKey(ecdsaPubBytes)
+
+		r, s, err := ecdsa.Sign(rand.Reader, ecdsaPrivKey, hashed[:])
+		require.NoError(t, err)
+
+		octetLength := (curve.Params().BitSize + 7) >> 3
+		raw := make([]byte, 2*octetLength)
+		rBytes, sBytes := r.Bytes(), s.Bytes()
+		copy(raw[octetLength-len(rBytes):], rBytes)
+		copy(raw[2*octetLength-len(sBytes):], sBytes)
+
+		der, err := asn1.Marshal(ecdsaDERSignature{R: r, S: s})
+		require.NoError(t, err)
+
+		ecdsaVerifier := ECDSAVerifier{}
+		for _, sig := range [][]byte{raw, der} {
+			require.NoError(t, ecdsaVerifier.Verify(pubKey, sig, message))
+			require.NoError(t, VerifySignature(message, &data.Signature{
+				KeyID: pubKey.ID(), Method: data.ECDSASignature, Signature: sig}, pubKey))
+
+			// a signature in either encoding over different content fails
+			require.Error(t, ecdsaVerifier.Verify(pubKey, sig, []byte("other data")))
+		}
+	}
+}
+
+// DER encoded signatures which are malformed, have trailing data, or have
+// invalid values are rejected
+func TestECDSAVerifierInvalidDEREncodings(t *testing.T) {
+	ecdsaPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecdsaPubBytes, err := x509.MarshalPKIXPublicKey(&ecdsaPrivKey.PublicKey)
+	require.NoError(t, err)
+	pubKey := data.NewECDSAPublicKey(ecdsaPubBytes)
+
+	message := []byte("test data for signing")
+	hashed := sha256.Sum256(message)
+	r, s, err := ecdsa.Sign(rand.Reader, ecdsaPrivKey, hashed[:])
+	require.NoError(t, err)
+	der, err := asn1.Marshal(ecdsaDERSignature{R: r, S: s})
+	require.NoError(t, err)
+	negative, err := asn1.Marshal(ecdsaDERSignature{R: new(big.Int).Neg(r), S: s})
+	require.NoError(t, err)
+
+	invalid := [][]byte{
+		der[:len(der)-1],
+		append(append([]byte{}, der...), 0),
+		negative,
+		{},
+	}
+	ecdsaVerifier := ECDSAVerifier{}
+	for _, sig := range invalid {
+		require.Equal(t, ErrInvalid, ecdsaVerifier.Verify(pubKey, sig, message))
+	}
+}
+
 func TestECDSAx509Verifier(t *testing.T) {
 	var jsonKey bytes.Buffer
 
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/docker/go/canonical/json"
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/utils"
@@ -54,11 +53,7 @@ func VerifySignatures(s *data.Signed, roleData data.BaseRole) error {
 
 	// remarshal the signed part so we can verify the signature, since the signature has
 	// to be of a canonically marshalled signed object
-	var decoded map[string]interface{}
-	if err := json.Unmarshal(*s.Signed, &decoded); err != nil {
-		return err
-	}
-	msg, err := json.MarshalCanonical(decoded)
+	msg, err := data.SignedBytes(s)
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package signed
 
 import (
 	"crypto/rand"
+	"math"
 	"testing"
 	"time"
 
@@ -247,3 +248,85 @@ func TestVerifyPublicKeyMatchesPrivateKeyFails(t *testing.T) {
 	require.Error(t, err, "should throw error if privKey is nil")
 
 }
+
+// A signature produced outside of Sign, over the bytes from data.SignedBytes,
+// verifies even if the signed portion is not in canonical form
+func TestVerifyExternalSignatureOverSignedBytes(t *testing.T) {
+	cs := NewEd25519()
+	k, err := cs.Create(data.CanonicalRootRole, "", data.ED25519Key)
+	require.NoError(t, err)
+	roleWithKeys := data.BaseRole{
+		Name: data.CanonicalRootRole, Keys: data.Keys{k.ID(): k}, Threshold: 1}
+
+	meta := &data.SignedCommon{Type: data.TUFTypes[data.CanonicalRootRole], Version: 1,
+		Expires: data.DefaultExpires(data.CanonicalRootRole)}
+	b, err := json.MarshalIndent(meta, "", "  ")
+	require.NoError(t, err)
+	s := &data.Signed{Signed: (*json.RawMessage)(&b)}
+
+	msg, err := data.SignedBytes(s)
+	require.NoError(t, err)
+	canonical, err := json.MarshalCanonical(meta)
+	require.NoError(t, err)
+	require.Equal(t, canonical, msg)
+
+	privKey, _, err := cs.GetPrivateKey(k.ID())
+	require.NoError(t, err)
+
+	// a signature over the bytes as received does not verify
+	sig, err := privKey.Sign(rand.Reader, b, nil)
+	require.NoError(t, err)
+	s.Signatures = []data.Signature{{KeyID: k.ID(), Method: privKey.SignatureAlgorithm(), Signature: sig}}
+	require.IsType(t, ErrRoleThreshold{}, VerifySignatures(s, roleWithKeys))
+
+	sig, err = privKey.Sign(rand.Reader, msg, nil)
+	require.NoError(t, err)
+	s.Signatures = []data.Signature{{KeyID: k.ID(), Method: privKey.SignatureAlgorithm(), Signature: sig}}
+	require.NoError(t, VerifySignatures(s, roleWithKeys))
+	require.NoError(t, VerifySignature(msg, &s.Signatures[0], k))
+}
+
+// Signatures over metadata with versions too large to be held exactly by a
+// float still verify, and non-integer versions are rejected
+func TestSigsOverLargeVersions(t *testing.T) {
+	cs := NewEd25519()
+	k, err := cs.Create("root", "", data.ED25519Key)
+	require.NoError(t, err)
+	roleWithKeys := data.BaseRole{Name: "root", Keys: data.Keys{k.ID(): k}, Threshold: 1}
+
+	for _, version := range []int{1<<53 + 1, math.MaxInt64} {
+		meta := &data.SignedCommon{Type: "Root", Version: version, Expires: data.DefaultExpires("root")}
+		b, err := json.MarshalCanonical(meta)
+		require.NoError(t, err)
+		s := &data.Signed{Signed: (*json.RawMessage)(&b)}
+		require.NoError(t, Sign(cs, s, []data.PublicKey{k}, 1, nil))
+		require.NoError(t, VerifySignatures(s, roleWithKeys), "version %d", version)
+	}
+
+	b := json.RawMessage(`{"_type":"Root","expires":"2030-01-01T00:00:00Z","version":1.5}`)
+	s := &data.Signed{Signed: &b}
+	require.NoError(t, Sign(cs, s, []data.PublicKey{k}, 1, nil))
+	require.IsType(t, data.ErrInvalidVersion{}, VerifySignatures(s, roleWithKeys))
+}
+
+// Numbers which are not integers are allowed in the custom data of targets, so
+// signatures over such targets still verify
+func TestSigsOverFloatCustomData(t *testing.T) {
+	cs := NewEd25519()
+	k, err := cs.Create("targets", "", data.ED25519Key)
+	require.NoError(t, err)
+	roleWithKeys := data.BaseRole{Name: "targets", Keys: data.Keys{k.ID(): k}, Threshold: 1}
+
+	custom := json.RawMessage(`{"score":1.5,"weights":[0.25,2e-3]}`)
+	tgts := data.NewTargets()
+	tgts.Signed.Version = 1
+	tgts.Signed.Targets["a"] = data.FileMeta{Length: 1, Hashes: data.Hashes{"sha256": make([]byte, 32)}, Custom: &custom}
+	s, err := tgts.ToSigned()
+	require.NoError(t, err)
+	require.NoError(t, Sign(cs, s, []data.PublicKey{k}, 1, nil))
+	require.NoError(t, VerifySignatures(s, roleWithKeys))
+
+	verified, err := data.TargetsFromSigned(s, data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.Equal(t, string(custom), string(*verified.Signed.Targets["a"].Custom))
+}
@@ -68,6 +68,13 @@ type Repo struct {
 	// If we know what the original was, we'll if and how to handle root
 	// rotations.
 	originalRootRole data.BaseRole
+
+	// if true, each role is signed with only as many of the available keys
+	// as are needed to meet its threshold, rather than with all of them
+	thresholdKeysOnly bool
+
+	// matches target paths against delegation paths, by prefix if nil
+	pathMatcher data.PathMatcher
 }
 
 // NewRepo initializes a Repo instance with a CryptoService.
@@ -80,6 +87,24 @@ func NewRepo(cryptoService signed.CryptoService) *Repo {
 	}
 }
 
+// SetSignWithAllKeys determines whether roles are signed with every available
+// key authorized for them, or with only as many keys as are needed to meet each
+// role's threshold.  Signing with every key is what a Repo has always done, and
+// remains the default; passing false selects the threshold-keys-only mode.
+func (tr *Repo) SetSignWithAllKeys(all bool) {
+	tr.thresholdKeysOnly = !all
+}
+
+// SetPathMatcher replaces the prefix matching of target paths against the
+// paths of delegations with the given matcher, both when looking up targets and
+// when validating the targets added to a delegation.  Under a custom matcher, a
+// delegation is trusted for a path only if the path matches the paths of the
+// delegation and of each of its ancestors.  A nil matcher restores prefix
+// matching.
+func (tr *Repo) SetPathMatcher(matcher data.PathMatcher) {
+	tr.pathMatcher = matcher
+}
+
 // AddBaseKeys is used to add keys to the role in root.json
 func (tr *Repo) AddBaseKeys(role data.RoleName, keys ...data.PublicKey) error {
 	if tr.Root == nil {
@@ -258,6 +283,12 @@ func (tr *Repo) GetDelegationRole(name data.RoleName) (data.DelegationRole, erro
 						return err
 					}
 				}
+				if validRole.Matcher != nil {
+					// custom matchers require the paths of the ancestors
+					if delgRole, err = validRole.Restrict(delgRole); err != nil {
+						return err
+					}
+				}
 				foundRole = &delgRole
 				return StopWalk{}
 			}
@@ -309,7 +340,9 @@ func delegationUpdateVisitor(roleName data.RoleName, addKeys data.KeyList, remov
 	return func(tgt *data.SignedTargets, validRole data.DelegationRole) interface{} {
 		var err error
 		// Validate the changes underneath this restricted validRole for adding paths, reject invalid path additions
-		if len(addPaths) != len(data.RestrictDelegationPathPrefixes(validRole.Paths, addPaths)) {
+		// Paths can't be validated this way under a custom matcher, which instead
+		// checks target paths against the paths of every ancestor
+		if validRole.Matcher == nil && len(addPaths) != len(data.RestrictDelegationPathPrefixes(validRole.Paths, addPaths)) {
 			return data.ErrInvalidRole{Role: roleName, Reason: "invalid paths to add to role"}
 		}
 		// Try to find the delegation and amend it using our changelist
@@ -506,6 +539,68 @@ func (tr *Repo) UpdateDelegationPaths(roleName data.RoleName, addPaths, removePa
 	return nil
 }
 
+// UpdateDelegationExcludePaths adds and removes path prefixes which an existing
+// delegation is excluded from.  It is not allowed to create a new delegation.
+func (tr *Repo) UpdateDelegationExcludePaths(roleName data.RoleName, addPaths, removePaths []string) error {
+	if !data.IsDelegation(roleName) {
+		return data.ErrInvalidRole{Role: roleName, Reason: "not a valid delegated role"}
+	}
+	parent := roleName.Parent()
+
+	if err := tr.VerifyCanSign(parent); err != nil {
+		return err
+	}
+
+	p, ok := tr.Targets[parent]
+	if !ok {
+		return data.ErrInvalidRole{Role: roleName, Reason: "no valid delegated role exists"}
+	}
+	foundAt := utils.FindRoleIndex(p.Signed.Delegations.Roles, roleName)
+	if foundAt < 0 {
+		return data.ErrInvalidRole{Role: roleName, Reason: "no valid delegated role exists"}
+	}
+	role := p.Signed.Delegations.Roles[foundAt]
+	role.AddExcludePaths(addPaths)
+	role.RemoveExcludePaths(removePaths)
+	p.Dirty = true
+	return nil
+}
+
+// SetDelegationThreshold changes the signing threshold of an existing delegation.
+// It is not allowed to create a new delegation.
+func (tr *Repo) SetDelegationThreshold(roleName data.RoleName, threshold int) error {
+	if !data.IsDelegation(roleName) {
+		return data.ErrInvalidRole{Role: roleName, Reason: "not a valid delegated role"}
+	}
+	if threshold < notary.MinThreshold {
+		return data.ErrInvalidRole{Role: roleName, Reason: "threshold must be at least 1"}
+	}
+	parent := roleName.Parent()
+
+	if err := tr.VerifyCanSign(parent); err != nil {
+		return err
+	}
+
+	p, ok := tr.Targets[parent]
+	if !ok {
+		return data.ErrInvalidRole{Role: roleName, Reason: "no valid delegated role exists"}
+	}
+	foundAt := utils.FindRoleIndex(p.Signed.Delegations.Roles, roleName)
+	if foundAt < 0 {
+		return data.ErrInvalidRole{Role: roleName, Reason: "no valid delegated role exists"}
+	}
+	role := p.Signed.Delegations.Roles[foundAt]
+	if role.Threshold == threshold {
+		return nil
+	}
+	if len(role.KeyIDs) < threshold {
+		logrus.Warnf("role %s has fewer keys than its threshold of %d; it will not be usable until keys are added to it", roleName, threshold)
+	}
+	role.Threshold = threshold
+	p.Dirty = true
+	return nil
+}
+
 // DeleteDelegation removes a delegated targets role from its parent
 // targets object. It also deletes the delegation from the snapshot.
 // DeleteDelegation will only make use of the role Name field.
@@ -686,7 +781,7 @@ func (tr *Repo) VerifyCanSign(roleName data.RoleName) error {
 			canonicalKeyIDs = append(canonicalKeyIDs, canonicalID)
 		}
 		for _, id := range check {
-			p, _, err := tr.cryptoService.GetPrivateKey(id)
+			p, _, err := signed.ForRole(tr.cryptoService, roleName).GetPrivateKey(id)
 			if err == nil && p != nil {
 				return nil
 			}
@@ -712,6 +807,7 @@ func (tr *Repo) WalkTargets(targetPath string, rolePath data.RoleName, visitTarg
 		{
 			BaseRole: targetsRole,
 			Paths:    []string{""},
+			Matcher:  tr.pathMatcher,
 		},
 	}
 
@@ -947,6 +1043,21 @@ func oldRootVersionName(version int) string {
 
 // SignTargets signs the targets file for the given top level or delegated targets role
 func (tr *Repo) SignTargets(role data.RoleName, expires time.Time) (*data.Signed, error) {
+	return tr.signTargets(role, expires, (*data.SignedTargets).ToSigned)
+}
+
+// SignTargetsStreamed signs the targets file for the given top level or
+// delegated targets role exactly as SignTargets does, but serializes its
+// signed portion onto the end of buf with ToSignedStreamed, which allocates
+// less for roles with many targets
+func (tr *Repo) SignTargetsStreamed(role data.RoleName, expires time.Time, buf *bytes.Buffer) (*data.Signed, error) {
+	return tr.signTargets(role, expires, func(t *data.SignedTargets) (*data.Signed, error) {
+		return t.ToSignedStreamed(buf)
+	})
+}
+
+func (tr *Repo) signTargets(role data.RoleName, expires time.Time,
+	toSigned func(*data.SignedTargets) (*data.Signed, error)) (*data.Signed, error) {
 	logrus.Debugf("sign targets called for role %s", role)
 	if _, ok := tr.Targets[role]; !ok {
 		return nil, data.ErrInvalidRole{
@@ -956,7 +1067,7 @@ func (tr *Repo) SignTargets(role data.RoleName, expires time.Time) (*data.Signed
 	}
 	tr.Targets[role].Signed.Expires = expires
 	tr.Targets[role].Signed.Version++
-	signed, err := tr.Targets[role].ToSigned()
+	signed, err := toSigned(tr.Targets[role])
 	if err != nil {
 		logrus.Debug("errored getting targets data.Signed object")
 		return nil, err
@@ -1061,7 +1172,12 @@ func (tr Repo) sign(signedData *data.Signed, roles []data.BaseRole, optionalKeys
 	for _, r := range roles {
 		roleKeys := r.ListKeys()
 		validKeys = append(roleKeys, validKeys...)
-		if err := signed.Sign(tr.cryptoService, signedData, roleKeys, r.Threshold, validKeys); err != nil {
+		service := signed.ForRole(tr.cryptoService, r.Name)
+		signingKeys := roleKeys
+		if tr.thresholdKeysOnly {
+			signingKeys = thresholdSigningKeys(service, roleKeys, r.Threshold)
+		}
+		if err := signed.Sign(service, signedData, signingKeys, r.Threshold, validKeys); err != nil {
 			return nil, err
 		}
 	}
@@ -1070,3 +1186,26 @@ func (tr Repo) sign(signedData *data.Signed, roles []data.BaseRole, optionalKeys
 
 	return signedData, nil
 }
+
+// thresholdSigningKeys returns the first threshold keys for which a private
+// key is available.  If there are not enough, all the keys are returned so that
+// signing fails reporting every missing key.
+func thresholdSigningKeys(service signed.CryptoService, keys []data.PublicKey, threshold int) []data.PublicKey {
+	var available []data.PublicKey
+	for _, key := range keys {
+		if len(available) >= threshold {
+			return available
+		}
+		canonicalID, err := utils.CanonicalKeyID(key)
+		if err != nil {
+			continue
+		}
+		if _, _, err := service.GetPrivateKey(canonicalID); err == nil {
+			available = append(available, key)
+		}
+	}
+	if len(available) >= threshold {
+		return available
+	}
+	return keys
+}
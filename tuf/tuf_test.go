@@ -1,12 +1,14 @@
 package tuf
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -453,6 +455,135 @@ func TestUpdateDelegationsAddKeyToRole(t *testing.T) {
 	require.True(t, r.Dirty)
 }
 
+func TestSetDelegationThreshold(t *testing.T) {
+	ed25519 := signed.NewEd25519()
+	repo := initRepo(t, ed25519)
+
+	key1, err := ed25519.Create("targets/test", testGUN, data.ED25519Key)
+	require.NoError(t, err)
+	key2, err := ed25519.Create("targets/test", testGUN, data.ED25519Key)
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateDelegationKeys("targets/test", []data.PublicKey{key1, key2}, []string{}, 1))
+
+	repo.Targets[data.CanonicalTargetsRole].Dirty = false
+	require.NoError(t, repo.SetDelegationThreshold("targets/test", 2))
+	r, err := repo.GetDelegationRole("targets/test")
+	require.NoError(t, err)
+	require.Equal(t, 2, r.Threshold)
+	require.True(t, repo.Targets[data.CanonicalTargetsRole].Dirty)
+
+	// invalid thresholds and roles
+	err = repo.SetDelegationThreshold("targets/test", 0)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+	err = repo.SetDelegationThreshold("targets/missing", 1)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+	err = repo.SetDelegationThreshold("targets/test/deep", 1)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+	err = repo.SetDelegationThreshold(data.CanonicalTargetsRole, 1)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+}
+
+// By default a role is signed with all of its available keys, but it can be
+// limited to only as many keys as are needed to meet its threshold
+func TestSignWithAllKeys(t *testing.T) {
+	ed25519 := signed.NewEd25519()
+	repo := initRepo(t, ed25519)
+
+	key1, err := ed25519.Create("targets/test", testGUN, data.ED25519Key)
+	require.NoError(t, err)
+	key2, err := ed25519.Create("targets/test", testGUN, data.ED25519Key)
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateDelegationKeys("targets/test", []data.PublicKey{key1, key2}, []string{}, 1))
+	_, err = repo.InitTargets("targets/test")
+	require.NoError(t, err)
+
+	s, err := repo.SignTargets("targets/test", data.DefaultExpires(data.CanonicalTargetsRole))
+	require.NoError(t, err)
+	require.Len(t, s.Signatures, 2)
+
+	repo.SetSignWithAllKeys(false)
+	s, err = repo.SignTargets("targets/test", data.DefaultExpires(data.CanonicalTargetsRole))
+	require.NoError(t, err)
+	require.Len(t, s.Signatures, 1)
+
+	// signing with all keys again restores the default
+	repo.SetSignWithAllKeys(true)
+	s, err = repo.SignTargets("targets/test", data.DefaultExpires(data.CanonicalTargetsRole))
+	require.NoError(t, err)
+	require.Len(t, s.Signatures, 2)
+
+	repo.SetSignWithAllKeys(false)
+	require.NoError(t, repo.SetDelegationThreshold("targets/test", 2))
+	s, err = repo.SignTargets("targets/test", data.DefaultExpires(data.CanonicalTargetsRole))
+	require.NoError(t, err)
+	require.Len(t, s.Signatures, 2)
+
+	// without enough private keys signing still fails
+	require.NoError(t, ed25519.RemoveKey(key2.ID()))
+	_, err = repo.SignTargets("targets/test", data.DefaultExpires(data.CanonicalTargetsRole))
+	require.IsType(t, signed.ErrInsufficientSignatures{}, err)
+}
+
+// Signing with all keys is the default, so setting it explicitly signs with
+// exactly the keys a repo which never sets it does
+func TestSignWithAllKeysIsDefault(t *testing.T) {
+	ed25519 := signed.NewEd25519()
+	repo := initRepo(t, ed25519)
+
+	keys := make([]data.PublicKey, 0, 2)
+	for i := 0; i < 2; i++ {
+		key, err := ed25519.Create("targets/test", testGUN, data.ED25519Key)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	require.NoError(t, repo.UpdateDelegationKeys("targets/test", keys, []string{}, 1))
+	_, err := repo.InitTargets("targets/test")
+	require.NoError(t, err)
+	expires := data.DefaultExpires(data.CanonicalTargetsRole)
+
+	signers := func() []string {
+		s, err := repo.SignTargets("targets/test", expires)
+		require.NoError(t, err)
+		keyIDs := make([]string, 0, len(s.Signatures))
+		for _, sig := range s.Signatures {
+			keyIDs = append(keyIDs, sig.KeyID)
+		}
+		sort.Strings(keyIDs)
+		return keyIDs
+	}
+	byDefault := signers()
+	require.Len(t, byDefault, 2)
+
+	repo.SetSignWithAllKeys(true)
+	require.Equal(t, byDefault, signers())
+}
+
+// Signing targets with the streamed serialization produces exactly the
+// metadata that the in-memory serialization does
+func TestSignTargetsStreamedMatchesSignTargets(t *testing.T) {
+	repo := initRepo(t, signed.NewEd25519())
+	for _, name := range []string{"a", "b/c", "<html> & \"quotes\"", "\u2028", "z\n", "日本"} {
+		_, err := repo.AddTargets(data.CanonicalTargetsRole, data.Files{
+			name: {Length: int64(len(name)), Hashes: data.Hashes{"sha256": []byte(name)}},
+		})
+		require.NoError(t, err)
+	}
+	expires := data.DefaultExpires(data.CanonicalTargetsRole)
+	version := repo.Targets[data.CanonicalTargetsRole].Signed.Version
+
+	inMemory, err := repo.SignTargets(data.CanonicalTargetsRole, expires)
+	require.NoError(t, err)
+	repo.Targets[data.CanonicalTargetsRole].Signed.Version = version
+	repo.Targets[data.CanonicalTargetsRole].Signatures = nil
+	buf := bytes.NewBufferString("prefix")
+	streamed, err := repo.SignTargetsStreamed(data.CanonicalTargetsRole, expires, buf)
+	require.NoError(t, err)
+
+	require.Equal(t, inMemory, streamed)
+	require.Equal(t, "prefix"+string(*streamed.Signed), buf.String())
+	require.Equal(t, version+1, repo.Targets[data.CanonicalTargetsRole].Signed.Version)
+}
+
 func TestDeleteDelegations(t *testing.T) {
 	ed25519 := signed.NewEd25519()
 	repo := initRepo(t, ed25519)
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Delta describes how to reconstruct a new version of some metadata from an
+// older version of it that the client already holds. It is a sequence of
+// operations that either copy a range of bytes from the base or insert
+// literal bytes.
+type Delta struct {
+	// Base is the hex encoded SHA256 checksum of the base the delta applies to
+	Base string `json:"base"`
+	// Length is the length of the metadata produced by applying the delta
+	Length int64     `json:"length"`
+	Ops    []DeltaOp `json:"ops"`
+}
+
+// DeltaOp is a single operation of a Delta. If Data is set, it is inserted
+// as-is, otherwise Length bytes are copied from the base starting at Offset.
+type DeltaOp struct {
+	Offset int64  `json:"offset,omitempty"`
+	Length int64  `json:"length,omitempty"`
+	Data   []byte `json:"data,omitempty"`
+}
+
+// ErrInvalidDelta is returned when a delta cannot be applied to a base
+type ErrInvalidDelta struct {
+	Reason string
+}
+
+func (e ErrInvalidDelta) Error() string {
+	return fmt.Sprintf("invalid delta: %s", e.Reason)
+}
+
+// ComputeDelta produces a delta which transforms base into target. Metadata
+// that changes slightly between versions tends to share a long common prefix
+// and suffix, so the delta copies those from the base and only carries the
+// bytes in between.
+func ComputeDelta(base, target []byte) *Delta {
+	checksum := sha256.Sum256(base)
+	d := &Delta{
+		Base:   hex.EncodeToString(checksum[:]),
+		Length: int64(len(target)),
+	}
+
+	prefix := 0
+	for prefix < len(base) && prefix < len(target) && base[prefix] == target[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(target)-prefix &&
+		base[len(base)-1-suffix] == target[len(target)-1-suffix] {
+		suffix++
+	}
+
+	if prefix > 0 {
+		d.Ops = append(d.Ops, DeltaOp{Offset: 0, Length: int64(prefix)})
+	}
+	if middle := target[prefix : len(target)-suffix]; len(middle) > 0 {
+		d.Ops = append(d.Ops, DeltaOp{Data: middle})
+	}
+	if suffix > 0 {
+		d.Ops = append(d.Ops, DeltaOp{Offset: int64(len(base) - suffix), Length: int64(suffix)})
+	}
+	return d
+}
+
+// ApplyDelta reconstructs the metadata described by the delta from the given
+// base. It fails if the delta was not computed against this base, or if any
+// operation falls outside of the base. The result is NOT otherwise verified:
+// callers must check it against the checksums in trusted metadata.
+func ApplyDelta(base []byte, d *Delta) ([]byte, error) {
+	checksum := sha256.Sum256(base)
+	if hex.EncodeToString(checksum[:]) != d.Base {
+		return nil, ErrInvalidDelta{Reason: "base checksum does not match"}
+	}
+
+	var out bytes.Buffer
+	for _, op := range d.Ops {
+		if op.Data != nil {
+			out.Write(op.Data)
+		} else {
+			if op.Offset < 0 || op.Length < 0 || op.Offset > int64(len(base)) || op.Length > int64(len(base))-op.Offset {
+				return nil, ErrInvalidDelta{Reason: "copy operation is out of range of the base"}
+			}
+			out.Write(base[op.Offset : op.Offset+op.Length])
+		}
+		if int64(out.Len()) > d.Length {
+			return nil, ErrInvalidDelta{Reason: "result is longer than the declared length"}
+		}
+	}
+	if int64(out.Len()) != d.Length {
+		return nil, ErrInvalidDelta{Reason: "result is shorter than the declared length"}
+	}
+	return out.Bytes(), nil
+}
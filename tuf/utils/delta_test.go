@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaRoundTrip(t *testing.T) {
+	cases := []struct {
+		base, target string
+	}{
+		{"", ""},
+		{"", "all new"},
+		{"all old", ""},
+		{"identical", "identical"},
+		{`{"targets":{"a":1}}`, `{"targets":{"a":1,"b":2}}`},
+		{`{"version":1,"x":"y"}`, `{"version":2,"x":"y"}`},
+		{"aaaa", "aa"},
+		{"aa", "aaaa"},
+		{"completely", "different"},
+	}
+	for _, c := range cases {
+		d := ComputeDelta([]byte(c.base), []byte(c.target))
+		out, err := ApplyDelta([]byte(c.base), d)
+		require.NoError(t, err, "%q -> %q", c.base, c.target)
+		require.Equal(t, c.target, string(out), "%q -> %q", c.base, c.target)
+	}
+}
+
+func TestDeltaOnlyCarriesChangedBytes(t *testing.T) {
+	base := []byte(`{"signed":{"targets":{"a":"aaaaaaaaaa"},"version":1}}`)
+	target := []byte(`{"signed":{"targets":{"a":"aaaaaaaaaa"},"version":2}}`)
+	d := ComputeDelta(base, target)
+	var inserted int
+	for _, op := range d.Ops {
+		inserted += len(op.Data)
+	}
+	require.Equal(t, 1, inserted)
+}
+
+func TestApplyDeltaWrongBase(t *testing.T) {
+	d := ComputeDelta([]byte("base"), []byte("based"))
+	_, err := ApplyDelta([]byte("other"), d)
+	require.IsType(t, ErrInvalidDelta{}, err)
+}
+
+func TestApplyDeltaOutOfRange(t *testing.T) {
+	base := []byte("base")
+	d := ComputeDelta(base, []byte("base"))
+	d.Ops = []DeltaOp{{Offset: 2, Length: 10}}
+	_, err := ApplyDelta(base, d)
+	require.IsType(t, ErrInvalidDelta{}, err)
+
+	// offsets and lengths whose sum overflows are out of range, not a panic
+	for _, op := range []DeltaOp{
+		{Offset: math.MaxInt64, Length: 1},
+		{Offset: 1, Length: math.MaxInt64},
+		{Offset: math.MaxInt64, Length: math.MaxInt64},
+	} {
+		d.Ops = []DeltaOp{op}
+		_, err = ApplyDelta(base, d)
+		require.IsType(t, ErrInvalidDelta{}, err, "offset %d, length %d", op.Offset, op.Length)
+	}
+
+	// declared length must match what was produced
+	d = ComputeDelta(base, []byte("basebase"))
+	d.Length = 4
+	_, err = ApplyDelta(base, d)
+	require.IsType(t, ErrInvalidDelta{}, err)
+	d.Length = 20
+	_, err = ApplyDelta(base, d)
+	require.IsType(t, ErrInvalidDelta{}, err)
+}
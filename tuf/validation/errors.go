@@ -55,6 +55,15 @@ func (err ErrBadSnapshot) Error() string {
 	return fmt.Sprintf("The snapshot metadata is invalid: %s", err.Msg)
 }
 
+// ErrBadTimestamp represents a failure to validate the timestamp
+type ErrBadTimestamp struct {
+	Msg string
+}
+
+func (err ErrBadTimestamp) Error() string {
+	return fmt.Sprintf("The timestamp metadata is invalid: %s", err.Msg)
+}
+
 // END VALIDATION ERRORS
 
 // SerializableError is a struct that can be used to serialize an error as JSON
@@ -92,6 +101,10 @@ func (s *SerializableError) UnmarshalJSON(text []byte) (err error) {
 		var e struct{ Error ErrBadSnapshot }
 		err = json.Unmarshal(text, &e)
 		theError = e.Error
+	case "ErrBadTimestamp":
+		var e struct{ Error ErrBadTimestamp }
+		err = json.Unmarshal(text, &e)
+		theError = e.Error
 	default:
 		err = fmt.Errorf("do not know how to unmarshal %s", x.Name)
 		return
@@ -119,6 +132,8 @@ func NewSerializableError(err error) (*SerializableError, error) {
 		name = "ErrBadTargets"
 	case ErrBadSnapshot:
 		name = "ErrBadSnapshot"
+	case ErrBadTimestamp:
+		name = "ErrBadTimestamp"
 	default:
 		return nil, fmt.Errorf("does not support serializing non-validation errors")
 	}
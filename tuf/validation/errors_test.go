@@ -31,6 +31,7 @@ func TestUnmarshalSerialiableErrorSuccessfully(t *testing.T) {
 		ErrBadRoot{"bad root"},
 		ErrBadTargets{"bad targets"},
 		ErrBadSnapshot{"bad snapshot"},
+		ErrBadTimestamp{"bad timestamp"},
 	}
 
 	for _, validError := range validationErrors {
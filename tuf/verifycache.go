@@ -0,0 +1,89 @@
+package tuf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// VerificationCache remembers which delegated targets metadata a builder has
+// already verified the signatures of, so that builders sharing the cache can
+// skip re-verifying a delegation whose content and keys are unchanged.  The
+// entries are only valid for the snapshot they were verified under, so the
+// cache is emptied whenever a builder loads a different snapshot.  It is safe
+// for concurrent use.
+type VerificationCache struct {
+	mu       sync.Mutex
+	snapshot string
+	verified map[verificationKey][]bool
+}
+
+// verificationKey identifies delegation metadata by its role, the checksum of
+// its content, and the keys and threshold it was verified against
+type verificationKey struct {
+	role     data.RoleName
+	checksum string
+	keys     string
+}
+
+// NewVerificationCache returns an empty VerificationCache
+func NewVerificationCache() *VerificationCache {
+	return &VerificationCache{verified: make(map[verificationKey][]bool)}
+}
+
+// Len returns the number of delegations whose verification is cached
+func (c *VerificationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.verified)
+}
+
+// useSnapshot empties the cache if the snapshot checksum differs from the one
+// the cached entries were verified under
+func (c *VerificationCache) useSnapshot(checksum string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snapshot != checksum {
+		c.snapshot = checksum
+		c.verified = make(map[verificationKey][]bool)
+	}
+}
+
+// lookup returns the validity of each signature of the metadata identified by
+// the key, if it has been verified under the given snapshot
+func (c *VerificationCache) lookup(snapshot string, key verificationKey) ([]bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snapshot != snapshot {
+		return nil, false
+	}
+	valid, ok := c.verified[key]
+	return valid, ok
+}
+
+// store records the validity of each signature of the metadata identified by
+// the key, as verified under the given snapshot
+func (c *VerificationCache) store(snapshot string, key verificationKey, valid []bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snapshot == snapshot {
+		c.verified[key] = valid
+	}
+}
+
+// newVerificationKey builds the key for a delegation's metadata content,
+// verified against the given role
+func newVerificationKey(role data.BaseRole, content []byte) verificationKey {
+	checksum := sha256.Sum256(content)
+	keyIDs := role.ListKeyIDs()
+	sort.Strings(keyIDs)
+	return verificationKey{
+		role:     role.Name,
+		checksum: hex.EncodeToString(checksum[:]),
+		keys:     fmt.Sprintf("%d:%v", role.Threshold, keyIDs),
+	}
+}
@@ -0,0 +1,144 @@
+package tuf_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+var verifyCacheDelegations = []data.RoleName{"targets/a", "targets/b", "targets/c"}
+
+// newDelegatedRepoMetadata creates the metadata for a repo in which each of
+// the delegations has been published
+func newDelegatedRepoMetadata(t testing.TB, gun data.GUN, delegations []data.RoleName) (map[data.RoleName][]byte, signed.CryptoService) {
+	repo, cs, err := testutils.EmptyRepo(gun, delegations...)
+	require.NoError(t, err)
+	for _, role := range delegations {
+		_, err := repo.InitTargets(role)
+		require.NoError(t, err)
+	}
+	meta, err := testutils.SignAndSerialize(repo)
+	require.NoError(t, err)
+	return meta, cs
+}
+
+// loadInUpdateOrder loads all the metadata into the builder in the order an
+// update would, parents before their delegations
+func loadInUpdateOrder(builder tuf.RepoBuilder, meta map[data.RoleName][]byte, delegations []data.RoleName) error {
+	roles := append([]data.RoleName{data.CanonicalRootRole, data.CanonicalTimestampRole,
+		data.CanonicalSnapshotRole, data.CanonicalTargetsRole}, delegations...)
+	for _, role := range roles {
+		if err := builder.Load(role, meta[role], 1, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func signatureValidity(repo *tuf.Repo) map[data.RoleName][]bool {
+	validity := make(map[data.RoleName][]bool)
+	for role, tgts := range repo.Targets {
+		for _, sig := range tgts.Signatures {
+			validity[role] = append(validity[role], sig.IsValid)
+		}
+	}
+	return validity
+}
+
+// Builders which use a verification cache produce the same repo, with the same
+// signatures marked valid, as builders which verify every delegation in full,
+// both when the cache is empty and when it holds every delegation
+func TestVerificationCacheMatchesFullVerification(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	meta, _ := newDelegatedRepoMetadata(t, gun, verifyCacheDelegations)
+
+	full := tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{})
+	require.NoError(t, loadInUpdateOrder(full, meta, verifyCacheDelegations))
+	expected, _, err := full.Finish()
+	require.NoError(t, err)
+
+	cache := tuf.NewVerificationCache()
+	for i := 0; i < 2; i++ {
+		builder := tuf.NewRepoBuilderWithVerificationCache(gun, nil, trustpinning.TrustPinConfig{}, cache)
+		require.NoError(t, loadInUpdateOrder(builder, meta, verifyCacheDelegations))
+		require.Equal(t, len(verifyCacheDelegations), cache.Len())
+		repo, _, err := builder.Finish()
+		require.NoError(t, err)
+		require.Equal(t, signatureValidity(expected), signatureValidity(repo))
+		require.Len(t, repo.Targets, len(expected.Targets))
+		for role, tgts := range expected.Targets {
+			expectedJSON, err := json.Marshal(tgts)
+			require.NoError(t, err)
+			actualJSON, err := json.Marshal(repo.Targets[role])
+			require.NoError(t, err)
+			require.Equal(t, expectedJSON, actualJSON)
+		}
+	}
+}
+
+// A new snapshot empties the cache, and delegation metadata whose signatures are
+// invalid is rejected whether or not the cache has seen its role before
+func TestVerificationCacheInvalidatedBySnapshot(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	meta, cs := newDelegatedRepoMetadata(t, gun, verifyCacheDelegations)
+
+	cache := tuf.NewVerificationCache()
+	builder := tuf.NewRepoBuilderWithVerificationCache(gun, nil, trustpinning.TrustPinConfig{}, cache)
+	require.NoError(t, loadInUpdateOrder(builder, meta, verifyCacheDelegations))
+	require.Equal(t, len(verifyCacheDelegations), cache.Len())
+
+	var err error
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	require.NoError(t, swizzler.InvalidateMetadataSignatures("targets/c"))
+	require.NoError(t, swizzler.UpdateSnapshotHashes("targets/c"))
+	require.NoError(t, swizzler.UpdateTimestampHash())
+	updated := make(map[data.RoleName][]byte)
+	for _, role := range append(data.BaseRoles, verifyCacheDelegations...) {
+		updated[role], err = swizzler.MetadataCache.GetSized(role.String(), storage.NoSizeLimit)
+		require.NoError(t, err)
+	}
+
+	builder = tuf.NewRepoBuilderWithVerificationCache(gun, nil, trustpinning.TrustPinConfig{}, cache)
+	for _, role := range data.BaseRoles {
+		require.NoError(t, builder.Load(role, updated[role], 1, false))
+	}
+	require.Equal(t, 0, cache.Len())
+	require.NoError(t, builder.Load("targets/a", updated["targets/a"], 1, false))
+	require.NoError(t, builder.Load("targets/b", updated["targets/b"], 1, false))
+	require.Error(t, builder.Load("targets/c", updated["targets/c"], 1, false))
+	require.Equal(t, 2, cache.Len())
+
+	full := tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{})
+	require.Error(t, loadInUpdateOrder(full, updated, verifyCacheDelegations))
+}
+
+func BenchmarkLoadDelegations(b *testing.B) {
+	var gun data.GUN = "docker.com/notary"
+	var delegations []data.RoleName
+	for i := 0; i < 50; i++ {
+		delegations = append(delegations, data.RoleName(fmt.Sprintf("targets/%d", i)))
+	}
+	meta, _ := newDelegatedRepoMetadata(b, gun, delegations)
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			builder := tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{})
+			require.NoError(b, loadInUpdateOrder(builder, meta, delegations))
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache := tuf.NewVerificationCache()
+		for i := 0; i < b.N; i++ {
+			builder := tuf.NewRepoBuilderWithVerificationCache(gun, nil, trustpinning.TrustPinConfig{}, cache)
+			require.NoError(b, loadInUpdateOrder(builder, meta, delegations))
+		}
+	})
+}